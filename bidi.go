@@ -0,0 +1,131 @@
+package scribe
+
+import (
+	"slices"
+
+	"github.com/kofi-q/scribe-go/internal/bidi"
+)
+
+// TextDirection is the base direction of a paragraph of text. Within a
+// paragraph, text is reordered for display with the Unicode Bidirectional
+// Algorithm, so that embedded runs in the opposite direction (e.g. an English
+// product code in a Hebrew sentence) read correctly.
+type TextDirection uint8
+
+const (
+	// TextDirectionAuto uses the direction of the first letter in the
+	// paragraph, falling back to left-to-right.
+	TextDirectionAuto = TextDirection(bidi.Auto)
+
+	TextDirectionLtr = TextDirection(bidi.LeftToRight)
+	TextDirectionRtl = TextDirection(bidi.RightToLeft)
+)
+
+// textDirection returns the base direction for a text output call: the
+// optional direction passed by the caller or, by default, right-to-left if
+// RTL() is enabled and auto-detected otherwise.
+func (f *Scribe) textDirection(dir []TextDirection) bidi.Direction {
+	if len(dir) > 0 {
+		return bidi.Direction(dir[0])
+	}
+
+	if f.isRTL {
+		return bidi.RightToLeft
+	}
+
+	return bidi.Auto
+}
+
+// paragraphDirection resolves the base direction of the paragraph at the
+// start of text, so that it can be applied consistently to each of its lines.
+func paragraphDirection(text []rune, dir bidi.Direction) bidi.Direction {
+	if dir != bidi.Auto {
+		return dir
+	}
+
+	if dir = bidi.BaseDirection(text); dir == bidi.Auto {
+		return bidi.LeftToRight
+	}
+
+	return dir
+}
+
+// bidiLine is a line of a paragraph, with its embedding levels resolved over
+// the whole paragraph, so that neutral characters and numbers at either end of
+// the line are ordered as they are in the paragraph. See shape().
+type bidiLine struct {
+	para       *bidi.Paragraph
+	start, end int    // The line's characters in the paragraph.
+	text       string // The line, as written, with any hyphen added.
+
+	// runs are the runs of part of a line, already reordered, with indices
+	// into text, in place of para.
+	runs []bidi.Run
+}
+
+// bidiText resolves the embedding levels of text a paragraph at a time, as
+// it's broken into lines. Paragraphs are delimited by newlines.
+type bidiText struct {
+	text []rune
+	dir  bidi.Direction
+
+	// The paragraph of the last line, from index start up to index end of
+	// text.
+	para       *bidi.Paragraph
+	start, end int
+}
+
+// line returns the line of text from index start up to index end, written as
+// lineText.
+func (t *bidiText) line(start, end int, lineText string) *bidiLine {
+	if t.para == nil || start < t.start || start > t.end {
+		t.start, t.end = start, start
+		for t.start > 0 && t.text[t.start-1] != '\n' {
+			t.start--
+		}
+		for t.end < len(t.text) && t.text[t.end] != '\n' {
+			t.end++
+		}
+
+		para := t.text[t.start:t.end]
+		t.para = bidi.NewParagraph(para, paragraphDirection(para, t.dir))
+	}
+
+	return &bidiLine{
+		para:  t.para,
+		start: start - t.start,
+		end:   min(end, t.end) - t.start,
+		text:  lineText,
+	}
+}
+
+// lineRuns returns the runs of txt, in display order, with indices into txt.
+// Lines written by MultiCell() and friends, and printed with a ScratchPad, are
+// reordered with the levels of their paragraphs. Other text is treated as a
+// paragraph of its own, with base direction dir.
+func (f *Scribe) lineRuns(txt []rune, str string, dir bidi.Direction) []bidi.Run {
+	line := f.bidiLine
+	if line != nil && line.text != str {
+		line = nil
+	}
+	if line != nil && line.runs != nil {
+		return slices.Clone(line.runs)
+	}
+	if line == nil || line.end == line.start || line.end-line.start > len(txt) {
+		return bidi.NewParagraph(txt, dir).Line(0, len(txt))
+	}
+
+	runs := line.para.Line(line.start, line.end)
+	for i := range runs {
+		runs[i].Start -= line.start
+		runs[i].End -= line.start
+
+		// Characters added to the line, i.e. a hyphen, take the level of
+		// the last character.
+		if runs[i].End == line.end-line.start {
+			runs[i].End = len(txt)
+		}
+	}
+
+	return runs
+}
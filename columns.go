@@ -0,0 +1,221 @@
+package scribe
+
+import (
+	"cmp"
+	"fmt"
+)
+
+// Columns configures multi-column layout. See Scribe.Columns().
+//
+// Zero values select the defaults, noted below.
+type Columns struct {
+	// Count is the number of columns. Default: 2.
+	Count int
+
+	// Gutter is the space between columns, in the unit of measure specified
+	// in New(). Default: 5 mm.
+	Gutter float32
+
+	// Balance evens out the columns on the last page, so that they end at
+	// about the same height, rather than filling each column in turn.
+	Balance bool
+}
+
+// columnLayout is the state of a multi-column layout.
+type columnLayout struct {
+	Columns
+
+	width   float32
+	current int
+
+	// The top of the columns on the current page, and the lowest position
+	// reached in them.
+	top, bottom float32
+
+	// The page margins outside the columns.
+	lMargin, rMargin float32
+
+	// The height of columns is limited to limit on page limitPage, when
+	// balancing them, by moving the page break trigger up from trigger.
+	// overflow is set if the text doesn't fit.
+	limit     float32
+	limitPage int
+	trigger   float32
+	overflow  bool
+}
+
+// Columns lays out the output of content in columns, starting at the current
+// position. Text written with CellFormat(), MultiCell(), Write() and the other
+// text functions, paragraphs (see NewParagraph()), tables (see NewTable()) and
+// images flow to the top of the next column when they reach the bottom margin,
+// and to the first column of a new page from the last column. Header and
+// footer functions are called with the page margins.
+//
+// The columns share the width between the left and right margins. The current
+// position after the call is the left margin, below the lowest column on the
+// last page.
+//
+// When the columns are balanced, content is called several times, to find
+// the height of columns on the last page, with the document restored to its
+// state before the call each time. Header and footer functions are called
+// again for any pages it spans. content should only write to the document.
+func (f *Scribe) Columns(cols Columns, content func()) {
+	if f.err != nil {
+		return
+	}
+	if f.columns != nil {
+		f.err = fmt.Errorf("columns can't be nested")
+		return
+	}
+
+	cols.Count = max(cmp.Or(cols.Count, 2), 1)
+	cols.Gutter = cmp.Or(cols.Gutter, 14.175/f.k)
+	layout := &columnLayout{
+		Columns: cols,
+		width:   (f.w - f.lMargin - f.rMargin - cols.Gutter*float32(cols.Count-1)) / float32(cols.Count),
+		lMargin: f.lMargin,
+		rMargin: f.rMargin,
+	}
+
+	var snap snapshot
+	if cols.Balance {
+		snap = f.snapshot()
+	}
+
+	f.columns = layout
+	f.startColumns(f.y)
+	content()
+	layout.bottom = max(layout.bottom, f.y)
+	if cols.Balance && f.err == nil {
+		f.balanceColumns(snap, content)
+		layout.bottom = max(layout.bottom, f.y)
+	}
+
+	f.lMargin, f.rMargin = layout.lMargin, layout.rMargin
+	f.columns = nil
+	if f.err != nil {
+		return
+	}
+	f.x = f.lMargin
+	f.y = layout.bottom
+}
+
+// balanceColumns repeats the layout of content, from the document state in
+// snap, with the shortest columns on the last page that the text fits in.
+func (f *Scribe) balanceColumns(snap snapshot, content func()) {
+	c := f.columns
+	lastPage := f.page
+	trigger := f.pageBreakTrigger
+
+	// The height of the text on the last page, which is spread over the
+	// columns, and the height of the first column, which it fits in.
+	used := c.bottom - c.top
+	hi := used
+	if c.current > 0 {
+		used = float32(c.current)*(trigger-c.top) + (f.y - c.top)
+		hi = trigger - c.top
+	}
+	lo := used / float32(c.Count)
+
+	fits := func(height float32) bool {
+		f.restore(snap)
+		c.current, c.bottom, c.overflow = 0, 0, false
+		c.limit, c.limitPage = height, lastPage
+		f.startColumns(f.y)
+		content()
+
+		return f.err == nil && !c.overflow && f.page == lastPage
+	}
+
+	for range 12 {
+		if hi-lo < 1/f.k {
+			break
+		}
+
+		mid := (lo + hi) / 2
+		if fits(mid) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+
+	fits(hi)
+	f.pageBreakTrigger = f.pageTrigger()
+	c.limitPage = 0
+}
+
+// startColumns starts the first column of the current page, with its top at
+// y.
+func (f *Scribe) startColumns(y float32) {
+	c := f.columns
+	c.top, c.bottom = y, y
+	if c.limitPage == f.page {
+		f.setPageTrigger(f.pageBreakTrigger)
+	}
+
+	f.setColumn(0)
+	f.y = y
+}
+
+// pageTrigger returns the page break trigger of the current page, without the
+// limit on the height of balanced columns.
+func (f *Scribe) pageTrigger() float32 {
+	if c := f.columns; c != nil && c.limitPage == f.page {
+		return c.trigger
+	}
+
+	return f.pageBreakTrigger
+}
+
+// setPageTrigger sets the page break trigger of the current page, keeping to
+// the limit on the height of balanced columns.
+func (f *Scribe) setPageTrigger(trigger float32) {
+	if c := f.columns; c != nil && c.limitPage == f.page {
+		c.trigger = trigger
+		f.pageBreakTrigger = min(trigger, c.top+c.limit)
+		return
+	}
+
+	f.pageBreakTrigger = trigger
+}
+
+// setColumn moves to the top of a column, setting the margins to its sides.
+func (f *Scribe) setColumn(col int) {
+	c := f.columns
+	c.current = col
+	f.lMargin = c.lMargin + float32(col)*(c.width+c.Gutter)
+	f.rMargin = f.w - f.lMargin - c.width
+	f.x = f.lMargin
+}
+
+// acceptBreak is called when output reaches the bottom margin, and returns
+// true if a page break should be made, as decided by the function set with
+// SetAcceptPageBreakFunc(). In multi-column layout, output moves to the next
+// column instead, until it reaches the last column, and it continues in the
+// first column of the new page.
+func (f *Scribe) acceptBreak() bool {
+	c := f.columns
+	if c == nil {
+		return f.acceptPageBreak()
+	}
+	if !f.acceptPageBreak() {
+		return false
+	}
+
+	c.bottom = max(c.bottom, f.y)
+	if c.current < c.Count-1 {
+		f.setColumn(c.current + 1)
+		f.y = c.top
+		return false
+	}
+
+	// Text that doesn't fit in balanced columns is left to overflow them.
+	if c.limitPage == f.page {
+		c.overflow = true
+		return false
+	}
+
+	f.setColumn(0)
+	return true
+}
@@ -30,6 +30,8 @@ import (
 	"time"
 
 	"github.com/bits-and-blooms/bitset"
+	"github.com/kofi-q/scribe-go/internal/exclusion"
+	"github.com/kofi-q/scribe-go/internal/hyphen"
 	"github.com/kofi-q/scribe-go/ttf"
 )
 
@@ -311,8 +313,9 @@ type fontFileType struct {
 
 type linkType struct {
 	x, y, wd, ht float32
-	link         int    // Auto-generated internal link ID or...
-	linkStr      string // ...application-provided external link string
+	link         int         // Auto-generated internal link ID or...
+	linkStr      string      // ...application-provided external link string or...
+	action       *linkAction // ...other action
 }
 
 type intLinkType struct {
@@ -323,9 +326,13 @@ type intLinkType struct {
 // outlineType is used for a sidebar outline of bookmarks
 type outlineType struct {
 	text                                   string
+	title                                  string // text, in UTF-8, for tables of contents
 	level, parent, first, last, next, prev int
-	y                                      float32
-	p                                      int
+	flags                                  int // 1: italic, 2: bold
+	color                                  *RGBType
+	open                                   bool
+	dest                                   Dest
+	uri, namedDest                         string
 }
 
 // InitType is used with NewCustom() to customize an Scribe instance.
@@ -388,6 +395,7 @@ type Scriber interface {
 		fill bool,
 		link int,
 		linkStr string,
+		dir ...TextDirection,
 	)
 	Cellf(w, h float32, fmtStr string, args ...interface{})
 	Cell(w, h float32, txtStr string)
@@ -490,7 +498,12 @@ type Scriber interface {
 	Link(x, y, w, h float32, link int)
 	Ln(h float32)
 	MoveTo(x, y float32)
-	MultiCell(w, h float32, txtStr, borderStr, alignStr string, fill bool)
+	MultiCell(
+		w, h float32,
+		txtStr, borderStr, alignStr string,
+		fill bool,
+		dir ...TextDirection,
+	)
 	Ok() bool
 	OpenLayerPane()
 	OutputAndClose(w io.WriteCloser) error
@@ -601,7 +614,7 @@ type Scriber interface {
 	UseTemplate(t Template)
 	WriteAligned(width, lineHeight float32, textStr, alignStr string)
 	Writef(h float32, fmtStr string, args ...interface{})
-	Write(h float32, txtStr string)
+	Write(h float32, txtStr string, dir ...TextDirection)
 	WriteLinkID(h float32, displayStr string, linkID int)
 	WriteLinkString(h float32, displayStr, targetStr string)
 }
@@ -630,16 +643,24 @@ type Scribe struct {
 
 	layer layerRecType // manages optional layers in document
 
-	attachments     []Attachment    // slice of content to embed globally
-	blendList       []blendModeType // slice[idx] of alpha transparency modes, 1-based
-	dashArray       []float32       // dash array
-	diffs           []string        // array of encoding differences
+	footnotes footnoteState // footnotes and endnotes
+
+	attachments     []Attachment        // slice of content to embed globally
+	blendList       []blendModeType     // slice[idx] of alpha transparency modes, 1-based
+	dashArray       []float32           // dash array
+	diffs           []string            // array of encoding differences
+	exclusions      []exclusion.Polygon // areas of the current page kept clear of text
 	fontObjIds      []uint32
+	fontVertObjIds  []uint32 // Identity-V fonts, for fonts used for vertical text
 	fonts           *FontSet
 	gradientList    []gradientType       // slice[idx] of gradient records
 	links           []intLinkType        // array of internal links
 	offsets         []uint32             // array of object offsets
+	namedDests      []namedDest          // destinations added with AddNamedDest()
 	outlines        []outlineType        // array of outlines
+	pageLabels      []pageLabel          // page label ranges, by first page
+	sections        []section            // ended sections, with their page count aliases
+	tocs            []*tocType           // tables of contents, written on Close()
 	outputIntents   []OutputIntentType   // OutputIntents
 	pageAttachments [][]annotationAttach // 1-based array of annotation for file attachments (per page)
 	pageLinks       [][]linkType         // pageLinks[page][link], both 1-based
@@ -647,8 +668,10 @@ type Scribe struct {
 	xobjects        []xobject
 	xobjectsUsed    []bool
 
-	usedRunes []bitset.BitSet // Runes added to the document with this font.
-	xmp       []byte          // XMP metadata
+	glyphText    []map[uint16]string // Text represented by each used glyph, per font.
+	usedGlyphs   []bitset.BitSet     // Glyphs added to the document with this font.
+	usedVertical []bool              // Fonts used for vertical text, per font.
+	xmp          []byte              // XMP metadata
 
 	defOrientation  string // default orientation
 	curOrientation  string // current orientation
@@ -664,6 +687,8 @@ type Scribe struct {
 	keywords        string // keywords
 	creator         string // creator
 	aliasNbPagesStr string // alias for total number of pages
+	aliasLabelStr   string // alias for the label of each page
+	aliasSectionStr string // alias for the number of pages in each section
 	fontDirStr      string // location of font definition files
 	blendMode       string // current blend mode
 
@@ -677,10 +702,18 @@ type Scribe struct {
 	clipNest      int // Number of active clipping contexts
 	joinStyle     int // line segment join style: miter 0, round 1, bevel 2
 	page          int // current page number
+	sectionFrom   int // first page of the current section
+	nextSection   int // first page of the next section, once started
 	transformNest int // Number of active transformation contexts
+	orphans       int // fewest lines of a paragraph left before a page break
+	widows        int // fewest lines of a paragraph carried after a page break
 
-	javascript *string // JavaScript code to include in the PDF
-	writer     io.Writer
+	javascript   *string       // JavaScript code to include in the PDF
+	bidiLine     *bidiLine     // line of a paragraph being written; see shape()
+	lineBreaking *LineBreaking // total-fit line breaking parameters; nil for first-fit
+	lineCounts   *[]int        // lines of each paragraph, when counted by countLines()
+	columns      *columnLayout // multi-column layout; nil outside Columns()
+	writer       io.Writer
 
 	templates       map[string]Template          // templates used in this document
 	templateObjects map[string]uint32            // template object IDs within this document
@@ -695,6 +728,7 @@ type Scribe struct {
 	aliasMap        map[string]string            // map of alias->replacement
 	blendMap        map[string]int               // map into blendList
 	spotColorMap    map[string]spotColorType     // Map of named ink-based colors
+	hyphenation     map[string]*hyphen.Patterns  // hyphenation patterns, by lowercase language
 
 	acceptPageBreak func() bool // returns true to accept page break
 	footerFnc       func()      // function provided by app and called to write footer
@@ -738,6 +772,7 @@ type Scribe struct {
 	state       uint8     // current document state
 
 	isRTL          bool // is is right to left mode enabled
+	kerning        bool // apply pair kerning to text output and measurement
 	compress       bool // compression flag
 	autoPageBreak  bool // automatic page breaking
 	inHeader       bool // flag set when processing header
@@ -0,0 +1,71 @@
+package scribe
+
+import "github.com/kofi-q/scribe-go/internal/exclusion"
+
+// AddExclusion keeps text out of the polygon with the given vertices on the
+// current page, in the unit of measure specified in New(). Lines written by
+// MultiCell(), Write() and related functions that would overlap it are
+// shortened to the widest space left beside it, and moved down if that's
+// narrower than the font size. Floating images (see ImageOptions) are added
+// as exclusions too.
+//
+// Exclusions are removed when a page is added.
+func (f *Scribe) AddExclusion(points []PointType) {
+	if len(points) < 3 {
+		f.SetErrorf("exclusion needs at least 3 points, got %d", len(points))
+		return
+	}
+
+	polygon := make(exclusion.Polygon, len(points))
+	for i, pt := range points {
+		polygon[i] = exclusion.Point{X: pt.X, Y: pt.Y}
+	}
+	f.exclusions = append(f.exclusions, polygon)
+}
+
+// ClearExclusions removes the exclusions of the current page, including
+// floating images, so that text isn't kept out of them.
+func (f *Scribe) ClearExclusions() {
+	f.exclusions = nil
+}
+
+// lineSpace returns the position and width of the space for a line of text of
+// height h, at y or the first position below it where the line fits between x
+// and x+w beside the exclusions of the page. Lines below the bottom margin are
+// given all the width, as they're moved to a new page.
+func (f *Scribe) lineSpace(x, y, w, h float32) (lineX, lineY, lineW float32) {
+	if len(f.exclusions) == 0 {
+		return x, y, w
+	}
+
+	minWidth := 2*f.cMargin + f.fontSize
+	for y+h <= f.pageBreakTrigger {
+		x0, x1, ok := exclusion.Space(f.exclusions, x, x+w, y, y+h, minWidth)
+		if ok {
+			return x0, y, x1 - x0
+		}
+		y += h
+	}
+
+	return x, y, w
+}
+
+// lineWidths returns the widths of successive lines of text of height h from
+// the current position, beside the exclusions of the page, in glyph units of
+// the current font, for line breaking. Lines are at dx from the left margin,
+// and at most w wide.
+func (f *Scribe) lineWidths(dx, w, h float32) func(line int) float32 {
+	var widths []float32
+	y := f.y
+
+	return func(line int) float32 {
+		for len(widths) <= line {
+			var lineW float32
+			_, y, lineW = f.lineSpace(f.lMargin+dx, y, w, h)
+			widths = append(widths, (lineW-2*f.cMargin)*1000/f.fontSize)
+			y += h
+		}
+
+		return widths[line]
+	}
+}
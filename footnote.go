@@ -0,0 +1,247 @@
+package scribe
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+)
+
+// FootnoteStyle configures footnotes and endnotes. See Footnote().
+//
+// Zero values select the defaults, noted below.
+type FootnoteStyle struct {
+	// Font is the font of notes. Default: the current font when the note is
+	// added.
+	Font *FontId
+
+	// Size is the size of the text of notes, in points. Default: 80% of the
+	// current font size when the note is added.
+	Size float32
+
+	// Separator is the length of the rule above the footnotes of a page, in
+	// the unit of measure specified in New(). A negative value omits it.
+	// Default: a third of the width between the margins.
+	Separator float32
+
+	// Spacing is the space between the text of a page and its footnotes, with
+	// the rule in the middle, in the unit of measure specified in New().
+	// Default: the line height of the notes.
+	Spacing float32
+}
+
+// footnoteState is the state of the footnotes and endnotes of a document.
+type footnoteState struct {
+	style FootnoteStyle
+
+	// The numbers of the last footnote and endnote.
+	number, endnote int
+
+	// The lines of the footnotes of the current page, and those carried over
+	// to the next page, which didn't fit on it.
+	lines, carry []footnoteLine
+
+	// The height reserved for footnotes at the bottom of the current page,
+	// including the spacing above them, and the page break trigger of the
+	// page without them, which they end at.
+	reserved, spacing float32
+	bottom            float32
+
+	endnotes []*Paragraph
+}
+
+// footnoteLine is a line of a footnote.
+type footnoteLine struct {
+	p        *Paragraph
+	text     []rune
+	spanIx   []int
+	bidiText *bidiText
+	line     paragraphLine
+	width    float32
+}
+
+// clone returns a copy of the state, for a snapshot.
+func (fn footnoteState) clone() footnoteState {
+	fn.lines = slices.Clone(fn.lines)
+	fn.carry = slices.Clone(fn.carry)
+	fn.endnotes = slices.Clone(fn.endnotes)
+	return fn
+}
+
+// SetFootnoteStyle sets the style of footnotes and endnotes added afterwards.
+// See FootnoteStyle.
+func (f *Scribe) SetFootnoteStyle(style FootnoteStyle) {
+	f.footnotes.style = style
+}
+
+// Footnote writes a superscript footnote marker at the current position, as
+// with Write(), with h the line height, and adds the note, with the same
+// number, to the bottom of the page, above the footer. Footnotes are numbered
+// from 1 through the document.
+//
+// Space for the note is reserved by moving the page break trigger up, so that
+// text following the marker breaks to a new page before reaching the notes.
+// Lines of the note that don't fit below the marker's line are continued at
+// the bottom of the next page.
+func (f *Scribe) Footnote(h float32, text string) {
+	if f.err != nil {
+		return
+	}
+
+	fn := &f.footnotes
+	fn.number++
+	number := strconv.Itoa(fn.number)
+	f.noteMarker(h, number)
+
+	// The notes span the width between the page margins, outside any columns.
+	lMargin, rMargin := f.lMargin, f.rMargin
+	if f.columns != nil {
+		lMargin, rMargin = f.columns.lMargin, f.columns.rMargin
+	}
+	width := f.w - lMargin - rMargin
+	p := f.noteParagraph(number, text)
+	runes, spanIx, lines := p.layout(width)
+	bidiText := &bidiText{text: runes, dir: f.textDirection(nil)}
+	for _, line := range lines {
+		fn.carry = append(
+			fn.carry,
+			footnoteLine{p, runes, spanIx, bidiText, line, width},
+		)
+	}
+
+	// Notes stay in order, so lines are only added to the page if none are
+	// waiting for the next page already.
+	if len(fn.carry) == len(lines) {
+		f.reserveFootnotes(f.y+h, false)
+	}
+}
+
+// Endnote writes a superscript endnote marker at the current position, as
+// with Write(), with h the line height, and keeps the note for
+// WriteEndnotes(). Endnotes are numbered from 1, and again after each call to
+// WriteEndnotes().
+func (f *Scribe) Endnote(h float32, text string) {
+	if f.err != nil {
+		return
+	}
+
+	fn := &f.footnotes
+	fn.endnote++
+	number := strconv.Itoa(fn.endnote)
+	f.noteMarker(h, number)
+	fn.endnotes = append(fn.endnotes, f.noteParagraph(number, text))
+}
+
+// WriteEndnotes writes the endnotes added since the last call at the current
+// position, one paragraph each, as with Paragraph.Write(). Numbering of
+// endnotes starts again afterwards.
+func (f *Scribe) WriteEndnotes() {
+	fn := &f.footnotes
+	for _, p := range fn.endnotes {
+		p.Write(0)
+	}
+	fn.endnotes = nil
+	fn.endnote = 0
+}
+
+// noteMarker writes the marker of a note in the flow of text.
+func (f *Scribe) noteMarker(h float32, number string) {
+	size := f.fontSizePt
+	f.SubWrite(h, number, 0.6*size, 0.5*size, 0, "")
+}
+
+// noteParagraph returns the paragraph of a note, starting with its number.
+func (f *Scribe) noteParagraph(number, text string) *Paragraph {
+	style := f.footnotes.style
+	font := f.currentFont
+	if style.Font != nil {
+		font = *style.Font
+	}
+	size := cmp.Or(style.Size, 0.8*f.fontSizePt)
+
+	p := &Paragraph{f: f, font: font, size: size, align: "L"}
+	marker := p.Span(number)
+	marker.Size = 0.6 * size
+	marker.Rise = 0.4 * size / f.k
+
+	return p.Add(marker).Text(" " + text)
+}
+
+// reserveFootnotes moves the lines of footnotes carried over to the current
+// page, where they fit below y, and reserves space for them. At least one line
+// is moved if force is true, e.g. at the top of a page.
+func (f *Scribe) reserveFootnotes(y float32, force bool) {
+	fn := &f.footnotes
+	if len(fn.carry) == 0 {
+		return
+	}
+
+	spacing := fn.spacing
+	if len(fn.lines) == 0 {
+		spacing = fn.style.Spacing
+		if spacing == 0 {
+			spacing = fn.carry[0].line.height
+		}
+	}
+
+	space := f.pageTrigger() - y
+	if len(fn.lines) == 0 {
+		space -= spacing
+	}
+	fit := 0
+	for fit < len(fn.carry) && fn.carry[fit].line.height <= space {
+		space -= fn.carry[fit].line.height
+		fit++
+	}
+	if force && len(fn.lines) == 0 {
+		fit = max(fit, 1)
+	}
+	if fit == 0 {
+		return
+	}
+
+	var height float32
+	if len(fn.lines) == 0 {
+		height, fn.spacing = spacing, spacing
+		fn.bottom = f.pageTrigger()
+	}
+	for _, line := range fn.carry[:fit] {
+		height += line.line.height
+	}
+	fn.lines = append(fn.lines, fn.carry[:fit]...)
+	fn.carry = slices.Delete(fn.carry, 0, fit)
+	fn.reserved += height
+	f.setPageTrigger(f.pageTrigger() - height)
+}
+
+// putFootnotes writes the footnotes of the current page, with the page
+// margins, and releases the space reserved for them.
+func (f *Scribe) putFootnotes() {
+	fn := &f.footnotes
+	if len(fn.lines) == 0 {
+		return
+	}
+
+	y := fn.bottom - fn.reserved
+	if sep := cmp.Or(fn.style.Separator, (f.w-f.lMargin-f.rMargin)/3); sep > 0 {
+		f.Line(f.lMargin, y+fn.spacing/2, f.lMargin+sep, y+fn.spacing/2)
+	}
+	y += fn.spacing
+
+	x, yPrev := f.x, f.y
+	for _, line := range fn.lines {
+		f.x, f.y = f.lMargin, y
+		line.p.writeLine(
+			line.text,
+			line.spanIx,
+			line.bidiText,
+			line.line,
+			line.width,
+		)
+		y += line.line.height
+	}
+	f.x, f.y = x, yPrev
+
+	fn.lines = nil
+	f.setPageTrigger(fn.bottom)
+	fn.reserved, fn.spacing, fn.bottom = 0, 0, 0
+}
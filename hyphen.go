@@ -0,0 +1,208 @@
+package scribe
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kofi-q/scribe-go/internal/hyphen"
+)
+
+// AddHyphenation loads hyphenation patterns for a language. Words too long to
+// fit at the end of a line are hyphenated with the patterns for the document
+// language, set with SetLang(), by MultiCell(), Write(), SplitLines(),
+// TextSplit() and ScratchPad.Text().
+//
+// Languages are matched ignoring case, with patterns for a language also used
+// for its regional variants: patterns for "de" apply to "de-CH", unless there
+// are patterns for "de-CH" itself.
+//
+// patterns is the content of a TeX hyphenation pattern file, e.g.
+// hyph-de-1996.tex from https://github.com/hyphenation/tex-hyphen, or of the
+// plain text pattern (.pat.txt) and exception (.hyp.txt) files from the same
+// source. Loading more files for a language adds to its patterns.
+//
+// Soft hyphens (U+00AD) in text mark the points at which words can be
+// hyphenated, in any language, and are only displayed when a line is broken
+// at one. Words with soft hyphens are only hyphenated at them.
+func (f *Scribe) AddHyphenation(lang string, patterns []byte) {
+	if f.err != nil {
+		return
+	}
+
+	key := strings.ToLower(lang)
+	p := f.hyphenation[key]
+	if p == nil {
+		p = hyphen.New()
+	}
+
+	if err := p.Parse(patterns); err != nil {
+		f.err = fmt.Errorf("unable to load hyphenation patterns for %q: %w", lang, err)
+		return
+	}
+
+	if f.hyphenation == nil {
+		f.hyphenation = map[string]*hyphen.Patterns{}
+	}
+	f.hyphenation[key] = p
+}
+
+// hyphenPatterns returns the hyphenation patterns for the document language,
+// or nil if there are none.
+func (f *Scribe) hyphenPatterns() *hyphen.Patterns {
+	lang := strings.ToLower(f.lang)
+	for lang != "" {
+		if p, ok := f.hyphenation[lang]; ok {
+			return p
+		}
+
+		i := strings.LastIndexAny(lang, "-_")
+		if i < 0 {
+			break
+		}
+		lang = lang[:i]
+	}
+
+	return nil
+}
+
+// hyphenPoints returns the indices in word that it can be hyphenated before:
+// its soft hyphens, if it has any, or the points found with the hyphenation
+// patterns of the document language. Only words of letters are hyphenated,
+// ignoring any leading and trailing punctuation.
+func (f *Scribe) hyphenPoints(word []rune) []int {
+	if points := hyphen.SoftHyphens(word); points != nil {
+		return points
+	}
+
+	p := f.hyphenPatterns()
+	if p == nil {
+		return nil
+	}
+
+	start, end := 0, len(word)
+	for start < end && !unicode.IsLetter(word[start]) {
+		start++
+	}
+	for end > start && !unicode.IsLetter(word[end-1]) {
+		end--
+	}
+	for _, char := range word[start:end] {
+		if !unicode.IsLetter(char) && !unicode.IsMark(char) {
+			return nil
+		}
+	}
+
+	points := p.Hyphenate(word[start:end])
+	for i := range points {
+		points[i] += start
+	}
+
+	return points
+}
+
+// hyphenBreak hyphenates the word containing text[overflow], the first
+// character that doesn't fit on the line starting at lineStart, at the last
+// hyphenation point that leaves the line no wider than wmax glyph units, with
+// a hyphen added. indent is the width of any text on the line before
+// lineStart. width returns the width of char, including any kerning after
+// prev.
+//
+// The line ends at end, and the next line starts at next. ok is false if
+// there's no such hyphenation point.
+func (f *Scribe) hyphenBreak(
+	text []rune,
+	lineStart, overflow int,
+	indent, wmax float32,
+	width func(prev, char rune) float32,
+) (end, next int, ok bool) {
+	return f.hyphenBreakFunc(
+		text,
+		lineStart,
+		overflow,
+		indent,
+		wmax,
+		func(_ int, prev, char rune) float32 { return width(prev, char) },
+	)
+}
+
+// hyphenBreakFunc is hyphenBreak for text whose characters are measured
+// individually, e.g. when set in several fonts. width returns the width of
+// char, which is text[i] or the hyphen added after it, including any kerning
+// after prev.
+func (f *Scribe) hyphenBreakFunc(
+	text []rune,
+	lineStart, overflow int,
+	indent, wmax float32,
+	width func(i int, prev, char rune) float32,
+) (end, next int, ok bool) {
+	if overflow >= len(text) || unicode.IsSpace(text[overflow]) {
+		return 0, 0, false
+	}
+
+	wordStart := overflow
+	for wordStart > lineStart && !unicode.IsSpace(text[wordStart-1]) {
+		wordStart--
+	}
+	wordEnd := overflow
+	for wordEnd < len(text) && !unicode.IsSpace(text[wordEnd]) {
+		wordEnd++
+	}
+
+	points := f.hyphenPoints(text[wordStart:wordEnd])
+	for i := len(points) - 1; i >= 0; i-- {
+		end = wordStart + points[i]
+		if end <= wordStart || end > overflow {
+			continue
+		}
+
+		lineWidth := indent + width(end-1, -1, '-')
+		prev := rune(-1)
+		for j := lineStart; j < end; j++ {
+			lineWidth += width(j, prev, text[j])
+			prev = text[j]
+		}
+		if lineWidth > wmax {
+			continue
+		}
+
+		next = end
+		if text[next] == hyphen.SoftHyphen {
+			next++
+		}
+		return end, next, true
+	}
+
+	return 0, 0, false
+}
+
+// hyphenBreakString is hyphenBreak for the line of text starting at byte
+// lineStart, which overflows at byte overflow. It returns the line, with its
+// hyphen, and the byte index of the next line.
+func (f *Scribe) hyphenBreakString(
+	text string,
+	lineStart, overflow int,
+	indent, wmax float32,
+	width func(prev, char rune) float32,
+) (line string, next int, ok bool) {
+	wordEnd := len(text)
+	if i := strings.IndexFunc(text[overflow:], unicode.IsSpace); i >= 0 {
+		wordEnd = overflow + i
+	}
+
+	runes := []rune(text[lineStart:wordEnd])
+	end, nextRune, ok := f.hyphenBreak(
+		runes,
+		0,
+		utf8.RuneCountInString(text[lineStart:overflow]),
+		indent,
+		wmax,
+		width,
+	)
+	if !ok {
+		return "", 0, false
+	}
+
+	return string(runes[:end]) + "-", lineStart + len(string(runes[:nextRune])), true
+}
@@ -0,0 +1,812 @@
+// Package bidi implements the Unicode Bidirectional Algorithm (UAX #9), for
+// ordering mixed left-to-right and right-to-left text for display:
+// https://www.unicode.org/reports/tr9/
+package bidi
+
+import (
+	"slices"
+)
+
+// Direction is the base direction of a paragraph.
+type Direction uint8
+
+const (
+	// Determine the direction from the first strong character in the
+	// paragraph, falling back to left-to-right.
+	Auto Direction = iota
+	LeftToRight
+	RightToLeft
+)
+
+// Level is an embedding level. Odd levels are right-to-left.
+type Level uint8
+
+// Rtl returns true for right-to-left levels.
+func (l Level) Rtl() bool {
+	return l&1 != 0
+}
+
+// Maximum explicit embedding level:
+// https://www.unicode.org/reports/tr9/#BD2
+const maxDepth = 125
+
+// Paragraph holds the resolved embedding levels of a paragraph of text.
+type Paragraph struct {
+	classes []Class
+	levels  []Level
+	level   Level
+}
+
+// NewParagraph resolves the embedding levels of text, which is treated as a
+// single paragraph with the given base direction.
+//
+// Text without right-to-left characters is resolved without running the full
+// algorithm.
+func NewParagraph(text []rune, dir Direction) *Paragraph {
+	p := &Paragraph{classes: make([]Class, len(text))}
+
+	rtl := dir == RightToLeft
+	for i, char := range text {
+		p.classes[i] = ClassOf(char)
+		rtl = rtl || isRtlTrigger(p.classes[i])
+	}
+
+	if dir == RightToLeft ||
+		dir == Auto && firstStrong(p.classes, 0, len(p.classes)) == R {
+		p.level = 1
+	}
+
+	p.levels = make([]Level, len(text))
+	if !rtl {
+		return p
+	}
+
+	p.resolve(text)
+
+	return p
+}
+
+// Direction returns the resolved base direction of the paragraph.
+func (p *Paragraph) Direction() Direction {
+	if p.level.Rtl() {
+		return RightToLeft
+	}
+
+	return LeftToRight
+}
+
+// Levels returns the resolved embedding levels of each character, before any
+// line-based adjustments.
+func (p *Paragraph) Levels() []Level {
+	return p.levels
+}
+
+// Run is a sequence of characters with the same embedding level, from
+// index Start up to (but not including) index End of the paragraph text.
+// Characters in right-to-left runs are displayed in reverse order.
+type Run struct {
+	Start int
+	End   int
+	Level Level
+}
+
+// Rtl returns true if the run is displayed right-to-left.
+func (r Run) Rtl() bool {
+	return r.Level.Rtl()
+}
+
+// Line returns the runs for the line of text from index start up to (but not
+// including) index end, in left-to-right display order.
+//
+// https://www.unicode.org/reports/tr9/#Reordering_Resolved_Levels
+func (p *Paragraph) Line(start, end int) []Run {
+	if start >= end {
+		return nil
+	}
+
+	levels := slices.Clone(p.levels[start:end])
+	classes := p.classes[start:end]
+
+	// L1: Reset separators, and whitespace preceding them or the end of the
+	// line, to the paragraph level.
+	trailing := true
+	for i := len(levels) - 1; i >= 0; i -= 1 {
+		switch classes[i] {
+		case S, B:
+			levels[i] = p.level
+			trailing = true
+		case WS, FSI, LRI, RLI, PDI, BN, LRE, RLE, LRO, RLO, PDF:
+			if trailing {
+				levels[i] = p.level
+			}
+		default:
+			trailing = false
+		}
+	}
+
+	var highest, lowestOdd Level = 0, maxDepth + 2
+	for _, level := range levels {
+		highest = max(highest, level)
+		if level.Rtl() {
+			lowestOdd = min(lowestOdd, level)
+		}
+	}
+
+	// L2: Reverse sequences at each level, from the highest down to the
+	// lowest odd level.
+	order := make([]int, len(levels))
+	for i := range order {
+		order[i] = i
+	}
+	for level := highest; level >= lowestOdd && level > 0; level -= 1 {
+		for i := 0; i < len(order); {
+			if levels[order[i]] < level {
+				i += 1
+				continue
+			}
+
+			j := i + 1
+			for j < len(order) && levels[order[j]] >= level {
+				j += 1
+			}
+			slices.Reverse(order[i:j])
+			i = j
+		}
+	}
+
+	var runs []Run
+	for _, i := range order {
+		level := levels[i]
+		if n := len(runs); n > 0 && runs[n-1].Level == level {
+			last := &runs[n-1]
+			if !level.Rtl() && last.End == start+i {
+				last.End += 1
+				continue
+			}
+			if level.Rtl() && last.Start == start+i+1 {
+				last.Start -= 1
+				continue
+			}
+		}
+
+		runs = append(runs, Run{Start: start + i, End: start + i + 1, Level: level})
+	}
+
+	return runs
+}
+
+// BaseDirection returns the direction of the first strong character in the
+// first paragraph of text, or Auto if there is none.
+//
+// https://www.unicode.org/reports/tr9/#P2
+func BaseDirection(text []rune) Direction {
+	depth := 0
+	for _, char := range text {
+		switch ClassOf(char) {
+		case L:
+			if depth == 0 {
+				return LeftToRight
+			}
+		case R, AL:
+			if depth == 0 {
+				return RightToLeft
+			}
+		case LRI, RLI, FSI:
+			depth += 1
+		case PDI:
+			if depth > 0 {
+				depth -= 1
+			}
+		case B:
+			return Auto
+		}
+	}
+
+	return Auto
+}
+
+// firstStrong returns the type (L or R) of the first strong character in
+// classes[start:end], skipping isolated sequences, or ON if there is none.
+// AL characters are reported as R.
+//
+// https://www.unicode.org/reports/tr9/#P2
+func firstStrong(classes []Class, start, end int) Class {
+	depth := 0
+	for _, class := range classes[start:end] {
+		switch class {
+		case L:
+			if depth == 0 {
+				return L
+			}
+		case R, AL:
+			if depth == 0 {
+				return R
+			}
+		case LRI, RLI, FSI:
+			depth += 1
+		case PDI:
+			if depth > 0 {
+				depth -= 1
+			}
+		case B:
+			return ON
+		}
+	}
+
+	return ON
+}
+
+// resolve runs the explicit, weak, neutral and implicit resolution steps of
+// the algorithm.
+func (p *Paragraph) resolve(text []rune) {
+	classes := slices.Clone(p.classes)
+	matchingPdi := p.matchIsolates()
+
+	p.resolveExplicit(classes, matchingPdi)
+
+	for _, seq := range p.isolatingRunSequences(classes, matchingPdi) {
+		seq.resolveWeak()
+		seq.resolveBrackets(text)
+		seq.resolveNeutral()
+		seq.resolveImplicit()
+	}
+
+	// Removed characters take the level of the preceding character, so they
+	// don't split runs.
+	for i, class := range p.classes {
+		if removed(class) {
+			if i > 0 {
+				p.levels[i] = p.levels[i-1]
+			} else {
+				p.levels[i] = p.level
+			}
+		}
+	}
+}
+
+// removed returns true for characters removed from resolution by rule X9.
+func removed(class Class) bool {
+	switch class {
+	case RLE, LRE, RLO, LRO, PDF, BN:
+		return true
+	}
+
+	return false
+}
+
+// matchIsolates returns the index of the matching PDI for each isolate
+// initiator, or -1 for initiators without one and all other characters.
+//
+// https://www.unicode.org/reports/tr9/#BD9
+func (p *Paragraph) matchIsolates() []int {
+	matching := make([]int, len(p.classes))
+	var stack []int
+	for i, class := range p.classes {
+		matching[i] = -1
+
+		switch class {
+		case LRI, RLI, FSI:
+			stack = append(stack, i)
+		case PDI:
+			if n := len(stack); n > 0 {
+				matching[stack[n-1]] = i
+				stack = stack[:n-1]
+			}
+		case B:
+			stack = stack[:0]
+		}
+	}
+
+	return matching
+}
+
+type statusEntry struct {
+	level    Level
+	override Class // L, R or ON for none.
+	isolate  bool
+}
+
+// resolveExplicit applies rules X1-X8, assigning explicit embedding levels
+// and applying directional overrides to classes.
+//
+// https://www.unicode.org/reports/tr9/#Explicit_Levels_and_Directions
+func (p *Paragraph) resolveExplicit(classes []Class, matchingPdi []int) {
+	stack := make([]statusEntry, 1, maxDepth+2)
+	stack[0] = statusEntry{level: p.level, override: ON}
+
+	overflowIsolates, overflowEmbeddings, validIsolates := 0, 0, 0
+
+	nextLevel := func(rtl bool) Level {
+		level := stack[len(stack)-1].level
+		if rtl {
+			return (level + 1) | 1
+		}
+		return (level + 2) &^ 1
+	}
+
+	for i, class := range classes {
+		top := stack[len(stack)-1]
+
+		switch class {
+		case RLE, LRE, RLO, LRO:
+			p.levels[i] = top.level
+
+			level := nextLevel(class == RLE || class == RLO)
+			if level <= maxDepth && overflowIsolates == 0 && overflowEmbeddings == 0 {
+				override := ON
+				switch class {
+				case RLO:
+					override = R
+				case LRO:
+					override = L
+				}
+				stack = append(stack, statusEntry{level: level, override: override})
+			} else if overflowIsolates == 0 {
+				overflowEmbeddings += 1
+			}
+
+		case RLI, LRI, FSI:
+			p.levels[i] = top.level
+			if top.override != ON {
+				classes[i] = top.override
+			}
+
+			rtl := class == RLI
+			if class == FSI {
+				end := matchingPdi[i]
+				if end < 0 {
+					end = len(classes)
+				}
+				rtl = firstStrong(p.classes, i+1, end) == R
+			}
+
+			level := nextLevel(rtl)
+			if level <= maxDepth && overflowIsolates == 0 && overflowEmbeddings == 0 {
+				validIsolates += 1
+				stack = append(stack, statusEntry{level: level, override: ON, isolate: true})
+			} else {
+				overflowIsolates += 1
+			}
+
+		case PDI:
+			switch {
+			case overflowIsolates > 0:
+				overflowIsolates -= 1
+			case validIsolates == 0:
+			default:
+				overflowEmbeddings = 0
+				for !stack[len(stack)-1].isolate {
+					stack = stack[:len(stack)-1]
+				}
+				stack = stack[:len(stack)-1]
+				validIsolates -= 1
+			}
+
+			top = stack[len(stack)-1]
+			p.levels[i] = top.level
+			if top.override != ON {
+				classes[i] = top.override
+			}
+
+		case PDF:
+			p.levels[i] = top.level
+
+			switch {
+			case overflowIsolates > 0:
+			case overflowEmbeddings > 0:
+				overflowEmbeddings -= 1
+			case !top.isolate && len(stack) >= 2:
+				stack = stack[:len(stack)-1]
+			}
+
+		case B:
+			p.levels[i] = p.level
+
+		case BN:
+			p.levels[i] = top.level
+
+		default:
+			p.levels[i] = top.level
+			if top.override != ON {
+				classes[i] = top.override
+			}
+		}
+	}
+}
+
+// isolatingRunSequence is a sequence of level runs, resolved as a unit.
+//
+// https://www.unicode.org/reports/tr9/#BD13
+type isolatingRunSequence struct {
+	p *Paragraph
+
+	// Paragraph classes, updated as resolution proceeds.
+	classes []Class
+
+	// Indices of the sequence's characters in the paragraph.
+	indices []int
+
+	level Level
+	sos   Class
+	eos   Class
+}
+
+// isolatingRunSequences splits the paragraph into isolating run sequences
+// (rule X10), excluding characters removed by rule X9.
+//
+// https://www.unicode.org/reports/tr9/#X10
+func (p *Paragraph) isolatingRunSequences(
+	classes []Class,
+	matchingPdi []int,
+) []*isolatingRunSequence {
+	var runs [][]int
+	var run []int
+	for i := range classes {
+		if removed(p.classes[i]) {
+			continue
+		}
+
+		if len(run) > 0 && p.levels[run[len(run)-1]] != p.levels[i] {
+			runs = append(runs, run)
+			run = nil
+		}
+		run = append(run, i)
+	}
+	if len(run) > 0 {
+		runs = append(runs, run)
+	}
+
+	// Runs starting with a PDI that matches an isolate initiator continue
+	// the sequence ending with that initiator.
+	runForPdi := map[int]int{}
+	for r, run := range runs {
+		if p.classes[run[0]] == PDI {
+			runForPdi[run[0]] = r
+		}
+	}
+
+	var seqs []*isolatingRunSequence
+	continued := make([]bool, len(runs))
+	for r := range runs {
+		if continued[r] {
+			continue
+		}
+
+		var indices []int
+		for next := r; next >= 0; {
+			run := runs[next]
+			indices = append(indices, run...)
+
+			next = -1
+			last := run[len(run)-1]
+			if c := p.classes[last]; c == LRI || c == RLI || c == FSI {
+				if pdi := matchingPdi[last]; pdi >= 0 {
+					if n, ok := runForPdi[pdi]; ok {
+						next = n
+						continued[n] = true
+					}
+				}
+			}
+		}
+
+		seqs = append(seqs, p.newSequence(classes, indices))
+	}
+
+	return seqs
+}
+
+func (p *Paragraph) newSequence(
+	classes []Class,
+	indices []int,
+) *isolatingRunSequence {
+	first, last := indices[0], indices[len(indices)-1]
+	level := p.levels[first]
+
+	prevLevel := p.level
+	for i := first - 1; i >= 0; i -= 1 {
+		if !removed(p.classes[i]) {
+			prevLevel = p.levels[i]
+			break
+		}
+	}
+
+	nextLevel := p.level
+	if c := p.classes[last]; c != LRI && c != RLI && c != FSI {
+		for i := last + 1; i < len(p.classes); i += 1 {
+			if !removed(p.classes[i]) {
+				nextLevel = p.levels[i]
+				break
+			}
+		}
+	}
+
+	return &isolatingRunSequence{
+		p:       p,
+		classes: classes,
+		indices: indices,
+		level:   level,
+		sos:     directionOf(max(level, prevLevel)),
+		eos:     directionOf(max(level, nextLevel)),
+	}
+}
+
+// directionOf returns the embedding direction of level, as L or R.
+func directionOf(level Level) Class {
+	if level.Rtl() {
+		return R
+	}
+
+	return L
+}
+
+func (s *isolatingRunSequence) class(i int) Class {
+	return s.classes[s.indices[i]]
+}
+
+func (s *isolatingRunSequence) setClass(i int, class Class) {
+	s.classes[s.indices[i]] = class
+}
+
+// resolveWeak applies rules W1-W7.
+//
+// https://www.unicode.org/reports/tr9/#Resolving_Weak_Types
+func (s *isolatingRunSequence) resolveWeak() {
+	n := len(s.indices)
+
+	// W1: Non-spacing marks take the type of the previous character.
+	prev := s.sos
+	for i := range n {
+		switch c := s.class(i); c {
+		case NSM:
+			s.setClass(i, prev)
+		case LRI, RLI, FSI, PDI:
+			prev = ON
+		default:
+			prev = c
+		}
+	}
+
+	// W2, W3: European numbers following Arabic letters become Arabic
+	// numbers, then Arabic letters become R.
+	strong := s.sos
+	for i := range n {
+		switch c := s.class(i); c {
+		case L, R:
+			strong = c
+		case AL:
+			strong = AL
+			s.setClass(i, R)
+		case EN:
+			if strong == AL {
+				s.setClass(i, AN)
+			}
+		}
+	}
+
+	// W4: Single separators between numbers of the same type.
+	for i := 1; i < n-1; i += 1 {
+		before, c, after := s.class(i-1), s.class(i), s.class(i+1)
+		switch {
+		case c == ES && before == EN && after == EN:
+			s.setClass(i, EN)
+		case c == CS && before == EN && after == EN:
+			s.setClass(i, EN)
+		case c == CS && before == AN && after == AN:
+			s.setClass(i, AN)
+		}
+	}
+
+	// W5: Terminators adjacent to European numbers.
+	for i := 0; i < n; {
+		if s.class(i) != ET {
+			i += 1
+			continue
+		}
+
+		j := i
+		for j < n && s.class(j) == ET {
+			j += 1
+		}
+
+		if (i > 0 && s.class(i-1) == EN) || (j < n && s.class(j) == EN) {
+			for k := i; k < j; k += 1 {
+				s.setClass(k, EN)
+			}
+		}
+		i = j
+	}
+
+	// W6: Remaining separators and terminators become neutral.
+	for i := range n {
+		switch s.class(i) {
+		case ES, ET, CS:
+			s.setClass(i, ON)
+		}
+	}
+
+	// W7: European numbers following L become L.
+	strong = s.sos
+	for i := range n {
+		switch c := s.class(i); c {
+		case L, R:
+			strong = c
+		case EN:
+			if strong == L {
+				s.setClass(i, L)
+			}
+		}
+	}
+}
+
+// strongDirection returns the direction used for neutral resolution, with
+// numbers treated as R, or ON for other types.
+func strongDirection(class Class) Class {
+	switch class {
+	case L:
+		return L
+	case R, AL, EN, AN:
+		return R
+	}
+
+	return ON
+}
+
+type bracketPair struct {
+	open  int
+	close int
+}
+
+// resolveBrackets applies rule N0, resolving paired brackets to the
+// direction of their content or context.
+//
+// https://www.unicode.org/reports/tr9/#N0
+func (s *isolatingRunSequence) resolveBrackets(text []rune) {
+	const maxStack = 63
+
+	type opener struct {
+		index int
+		close rune
+	}
+
+	var pairs []bracketPair
+	var stack []opener
+
+scan:
+	for i := range s.indices {
+		if s.class(i) != ON {
+			continue
+		}
+
+		char := text[s.indices[i]]
+		if close, ok := bracketOpen[char]; ok {
+			if len(stack) == maxStack {
+				break scan
+			}
+			stack = append(stack, opener{index: i, close: canonicalBracket(close)})
+			continue
+		}
+
+		if _, ok := bracketClose[char]; !ok {
+			continue
+		}
+		for k := len(stack) - 1; k >= 0; k -= 1 {
+			if stack[k].close == canonicalBracket(char) {
+				pairs = append(pairs, bracketPair{open: stack[k].index, close: i})
+				stack = stack[:k]
+				break
+			}
+		}
+	}
+
+	slices.SortFunc(pairs, func(a, b bracketPair) int {
+		return a.open - b.open
+	})
+
+	embedding := directionOf(s.level)
+	for _, pair := range pairs {
+		var found Class = ON
+		for i := pair.open + 1; i < pair.close; i += 1 {
+			dir := strongDirection(s.class(i))
+			if dir == ON {
+				continue
+			}
+
+			found = dir
+			if dir == embedding {
+				break
+			}
+		}
+
+		switch found {
+		case ON:
+			continue
+		case embedding:
+		default:
+			context := s.sos
+			for i := pair.open - 1; i >= 0; i -= 1 {
+				if dir := strongDirection(s.class(i)); dir != ON {
+					context = dir
+					break
+				}
+			}
+			if context != found {
+				found = embedding
+			}
+		}
+
+		for _, i := range []int{pair.open, pair.close} {
+			s.setClass(i, found)
+			for j := i + 1; j < len(s.indices) && s.p.classes[s.indices[j]] == NSM; j += 1 {
+				s.setClass(j, found)
+			}
+		}
+	}
+}
+
+// isNeutral returns true for the neutral and isolate formatting types
+// resolved by rules N1 and N2.
+func isNeutral(class Class) bool {
+	switch class {
+	case B, S, WS, ON, LRI, RLI, FSI, PDI:
+		return true
+	}
+
+	return false
+}
+
+// resolveNeutral applies rules N1 and N2.
+//
+// https://www.unicode.org/reports/tr9/#Resolving_Neutral_Types
+func (s *isolatingRunSequence) resolveNeutral() {
+	n := len(s.indices)
+	embedding := directionOf(s.level)
+
+	for i := 0; i < n; {
+		if !isNeutral(s.class(i)) {
+			i += 1
+			continue
+		}
+
+		j := i
+		for j < n && isNeutral(s.class(j)) {
+			j += 1
+		}
+
+		before := s.sos
+		if i > 0 {
+			before = strongDirection(s.class(i - 1))
+		}
+		after := s.eos
+		if j < n {
+			after = strongDirection(s.class(j))
+		}
+
+		dir := embedding
+		if before == after && before != ON {
+			dir = before
+		}
+		for k := i; k < j; k += 1 {
+			s.setClass(k, dir)
+		}
+
+		i = j
+	}
+}
+
+// resolveImplicit applies rules I1 and I2.
+//
+// https://www.unicode.org/reports/tr9/#Resolving_Implicit_Levels
+func (s *isolatingRunSequence) resolveImplicit() {
+	for i, index := range s.indices {
+		level := &s.p.levels[index]
+
+		switch c := s.class(i); {
+		case !level.Rtl() && c == R:
+			*level += 1
+		case !level.Rtl() && (c == AN || c == EN):
+			*level += 2
+		case level.Rtl() && (c == L || c == EN || c == AN):
+			*level += 1
+		}
+	}
+}
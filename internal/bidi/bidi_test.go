@@ -0,0 +1,90 @@
+package bidi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// visual returns text reordered for display, with right-to-left runs
+// reversed.
+func visual(text string, dir Direction) string {
+	runes := []rune(text)
+	p := NewParagraph(runes, dir)
+
+	var buf strings.Builder
+	for _, run := range p.Line(0, len(runes)) {
+		if !run.Rtl() {
+			buf.WriteString(string(runes[run.Start:run.End]))
+			continue
+		}
+		for i := run.End - 1; i >= run.Start; i -= 1 {
+			buf.WriteRune(Mirror(runes[i]))
+		}
+	}
+
+	return buf.String()
+}
+
+func TestLtr(t *testing.T) {
+	runes := []rune("plain text")
+	p := NewParagraph(runes, Auto)
+	require.Equal(t, LeftToRight, p.Direction())
+	require.Equal(t, []Run{{Start: 0, End: 10}}, p.Line(0, len(runes)))
+}
+
+func TestBaseDirection(t *testing.T) {
+	require.Equal(t, RightToLeft, NewParagraph([]rune("שלום abc"), Auto).Direction())
+	require.Equal(t, LeftToRight, NewParagraph([]rune("abc שלום"), Auto).Direction())
+	require.Equal(t, LeftToRight, NewParagraph([]rune("שלום"), LeftToRight).Direction())
+	require.Equal(t, RightToLeft, NewParagraph([]rune("abc"), RightToLeft).Direction())
+}
+
+func TestMixed(t *testing.T) {
+	// Hebrew sentence with an embedded English product code and a number.
+	require.Equal(t,
+		"XR-200 םגד 12 ינק",
+		visual("קני 12 דגם XR-200", Auto),
+	)
+
+	require.Equal(t, "buy ינק now", visual("buy קני now", Auto))
+	require.Equal(t, "now ינק buy", visual("buy קני now", RightToLeft))
+}
+
+func TestBrackets(t *testing.T) {
+	// Brackets around right-to-left text in a right-to-left paragraph are
+	// mirrored with the text.
+	require.Equal(t, "(בא) גד", visual("דג (אב)", Auto))
+	require.Equal(t, "a (בא)", visual("a (אב)", Auto))
+}
+
+func TestArabicNumbers(t *testing.T) {
+	// Arabic-Indic digits keep their left-to-right order.
+	require.Equal(t, "١٢٣ بأ", visual("أب ١٢٣", Auto))
+}
+
+func TestExplicitIsolate(t *testing.T) {
+	// Right-to-left isolate in a left-to-right paragraph.
+	text := "⁧abc אב⁩ x"
+	p := NewParagraph([]rune(text), Auto)
+	require.Equal(t, LeftToRight, p.Direction())
+	require.Equal(t, Level(2), p.Levels()[1])
+	require.Equal(t, Level(1), p.Levels()[5])
+	require.Equal(t, "⁧בא abc⁩ x", visual(text, Auto))
+}
+
+func TestTrailingWhitespace(t *testing.T) {
+	runes := []rune("אב  ")
+	p := NewParagraph(runes, LeftToRight)
+	runs := p.Line(0, len(runes))
+	require.Equal(t, Run{Start: 0, End: 2, Level: 1}, runs[0])
+	require.Equal(t, Run{Start: 2, End: 4, Level: 0}, runs[1])
+}
+
+func TestBaseDirectionOf(t *testing.T) {
+	require.Equal(t, RightToLeft, BaseDirection([]rune("12 שלום abc")))
+	require.Equal(t, LeftToRight, BaseDirection([]rune("⁧שלום⁩ abc")))
+	require.Equal(t, Auto, BaseDirection([]rune("123 ...")))
+	require.Equal(t, Auto, BaseDirection([]rune("12\nשלום")))
+}
@@ -0,0 +1,254 @@
+package bidi
+
+import (
+	"unicode"
+)
+
+// Class is a Unicode bidirectional character type:
+// https://www.unicode.org/reports/tr9/#Bidirectional_Character_Types
+type Class uint8
+
+const (
+	L   Class = iota // Left-to-right
+	R                // Right-to-left
+	AL               // Arabic letter
+	EN               // European number
+	ES               // European separator
+	ET               // European terminator
+	AN               // Arabic number
+	CS               // Common separator
+	NSM              // Non-spacing mark
+	BN               // Boundary neutral
+	B                // Paragraph separator
+	S                // Segment separator
+	WS               // Whitespace
+	ON               // Other neutral
+	LRE              // Left-to-right embedding
+	LRO              // Left-to-right override
+	RLE              // Right-to-left embedding
+	RLO              // Right-to-left override
+	PDF              // Pop directional format
+	LRI              // Left-to-right isolate
+	RLI              // Right-to-left isolate
+	FSI              // First strong isolate
+	PDI              // Pop directional isolate
+)
+
+// Blocks of right-to-left scripts, excluding Arabic-type scripts.
+var rangesR = [][2]rune{
+	{0x0590, 0x05ff}, // Hebrew
+	{0x07c0, 0x085f}, // NKo, Samaritan, Mandaic
+	{0xfb1d, 0xfb4f}, // Hebrew presentation forms
+	{0x10800, 0x10cff},
+	{0x10d40, 0x10ebf},
+	{0x10f00, 0x10f2f},
+	{0x10f70, 0x10fff},
+	{0x1e800, 0x1ec6f},
+	{0x1ef00, 0x1efff},
+}
+
+// Blocks of Arabic-type scripts.
+var rangesAL = [][2]rune{
+	{0x0600, 0x07bf}, // Arabic, Syriac, Arabic Supplement, Thaana
+	{0x0860, 0x08ff}, // Syriac Supplement, Arabic Extended
+	{0xfb50, 0xfdff}, // Arabic presentation forms A
+	{0xfe70, 0xfeff}, // Arabic presentation forms B
+	{0x10d00, 0x10d3f},
+	{0x10ec0, 0x10eff},
+	{0x10f30, 0x10f6f},
+	{0x1ec70, 0x1eeff},
+}
+
+// ClassOf returns the bidirectional character type of char. Types are
+// derived from explicit tables for directional formatting characters,
+// numbers and separators, and from script blocks and general categories for
+// everything else.
+//
+// https://www.unicode.org/Public/UCD/latest/ucd/extracted/DerivedBidiClass.txt
+func ClassOf(char rune) Class {
+	switch char {
+	case 0x202a:
+		return LRE
+	case 0x202b:
+		return RLE
+	case 0x202c:
+		return PDF
+	case 0x202d:
+		return LRO
+	case 0x202e:
+		return RLO
+	case 0x2066:
+		return LRI
+	case 0x2067:
+		return RLI
+	case 0x2068:
+		return FSI
+	case 0x2069:
+		return PDI
+	case 0x200e: // Left-to-right mark
+		return L
+	case 0x200f: // Right-to-left mark
+		return R
+	case 0x061c: // Arabic letter mark
+		return AL
+
+	case '\n', '\r', 0x1c, 0x1d, 0x1e, 0x85, 0x2029:
+		return B
+	case '\t', 0x0b, 0x1f:
+		return S
+	case ' ', 0x0c, 0x1680, 0x2028, 0x205f, 0x3000:
+		return WS
+
+	case '+', '-', 0x207a, 0x207b, 0x208a, 0x208b, 0x2212, 0xfb29, 0xfe62,
+		0xfe63, 0xff0b, 0xff0d:
+		return ES
+	case ',', '.', '/', ':', 0xa0, 0x060c, 0x202f, 0x2044, 0xfe50, 0xfe52,
+		0xfe55, 0xff0c, 0xff0e, 0xff0f, 0xff1a:
+		return CS
+	case '#', '$', '%', 0xa2, 0xa3, 0xa4, 0xa5, 0xb0, 0xb1, 0x0609, 0x060a,
+		0x066a, 0x09f2, 0x09f3, 0x0e3f, 0x17db, 0x212e, 0x2213, 0xfe5f, 0xfe69,
+		0xfe6a, 0xff03, 0xff04, 0xff05, 0xffe0, 0xffe1, 0xffe5, 0xffe6:
+		return ET
+	case 0xb2, 0xb3, 0xb9, 0x2070:
+		return EN
+	case 0x066b, 0x066c, 0x06dd, 0x0890, 0x0891, 0x08e2:
+		return AN
+	case 0xfd3e, 0xfd3f: // Ornate parentheses
+		return ON
+	}
+
+	switch {
+	case char >= '0' && char <= '9',
+		char >= 0x06f0 && char <= 0x06f9,
+		char >= 0x2074 && char <= 0x2079,
+		char >= 0x2080 && char <= 0x2089,
+		char >= 0x2488 && char <= 0x249b,
+		char >= 0xff10 && char <= 0xff19,
+		char >= 0x1d7ce && char <= 0x1d7ff:
+		return EN
+	case char >= 0x0600 && char <= 0x0605,
+		char >= 0x0660 && char <= 0x0669,
+		char >= 0x10e60 && char <= 0x10e7e:
+		return AN
+	case char >= 0x2000 && char <= 0x200a:
+		return WS
+	case char >= 0x2030 && char <= 0x2034,
+		char >= 0x20a0 && char <= 0x20cf:
+		return ET
+	}
+
+	if unicode.In(char, unicode.Mn, unicode.Me) {
+		return NSM
+	}
+
+	if unicode.IsControl(char) || unicode.Is(unicode.Cf, char) {
+		return BN
+	}
+
+	for _, r := range rangesAL {
+		if char >= r[0] && char <= r[1] {
+			return AL
+		}
+	}
+	for _, r := range rangesR {
+		if char >= r[0] && char <= r[1] {
+			return R
+		}
+	}
+
+	if unicode.In(char, unicode.P, unicode.S) {
+		return ON
+	}
+	if unicode.Is(unicode.Zs, char) {
+		return WS
+	}
+
+	return L
+}
+
+// isRtlTrigger returns true for types that can produce right-to-left levels.
+// Text without any of these is resolved to a single left-to-right run.
+func isRtlTrigger(class Class) bool {
+	switch class {
+	case R, AL, AN, RLE, RLO, RLI, FSI:
+		return true
+	}
+
+	return false
+}
+
+// Mirror returns the mirrored form of char, for display in right-to-left
+// runs, or char itself if it has none.
+//
+// https://www.unicode.org/Public/UCD/latest/ucd/BidiMirroring.txt
+func Mirror(char rune) rune {
+	if m, ok := mirrors[char]; ok {
+		return m
+	}
+
+	return char
+}
+
+// Bracket pairs, as opening/closing characters. See BidiBrackets.txt.
+var bracketPairs = [][2]rune{
+	{'(', ')'}, {'[', ']'}, {'{', '}'},
+	{0x0f3a, 0x0f3b}, {0x0f3c, 0x0f3d}, {0x169b, 0x169c},
+	{0x2045, 0x2046}, {0x207d, 0x207e}, {0x208d, 0x208e},
+	{0x2308, 0x2309}, {0x230a, 0x230b}, {0x2329, 0x232a},
+	{0x2768, 0x2769}, {0x276a, 0x276b}, {0x276c, 0x276d}, {0x276e, 0x276f},
+	{0x2770, 0x2771}, {0x2772, 0x2773}, {0x2774, 0x2775},
+	{0x27c5, 0x27c6}, {0x27e6, 0x27e7}, {0x27e8, 0x27e9}, {0x27ea, 0x27eb},
+	{0x27ec, 0x27ed}, {0x27ee, 0x27ef},
+	{0x2983, 0x2984}, {0x2985, 0x2986}, {0x2987, 0x2988}, {0x2989, 0x298a},
+	{0x298b, 0x298c}, {0x298d, 0x2990}, {0x298f, 0x298e}, {0x2991, 0x2992},
+	{0x2993, 0x2994}, {0x2995, 0x2996}, {0x2997, 0x2998},
+	{0x29d8, 0x29d9}, {0x29da, 0x29db}, {0x29fc, 0x29fd},
+	{0x3008, 0x3009}, {0x300a, 0x300b}, {0x300c, 0x300d}, {0x300e, 0x300f},
+	{0x3010, 0x3011}, {0x3014, 0x3015}, {0x3016, 0x3017}, {0x3018, 0x3019},
+	{0x301a, 0x301b},
+	{0xfe59, 0xfe5a}, {0xfe5b, 0xfe5c}, {0xfe5d, 0xfe5e},
+	{0xff08, 0xff09}, {0xff3b, 0xff3d}, {0xff5b, 0xff5d}, {0xff5f, 0xff60},
+	{0xff62, 0xff63},
+}
+
+// Mirrored characters that aren't brackets.
+var mirrorPairs = [][2]rune{
+	{'<', '>'}, {0xab, 0xbb}, {0x2039, 0x203a},
+	{0x2208, 0x220b}, {0x2209, 0x220c}, {0x220a, 0x220d},
+	{0x2264, 0x2265}, {0x2266, 0x2267}, {0x226a, 0x226b},
+	{0x2282, 0x2283}, {0x2286, 0x2287},
+	{0xff1c, 0xff1e},
+}
+
+var (
+	bracketOpen  = map[rune]rune{}
+	bracketClose = map[rune]rune{}
+	mirrors      = map[rune]rune{}
+)
+
+func init() {
+	for _, pair := range bracketPairs {
+		bracketOpen[pair[0]] = pair[1]
+		bracketClose[pair[1]] = pair[0]
+	}
+
+	for _, pairs := range [][][2]rune{bracketPairs, mirrorPairs} {
+		for _, pair := range pairs {
+			mirrors[pair[0]] = pair[1]
+			mirrors[pair[1]] = pair[0]
+		}
+	}
+}
+
+// canonicalBracket maps brackets with canonical equivalents to a single
+// form, for bracket pair matching.
+func canonicalBracket(char rune) rune {
+	switch char {
+	case 0x2329:
+		return 0x3008
+	case 0x232a:
+		return 0x3009
+	}
+
+	return char
+}
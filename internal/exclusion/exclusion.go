@@ -0,0 +1,92 @@
+// Package exclusion finds the space left for lines of text beside areas of a
+// page that text is kept out of, e.g. floating images.
+package exclusion
+
+// Point is a point on the page, with y increasing downwards.
+type Point struct {
+	X, Y float32
+}
+
+// Polygon is an area that text is kept out of.
+type Polygon []Point
+
+// Rect returns the polygon of a rectangle, at x, y with width w and height h.
+func Rect(x, y, w, h float32) Polygon {
+	return Polygon{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}}
+}
+
+// Extent returns the horizontal extent of the part of the polygon between y0
+// and y1, and whether any of it is between them.
+func (p Polygon) Extent(y0, y1 float32) (x0, x1 float32, ok bool) {
+	add := func(x float32) {
+		if !ok {
+			x0, x1, ok = x, x, true
+			return
+		}
+		x0, x1 = min(x0, x), max(x1, x)
+	}
+
+	for i, a := range p {
+		b := p[(i+1)%len(p)]
+		if a.Y > b.Y {
+			a, b = b, a
+		}
+
+		// Edges that only touch the line are skipped.
+		if b.Y <= y0 || a.Y >= y1 {
+			continue
+		}
+
+		// The ends of the part of the edge between y0 and y1.
+		if a.Y == b.Y {
+			add(a.X)
+			add(b.X)
+			continue
+		}
+		at := func(y float32) float32 {
+			return a.X + (b.X-a.X)*(y-a.Y)/(b.Y-a.Y)
+		}
+		add(at(max(a.Y, y0)))
+		add(at(min(b.Y, y1)))
+	}
+
+	return x0, x1, ok
+}
+
+// Space returns the widest space between left and right beside the polygons,
+// for a line between y0 and y1. ok is false if it's narrower than minWidth.
+func Space(
+	polygons []Polygon,
+	left, right, y0, y1, minWidth float32,
+) (x0, x1 float32, ok bool) {
+	// The spans blocked by polygons, in order.
+	var blocked [][2]float32
+	for _, p := range polygons {
+		b0, b1, ok := p.Extent(y0, y1)
+		if !ok || b1 <= left || b0 >= right {
+			continue
+		}
+
+		i := 0
+		for i < len(blocked) && blocked[i][0] < b0 {
+			i++
+		}
+		blocked = append(blocked, [2]float32{})
+		copy(blocked[i+1:], blocked[i:])
+		blocked[i] = [2]float32{b0, b1}
+	}
+
+	x0, x1 = left, left
+	start := left
+	for _, b := range blocked {
+		if b[0]-start > x1-x0 {
+			x0, x1 = start, b[0]
+		}
+		start = max(start, b[1])
+	}
+	if right-start > x1-x0 {
+		x0, x1 = start, right
+	}
+
+	return x0, x1, x1-x0 >= minWidth
+}
@@ -0,0 +1,45 @@
+package exclusion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtent(t *testing.T) {
+	triangle := Polygon{{0, 0}, {10, 10}, {0, 10}}
+
+	x0, x1, ok := triangle.Extent(4, 6)
+	require.True(t, ok)
+	require.Equal(t, [2]float32{0, 6}, [2]float32{x0, x1})
+
+	_, _, ok = triangle.Extent(11, 12)
+	require.False(t, ok)
+
+	x0, x1, ok = Rect(5, 5, 10, 10).Extent(0, 6)
+	require.True(t, ok)
+	require.Equal(t, [2]float32{5, 15}, [2]float32{x0, x1})
+}
+
+func TestSpace(t *testing.T) {
+	left := Rect(0, 0, 30, 20)
+	right := Rect(80, 10, 20, 20)
+
+	space := func(y0, y1, minWidth float32) []float32 {
+		x0, x1, ok := Space([]Polygon{left, right}, 0, 100, y0, y1, minWidth)
+		if !ok {
+			return nil
+		}
+		return []float32{x0, x1}
+	}
+
+	require.Equal(t, []float32{30, 100}, space(0, 5, 10))
+	require.Equal(t, []float32{30, 80}, space(5, 15, 10))
+	require.Equal(t, []float32{0, 80}, space(20, 30, 10))
+	require.Equal(t, []float32{0, 100}, space(40, 50, 10))
+	require.Nil(t, space(5, 15, 60))
+
+	// The wider side of a polygon in the middle is used.
+	x0, x1, _ := Space([]Polygon{Rect(20, 0, 20, 10)}, 0, 100, 0, 10, 0)
+	require.Equal(t, [2]float32{40, 100}, [2]float32{x0, x1})
+}
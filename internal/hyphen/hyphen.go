@@ -0,0 +1,204 @@
+// Package hyphen implements Liang's hyphenation algorithm, with the pattern
+// files used by TeX (e.g. from https://github.com/hyphenation/tex-hyphen), as
+// described in "Word Hy-phen-a-tion by Com-put-er" (Liang, 1983).
+package hyphen
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SoftHyphen marks a point at which a word can be hyphenated. It's only
+// displayed, as a hyphen, when a line is broken there.
+const SoftHyphen = '\u00ad'
+
+// Patterns are the hyphenation patterns and exceptions for a language.
+type Patterns struct {
+	// Inter-letter values, keyed by the letters of each pattern. There's one
+	// value more than there are letters, for the positions before, between
+	// and after them.
+	patterns   map[string][]uint8
+	maxLen     int
+	exceptions map[string][]int
+
+	// Minimum number of letters before and after a hyphen.
+	LeftMin, RightMin int
+}
+
+// New returns an empty set of patterns, with TeX's default minimum of 2
+// letters before a hyphen and 3 after.
+func New() *Patterns {
+	return &Patterns{
+		patterns:   map[string][]uint8{},
+		exceptions: map[string][]int{},
+		LeftMin:    2,
+		RightMin:   3,
+	}
+}
+
+// Parse adds the patterns and exceptions in a pattern file: either a TeX file,
+// with \patterns{...} and \hyphenation{...} groups, or plain text with one
+// pattern or exception per line. Exceptions are words with their hyphenation
+// points marked with hyphens, e.g. "as-so-ciate". Comments start with '%'.
+func (p *Patterns) Parse(data []byte) error {
+	var lines []string
+	for line := range strings.Lines(string(data)) {
+		if i := strings.IndexByte(line, '%'); i >= 0 {
+			line = line[:i]
+		}
+		lines = append(lines, line)
+	}
+	text := strings.Join(lines, "\n")
+
+	if !strings.Contains(text, `\patterns`) && !strings.Contains(text, `\hyphenation`) {
+		return p.parseTokens(text, "")
+	}
+
+	for _, group := range []string{`\patterns`, `\hyphenation`} {
+		rest := text
+		for {
+			start := strings.Index(rest, group+"{")
+			if start < 0 {
+				break
+			}
+			rest = rest[start+len(group)+1:]
+
+			end := strings.IndexByte(rest, '}')
+			if end < 0 {
+				return fmt.Errorf("unterminated %s group", group)
+			}
+			if err := p.parseTokens(rest[:end], group); err != nil {
+				return err
+			}
+			rest = rest[end+1:]
+		}
+	}
+
+	return nil
+}
+
+// parseTokens adds the whitespace-separated patterns or exceptions in text.
+// Without a group, tokens with hyphens are taken to be exceptions.
+func (p *Patterns) parseTokens(text, group string) error {
+	for token := range strings.FieldsSeq(text) {
+		var err error
+		switch {
+		case group == `\hyphenation`,
+			group == "" && strings.ContainsRune(token, '-'):
+			p.addException(token)
+		default:
+			err = p.addPattern(token)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addPattern adds a pattern, e.g. ".ach4", of letters with digits giving the
+// values of the positions between them.
+func (p *Patterns) addPattern(pattern string) error {
+	letters := make([]rune, 0, len(pattern))
+	values := []uint8{0}
+	for _, char := range pattern {
+		switch {
+		case char >= '0' && char <= '9':
+			values[len(values)-1] = uint8(char - '0')
+		case char == '.' || unicode.IsLetter(char) || unicode.IsMark(char) ||
+			char == '\'' || char == '’':
+			letters = append(letters, unicode.ToLower(char))
+			values = append(values, 0)
+		default:
+			return fmt.Errorf("invalid hyphenation pattern %q", pattern)
+		}
+	}
+
+	if len(letters) == 0 {
+		return fmt.Errorf("invalid hyphenation pattern %q", pattern)
+	}
+
+	p.patterns[string(letters)] = values
+	p.maxLen = max(p.maxLen, len(letters))
+
+	return nil
+}
+
+// addException adds a word with its hyphenation points marked by hyphens.
+func (p *Patterns) addException(word string) {
+	var letters []rune
+	var points []int
+	for _, char := range word {
+		if char == '-' {
+			points = append(points, len(letters))
+			continue
+		}
+		letters = append(letters, unicode.ToLower(char))
+	}
+
+	p.exceptions[string(letters)] = points
+}
+
+// Hyphenate returns the indices of the letters in word that it can be
+// hyphenated before, in ascending order.
+func (p *Patterns) Hyphenate(word []rune) []int {
+	lower := make([]rune, len(word))
+	for i, char := range word {
+		lower[i] = unicode.ToLower(char)
+	}
+
+	if points, ok := p.exceptions[string(lower)]; ok {
+		return slices.Clone(points)
+	}
+
+	if len(word) < p.LeftMin+p.RightMin {
+		return nil
+	}
+
+	// Values of the positions between the letters of ".word.", with each
+	// pattern found in the word raising them to its own values.
+	dotted := slices.Concat([]rune{'.'}, lower, []rune{'.'})
+	values := make([]uint8, len(dotted)+1)
+
+	var key []byte
+	for start := range dotted {
+		key = key[:0]
+		for end := start; end < min(len(dotted), start+p.maxLen); end++ {
+			key = utf8.AppendRune(key, dotted[end])
+			pattern, ok := p.patterns[string(key)]
+			if !ok {
+				continue
+			}
+
+			for i, v := range pattern {
+				values[start+i] = max(values[start+i], v)
+			}
+		}
+	}
+
+	// Odd values allow hyphens. The position before word[i] is i+1 in the
+	// dotted word.
+	var points []int
+	for i := p.LeftMin; i <= len(word)-p.RightMin; i++ {
+		if values[i+1]%2 == 1 {
+			points = append(points, i)
+		}
+	}
+
+	return points
+}
+
+// SoftHyphens returns the indices of the soft hyphens in word.
+func SoftHyphens(word []rune) (points []int) {
+	for i, char := range word {
+		if char == SoftHyphen {
+			points = append(points, i)
+		}
+	}
+
+	return points
+}
@@ -0,0 +1,76 @@
+package hyphen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// hyphenated returns word with hyphens at its hyphenation points.
+func hyphenated(p *Patterns, word string) string {
+	runes := []rune(word)
+
+	var buf strings.Builder
+	prev := 0
+	for _, point := range p.Hyphenate(runes) {
+		buf.WriteString(string(runes[prev:point]))
+		buf.WriteByte('-')
+		prev = point
+	}
+	buf.WriteString(string(runes[prev:]))
+
+	return buf.String()
+}
+
+// Patterns from Liang's thesis, for "hyphenation".
+const liang = `
+% Comments are ignored.
+hy3ph he2n hena4 hen5at 1na n2at 1tio 2io o2n
+`
+
+func TestHyphenate(t *testing.T) {
+	p := New()
+	require.NoError(t, p.Parse([]byte(liang)))
+
+	require.Equal(t, "hy-phen-ation", hyphenated(p, "hyphenation"))
+	require.Equal(t, "Hy-phen-ation", hyphenated(p, "Hyphenation"))
+	require.Equal(t, "na-tion", hyphenated(p, "nation"))
+
+	// Words too short for the minimum letters before and after a hyphen.
+	require.Equal(t, "tion", hyphenated(p, "tion"))
+
+	p.LeftMin = 5
+	require.Equal(t, "hyphen-ation", hyphenated(p, "hyphenation"))
+}
+
+func TestParseTex(t *testing.T) {
+	p := New()
+	require.NoError(t, p.Parse([]byte(`
+\patterns{ % Patterns
+hy3ph he2n hena4 hen5at 1na n2at 1tio 2io o2n
+}
+\hyphenation{
+ta-ble
+}
+`)))
+
+	require.Equal(t, "hy-phen-ation", hyphenated(p, "hyphenation"))
+	require.Equal(t, "ta-ble", hyphenated(p, "table"))
+
+	require.Error(t, p.Parse([]byte(`\patterns{ a1b`)))
+	require.Error(t, p.Parse([]byte(`a1b \foo`)))
+}
+
+func TestExceptions(t *testing.T) {
+	p := New()
+	require.NoError(t, p.Parse([]byte("1na\nas-so-ciate\n")))
+
+	require.Equal(t, "as-so-ciate", hyphenated(p, "associate"))
+	require.Equal(t, "As-so-ciate", hyphenated(p, "Associate"))
+}
+
+func TestSoftHyphens(t *testing.T) {
+	require.Equal(t, []int{3, 7}, SoftHyphens([]rune("abc\u00addef\u00adg")))
+	require.Nil(t, SoftHyphens([]rune("abc")))
+}
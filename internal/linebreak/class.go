@@ -0,0 +1,239 @@
+package linebreak
+
+import (
+	"unicode"
+)
+
+// Class is a Unicode line breaking class:
+// https://www.unicode.org/reports/tr14/#Table1
+type Class uint8
+
+const (
+	XX  Class = iota // Unknown
+	AL               // Alphabetic
+	BK               // Mandatory break
+	CR               // Carriage return
+	LF               // Line feed
+	NL               // Next line
+	SP               // Space
+	ZW               // Zero width space
+	ZWJ              // Zero width joiner
+	CM               // Combining mark
+	WJ               // Word joiner
+	GL               // Non-breaking ("glue")
+	BA               // Break after
+	BB               // Break before
+	B2               // Break opportunity before and after
+	HY               // Hyphen
+	CB               // Contingent break opportunity
+	CL               // Close punctuation
+	CP               // Close parenthesis
+	EX               // Exclamation/interrogation
+	IN               // Inseparable
+	NS               // Nonstarter
+	OP               // Open punctuation
+	QU               // Quotation
+	IS               // Infix numeric separator
+	NU               // Numeric
+	PO               // Postfix numeric
+	PR               // Prefix numeric
+	SY               // Symbols allowing break after
+	HL               // Hebrew letter
+	ID               // Ideographic
+	CJ               // Conditional Japanese starter
+	EB               // Emoji base
+	EM               // Emoji modifier
+	RI               // Regional indicator
+	SA               // Complex context dependent (South East Asian)
+)
+
+// Blocks of ideographs, syllabaries and other characters that lines can be
+// broken between, as in Chinese, Japanese, Korean and Yi text, and emoji.
+var rangesID = [][2]rune{
+	{0x1100, 0x115f},   // Hangul leading jamo
+	{0x2e80, 0x2fff},   // CJK radicals, Kangxi radicals
+	{0x3000, 0x303f},   // CJK symbols and punctuation
+	{0x3040, 0x30ff},   // Hiragana, Katakana
+	{0x3100, 0x31ff},   // Bopomofo, Hangul compatibility jamo, Kanbun
+	{0x3200, 0x4dbf},   // Enclosed CJK, CJK compatibility, CJK extension A
+	{0x4e00, 0x9fff},   // CJK unified ideographs
+	{0xa000, 0xa4cf},   // Yi
+	{0xac00, 0xd7a3},   // Hangul syllables
+	{0xf900, 0xfaff},   // CJK compatibility ideographs
+	{0xfe30, 0xfe4f},   // CJK compatibility forms
+	{0xff00, 0xff60},   // Fullwidth forms
+	{0xffe0, 0xffe6},   // Fullwidth signs
+	{0x1f000, 0x1faff}, // Emoji and pictographs
+	{0x20000, 0x3fffd}, // CJK extensions B onwards
+}
+
+// Blocks of scripts written without spaces between words, which need a
+// dictionary to find line breaks.
+var rangesSA = [][2]rune{
+	{0x0e00, 0x0eff}, // Thai, Lao
+	{0x1000, 0x109f}, // Myanmar
+	{0x1780, 0x17ff}, // Khmer
+	{0x1950, 0x19df}, // Tai Le, New Tai Lue
+	{0x1a20, 0x1aaf}, // Tai Tham
+	{0xaa60, 0xaadf}, // Myanmar extended, Tai Viet
+}
+
+// ClassOf returns the line breaking class of char. Classes are derived from
+// explicit tables for spaces, punctuation and other characters with special
+// behaviour, and from script blocks and general categories for everything
+// else.
+//
+// https://www.unicode.org/Public/UCD/latest/ucd/LineBreak.txt
+func ClassOf(char rune) Class {
+	switch char {
+	case '\n':
+		return LF
+	case '\r':
+		return CR
+	case 0x0b, 0x0c, 0x2028, 0x2029:
+		return BK
+	case 0x85:
+		return NL
+	case ' ':
+		return SP
+	case 0x200b:
+		return ZW
+	case 0x200d:
+		return ZWJ
+	case 0x200c:
+		return CM
+	case 0x2060, 0xfeff:
+		return WJ
+	case 0xa0, 0x034f, 0x0f0c, 0x2007, 0x2011, 0x202f:
+		return GL
+
+	case '\t', '|', 0xad, 0x058a, 0x1680, 0x2010, 0x2012, 0x2013, 0x2027,
+		0x205f, 0x3000:
+		return BA
+	case 0xb4, 0x02c8, 0x02cc, 0x02df:
+		return BB
+	case 0x2014, 0x2e3a, 0x2e3b:
+		return B2
+	case '-':
+		return HY
+	case 0xfffc:
+		return CB
+
+	case '}', 0x0f3b, 0x0f3d, 0x169c, 0x2046, 0x207e, 0x208e, 0x2309,
+		0x230b, 0x232a, 0x3001, 0x3002, 0x3009, 0x300b, 0x300d, 0x300f, 0x3011,
+		0x3015, 0x3017, 0x3019, 0x301b, 0x301e, 0x301f, 0xfe11, 0xfe12,
+		0xfe36, 0xfe38, 0xfe3a, 0xfe3c, 0xfe3e, 0xfe40, 0xfe42, 0xfe44, 0xfe48,
+		0xfe50, 0xfe52, 0xfe5a, 0xfe5c, 0xfe5e, 0xff0c, 0xff0e, 0xff5d, 0xff60,
+		0xff61, 0xff63, 0xff64:
+		return CL
+	case ')', ']', 0xff09, 0xff3d:
+		return CP
+	case '!', '?', 0x05c6, 0x061b, 0x061f, 0x06d4, 0x07f9, 0x0f0d, 0x203c,
+		0x2047, 0x2048, 0x2049, 0xfe15, 0xfe16, 0xfe56, 0xfe57, 0xff01, 0xff1f:
+		return EX
+	case 0x2024, 0x2025, 0x2026, 0xfe19:
+		return IN
+	case 0x17d6, 0x203d, 0x3005, 0x301c, 0x303b, 0x303c, 0x309b,
+		0x309c, 0x309d, 0x309e, 0x30a0, 0x30fb, 0x30fd, 0x30fe, 0xfe54, 0xfe55,
+		0xff1a, 0xff1b, 0xff65, 0xff9e, 0xff9f:
+		return NS
+	case '(', '[', '{', 0xa1, 0xbf, 0x0f3a, 0x0f3c, 0x169b, 0x201a, 0x201e,
+		0x2045, 0x207d, 0x208d, 0x2308, 0x230a, 0x2329, 0x2e18, 0x3008, 0x300a,
+		0x300c, 0x300e, 0x3010, 0x3014, 0x3016, 0x3018, 0x301a, 0x301d, 0xfe35,
+		0xfe37, 0xfe39, 0xfe3b, 0xfe3d, 0xfe3f, 0xfe41, 0xfe43, 0xfe47, 0xfe59,
+		0xfe5b, 0xfe5d, 0xff08, 0xff3b, 0xff5b, 0xff5f, 0xff62:
+		return OP
+	case '"', '\'', 0xab, 0xbb, 0x2018, 0x2019, 0x201b, 0x201c, 0x201d,
+		0x201f, 0x2039, 0x203a, 0x275b, 0x275c, 0x275d, 0x275e, 0x2e00, 0x2e01,
+		0x2e02, 0x2e03, 0x2e04, 0x2e05, 0x2e06, 0x2e07, 0x2e08, 0x2e0b:
+		return QU
+	case ',', '.', ':', ';', 0x037e, 0x0589, 0x060c, 0x060d, 0x07f8, 0x2044,
+		0xfe10, 0xfe13, 0xfe14:
+		return IS
+	case '%', 0xa2, 0xb0, 0x0609, 0x060a, 0x060b, 0x066a, 0x2030, 0x2031,
+		0x2032, 0x2033, 0x2034, 0x2035, 0x2036, 0x2037, 0x20a7, 0x2103, 0x2109,
+		0xfe6a, 0xff05, 0xffe0:
+		return PO
+	case '$', '+', '\\', 0xa3, 0xa4, 0xa5, 0xb1, 0x058f, 0x09fb, 0x0af1,
+		0x0bf9, 0x0e3f, 0x17db, 0x2116, 0x2212, 0x2213, 0xfe69, 0xff04, 0xffe1,
+		0xffe5, 0xffe6:
+		return PR
+	case '/':
+		return SY
+
+	// Small kana and the prolonged sound mark, which can't start a line in
+	// strict Japanese line breaking.
+	case 0x3041, 0x3043, 0x3045, 0x3047, 0x3049, 0x3063, 0x3083, 0x3085,
+		0x3087, 0x308e, 0x3095, 0x3096, 0x30a1, 0x30a3, 0x30a5, 0x30a7, 0x30a9,
+		0x30c3, 0x30e3, 0x30e5, 0x30e7, 0x30ee, 0x30f5, 0x30f6, 0x30fc, 0xff67,
+		0xff68, 0xff69, 0xff6a, 0xff6b, 0xff6c, 0xff6d, 0xff6e, 0xff6f, 0xff70:
+		return CJ
+	}
+
+	switch {
+	case char >= 0x2000 && char <= 0x2006, char >= 0x2008 && char <= 0x200a:
+		return BA
+	case char >= 0x20a0 && char <= 0x20cf:
+		return PR
+	case char >= 0x1f1e6 && char <= 0x1f1ff:
+		return RI
+	case char >= 0x1f3fb && char <= 0x1f3ff:
+		return EM
+	case char >= 0x261d && char <= 0x270d,
+		char >= 0x1f385 && char <= 0x1f3cc,
+		char >= 0x1f442 && char <= 0x1f4aa,
+		char >= 0x1f574 && char <= 0x1f64f,
+		char >= 0x1f6a3 && char <= 0x1f6cc,
+		char >= 0x1f90c && char <= 0x1f9dd:
+		if unicode.Is(unicode.So, char) {
+			return EB
+		}
+	}
+
+	if unicode.In(char, unicode.Mn, unicode.Mc, unicode.Me) {
+		return CM
+	}
+	if unicode.IsControl(char) {
+		return CM
+	}
+	if unicode.Is(unicode.Nd, char) {
+		return NU
+	}
+	if unicode.Is(unicode.Hebrew, char) && unicode.IsLetter(char) {
+		return HL
+	}
+
+	for _, r := range rangesID {
+		if char >= r[0] && char <= r[1] {
+			return ID
+		}
+	}
+	for _, r := range rangesSA {
+		if char >= r[0] && char <= r[1] {
+			return SA
+		}
+	}
+
+	if unicode.IsLetter(char) || unicode.In(char, unicode.P, unicode.S, unicode.No) {
+		return AL
+	}
+
+	return XX
+}
+
+// resolve maps the classes that UAX #14 leaves to tailoring to the classes
+// they're treated as: ambiguous and unknown characters as alphabetic, as
+// there's no dictionary for South East Asian scripts, and conditional
+// Japanese starters as nonstarters.
+//
+// https://www.unicode.org/reports/tr14/#LB1
+func resolve(class Class) Class {
+	switch class {
+	case XX, SA:
+		return AL
+	case CJ:
+		return NS
+	}
+
+	return class
+}
@@ -0,0 +1,562 @@
+// Package linebreak implements total-fit line breaking, which chooses the
+// line breaks of a paragraph together to minimise the variation in spacing
+// between lines, as described in "Breaking Paragraphs into Lines" (Knuth &
+// Plass, 1981).
+package linebreak
+
+import (
+	"math"
+	"slices"
+	"unicode"
+
+	"github.com/kofi-q/scribe-go/internal/hyphen"
+)
+
+// Params are the costs and spacing limits that lines are chosen by.
+type Params struct {
+	// Stretch and Shrink are the amounts spaces can grow and shrink by, as
+	// fractions of the width of a space.
+	Stretch, Shrink float32
+
+	// Tolerance is the largest adjustment ratio allowed for a line: the
+	// stretch used by its spaces, as a multiple of their Stretch.
+	Tolerance float32
+
+	// LinePenalty is added to the badness of each line.
+	LinePenalty float32
+
+	// FitnessPenalty is added to the demerits of a line that is much tighter
+	// or looser than the previous one.
+	FitnessPenalty float32
+
+	// HyphenPenalty is the penalty for breaking a line at a hyphenation
+	// point.
+	HyphenPenalty float32
+
+	// DoubleHyphenDemerits are added for consecutive hyphenated lines.
+	DoubleHyphenDemerits float32
+}
+
+type breakItemKind uint8
+
+const (
+	breakItemBox breakItemKind = iota
+	breakItemGlue
+	breakItemPenalty
+)
+
+// Penalties at or beyond these values forbid and force line breaks.
+const (
+	penaltyForbid = 10000
+	penaltyForce  = -10000
+)
+
+// Penalty for breaking a word that is too long to fit on a line, between any
+// two characters.
+const penaltyWordBreak = 5000
+
+// Badness of lines that are too loose to fit within the tolerance, as in TeX.
+const badnessMax = 10000
+
+// Extra stretch of each line, as a fraction of its width, when there's no way
+// to break a paragraph within the tolerance.
+const emergencyStretch = 0.25
+
+// breakItem is a box (characters), glue (a space) or a penalty (a possible
+// break, at a cost) in a paragraph.
+type breakItem struct {
+	kind    breakItemKind
+	width   float32
+	stretch float32
+	shrink  float32
+	penalty float32
+
+	// Hyphenation point, with a hyphen added to the line if broken here.
+	flagged bool
+
+	// Rune index in the paragraph of the start of the item.
+	pos int
+}
+
+// breakNode is a feasible line break, with the best chain of breaks before
+// it.
+type breakNode struct {
+	item      int
+	line      int
+	fitness   int
+	demerits  float32
+	prev      *breakNode
+	active    bool
+	flagged   bool
+	width     float32 // Totals of items after the break.
+	stretch   float32
+	shrink    float32
+	nextStart int // Index of the first item of the next line.
+}
+
+// Line is a line of text chosen by the line breaker, as rune indices in its
+// paragraph.
+type Line struct {
+	Start, End int
+
+	// Natural width of the line, in glyph units, including any hyphen.
+	Width float32
+
+	// The line ends at a hyphenation point, and needs a hyphen added.
+	Hyphen bool
+}
+
+// Break chooses the line breaks of a paragraph with no line feeds, for lines
+// of widthMax glyph units. indent is the width of text already on the first
+// line. width returns the width of char, in glyph units, including any kerning
+// after prev, which is negative at the start of words. hyphens, if not nil,
+// returns the indices in a word that it can be hyphenated before.
+func (lb *Params) Break(
+	text []rune,
+	widthMax, indent float32,
+	width func(prev, char rune) float32,
+	hyphens func(word []rune) []int,
+) []Line {
+	return lb.BreakFunc(
+		text,
+		widthMax,
+		indent,
+		func(_ int, prev, char rune) float32 { return width(prev, char) },
+		hyphens,
+	)
+}
+
+// BreakFunc is Break for text whose characters are measured individually,
+// e.g. when set in several fonts and sizes. width returns the width of char,
+// which is text[i] or, at a hyphenation point before text[i+1], the hyphen
+// added to the line, including any kerning after prev.
+func (lb *Params) BreakFunc(
+	text []rune,
+	widthMax, indent float32,
+	width func(i int, prev, char rune) float32,
+	hyphens func(word []rune) []int,
+) []Line {
+	widths := func(int) float32 { return widthMax }
+	return lb.breakShape(text, widths, false, indent, width, hyphens)
+}
+
+// BreakShape is BreakFunc for lines of varying widths, e.g. around images.
+// widths returns the width of each line, in glyph units, by its index in the
+// paragraph.
+func (lb *Params) BreakShape(
+	text []rune,
+	widths func(line int) float32,
+	indent float32,
+	width func(i int, prev, char rune) float32,
+	hyphens func(word []rune) []int,
+) []Line {
+	return lb.breakShape(text, widths, true, indent, width, hyphens)
+}
+
+// breakShape chooses the line breaks of a paragraph for lines of the given
+// widths. Breaks ending different lines are only compared if varying, as
+// the lines after them may have different widths.
+func (lb *Params) breakShape(
+	text []rune,
+	widths func(line int) float32,
+	varying bool,
+	indent float32,
+	width func(i int, prev, char rune) float32,
+	hyphens func(word []rune) []int,
+) []Line {
+	items := lb.items(text, widths(0), width, hyphens)
+
+	nodes := lb.breakNodes(items, widths, varying, indent, lb.Tolerance, 0)
+	if nodes == nil {
+		// Failing that, lines are allowed any amount of stretch, with extra
+		// stretch given to every line so that lines without spaces, and
+		// lines with few, are weighed against each other.
+		nodes = lb.breakNodes(
+			items,
+			widths,
+			varying,
+			indent,
+			float32(math.Inf(1)),
+			emergencyStretch,
+		)
+	}
+
+	lines := make([]Line, len(nodes)-1)
+	for i := range lines {
+		from, to := nodes[i], nodes[i+1]
+		end := items[to.item].pos
+		start := min(items[from.nextStart].pos, end)
+
+		lines[i] = Line{
+			Start:  start,
+			End:    end,
+			Width:  lb.lineWidth(items, from, to),
+			Hyphen: to.flagged,
+		}
+	}
+
+	return lines
+}
+
+// items converts text to boxes for words, glue for spaces and penalties for
+// the other line break opportunities found by Opportunities(), at hyphenation
+// points and within words too long to fit on a line.
+func (lb *Params) items(
+	text []rune,
+	widthMax float32,
+	width func(i int, prev, char rune) float32,
+	hyphens func(word []rune) []int,
+) (items []breakItem) {
+	word := -1 // Start of the current word.
+
+	// addBox adds the characters in text[start:end], split into single
+	// characters if too wide for a line.
+	addBox := func(start, end int) {
+		var boxWidth float32
+		prev := rune(-1)
+		for i := start; i < end; i++ {
+			boxWidth += width(i, prev, text[i])
+			prev = text[i]
+		}
+
+		if boxWidth <= widthMax {
+			items = append(items, breakItem{width: boxWidth, pos: start})
+			return
+		}
+
+		prev = -1
+		for i := start; i < end; i++ {
+			if i > start {
+				items = append(items, breakItem{
+					kind:    breakItemPenalty,
+					penalty: penaltyWordBreak,
+					pos:     i,
+				})
+			}
+			items = append(items, breakItem{width: width(i, prev, text[i]), pos: i})
+			prev = text[i]
+		}
+	}
+
+	endWord := func(end int) {
+		if word < 0 {
+			return
+		}
+
+		var points []int
+		if hyphens != nil {
+			points = hyphens(text[word:end])
+		}
+
+		start := word
+		for _, point := range points {
+			if word+point <= start || word+point >= end {
+				continue
+			}
+
+			addBox(start, word+point)
+			items = append(items, breakItem{
+				kind:    breakItemPenalty,
+				width:   width(word+point-1, -1, '-'),
+				penalty: lb.HyphenPenalty,
+				flagged: true,
+				pos:     word + point,
+			})
+
+			start = word + point
+			if text[start] == hyphen.SoftHyphen {
+				start++
+			}
+		}
+		addBox(start, end)
+
+		word = -1
+	}
+
+	ops := Opportunities(text)
+	for i, char := range text {
+		if IsSpace(char) || unicode.IsSpace(char) {
+			endWord(i)
+
+			// No-break spaces stretch and shrink like other spaces, but
+			// lines can't be broken at them.
+			if !IsSpace(char) {
+				items = append(items, breakItem{
+					kind:    breakItemPenalty,
+					penalty: penaltyForbid,
+					pos:     i,
+				})
+			}
+
+			w := width(i, -1, char)
+			items = append(items, breakItem{
+				kind:    breakItemGlue,
+				width:   w,
+				stretch: w * lb.Stretch,
+				shrink:  w * lb.Shrink,
+				pos:     i,
+			})
+			continue
+		}
+
+		// Break opportunities within words, e.g. between ideographs, after
+		// hyphens and slashes, and at zero width spaces.
+		if word >= 0 && ops[i] != Prohibited {
+			endWord(i)
+			items = append(items, breakItem{kind: breakItemPenalty, pos: i})
+		}
+
+		if word < 0 {
+			word = i
+		}
+	}
+	endWord(len(text))
+
+	// Finish with glue that fills out the last line.
+	return append(items,
+		breakItem{kind: breakItemPenalty, penalty: penaltyForbid, pos: len(text)},
+		breakItem{kind: breakItemGlue, stretch: float32(math.Inf(1)), pos: len(text)},
+		breakItem{kind: breakItemPenalty, penalty: penaltyForce, pos: len(text)},
+	)
+}
+
+// breakNodes returns the best sequence of breaks for items, starting with the
+// start of the paragraph and ending with its last item, for lines of the given
+// widths. extraStretch, as a fraction of the width of each line, is added to
+// its stretch. Returns nil if there's no sequence of lines within the given
+// tolerance.
+func (lb *Params) breakNodes(
+	items []breakItem,
+	widths func(line int) float32,
+	varying bool,
+	indent, tolerance, extraStretch float32,
+) []*breakNode {
+	// Text already on the first line is counted as if before the start.
+	start := &breakNode{item: -1, fitness: 1, active: true, width: -indent}
+	active := []*breakNode{start}
+
+	var width, stretch, shrink float32
+	for i, item := range items {
+		legal := false
+		switch item.kind {
+		case breakItemBox:
+			width += item.width
+		case breakItemGlue:
+			legal = i > 0 && items[i-1].kind == breakItemBox
+		case breakItemPenalty:
+			legal = item.penalty < penaltyForbid
+		}
+
+		if legal {
+			// The best break here for each fitness class, and for each line
+			// number if lines vary in width.
+			var best []*breakNode
+			var deactivated *breakNode
+
+			for _, a := range active {
+				widthMax := widths(a.line)
+				lineWidth := width - a.width
+				if item.kind == breakItemPenalty {
+					lineWidth += item.width
+				}
+
+				var ratio float32
+				switch {
+				case lineWidth < widthMax:
+					ratio = float32(math.Inf(1))
+					if s := stretch - a.stretch + widthMax*extraStretch; s > 0 {
+						ratio = (widthMax - lineWidth) / s
+					}
+				case lineWidth > widthMax:
+					ratio = float32(math.Inf(-1))
+					if s := shrink - a.shrink; s > 0 {
+						ratio = (widthMax - lineWidth) / s
+					}
+				}
+
+				if ratio < -1 || item.penalty <= penaltyForce {
+					a.active = false
+					if deactivated == nil || a.demerits < deactivated.demerits {
+						deactivated = a
+					}
+				}
+				if ratio < -1 || ratio > tolerance {
+					continue
+				}
+
+				demerits, fitness := lb.demerits(ratio, item.penalty, a.fitness)
+				demerits += a.demerits
+				if item.flagged && a.flagged {
+					demerits += lb.DoubleHyphenDemerits
+				}
+				ix := slices.IndexFunc(best, func(node *breakNode) bool {
+					return node.fitness == fitness && (!varying || node.line == a.line+1)
+				})
+				node := &breakNode{
+					item:     i,
+					line:     a.line + 1,
+					fitness:  fitness,
+					demerits: demerits,
+					prev:     a,
+					active:   true,
+					flagged:  item.flagged,
+				}
+				switch {
+				case ix < 0:
+					best = append(best, node)
+				case demerits < best[ix].demerits:
+					best[ix] = node
+				}
+			}
+
+			active = slices.DeleteFunc(active, func(node *breakNode) bool {
+				return !node.active
+			})
+
+			// With no tolerance, a break is forced after a line that can't
+			// be shrunk to fit, if there's no other way to continue.
+			if len(active) == 0 && len(best) == 0 {
+				if deactivated == nil || !math.IsInf(float64(tolerance), 1) {
+					return nil
+				}
+
+				best = append(best, &breakNode{
+					item:     i,
+					line:     deactivated.line + 1,
+					fitness:  deactivated.fitness,
+					demerits: deactivated.demerits + badnessMax*badnessMax,
+					prev:     deactivated,
+					active:   true,
+					flagged:  item.flagged,
+				})
+			}
+
+			slices.SortFunc(best, func(a, b *breakNode) int {
+				if varying && a.line != b.line {
+					return a.line - b.line
+				}
+				return a.fitness - b.fitness
+			})
+			for _, node := range best {
+				node.nextStart, node.width, node.stretch, node.shrink = afterBreak(
+					items, i, width, stretch, shrink,
+				)
+				active = append(active, node)
+			}
+		}
+
+		if item.kind == breakItemGlue {
+			width += item.width
+			stretch += item.stretch
+			shrink += item.shrink
+		}
+	}
+
+	var last *breakNode
+	for _, node := range active {
+		if node.item == len(items)-1 &&
+			(last == nil || node.demerits < last.demerits) {
+			last = node
+		}
+	}
+	if last == nil {
+		return nil
+	}
+
+	nodes := make([]*breakNode, last.line+1)
+	for node := last; node != nil; node = node.prev {
+		nodes[node.line] = node
+	}
+
+	return nodes
+}
+
+// demerits returns the demerits of a line with the given adjustment ratio,
+// ending with a break of the given penalty, and its fitness class: tight,
+// decent, loose or very loose.
+func (lb *Params) demerits(
+	ratio, penalty float32,
+	fitnessPrev int,
+) (demerits float32, fitness int) {
+	badness := float32(badnessMax)
+	if ratio < 1e3 {
+		badness = min(100*float32(math.Pow(math.Abs(float64(ratio)), 3)), badnessMax)
+	}
+
+	demerits = (lb.LinePenalty + badness) * (lb.LinePenalty + badness)
+	switch {
+	case penalty >= 0:
+		demerits += penalty * penalty
+	case penalty > penaltyForce:
+		demerits -= penalty * penalty
+	}
+
+	switch {
+	case ratio < -0.5:
+		fitness = 0
+	case ratio <= 0.5:
+		fitness = 1
+	case ratio <= 1:
+		fitness = 2
+	default:
+		fitness = 3
+	}
+
+	if fitness-fitnessPrev > 1 || fitnessPrev-fitness > 1 {
+		demerits += lb.FitnessPenalty
+	}
+
+	return demerits, fitness
+}
+
+// afterBreak returns the index of the first item of the line after a break at
+// item i, and the totals of the items before it, given the totals before i.
+// Glue and penalties after a break are discarded.
+func afterBreak(
+	items []breakItem,
+	i int,
+	width, stretch, shrink float32,
+) (next int, widthAfter, stretchAfter, shrinkAfter float32) {
+	for next = i; next < len(items); next++ {
+		item := items[next]
+		if item.kind == breakItemBox ||
+			(item.kind == breakItemPenalty && item.penalty <= penaltyForce && next > i) {
+			break
+		}
+		if item.kind == breakItemGlue {
+			width += item.width
+			stretch += item.stretch
+			shrink += item.shrink
+		}
+	}
+
+	return next, width, stretch, shrink
+}
+
+// lineWidth returns the natural width of the line between two breaks.
+func (lb *Params) lineWidth(items []breakItem, from, to *breakNode) float32 {
+	var width float32
+	for i := from.nextStart; i < to.item; i++ {
+		if items[i].kind != breakItemPenalty {
+			width += items[i].width
+		}
+	}
+	if items[to.item].kind == breakItemPenalty {
+		width += items[to.item].width
+	}
+
+	return width
+}
+
+// Paragraphs splits text at line feeds.
+func Paragraphs(text []rune) (paras [][]rune) {
+	start := 0
+	for i, char := range text {
+		if char == '\n' {
+			paras = append(paras, text[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(paras, text[start:])
+}
@@ -0,0 +1,145 @@
+package linebreak
+
+import (
+	"testing"
+
+	"github.com/kofi-q/scribe-go/internal/hyphen"
+	"github.com/stretchr/testify/require"
+)
+
+// monospace measures every character as 1 unit wide.
+func monospace(_, _ rune) float32 {
+	return 1
+}
+
+// lines returns the text of each line chosen for text, with hyphens added at
+// hyphenation points.
+func lines(
+	p *Params,
+	text string,
+	widthMax, indent float32,
+	hyphens func(word []rune) []int,
+) []string {
+	runes := []rune(text)
+
+	var out []string
+	for _, line := range p.Break(runes, widthMax, indent, monospace, hyphens) {
+		str := string(runes[line.Start:line.End])
+		if line.Hyphen {
+			str += "-"
+		}
+		out = append(out, str)
+	}
+
+	return out
+}
+
+var params = Params{
+	Stretch:              1,
+	Shrink:               1,
+	Tolerance:            2,
+	LinePenalty:          10,
+	FitnessPenalty:       100,
+	HyphenPenalty:        50,
+	DoubleHyphenDemerits: 3000,
+}
+
+func TestTotalFit(t *testing.T) {
+	// First-fit breaking leaves "cc dd" stretched to twice its width.
+	require.Equal(t,
+		[]string{"aaa bbb cc", "dd eeeeeee"},
+		lines(&params, "aaa bbb cc dd eeeeeee", 9, 0, nil),
+	)
+
+	// Without shrinkable spaces, the loose line can't be avoided.
+	rigid := params
+	rigid.Shrink = 0.1
+	require.Equal(t,
+		[]string{"aaa bbb", "cc dd", "eeeeeee"},
+		lines(&rigid, "aaa bbb cc dd eeeeeee", 9, 0, nil),
+	)
+}
+
+func TestLineWidths(t *testing.T) {
+	runes := []rune("aa bbb c")
+	require.Equal(t,
+		[]Line{{Start: 0, End: 6, Width: 6}, {Start: 7, End: 8, Width: 1}},
+		params.Break(runes, 6, 0, monospace, nil),
+	)
+}
+
+func TestShape(t *testing.T) {
+	// Lines are narrower beside a float, and the rest are wider.
+	runes := []rune("aaa bbb cc dd eee ff gg")
+	widths := func(line int) float32 {
+		if line < 2 {
+			return 4
+		}
+		return 10
+	}
+
+	var out []string
+	for _, line := range params.BreakShape(runes, widths, 0, func(_ int, prev, char rune) float32 {
+		return monospace(prev, char)
+	}, nil) {
+		out = append(out, string(runes[line.Start:line.End]))
+	}
+	require.Equal(t, []string{"aaa", "bbb", "cc dd eee", "ff gg"}, out)
+}
+
+func TestIndent(t *testing.T) {
+	require.Equal(t,
+		[]string{"aaa", "bbb"},
+		lines(&params, "aaa bbb", 10, 6, nil),
+	)
+}
+
+func TestLongWords(t *testing.T) {
+	require.Equal(t,
+		[]string{"abc", "def", "g"},
+		lines(&params, "abcdefg", 3, 0, nil),
+	)
+}
+
+func TestHyphens(t *testing.T) {
+	// Hyphenation points after every other letter.
+	hyphens := func(word []rune) (points []int) {
+		for i := 2; i < len(word)-1; i += 2 {
+			points = append(points, i)
+		}
+		return points
+	}
+
+	require.Equal(t,
+		[]string{"aa bb-", "bbbb cc"},
+		lines(&params, "aa bbbbbb cc", 7, 0, hyphens),
+	)
+
+	// Soft hyphens are dropped at the start of lines.
+	soft := func(word []rune) (points []int) {
+		for i, char := range word {
+			if char == hyphen.SoftHyphen {
+				points = append(points, i)
+			}
+		}
+		return points
+	}
+	require.Equal(t,
+		[]string{"aa bbb-", "bbb cc"},
+		lines(&params, "aa bbb\u00adbbb cc", 7, 0, soft),
+	)
+}
+
+func TestIdeographs(t *testing.T) {
+	require.Equal(t,
+		[]string{"中文中", "文"},
+		lines(&params, "中文中文", 3, 0, nil),
+	)
+}
+
+func TestParagraphs(t *testing.T) {
+	paras := Paragraphs([]rune("a\n\nbc"))
+	require.Equal(t, [][]rune{[]rune("a"), {}, []rune("bc")}, paras)
+
+	require.Equal(t, []string{""}, lines(&params, "", 10, 0, nil))
+}
@@ -0,0 +1,255 @@
+package linebreak
+
+import (
+	"unicode"
+)
+
+// Opportunity is the kind of line break allowed at a position in text.
+type Opportunity uint8
+
+const (
+	Prohibited Opportunity = iota
+	Allowed
+	Mandatory
+)
+
+// Opportunities returns the line break opportunities in text, found with the
+// Unicode Line Breaking Algorithm. The opportunity at i is for a break before
+// text[i], and there's one more opportunity than there are characters, for
+// the end of the text.
+//
+// Characters whose classes are left to tailoring are treated as alphabetic,
+// except for conditional Japanese starters, which are treated as nonstarters,
+// and Hangul syllables and jamo, which are treated as ideographs.
+//
+// https://www.unicode.org/reports/tr14/
+func Opportunities(text []rune) []Opportunity {
+	ops := make([]Opportunity, len(text)+1)
+	if len(text) == 0 {
+		return ops
+	}
+
+	raw := make([]Class, len(text))
+	for i, char := range text {
+		raw[i] = resolve(ClassOf(char))
+	}
+
+	// LB9, LB10: Combining marks and joiners take the class of the character
+	// they follow, and are otherwise treated as alphabetic.
+	classes := make([]Class, len(text))
+	combined := make([]bool, len(text))
+	for i, class := range raw {
+		classes[i] = class
+		if class != CM && class != ZWJ {
+			continue
+		}
+
+		if i > 0 {
+			switch classes[i-1] {
+			case BK, CR, LF, NL, SP, ZW:
+			default:
+				classes[i] = classes[i-1]
+				combined[i] = true
+				continue
+			}
+		}
+		classes[i] = AL
+	}
+
+	for i := 1; i < len(text); i++ {
+		ops[i] = opportunity(text, raw, classes, combined, i)
+	}
+
+	// LB3: Always break at the end of text.
+	ops[len(text)] = Mandatory
+
+	return ops
+}
+
+// opportunity returns the break opportunity before text[i], given the classes
+// of the characters in text, before and after combining marks are resolved.
+func opportunity(
+	text []rune,
+	raw, classes []Class,
+	combined []bool,
+	i int,
+) Opportunity {
+	before, after := raw[i-1], raw[i]
+
+	// LB4, LB5: Break after hard line breaks, keeping CR LF together.
+	switch {
+	case before == CR && after == LF:
+		return Prohibited
+	case before == BK, before == CR, before == LF, before == NL:
+		return Mandatory
+	}
+
+	// LB6, LB7: Don't break before hard line breaks, spaces or zero width
+	// spaces.
+	switch after {
+	case BK, CR, LF, NL, SP, ZW:
+		return Prohibited
+	}
+
+	// LB8: Break after zero width spaces, and any spaces after them.
+	k := i - 1
+	for k > 0 && raw[k] == SP {
+		k--
+	}
+	if raw[k] == ZW {
+		return Allowed
+	}
+
+	// LB8a, LB9: Don't break after zero width joiners, or before combining
+	// marks.
+	if before == ZWJ || combined[i] {
+		return Prohibited
+	}
+
+	before, after = classes[i-1], classes[i]
+
+	// The class before any spaces preceding i.
+	k = i - 1
+	for k > 0 && classes[k] == SP {
+		k--
+	}
+	beforeSpaces := classes[k]
+
+	switch {
+	// LB11: Don't break around word joiners.
+	case before == WJ, after == WJ:
+		return Prohibited
+
+	// LB12, LB12a: Don't break after no-break spaces, or before them except
+	// after spaces and hyphens.
+	case before == GL:
+		return Prohibited
+	case after == GL && before != SP && before != BA && before != HY:
+		return Prohibited
+
+	// LB13: Don't break before closing punctuation, exclamation marks or
+	// symbols, even after spaces.
+	case after == CL, after == CP, after == EX, after == IS, after == SY:
+		return Prohibited
+
+	// LB14-LB17: Don't break after opening punctuation, or between quotes
+	// and opening punctuation, closing punctuation and nonstarters, and
+	// pairs of em dashes, even with spaces in between.
+	case beforeSpaces == OP,
+		beforeSpaces == QU && after == OP,
+		(beforeSpaces == CL || beforeSpaces == CP) && after == NS,
+		beforeSpaces == B2 && after == B2:
+		return Prohibited
+
+	// LB18: Break after spaces.
+	case before == SP:
+		return Allowed
+
+	// LB19, LB20: Don't break around quotes, and break around contingent
+	// break opportunities.
+	case before == QU, after == QU:
+		return Prohibited
+	case before == CB, after == CB:
+		return Allowed
+
+	// LB21-LB21b: Don't break before hyphens and other characters that can't
+	// start a line, or after characters that can't end one, or after hyphens
+	// after Hebrew letters, or between symbols and Hebrew letters.
+	case after == BA, after == HY, after == NS, before == BB:
+		return Prohibited
+	case i > 1 && classes[i-2] == HL && (before == HY || before == BA):
+		return Prohibited
+	case before == SY && after == HL:
+		return Prohibited
+
+	// LB22: Don't break before ellipses.
+	case after == IN:
+		return Prohibited
+
+	// LB23-LB25: Keep words, numbers, and prefixes and suffixes of numbers
+	// together.
+	case isAlpha(before) && after == NU, before == NU && isAlpha(after):
+		return Prohibited
+	case before == PR && (after == ID || after == EB || after == EM),
+		(before == ID || before == EB || before == EM) && after == PO:
+		return Prohibited
+	case (before == PR || before == PO) && isAlpha(after),
+		isAlpha(before) && (after == PR || after == PO):
+		return Prohibited
+	case isNumeric(before, after):
+		return Prohibited
+
+	// LB28, LB29: Don't break between letters, or after infix separators
+	// before letters.
+	case isAlpha(before) && isAlpha(after), before == IS && isAlpha(after):
+		return Prohibited
+
+	// LB30: Don't break between letters or numbers and parentheses, unless
+	// the parentheses are East Asian.
+	case (isAlpha(before) || before == NU) && after == OP && !isWide(text[i]),
+		before == CP && (isAlpha(after) || after == NU) && !isWide(text[i-1]):
+		return Prohibited
+
+	// LB30a: Break between pairs of regional indicators (flags).
+	case before == RI && after == RI:
+		count := 0
+		for k := i - 1; k >= 0 && classes[k] == RI; k-- {
+			count++
+		}
+		if count%2 == 1 {
+			return Prohibited
+		}
+		return Allowed
+
+	// LB30b: Don't break between emoji bases and modifiers.
+	case before == EB && after == EM:
+		return Prohibited
+	}
+
+	// LB31: Break everywhere else.
+	return Allowed
+}
+
+// isAlpha returns true for alphabetic classes.
+func isAlpha(class Class) bool {
+	return class == AL || class == HL
+}
+
+// isNumeric returns true for pairs of classes within numbers, with their
+// prefixes and suffixes, e.g. "$(12.5)" or "-3%".
+//
+// https://www.unicode.org/reports/tr14/#LB25
+func isNumeric(before, after Class) bool {
+	switch {
+	case (before == CL || before == CP) && (after == PO || after == PR):
+		return true
+	case before == NU && (after == PO || after == PR || after == NU):
+		return true
+	case (before == PO || before == PR) && (after == OP || after == NU):
+		return true
+	case (before == HY || before == IS || before == SY) && after == NU:
+		return true
+	}
+
+	return false
+}
+
+// isWide returns true for the fullwidth and wide punctuation of East Asian
+// text.
+func isWide(char rune) bool {
+	return char >= 0x2e80
+}
+
+// IsSpace returns true for spaces that lines can be broken after, and that
+// are dropped from the ends of lines, including zero width spaces. No-break
+// spaces aren't.
+func IsSpace(char rune) bool {
+	switch ClassOf(char) {
+	case SP, ZW:
+		return true
+	case BA:
+		return unicode.IsSpace(char)
+	}
+
+	return false
+}
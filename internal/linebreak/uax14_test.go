@@ -0,0 +1,91 @@
+package linebreak
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// segments splits text at its break opportunities, with mandatory breaks
+// marked with "!".
+func segments(text string) []string {
+	runes := []rune(text)
+	ops := Opportunities(runes)
+	require.Len(nil, ops, len(runes)+1)
+
+	var out []string
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		if ops[i] == Prohibited {
+			continue
+		}
+
+		segment := string(runes[start:i])
+		if ops[i] == Mandatory && i < len(runes) {
+			segment += "!"
+		}
+		out = append(out, segment)
+		start = i
+	}
+
+	return out
+}
+
+func TestOpportunities(t *testing.T) {
+	require.Equal(t, []string{"The ", "quick ", "(“brown”) ", "fox."}, segments(
+		"The quick (“brown”) fox.",
+	))
+
+	// Ideographs can be broken between, but not before closing punctuation
+	// or small kana.
+	require.Equal(t, []string{"中", "文，", "中", "文。"}, segments("中文，中文。"))
+	require.Equal(t, []string{"ちょっ", "と"}, segments("ちょっと"))
+
+	// URLs are broken after slashes and hyphens, but not within numbers.
+	require.Equal(t,
+		[]string{"https://", "example.com/", "long-", "path/", "v1.2"},
+		segments("https://example.com/long-path/v1.2"),
+	)
+	require.Equal(t, []string{"$(12.50) ", "-3% ", "a-", "b"}, segments(
+		"$(12.50) -3% a-b",
+	))
+
+	// No-break spaces and word joiners prevent breaks, and zero width
+	// spaces allow them.
+	require.Equal(t, []string{"10\u00a0km ", "a/\u2060b"}, segments(
+		"10\u00a0km a/\u2060b",
+	))
+	require.Equal(t, []string{"over\u200b", "long"}, segments("over\u200blong"))
+
+	require.Equal(t, []string{"a\n!", "b\r\n!", "c"}, segments("a\nb\r\nc"))
+
+	// Combining marks stay with their base.
+	require.Equal(t,
+		[]string{"e\u0301 ", "\u0301x"},
+		segments("e\u0301 \u0301x"),
+	)
+
+	require.Equal(t, []Opportunity{Prohibited}, Opportunities(nil))
+}
+
+func TestIsSpace(t *testing.T) {
+	for _, char := range " \t\u200b\u2003\u3000" {
+		require.True(t, IsSpace(char), "%U", char)
+	}
+	for _, char := range "\u00a0\u2007\u202f\nx-" {
+		require.False(t, IsSpace(char), "%U", char)
+	}
+}
+
+func TestBreakOpportunities(t *testing.T) {
+	require.Equal(t,
+		[]string{"see", "example.com/", "a/b"},
+		lines(&params, "see example.com/a/b", 12, 0, nil),
+	)
+
+	// Lines aren't broken at no-break spaces.
+	require.Equal(t,
+		[]string{"aa", "bbb\u00a0cc"},
+		lines(&params, "aa bbb\u00a0cc", 6, 0, nil),
+	)
+}
@@ -0,0 +1,188 @@
+package markup
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Elements, by their HTML tag names.
+var htmlTags = map[string]Tag{
+	"p":      P,
+	"div":    P,
+	"h1":     H1,
+	"h2":     H2,
+	"h3":     H3,
+	"h4":     H4,
+	"h5":     H5,
+	"h6":     H6,
+	"ul":     UL,
+	"ol":     OL,
+	"li":     LI,
+	"b":      B,
+	"strong": B,
+	"i":      I,
+	"em":     I,
+	"code":   Code,
+	"kbd":    Code,
+	"samp":   Code,
+	"tt":     Code,
+	"a":      A,
+}
+
+// ParseHTML parses an HTML fragment. Supported elements are p and div,
+// h1 to h6, ul, ol (with a start attribute) and li, b and strong, i and em,
+// code, a (with an href attribute) and br. Other elements are ignored, but
+// their text is kept. Whitespace is collapsed, and character references are
+// decoded.
+//
+// As in browsers, paragraphs and list items are closed by the start of the
+// next one, and elements left open are closed at the end of the fragment.
+func ParseHTML(src string) []Token {
+	var b builder
+	b.space = true
+
+	for src != "" {
+		i := strings.IndexByte(src, '<')
+		if i < 0 {
+			break
+		}
+
+		b.text(html.UnescapeString(src[:i]))
+		src = src[i:]
+
+		if strings.HasPrefix(src, "<!--") {
+			end := strings.Index(src, "-->")
+			if end < 0 {
+				return b.finish()
+			}
+			src = src[end+len("-->"):]
+			continue
+		}
+
+		end := strings.IndexByte(src, '>')
+		if end < 0 {
+			break
+		}
+		htmlTag(&b, src[1:end])
+		src = src[end+1:]
+	}
+	b.text(html.UnescapeString(src))
+
+	return b.finish()
+}
+
+// htmlTag adds the start or end of an element, from the content of an HTML
+// tag between its angle brackets.
+func htmlTag(b *builder, content string) {
+	closing := strings.HasPrefix(content, "/")
+	content = strings.TrimPrefix(content, "/")
+	content = strings.TrimSuffix(content, "/")
+
+	name := content
+	if i := strings.IndexFunc(content, unicode.IsSpace); i >= 0 {
+		name = content[:i]
+	}
+	name = strings.ToLower(name)
+
+	if name == "br" {
+		b.lineBreak()
+		return
+	}
+
+	tag, ok := htmlTags[name]
+	if !ok {
+		return
+	}
+
+	if closing {
+		b.closeTag(tag)
+		return
+	}
+
+	// Blocks close any open paragraph, and list items close the previous
+	// item of their list.
+	if tag.Block() {
+		b.closeTag(P)
+	}
+	if tag == LI {
+		if i := b.lastOpen(LI); i >= 0 && i > b.lastList() {
+			b.closeTag(LI)
+		}
+	}
+
+	switch tag {
+	case A:
+		b.openTag(tag, htmlAttr(content, "href"), 0)
+	case OL:
+		start, err := strconv.Atoi(htmlAttr(content, "start"))
+		if err != nil {
+			start = 1
+		}
+		b.openTag(tag, "", start)
+	default:
+		b.openTag(tag, "", 0)
+	}
+}
+
+// lastList returns the index of the innermost open list, or -1 if there's
+// none.
+func (b *builder) lastList() int {
+	return max(b.lastOpen(UL), b.lastOpen(OL))
+}
+
+// htmlAttr returns the decoded value of an attribute of an HTML tag, or an
+// empty string if the tag doesn't have it.
+func htmlAttr(content, name string) string {
+	// Skip the tag name.
+	i := strings.IndexFunc(content, unicode.IsSpace)
+	if i < 0 {
+		return ""
+	}
+	rest := content[i:]
+
+	for {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			return ""
+		}
+
+		end := strings.IndexFunc(rest, func(char rune) bool {
+			return char == '=' || unicode.IsSpace(char)
+		})
+		if end < 0 {
+			end = len(rest)
+		}
+		key := strings.ToLower(rest[:end])
+		rest = strings.TrimLeftFunc(rest[end:], unicode.IsSpace)
+
+		// Attributes without values.
+		if !strings.HasPrefix(rest, "=") {
+			if key == name {
+				return ""
+			}
+			continue
+		}
+		rest = strings.TrimLeftFunc(rest[1:], unicode.IsSpace)
+
+		var value string
+		if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+			end := strings.IndexByte(rest[1:], rest[0])
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			value, rest = rest[1:end+1], rest[min(end+2, len(rest)):]
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			value, rest = rest[:end], rest[end:]
+		}
+
+		if key == name {
+			return html.UnescapeString(value)
+		}
+	}
+}
@@ -0,0 +1,572 @@
+package markup
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// list is an open Markdown list.
+type list struct {
+	ordered bool
+
+	// Columns of the item markers, and of the content of items.
+	indent, content int
+}
+
+// mdParser parses the blocks of a Markdown document.
+type mdParser struct {
+	b     builder
+	lists []list
+
+	// Lines of the paragraph being parsed.
+	para []string
+
+	// The current list item has content, so that any further paragraphs in it
+	// are separated from it.
+	itemContent bool
+}
+
+// ParseMarkdown parses a Markdown document, written in a subset of CommonMark.
+// Supported blocks are paragraphs, ATX headings (# to ######), and bullet (-,
+// + or *) and ordered (1. or 1)) lists, which are nested by indenting their
+// items. Supported inlines are emphasis and strong emphasis with * or _, code
+// spans, inline links ([text](url)), autolinks (<url>), backslash escapes and
+// hard line breaks (two spaces or a backslash at the end of a line).
+//
+// Other syntax, e.g. block quotes, code blocks and HTML, is kept as text.
+func ParseMarkdown(src string) []Token {
+	var p mdParser
+	p.b.space = true
+
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	blank := false
+	for line := range strings.SplitSeq(src, "\n") {
+		indent, rest := mdIndent(line)
+		if rest == "" {
+			p.flush()
+			blank = true
+			continue
+		}
+
+		if ordered, start, width, ok := mdListMarker(rest); ok {
+			p.flush()
+			p.listItem(ordered, start, indent, indent+width)
+			rest = strings.TrimLeft(rest[width:], " \t")
+			if rest != "" {
+				p.para = append(p.para, rest)
+			}
+			blank = false
+			continue
+		}
+
+		// Lines indented less than the content of the current list item end
+		// the list, unless they continue a paragraph.
+		if blank || len(p.para) == 0 {
+			p.closeLists(indent)
+		}
+		blank = false
+
+		if level, text, ok := mdHeading(rest); ok {
+			p.closeLists(indent)
+			p.flush()
+			p.b.openTag(H1+Tag(level-1), "", 0)
+			mdInline(&p.b, text)
+			p.b.closeTag(H1 + Tag(level-1))
+			continue
+		}
+
+		p.para = append(p.para, rest)
+	}
+	p.flush()
+
+	return p.b.finish()
+}
+
+// listItem starts an item of a list, with its marker at column indent and its
+// content at column content, closing the previous item at the same level and
+// any lists nested in it.
+func (p *mdParser) listItem(ordered bool, start, indent, content int) {
+	for len(p.lists) > 0 {
+		last := p.lists[len(p.lists)-1]
+		if indent >= last.content {
+			break
+		}
+
+		if indent >= last.indent && last.ordered == ordered {
+			p.b.closeTag(LI)
+			p.b.openTag(LI, "", 0)
+			p.itemContent = false
+			return
+		}
+
+		p.closeList()
+	}
+
+	p.lists = append(p.lists, list{ordered: ordered, indent: indent, content: content})
+	if ordered {
+		p.b.openTag(OL, "", start)
+	} else {
+		p.b.openTag(UL, "", 0)
+	}
+	p.b.openTag(LI, "", 0)
+	p.itemContent = false
+}
+
+// closeLists closes the lists whose item content is indented further than
+// column indent.
+func (p *mdParser) closeLists(indent int) {
+	for len(p.lists) > 0 && indent < p.lists[len(p.lists)-1].content {
+		p.flush()
+		p.closeList()
+	}
+}
+
+// closeList closes the innermost list.
+func (p *mdParser) closeList() {
+	last := p.lists[len(p.lists)-1]
+	p.lists = p.lists[:len(p.lists)-1]
+
+	if last.ordered {
+		p.b.closeTag(OL)
+	} else {
+		p.b.closeTag(UL)
+	}
+	p.itemContent = len(p.lists) > 0
+}
+
+// flush adds the paragraph being parsed. The first paragraph of a list item
+// is added directly to the item.
+func (p *mdParser) flush() {
+	if len(p.para) == 0 {
+		return
+	}
+
+	text := strings.Join(p.para, "\n")
+	p.para = p.para[:0]
+
+	if len(p.lists) > 0 && !p.itemContent {
+		mdInline(&p.b, text)
+		p.itemContent = true
+		return
+	}
+
+	p.b.openTag(P, "", 0)
+	mdInline(&p.b, text)
+	p.b.closeTag(P)
+	p.itemContent = len(p.lists) > 0
+}
+
+// mdIndent returns the column of the first non-blank character of a line, with
+// tab stops every 4 columns, and the rest of the line.
+func mdIndent(line string) (indent int, rest string) {
+	for i, char := range line {
+		switch char {
+		case ' ':
+			indent++
+		case '\t':
+			indent += 4 - indent%4
+		default:
+			return indent, line[i:]
+		}
+	}
+
+	return indent, ""
+}
+
+// mdListMarker parses the marker of a list item at the start of line: a
+// bullet, or a number followed by a period or parenthesis, followed by a space
+// or the end of the line. width is the width of the marker and the space after
+// it.
+func mdListMarker(line string) (ordered bool, start, width int, ok bool) {
+	digits := 0
+	for digits < len(line) && digits < 9 && line[digits] >= '0' && line[digits] <= '9' {
+		digits++
+	}
+
+	end := 1
+	switch {
+	case digits > 0:
+		if digits == len(line) || (line[digits] != '.' && line[digits] != ')') {
+			return false, 0, 0, false
+		}
+		ordered = true
+		start, _ = strconv.Atoi(line[:digits])
+		end = digits + 1
+	case line[0] != '-' && line[0] != '+' && line[0] != '*':
+		return false, 0, 0, false
+	}
+
+	if end == len(line) {
+		return ordered, start, end, true
+	}
+	if line[end] != ' ' && line[end] != '\t' {
+		return false, 0, 0, false
+	}
+
+	return ordered, start, end + 1, true
+}
+
+// mdHeading parses an ATX heading, returning its level and text, without any
+// closing sequence of #s.
+func mdHeading(line string) (level int, text string, ok bool) {
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0, "", false
+	}
+	if level < len(line) && line[level] != ' ' && line[level] != '\t' {
+		return 0, "", false
+	}
+
+	text = strings.TrimSpace(line[level:])
+	if trimmed := strings.TrimRight(text, "#"); trimmed == "" {
+		text = ""
+	} else if trimmed != text && strings.HasSuffix(trimmed, " ") {
+		text = strings.TrimSpace(trimmed)
+	}
+
+	return level, text, true
+}
+
+// inline is a parsed piece of inline Markdown: text, a run of emphasis
+// delimiters, a code span, a link or a hard line break.
+type inline struct {
+	text string
+
+	// Runs of * or _ characters, with the number of characters left
+	// unmatched, the number in the original run, and whether the run can
+	// open or close emphasis.
+	delim         byte
+	count, length int
+	open, close   bool
+
+	// Emphasis opened after the run, in the order they were matched, and
+	// closed before it.
+	opens, closes []Tag
+
+	code     bool
+	href     string
+	link     bool
+	children []inline
+	brk      bool
+}
+
+// mdInline adds the tokens of a paragraph or heading of inline Markdown.
+func mdInline(b *builder, text string) {
+	emitInlines(b, parseInlines(text))
+}
+
+// parseInlines parses inline Markdown, matching emphasis delimiters.
+func parseInlines(text string) (nodes []inline) {
+	var buf strings.Builder
+	flush := func() {
+		if buf.Len() > 0 {
+			nodes = append(nodes, inline{text: buf.String()})
+			buf.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); {
+		char := text[i]
+		switch {
+		case char == '\\' && i+1 < len(text) && text[i+1] == '\n':
+			flush()
+			nodes = append(nodes, inline{brk: true})
+			i += 2
+			continue
+
+		case char == '\\' && i+1 < len(text) && isASCIIPunct(text[i+1]):
+			buf.WriteByte(text[i+1])
+			i += 2
+			continue
+
+		case char == '\n':
+			// Hard line breaks follow two or more spaces.
+			str := buf.String()
+			trimmed := strings.TrimRight(str, " ")
+			buf.Reset()
+			buf.WriteString(trimmed)
+			flush()
+			if len(str)-len(trimmed) >= 2 {
+				nodes = append(nodes, inline{brk: true})
+			} else {
+				nodes = append(nodes, inline{text: " "})
+			}
+			i++
+			continue
+
+		case char == '`':
+			n := 1
+			for i+n < len(text) && text[i+n] == '`' {
+				n++
+			}
+			if end := closingBackticks(text[i+n:], n); end >= 0 {
+				flush()
+				nodes = append(nodes, inline{code: true, text: codeText(text[i+n : i+n+end])})
+				i += n + end + n
+				continue
+			}
+			buf.WriteString(text[i : i+n])
+			i += n
+			continue
+
+		case char == '[':
+			if label, href, n, ok := parseLink(text[i:]); ok {
+				flush()
+				nodes = append(nodes, inline{
+					link:     true,
+					href:     href,
+					children: parseInlines(label),
+				})
+				i += n
+				continue
+			}
+
+		case char == '<':
+			if href, n, ok := parseAutolink(text[i:]); ok {
+				flush()
+				nodes = append(nodes, inline{
+					link:     true,
+					href:     href,
+					children: []inline{{text: text[i+1 : i+n-1]}},
+				})
+				i += n
+				continue
+			}
+
+		case char == '*' || char == '_':
+			n := 1
+			for i+n < len(text) && text[i+n] == char {
+				n++
+			}
+
+			before, _ := utf8.DecodeLastRuneInString(text[:i])
+			after, _ := utf8.DecodeRuneInString(text[i+n:])
+			if i == 0 {
+				before = ' '
+			}
+			if i+n == len(text) {
+				after = ' '
+			}
+			left := !unicode.IsSpace(after) &&
+				(!isPunct(after) || unicode.IsSpace(before) || isPunct(before))
+			right := !unicode.IsSpace(before) &&
+				(!isPunct(before) || unicode.IsSpace(after) || isPunct(after))
+
+			node := inline{delim: char, count: n, length: n, open: left, close: right}
+			if char == '_' {
+				node.open = left && (!right || isPunct(before))
+				node.close = right && (!left || isPunct(after))
+			}
+
+			flush()
+			nodes = append(nodes, node)
+			i += n
+			continue
+		}
+
+		buf.WriteByte(char)
+		i++
+	}
+	flush()
+
+	matchEmphasis(nodes)
+	return nodes
+}
+
+// matchEmphasis matches runs of emphasis delimiters, following the CommonMark
+// rules for emphasis: each closing run is matched with the nearest run before
+// it of the same character that can open emphasis, as strong emphasis if both
+// runs have at least two characters left.
+//
+// https://spec.commonmark.org/0.31.2/#process-emphasis
+func matchEmphasis(nodes []inline) {
+	for i := range nodes {
+		closer := &nodes[i]
+		if closer.delim == 0 || !closer.close {
+			continue
+		}
+
+		for j := i - 1; j >= 0 && closer.count > 0; j-- {
+			opener := &nodes[j]
+			if opener.delim != closer.delim || !opener.open || opener.count == 0 {
+				continue
+			}
+
+			// Runs that can both open and close emphasis aren't matched if
+			// their lengths add up to a multiple of 3, unless both are.
+			if (opener.close || closer.open) &&
+				(opener.length+closer.length)%3 == 0 &&
+				(opener.length%3 != 0 || closer.length%3 != 0) {
+				continue
+			}
+
+			tag, n := I, 1
+			if opener.count >= 2 && closer.count >= 2 {
+				tag, n = B, 2
+			}
+			opener.count -= n
+			closer.count -= n
+			opener.opens = append(opener.opens, tag)
+			closer.closes = append(closer.closes, tag)
+
+			// Runs between the two can no longer open emphasis.
+			for k := j + 1; k < i; k++ {
+				nodes[k].open = false
+			}
+
+			// Look for another opener for the rest of the run.
+			j++
+		}
+	}
+}
+
+// emitInlines adds the tokens of parsed inline Markdown.
+func emitInlines(b *builder, nodes []inline) {
+	for _, node := range nodes {
+		switch {
+		case node.brk:
+			b.lineBreak()
+
+		case node.code:
+			b.openTag(Code, "", 0)
+			b.literal(node.text)
+			b.closeTag(Code)
+
+		case node.link:
+			b.openTag(A, node.href, 0)
+			emitInlines(b, node.children)
+			b.closeTag(A)
+
+		case node.delim != 0:
+			for _, tag := range node.closes {
+				b.closeTag(tag)
+			}
+			b.text(strings.Repeat(string(node.delim), node.count))
+			for k := len(node.opens) - 1; k >= 0; k-- {
+				b.openTag(node.opens[k], "", 0)
+			}
+
+		default:
+			b.text(node.text)
+		}
+	}
+}
+
+// closingBackticks returns the index in text of a run of exactly n backticks,
+// or -1 if there's none.
+func closingBackticks(text string, n int) int {
+	for i := 0; i < len(text); {
+		if text[i] != '`' {
+			i++
+			continue
+		}
+
+		run := 1
+		for i+run < len(text) && text[i+run] == '`' {
+			run++
+		}
+		if run == n {
+			return i
+		}
+		i += run
+	}
+
+	return -1
+}
+
+// codeText returns the text of a code span: line endings become spaces, and a
+// space is stripped from each end if there's one at both.
+func codeText(text string) string {
+	text = strings.ReplaceAll(text, "\n", " ")
+	if len(text) >= 2 && text[0] == ' ' && text[len(text)-1] == ' ' &&
+		strings.Trim(text, " ") != "" {
+		text = text[1 : len(text)-1]
+	}
+
+	return text
+}
+
+// parseLink parses an inline link at the start of text, returning its label,
+// destination and length. Titles are allowed, but ignored.
+func parseLink(text string) (label, href string, n int, ok bool) {
+	depth := 0
+	end := -1
+	for i := 1; i < len(text) && end < 0; i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			if depth == 0 {
+				end = i
+			}
+			depth--
+		}
+	}
+	if end < 0 || end+1 >= len(text) || text[end+1] != '(' {
+		return "", "", 0, false
+	}
+
+	closing := strings.IndexByte(text[end+2:], ')')
+	if closing < 0 {
+		return "", "", 0, false
+	}
+	dest := strings.TrimSpace(text[end+2 : end+2+closing])
+
+	if strings.HasPrefix(dest, "<") {
+		if i := strings.IndexByte(dest, '>'); i > 0 {
+			dest = dest[1:i]
+		}
+	} else if i := strings.IndexFunc(dest, unicode.IsSpace); i >= 0 {
+		dest = dest[:i]
+	}
+
+	return text[1:end], dest, end + 2 + closing + 1, true
+}
+
+// parseAutolink parses an autolink at the start of text, returning its
+// destination and length. Email addresses are linked with mailto:.
+func parseAutolink(text string) (href string, n int, ok bool) {
+	end := strings.IndexByte(text, '>')
+	if end < 0 {
+		return "", 0, false
+	}
+
+	dest := text[1:end]
+	if dest == "" || strings.ContainsAny(dest, " \t\n<") {
+		return "", 0, false
+	}
+
+	if scheme, _, found := strings.Cut(dest, ":"); found && len(scheme) >= 2 {
+		for _, char := range scheme {
+			if !unicode.IsLetter(char) && !unicode.IsDigit(char) &&
+				char != '+' && char != '.' && char != '-' {
+				return "", 0, false
+			}
+		}
+		return dest, end + 1, true
+	}
+
+	if strings.Contains(dest, "@") {
+		return "mailto:" + dest, end + 1, true
+	}
+
+	return "", 0, false
+}
+
+// isASCIIPunct returns true for characters that can be escaped with a
+// backslash.
+func isASCIIPunct(char byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", char) >= 0
+}
+
+// isPunct returns true for punctuation and symbols, for finding whether
+// emphasis delimiters can open or close emphasis.
+func isPunct(char rune) bool {
+	return unicode.IsPunct(char) || unicode.IsSymbol(char)
+}
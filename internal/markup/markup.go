@@ -0,0 +1,188 @@
+// Package markup parses small subsets of HTML and CommonMark into a common
+// stream of tokens, for rendering formatted text: paragraphs, headings, lists,
+// bold and italic text, code spans, links and line breaks.
+package markup
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Kind is the kind of a token.
+type Kind uint8
+
+const (
+	Text Kind = iota
+	Open
+	Close
+	Break
+)
+
+// Tag is an element opened or closed by a token.
+type Tag uint8
+
+const (
+	P Tag = iota
+	H1
+	H2
+	H3
+	H4
+	H5
+	H6
+	UL
+	OL
+	LI
+
+	B
+	I
+	Code
+	A
+)
+
+// Block returns true for elements that start on a new line.
+func (t Tag) Block() bool {
+	return t <= LI
+}
+
+// Heading returns the level of a heading, from 1 to 6, or 0 for other
+// elements.
+func (t Tag) Heading() int {
+	if t < H1 || t > H6 {
+		return 0
+	}
+
+	return int(t-H1) + 1
+}
+
+// Token is a run of text, a line break, or the start or end of an element.
+type Token struct {
+	Kind Kind
+	Tag  Tag
+
+	// Text is the text of Text tokens, or the destination of links.
+	Text string
+
+	// Start is the number of the first item of ordered lists.
+	Start int
+}
+
+// builder collects tokens, collapsing whitespace in text and closing
+// elements left open.
+type builder struct {
+	tokens []Token
+	open   []Tag
+
+	// Spaces are dropped at the start of blocks and lines, and after other
+	// spaces.
+	space bool
+}
+
+// text adds text, with each run of whitespace collapsed to a single space.
+func (b *builder) text(text string) {
+	var buf strings.Builder
+	for _, char := range text {
+		if unicode.IsSpace(char) {
+			if !b.space {
+				buf.WriteByte(' ')
+			}
+			b.space = true
+			continue
+		}
+
+		buf.WriteRune(char)
+		b.space = false
+	}
+
+	b.literal(buf.String())
+}
+
+// literal adds text as is.
+func (b *builder) literal(text string) {
+	if text == "" {
+		return
+	}
+
+	b.space = strings.HasSuffix(text, " ")
+	if n := len(b.tokens); n > 0 && b.tokens[n-1].Kind == Text {
+		b.tokens[n-1].Text += text
+		return
+	}
+	b.tokens = append(b.tokens, Token{Kind: Text, Text: text})
+}
+
+// trimSpace drops a space at the end of the text added so far, at the end of
+// a block or line.
+func (b *builder) trimSpace() {
+	n := len(b.tokens)
+	if n == 0 || b.tokens[n-1].Kind != Text {
+		return
+	}
+
+	text := strings.TrimRight(b.tokens[n-1].Text, " ")
+	if text == "" {
+		b.tokens = b.tokens[:n-1]
+		return
+	}
+	b.tokens[n-1].Text = text
+}
+
+// openTag opens an element. text is the destination of links, and start the
+// number of the first item of ordered lists.
+func (b *builder) openTag(tag Tag, text string, start int) {
+	if tag.Block() {
+		b.trimSpace()
+		b.space = true
+	}
+
+	b.open = append(b.open, tag)
+	b.tokens = append(b.tokens, Token{Kind: Open, Tag: tag, Text: text, Start: start})
+}
+
+// closeTag closes the innermost open element with the given tag, and any
+// elements opened within it. It does nothing if there's no such element.
+func (b *builder) closeTag(tag Tag) {
+	i := b.lastOpen(tag)
+	if i < 0 {
+		return
+	}
+
+	for len(b.open) > i {
+		last := b.open[len(b.open)-1]
+		if last.Block() {
+			b.trimSpace()
+			b.space = true
+		}
+
+		b.open = b.open[:len(b.open)-1]
+		b.tokens = append(b.tokens, Token{Kind: Close, Tag: last})
+	}
+}
+
+// lastOpen returns the index of the innermost open element with the given
+// tag, or -1 if there's none.
+func (b *builder) lastOpen(tag Tag) int {
+	for i := len(b.open) - 1; i >= 0; i-- {
+		if b.open[i] == tag {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// lineBreak adds a line break.
+func (b *builder) lineBreak() {
+	b.trimSpace()
+	b.space = true
+	b.tokens = append(b.tokens, Token{Kind: Break})
+}
+
+// finish closes any elements left open, and returns the tokens.
+func (b *builder) finish() []Token {
+	if len(b.open) > 0 {
+		b.closeTag(b.open[0])
+	}
+	b.trimSpace()
+
+	return b.tokens
+}
@@ -0,0 +1,136 @@
+package markup
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var tagNames = map[Tag]string{
+	P: "p", H1: "h1", H2: "h2", H3: "h3", H4: "h4", H5: "h5", H6: "h6",
+	UL: "ul", OL: "ol", LI: "li", B: "b", I: "i", Code: "code", A: "a",
+}
+
+// render formats tokens as HTML-like text, for comparing them.
+func render(tokens []Token) string {
+	var buf strings.Builder
+	for _, token := range tokens {
+		name := tagNames[token.Tag]
+		switch token.Kind {
+		case Text:
+			buf.WriteString(token.Text)
+		case Break:
+			buf.WriteString("<br>")
+		case Open:
+			buf.WriteString("<" + name)
+			if token.Tag == A {
+				buf.WriteString(" href=" + token.Text)
+			}
+			if token.Tag == OL {
+				buf.WriteString(" start=" + strconv.Itoa(token.Start))
+			}
+			buf.WriteString(">")
+		case Close:
+			buf.WriteString("</" + name + ">")
+		}
+	}
+
+	return buf.String()
+}
+
+func TestParseHTML(t *testing.T) {
+	require.Equal(t,
+		"<h1>Title</h1><p>Some <b>bold</b> and <i>italic <b>text</b></i>.</p>",
+		render(ParseHTML(`
+			<h1> Title </h1>
+			<p>Some <strong>bold</strong>
+			and <em>italic <b>text</b></em>.</p>
+		`)),
+	)
+
+	// Character references are decoded, and unknown elements are ignored.
+	require.Equal(t,
+		`<p>Fish &amp; <a href=/chips?a=1&b=2>"chips"</a></p>`,
+		render(ParseHTML(`<p>Fish &amp;amp; <a class=x href="/chips?a=1&amp;b=2">&quot;<span>chips</span>"</a>`)),
+	)
+
+	require.Equal(t,
+		"<p>one<br>two <code>x()</code></p>",
+		render(ParseHTML("<p>one <br/> two <!-- <b>comment</b> --><code>x()</code>")),
+	)
+}
+
+func TestParseHTMLImplicitClose(t *testing.T) {
+	// Paragraphs and list items are closed by the next block, or item.
+	require.Equal(t,
+		"<p>a <b>b</b></p><p>c</p><ol start=3><li>d</li><li>e<ul><li>f</li></ul></li></ol>",
+		render(ParseHTML(`<p>a <b>b<p>c<ol start="3"><li>d<li>e<ul><li>f</ul></ol>`)),
+	)
+
+	// Closing tags without an open element are ignored.
+	require.Equal(t, "<p>a</p>", render(ParseHTML("</b><p>a</i>")))
+}
+
+func TestParseMarkdown(t *testing.T) {
+	require.Equal(t,
+		"<h1>Title</h1><p>Some <b>bold</b> and <i>italic <b>text</b>.</i></p><h2>Sub</h2>",
+		render(ParseMarkdown(strings.Join([]string{
+			"# Title #",
+			"Some **bold**",
+			"and _italic __text__._",
+			"",
+			"## Sub",
+		}, "\n"))),
+	)
+
+	require.Equal(t,
+		"<p>one<br>two<br>three <code>a * b</code> *x* <a href=https://example.com>the <i>site</i></a></p>",
+		render(ParseMarkdown(
+			"one  \ntwo\\\nthree `` a * b `` \\*x\\* [the *site*](https://example.com \"Title\")",
+		)),
+	)
+
+	require.Equal(t,
+		"<p><a href=https://example.com>https://example.com</a> <a href=mailto:a@b.c>a@b.c</a></p>",
+		render(ParseMarkdown("<https://example.com> <a@b.c>")),
+	)
+}
+
+func TestParseMarkdownEmphasis(t *testing.T) {
+	for md, want := range map[string]string{
+		"***both***":       "<p><i><b>both</b></i></p>",
+		"**a *b* c**":      "<p><b>a <i>b</i> c</b></p>",
+		"snake_case_name":  "<p>snake_case_name</p>",
+		"2 * 3 * 4":        "<p>2 * 3 * 4</p>",
+		"**unmatched":      "<p>**unmatched</p>",
+		"*a **b** c*":      "<p><i>a <b>b</b> c</i></p>",
+		"_a_b":             "<p>_a_b</p>",
+		"`*not emphasis*`": "<p><code>*not emphasis*</code></p>",
+	} {
+		require.Equal(t, want, render(ParseMarkdown(md)), md)
+	}
+}
+
+func TestParseMarkdownLists(t *testing.T) {
+	require.Equal(t,
+		"<ul><li>a</li><li>b <i>c</i><ol start=3><li>d</li><li>e</li></ol></li><li>f</li></ul><p>g</p>",
+		render(ParseMarkdown(strings.Join([]string{
+			"- a",
+			"- b",
+			"  *c*",
+			"  3. d",
+			"  4) e",
+			"- f",
+			"",
+			"g",
+		}, "\n"))),
+	)
+
+	// Further paragraphs in an item are indented to its content.
+	require.Equal(t,
+		"<ol start=1><li>a<p>b</p></li></ol><p>c</p>",
+		render(ParseMarkdown("1. a\n\n   b\n\nc")),
+	)
+}
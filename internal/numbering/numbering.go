@@ -0,0 +1,48 @@
+// Package numbering formats page numbers as roman numerals and letters, as in
+// PDF page labels.
+package numbering
+
+import "strings"
+
+var romans = []struct {
+	value  int
+	symbol string
+}{
+	{1000, "M"},
+	{900, "CM"},
+	{500, "D"},
+	{400, "CD"},
+	{100, "C"},
+	{90, "XC"},
+	{50, "L"},
+	{40, "XL"},
+	{10, "X"},
+	{9, "IX"},
+	{5, "V"},
+	{4, "IV"},
+	{1, "I"},
+}
+
+// Roman returns n in uppercase roman numerals, or "" for n < 1. Thousands are
+// repeated as needed.
+func Roman(n int) string {
+	var s strings.Builder
+	for _, r := range romans {
+		for ; n >= r.value; n -= r.value {
+			s.WriteString(r.symbol)
+		}
+	}
+
+	return s.String()
+}
+
+// Letters returns n in uppercase letters, A to Z for 1 to 26, then AA to ZZ,
+// AAA to ZZZ and so on, or "" for n < 1.
+func Letters(n int) string {
+	if n < 1 {
+		return ""
+	}
+
+	letter := string(rune('A' + (n-1)%26))
+	return strings.Repeat(letter, (n-1)/26+1)
+}
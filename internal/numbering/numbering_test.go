@@ -0,0 +1,35 @@
+package numbering
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoman(t *testing.T) {
+	for n, expected := range map[int]string{
+		0:    "",
+		1:    "I",
+		4:    "IV",
+		9:    "IX",
+		14:   "XIV",
+		40:   "XL",
+		1994: "MCMXCIV",
+		2026: "MMXXVI",
+	} {
+		require.Equal(t, expected, Roman(n), n)
+	}
+}
+
+func TestLetters(t *testing.T) {
+	for n, expected := range map[int]string{
+		0:  "",
+		1:  "A",
+		26: "Z",
+		27: "AA",
+		28: "BB",
+		53: "AAA",
+	} {
+		require.Equal(t, expected, Letters(n), n)
+	}
+}
@@ -0,0 +1,97 @@
+package pdftest
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kofi-q/scribe-go"
+)
+
+// The comma after the Hebrew word, at the end of the first line, is between
+// two Hebrew words in the paragraph, so it's ordered as right-to-left text,
+// before the word, though it'd be ordered after it on a line of its own.
+const (
+	bidiText = "abc אבג, דהו 12"
+	bidiLine = "abc אבג,"
+)
+
+var bidiLines = []string{"abc ,גבא", "12 והד"}
+
+func TestBidiWrappedLines(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		write func(doc *Doc, width float32)
+	}{
+		{"MultiCell", func(doc *Doc, width float32) {
+			doc.MultiCell(width, 5, bidiText, "", "L", false)
+		}},
+		{"MultiCellLineBreaking", func(doc *Doc, width float32) {
+			doc.SetLineBreaking(&scribe.LineBreaking{})
+			doc.MultiCell(width, 5, bidiText, "", "L", false)
+		}},
+		{"Write", func(doc *Doc, width float32) {
+			pageWidth, _ := doc.GetPageSize()
+			doc.SetRightMargin(pageWidth - doc.GetX() - width)
+			doc.Write(5, bidiText)
+		}},
+		{"Table", func(doc *Doc, width float32) {
+			tbl := doc.NewTable(scribe.TableColumn{Width: width})
+			tbl.Row(scribe.TableCell{Text: bidiText}).Write(0)
+		}},
+		{"ScratchPad", func(doc *Doc, width float32) {
+			sc, err := doc.Scratch(width - 2*doc.GetCellMargin())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, line := range sc.Text(5, bidiText) {
+				sc.CellFormat(width, 5, line, "", 2, "L", false, 0, "")
+			}
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := New(t)
+			doc.AddPage()
+			width := doc.GetStringWidth(bidiLine+" ") + 2*doc.GetCellMargin() + 1
+			tc.write(doc, width)
+
+			pages := Pages(t, doc.Output(t))
+			if lines := doc.Lines(t, pages[0]); !slices.Equal(lines, bidiLines) {
+				t.Errorf("got lines %q, want %q", lines, bidiLines)
+			}
+		})
+	}
+}
+
+func TestBidiUnwrappedLine(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	doc.CellFormat(0, 5, bidiLine, "", 2, "L", false, 0, "")
+
+	pages := Pages(t, doc.Output(t))
+	want := []string{"abc גבא,"}
+	if lines := doc.Lines(t, pages[0]); !slices.Equal(lines, want) {
+		t.Errorf("got lines %q, want %q", lines, want)
+	}
+}
+
+func TestBidiScratchPadScope(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	sc, err := doc.Scratch(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const text = "abc שלום def"
+	lines := sc.Text(5, text, scribe.TextDirectionRtl)
+
+	// Lines measured by a scratch pad are only reordered as measured when
+	// printed with it.
+	doc.CellFormat(0, 5, text, "", 2, "L", false, 0, "", scribe.TextDirectionLtr)
+	sc.CellFormat(0, 5, lines[0], "", 2, "L", false, 0, "")
+
+	pages := Pages(t, doc.Output(t))
+	want := []string{"abc םולש def", "def םולש abc"}
+	if got := doc.Lines(t, pages[0]); !slices.Equal(got, want) {
+		t.Errorf("got lines %q, want %q", got, want)
+	}
+}
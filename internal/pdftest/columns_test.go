@@ -0,0 +1,182 @@
+package pdftest
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/kofi-q/scribe-go"
+)
+
+// newColumnsDoc returns a document with a page started, and room for 7 lines
+// of writeColumnLines() in each column.
+func newColumnsDoc(t *testing.T) *Doc {
+	doc := New(t)
+	_, pageHeight := doc.GetPageSize()
+	doc.SetAutoPageBreak(true, pageHeight-800)
+	doc.SetTopMargin(10)
+	doc.AddPage()
+	return doc
+}
+
+// writeColumnLines writes lines numbered from first to last, 100 high.
+func writeColumnLines(doc *Doc, first, last int) {
+	for i := first; i <= last; i++ {
+		doc.CellFormat(0, 100, fmt.Sprint(i), "", 1, "L", false, 0, "")
+	}
+}
+
+// columnOf returns the column of each text object of a page, from the X of the
+// first object in each column.
+func columnOf(objs []TextObject) (cols []int) {
+	var xs []float32
+	for _, obj := range objs {
+		col := slices.Index(xs, obj.X)
+		if col < 0 {
+			col = len(xs)
+			xs = append(xs, obj.X)
+		}
+		cols = append(cols, col)
+	}
+	return cols
+}
+
+func TestColumnsFlow(t *testing.T) {
+	doc := newColumnsDoc(t)
+	doc.Columns(scribe.Columns{}, func() {
+		writeColumnLines(doc, 1, 20)
+	})
+	y := doc.GetY()
+
+	pages := Pages(t, doc.Output(t))
+	if len(pages) != 2 {
+		t.Fatalf("got %d pages, want 2", len(pages))
+	}
+
+	want := [][]string{
+		{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12", "13", "14"},
+		{"15", "16", "17", "18", "19", "20"},
+	}
+	wantCols := [][]int{
+		{0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1},
+		{0, 0, 0, 0, 0, 0},
+	}
+	var x0 float32
+	for i, page := range pages {
+		objs := doc.Text(t, page)
+		if lines := doc.Lines(t, page); !slices.Equal(lines, want[i]) {
+			t.Errorf("got lines %q on page %d, want %q", lines, i+1, want[i])
+		}
+		if cols := columnOf(objs); !slices.Equal(cols, wantCols[i]) {
+			t.Errorf("got columns %v on page %d, want %v", cols, i+1, wantCols[i])
+		}
+		if i == 0 {
+			x0 = objs[0].X
+		} else if objs[0].X != x0 {
+			t.Errorf("page %d starts at x %g, want %g", i+1, objs[0].X, x0)
+		}
+	}
+
+	// The position is left below the lowest column on the last page.
+	if y != 610 {
+		t.Errorf("got y %g after the columns, want 610", y)
+	}
+}
+
+func TestColumnsBalanced(t *testing.T) {
+	doc := newColumnsDoc(t)
+	doc.Columns(scribe.Columns{Balance: true}, func() {
+		writeColumnLines(doc, 1, 6)
+	})
+	y := doc.GetY()
+
+	pages := Pages(t, doc.Output(t))
+	if len(pages) != 1 {
+		t.Fatalf("got %d pages, want 1", len(pages))
+	}
+	cols := columnOf(doc.Text(t, pages[0]))
+	if want := []int{0, 0, 0, 1, 1, 1}; !slices.Equal(cols, want) {
+		t.Errorf("got columns %v, want %v", cols, want)
+	}
+	if math.Abs(float64(y-310)) > 0.5 {
+		t.Errorf("got y %g after the columns, want 310", y)
+	}
+}
+
+func TestColumnsBalancedLastPage(t *testing.T) {
+	doc := newColumnsDoc(t)
+	doc.Columns(scribe.Columns{Balance: true}, func() {
+		writeColumnLines(doc, 1, 20)
+	})
+	y := doc.GetY()
+
+	// Only the columns on the last page are balanced.
+	pages := Pages(t, doc.Output(t))
+	if len(pages) != 2 {
+		t.Fatalf("got %d pages, want 2", len(pages))
+	}
+	cols := columnOf(doc.Text(t, pages[0]))
+	if want := []int{0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1}; !slices.Equal(cols, want) {
+		t.Errorf("got columns %v on page 1, want %v", cols, want)
+	}
+	lines := doc.Lines(t, pages[1])
+	if want := []string{"15", "16", "17", "18", "19", "20"}; !slices.Equal(lines, want) {
+		t.Errorf("got lines %q on page 2, want %q", lines, want)
+	}
+	cols = columnOf(doc.Text(t, pages[1]))
+	if want := []int{0, 0, 0, 1, 1, 1}; !slices.Equal(cols, want) {
+		t.Errorf("got columns %v on page 2, want %v", cols, want)
+	}
+	if math.Abs(float64(y-310)) > 0.5 {
+		t.Errorf("got y %g after the columns, want 310", y)
+	}
+
+	// The page break trigger isn't left limited by balancing.
+	writeColumnLines(doc, 21, 24)
+	if doc.PageNo() != 2 {
+		t.Errorf("got page %d after more lines, want 2", doc.PageNo())
+	}
+}
+
+func TestColumnsHeaderFooter(t *testing.T) {
+	doc := New(t)
+	doc.SetHeaderFunc(func() {
+		doc.CellFormat(0, 10, "header", "", 1, "R", false, 0, "")
+	})
+	doc.SetFooterFunc(func() {
+		doc.SetY(-15)
+		doc.CellFormat(0, 10, "footer", "", 0, "L", false, 0, "")
+	})
+	doc.AddPage()
+	doc.Columns(scribe.Columns{Count: 3}, func() {
+		for range 600 {
+			doc.CellFormat(0, 10, "text", "", 1, "L", false, 0, "")
+		}
+	})
+
+	// The header and footer are written with the page margins on each page,
+	// as on the first, which starts before the columns.
+	pages := Pages(t, doc.Output(t))
+	if len(pages) < 2 {
+		t.Fatalf("got %d pages, want several", len(pages))
+	}
+	var header, footer TextObject
+	for i, page := range pages {
+		for _, obj := range doc.Text(t, page) {
+			switch {
+			case obj.Text == "header" && i == 0:
+				header = obj
+			case obj.Text == "footer" && i == 0:
+				footer = obj
+			case obj.Text == "header" && obj.X != header.X:
+				t.Errorf("header at x %g on page %d, want %g", obj.X, i+1, header.X)
+			case obj.Text == "footer" && obj.X != footer.X:
+				t.Errorf("footer at x %g on page %d, want %g", obj.X, i+1, footer.X)
+			}
+		}
+	}
+	if header.Text == "" || footer.Text == "" {
+		t.Fatal("no header or footer on page 1")
+	}
+}
@@ -0,0 +1,40 @@
+package pdftest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kofi-q/scribe-go"
+)
+
+func TestExclusion(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	doc.AddExclusion([]scribe.PointType{
+		{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 40}, {X: 0, Y: 40},
+	})
+	doc.MultiCell(0, 6, strings.Repeat("word ", 400), "", "L", false)
+
+	// Lines beside the exclusion start to its right, and lines below it at
+	// the left margin.
+	pages := Pages(t, doc.Output(t))
+	_, pageHeight := doc.GetPageSize()
+	left, _, _, _ := doc.GetMargins()
+	var beside, below int
+	for _, obj := range doc.Text(t, pages[0]) {
+		if baseline := pageHeight - obj.Y; baseline < 40 {
+			beside++
+			if obj.X < 100 {
+				t.Errorf("got line at x %g beside the exclusion, want at least 100", obj.X)
+			}
+		} else {
+			below++
+			if obj.X > left+doc.GetCellMargin()+0.01 {
+				t.Errorf("got line at x %g below the exclusion, want the left margin", obj.X)
+			}
+		}
+	}
+	if beside == 0 || below == 0 {
+		t.Errorf("got %d lines beside the exclusion and %d below, want some of each", beside, below)
+	}
+}
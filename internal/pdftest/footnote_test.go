@@ -0,0 +1,188 @@
+package pdftest
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kofi-q/scribe-go"
+)
+
+// The notes of footnote tests are 10 points, and spaced 4 from the text.
+var footnoteStyle = scribe.FootnoteStyle{Size: 10, Spacing: 4}
+
+// noteRows returns the rows of a page with text from notes, which are made of
+// "word".
+func noteRows(t *testing.T, doc *Doc, page string) (rows []string) {
+	for _, row := range doc.Rows(t, page) {
+		if strings.Contains(row, "word") {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func TestFootnoteReserve(t *testing.T) {
+	doc := New(t)
+	doc.SetFootnoteStyle(footnoteStyle)
+	doc.AddPage()
+	doc.Footnote(5, "word")
+	doc.Ln(5)
+
+	// Text breaks to the next page above the note and the spacing.
+	lineHeight := 1.2 * 10 / doc.GetConversionRatio()
+	limit := pageTrigger(doc) - footnoteStyle.Spacing - lineHeight
+	y := doc.GetY()
+	for doc.PageNo() == 1 {
+		y = doc.GetY()
+		doc.CellFormat(0, 1, "", "", 1, "L", false, 0, "")
+	}
+	if y > limit || y <= limit-1 {
+		t.Errorf("text ended at y %g on page 1, want just above %g", y, limit)
+	}
+
+	// The space is released on the next page.
+	for doc.PageNo() == 2 {
+		y = doc.GetY()
+		doc.CellFormat(0, 1, "", "", 1, "L", false, 0, "")
+	}
+	if limit := pageTrigger(doc); y > limit || y <= limit-1 {
+		t.Errorf("text ended at y %g on page 2, want just above %g", y, limit)
+	}
+
+	pages := Pages(t, doc.Output(t))
+	if rows := noteRows(t, doc, pages[0]); len(rows) != 1 {
+		t.Errorf("got notes %q on page 1, want 1", rows)
+	}
+}
+
+// longNote is the text of a note of several lines.
+var longNote = strings.Repeat("word ", 200)
+
+func TestFootnoteCarry(t *testing.T) {
+	doc := New(t)
+	doc.SetFootnoteStyle(footnoteStyle)
+	doc.AddPage()
+
+	// One line of the note fits below the marker.
+	lineHeight := 1.2 * 10 / doc.GetConversionRatio()
+	doc.SetY(pageTrigger(doc) - 5 - footnoteStyle.Spacing - 1.5*lineHeight)
+	doc.Footnote(5, longNote)
+	doc.AddPage()
+	doc.CellFormat(0, 5, "page two", "", 1, "L", false, 0, "")
+
+	pages := Pages(t, doc.Output(t))
+	if len(pages) != 2 {
+		t.Fatalf("got %d pages, want 2", len(pages))
+	}
+	if rows := noteRows(t, doc, pages[0]); len(rows) != 1 {
+		t.Errorf("got notes %q on page 1, want 1 line", rows)
+	}
+	rows := noteRows(t, doc, pages[1])
+	if len(rows) == 0 {
+		t.Fatal("no notes carried over to page 2")
+	}
+
+	// The rest of the note is at the bottom of the page, below the text.
+	objs := doc.Text(t, pages[1])
+	if objs[0].Text != "page two" {
+		t.Fatalf("got %q first on page 2, want the text", objs[0].Text)
+	}
+	last := objs[len(objs)-1]
+	if !strings.Contains(last.Text, "word") {
+		t.Fatalf("got %q last on page 2, want the note", last.Text)
+	}
+	_, pageHeight := doc.GetPageSize()
+	bottom := pageHeight - pageTrigger(doc)
+	if last.Y < bottom || last.Y > bottom+lineHeight {
+		t.Errorf("got the last line of the note at y %g, want above %g", last.Y, bottom)
+	}
+}
+
+func TestFootnoteClose(t *testing.T) {
+	doc := New(t)
+	doc.SetFootnoteStyle(footnoteStyle)
+	doc.AddPage()
+	lineHeight := 1.2 * 10 / doc.GetConversionRatio()
+	doc.SetY(pageTrigger(doc) - 5 - footnoteStyle.Spacing - 1.5*lineHeight)
+	doc.Footnote(5, longNote+longNote+longNote+longNote)
+
+	// Pages are added on Close() for the lines left over.
+	pages := Pages(t, doc.Output(t))
+	if len(pages) < 2 {
+		t.Fatalf("got %d pages, want more than 1", len(pages))
+	}
+	var words int
+	for _, page := range pages {
+		for _, row := range noteRows(t, doc, page) {
+			words += strings.Count(row, "word")
+		}
+	}
+	if words != 800 {
+		t.Errorf("got %d words in the notes, want 800", words)
+	}
+}
+
+func TestFootnoteBalancedColumns(t *testing.T) {
+	doc := newColumnsDoc(t)
+	doc.SetFootnoteStyle(footnoteStyle)
+	writeLines := func(n int) {
+		for range n {
+			doc.CellFormat(0, 5, "line", "", 1, "L", false, 0, "")
+		}
+	}
+
+	// The note is taller than the balanced columns, but fits on the page below
+	// them, and doesn't make them taller.
+	doc.Columns(scribe.Columns{Balance: true}, func() {
+		writeLines(10)
+		doc.Footnote(5, strings.Repeat(longNote, 9))
+		writeLines(50)
+	})
+	y := doc.GetY()
+
+	pages := Pages(t, doc.Output(t))
+	if len(pages) != 1 {
+		t.Fatalf("got %d pages, want 1", len(pages))
+	}
+	var words int
+	for _, row := range noteRows(t, doc, pages[0]) {
+		words += strings.Count(row, "word")
+	}
+	if words != 1800 {
+		t.Errorf("got %d words in the notes, want 1800", words)
+	}
+	if math.Abs(float64(y-160)) > 0.5 {
+		t.Errorf("got y %g after the columns, want 160", y)
+	}
+}
+
+func TestEndnotes(t *testing.T) {
+	doc := New(t)
+	doc.SetFootnoteStyle(footnoteStyle)
+	doc.AddPage()
+	doc.Write(5, "text")
+	doc.Endnote(5, "alpha")
+	doc.Endnote(5, "beta")
+	doc.Ln(5)
+	doc.WriteEndnotes()
+
+	// Numbering starts again after the notes are written.
+	doc.Endnote(5, "gamma")
+	doc.Ln(5)
+	doc.WriteEndnotes()
+
+	// The markers, and the numbers of the notes, are raised from the text, in
+	// text objects of their own.
+	pages := Pages(t, doc.Output(t))
+	want := []string{
+		"text", "1", "2",
+		"1", " alpha", "2", " beta",
+		"1",
+		"1", " gamma",
+	}
+	if lines := doc.Lines(t, pages[0]); !slices.Equal(lines, want) {
+		t.Errorf("got lines %q, want %q", lines, want)
+	}
+}
@@ -0,0 +1,208 @@
+package pdftest
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kofi-q/scribe-go"
+)
+
+// pageTrigger returns the y position of the page break trigger of doc.
+func pageTrigger(doc *Doc) float32 {
+	_, pageHeight := doc.GetPageSize()
+	_, _, _, bottom := doc.GetMargins()
+	return pageHeight - bottom
+}
+
+func TestOrphansWidows(t *testing.T) {
+	// A paragraph of four lines, 6 high, with orphans and widows of 2.
+	const text = "aaa bbb ccc ddd"
+	lines := []string{"aaa", "bbb", "ccc", "ddd"}
+
+	for _, tc := range []struct {
+		name  string
+		write func(doc *Doc, width float32)
+	}{
+		{"Paragraph", func(doc *Doc, width float32) {
+			doc.NewParagraph().LineHeight(6).Text(text).Write(width)
+		}},
+		{"MultiCell", func(doc *Doc, width float32) {
+			doc.MultiCell(width, 6, text, "", "L", false)
+		}},
+		{"MultiCellLineBreaking", func(doc *Doc, width float32) {
+			doc.SetLineBreaking(&scribe.LineBreaking{})
+			doc.MultiCell(width, 6, text, "", "L", false)
+		}},
+	} {
+		for _, pos := range []struct {
+			name string
+			// The space left above the page break trigger.
+			space float32
+			want  [][]string
+		}{
+			{"Orphans", 8, [][]string{nil, lines}},
+			{"Widows", 20, [][]string{lines[:2], lines[2:]}},
+		} {
+			t.Run(tc.name+pos.name, func(t *testing.T) {
+				doc := New(t)
+				doc.SetOrphansWidows(2, 2)
+				doc.AddPage()
+				doc.SetY(pageTrigger(doc) - pos.space)
+				tc.write(doc, doc.GetStringWidth("aaa ")+2*doc.GetCellMargin())
+
+				pages := Pages(t, doc.Output(t))
+				if len(pages) != len(pos.want) {
+					t.Fatalf("got %d pages, want %d", len(pages), len(pos.want))
+				}
+				for i, page := range pages {
+					if rows := doc.Rows(t, page); !slices.Equal(rows, pos.want[i]) {
+						t.Errorf("got rows %q on page %d, want %q", rows, i+1, pos.want[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestOrphansExclusion(t *testing.T) {
+	const text = "Lines beside an exclusion on one page are broken again at the " +
+		"full width when the paragraph is moved to the next page to keep its " +
+		"orphans, where the exclusion isn't."
+	write := func(doc *Doc) {
+		doc.SetLineBreaking(&scribe.LineBreaking{})
+		doc.MultiCell(0, 6, text, "", "L", false)
+	}
+
+	want := New(t)
+	want.AddPage()
+	write(want)
+	wantRows := want.Rows(t, Pages(t, want.Output(t))[0])
+
+	doc := New(t)
+	doc.SetOrphansWidows(2, 2)
+	doc.AddPage()
+	pageWidth, _ := doc.GetPageSize()
+	trigger := pageTrigger(doc)
+	doc.AddExclusion([]scribe.PointType{
+		{X: pageWidth / 2, Y: trigger - 30},
+		{X: pageWidth, Y: trigger - 30},
+		{X: pageWidth, Y: trigger},
+		{X: pageWidth / 2, Y: trigger},
+	})
+	doc.SetY(trigger - 8)
+	write(doc)
+
+	pages := Pages(t, doc.Output(t))
+	if len(pages) != 2 {
+		t.Fatalf("got %d pages, want 2", len(pages))
+	}
+	if rows := doc.Rows(t, pages[1]); !slices.Equal(rows, wantRows) {
+		t.Errorf("got rows %q on page 2, want %q", rows, wantRows)
+	}
+}
+
+func TestKeepTogether(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	doc.SetY(pageTrigger(doc) - 15)
+	doc.KeepTogether(func() {
+		for _, line := range []string{"one", "two", "three"} {
+			doc.CellFormat(0, 6, line, "", 1, "L", false, 0, "")
+		}
+	})
+
+	pages := Pages(t, doc.Output(t))
+	if len(pages) != 2 {
+		t.Fatalf("got %d pages, want 2", len(pages))
+	}
+	if rows := doc.Rows(t, pages[0]); len(rows) > 0 {
+		t.Errorf("got rows %q on page 1, want none", rows)
+	}
+	if rows, want := doc.Rows(t, pages[1]), []string{"one", "two", "three"}; !slices.Equal(rows, want) {
+		t.Errorf("got rows %q on page 2, want %q", rows, want)
+	}
+}
+
+func TestKeepWithNext(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	doc.SetY(pageTrigger(doc) - 15)
+	doc.KeepWithNext(12, func() {
+		doc.CellFormat(0, 6, "heading", "", 1, "L", false, 0, "")
+	})
+	doc.CellFormat(0, 6, "text", "", 1, "L", false, 0, "")
+
+	pages := Pages(t, doc.Output(t))
+	if len(pages) != 2 {
+		t.Fatalf("got %d pages, want 2", len(pages))
+	}
+	if rows, want := doc.Rows(t, pages[1]), []string{"heading", "text"}; !slices.Equal(rows, want) {
+		t.Errorf("got rows %q on page 2, want %q", rows, want)
+	}
+}
+
+func TestKeepTogetherSection(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	doc.SetY(pageTrigger(doc) - 15)
+
+	// The section started in the first call isn't started on the page added
+	// before the second.
+	doc.KeepTogether(func() {
+		doc.StartSection()
+		for _, line := range []string{"one", "two", "three"} {
+			doc.CellFormat(0, 6, line, "", 1, "L", false, 0, "")
+		}
+	})
+	if n := doc.SectionPageNo(); n != 2 {
+		t.Errorf("got section page %d, want 2", n)
+	}
+	doc.AddPage()
+	if n := doc.SectionPageNo(); n != 1 {
+		t.Errorf("got section page %d on the next page, want 1", n)
+	}
+}
+
+func TestKeepTogetherDash(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	doc.SetY(pageTrigger(doc) - 15)
+
+	// The dash pattern set in the first call isn't set on the page added
+	// before the second.
+	doc.KeepTogether(func() {
+		for _, line := range []string{"one", "two", "three"} {
+			doc.CellFormat(0, 6, line, "", 1, "L", false, 0, "")
+		}
+		doc.SetDashPattern([]float32{1, 1}, 0)
+	})
+
+	pages := Pages(t, doc.Output(t))
+	if len(pages) != 2 {
+		t.Fatalf("got %d pages, want 2", len(pages))
+	}
+	if text, dash := strings.Index(pages[1], "BT"), strings.Index(pages[1], " d\n"); dash < text {
+		t.Errorf("got a dash pattern before the text on page 2:\n%s", pages[1])
+	}
+}
+
+func TestOrphansWidowsHeaderFooter(t *testing.T) {
+	doc := New(t)
+	var headers, footers int
+	doc.SetHeaderFunc(func() { headers++ })
+	doc.SetFooterFunc(func() { footers++ })
+	doc.SetOrphansWidows(2, 2)
+	doc.AddPage()
+
+	// The header and footer are written once for each page, though the lines
+	// are counted first.
+	doc.MultiCell(0, 6, strings.Repeat("line\n", 150), "", "L", false)
+	pages := Pages(t, doc.Output(t))
+	if len(pages) != 2 {
+		t.Fatalf("got %d pages, want 2", len(pages))
+	}
+	if headers != 2 || footers != 2 {
+		t.Errorf("got %d headers and %d footers, want 2", headers, footers)
+	}
+}
@@ -0,0 +1,125 @@
+package pdftest
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kofi-q/scribe-go"
+)
+
+var destsTree = regexp.MustCompile(`(?s)/Dests << /Names \[\n(.*?)\] >>`)
+
+func TestNamedDests(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	doc.AddNamedDest("b")
+	doc.AddPage()
+	doc.AddNamedDest("a")
+	doc.AddNamedDest("c")
+	doc.AddNamedDest("B")
+
+	// Names are sorted, as name trees require, by byte.
+	m := destsTree.FindStringSubmatch(doc.Output(t))
+	if m == nil {
+		t.Fatal("no /Dests name tree")
+	}
+	entries := strings.Split(strings.TrimSuffix(m[1], "\n"), "\n")
+	var names []string
+	for _, entry := range entries {
+		name, _, _ := strings.Cut(entry, " ")
+		names = append(names, name)
+	}
+	if want := []string{"(B)", "(a)", "(b)", "(c)"}; !slices.Equal(names, want) {
+		t.Errorf("got names %q, want %q", names, want)
+	}
+	if !strings.HasPrefix(entries[2], "(b) [3 0 R /XYZ ") {
+		t.Errorf("got entry %q, want a destination on page 1", entries[2])
+	}
+}
+
+func TestNamedDestFirstPage(t *testing.T) {
+	doc := New(t)
+	doc.AddNamedDest("start")
+	doc.AddPage()
+
+	err := doc.Scribe.Output(&strings.Builder{})
+	const want = `named destination "start" added before the first page`
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
+
+func TestLinkActions(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	doc.AddNamedDest("here")
+	doc.LinkNamedDest(10, 10, 20, 5, "here")
+	doc.LinkRemote(10, 20, 20, 5, "other.pdf", "chapter", 0)
+	doc.LinkRemote(10, 30, 20, 5, "other.pdf", "", 3)
+	doc.LinkLaunch(10, 40, 20, 5, "readme.txt")
+	doc.LinkNamedAction(10, 50, 20, 5, "NextPage")
+
+	pdf := doc.Output(t)
+	for _, want := range []string{
+		"/A <</S /GoTo /D (here)>>>>",
+		"/A <</S /GoToR /F (other.pdf) /D (chapter)>>>>",
+		"/A <</S /GoToR /F (other.pdf) /D [2 /Fit]>>>>",
+		"/A <</S /Launch /F (readme.txt)>>>>",
+		"/A <</S /Named /N /NextPage>>>>",
+	} {
+		if !strings.Contains(pdf, want) {
+			t.Errorf("no action %q in annotations", want)
+		}
+	}
+}
+
+func TestLinkNamedActionInvalid(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	doc.LinkNamedAction(10, 10, 20, 5, "Next/Page")
+
+	err := doc.Scribe.Output(&strings.Builder{})
+	const want = `invalid action name "Next/Page"`
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
+
+func TestNamedDestErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		write func(doc *Doc)
+		want  string
+	}{
+		{"LinkUndefined", func(doc *Doc) {
+			doc.AddNamedDest("here")
+			doc.LinkNamedDest(10, 10, 20, 5, "there")
+		}, `link on page 1 goes to named destination "there", which wasn't added`},
+		{"PageMissing", func(doc *Doc) {
+			doc.AddNamedDestAt("later", scribe.Dest{Page: 3})
+			doc.AddPage()
+		}, `named destination "later" is on page 3 of 2`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := New(t)
+			doc.AddPage()
+			tc.write(doc)
+
+			err := doc.Scribe.Output(&strings.Builder{})
+			if err == nil || err.Error() != tc.want {
+				t.Errorf("got error %v, want %q", err, tc.want)
+			}
+		})
+	}
+
+	// Links can go to names added after them, and destinations to pages
+	// added after them.
+	doc := New(t)
+	doc.AddPage()
+	doc.LinkNamedDest(10, 10, 20, 5, "next")
+	doc.AddNamedDestAt("next", scribe.Dest{Page: 2})
+	doc.AddPage()
+	doc.Output(t)
+}
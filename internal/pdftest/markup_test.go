@@ -0,0 +1,101 @@
+package pdftest
+
+import (
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// markupRows are the rows of text rendered from the markup of markup tests.
+var markupRows = []string{
+	"Title",
+	"Some bold and italic text.",
+	"•one",
+	"•two",
+	"1.three",
+	"2.four",
+	"A link.",
+}
+
+// fontSize matches the selection of fonts, with their size.
+var fontSize = regexp.MustCompile(`/F\w+ ([\d.]+) Tf`)
+
+func TestMarkup(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		write func(doc *Doc)
+	}{
+		{"HTML", func(doc *Doc) {
+			doc.NewMarkup(nil).WriteHTML(`<h1>Title</h1>
+				<p>Some <b>bold</b> and <i>italic</i> text.</p>
+				<ul><li>one</li><li>two</li></ul>
+				<ol><li>three</li><li>four</li></ol>
+				<p>A <a href="https://example.com">link</a>.</p>`)
+		}},
+		{"Markdown", func(doc *Doc) {
+			doc.NewMarkup(nil).WriteMarkdown("# Title\n\n" +
+				"Some **bold** and _italic_ text.\n\n" +
+				"- one\n- two\n\n" +
+				"1. three\n2. four\n\n" +
+				"A [link](https://example.com).\n")
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := New(t)
+			doc.AddPage()
+			tc.write(doc)
+			y := doc.GetY()
+
+			pdf := doc.Output(t)
+			pages := Pages(t, pdf)
+			if len(pages) != 1 {
+				t.Fatalf("got %d pages, want 1", len(pages))
+			}
+			if rows := doc.Rows(t, pages[0]); !slices.Equal(rows, markupRows) {
+				t.Errorf("got rows %q, want %q", rows, markupRows)
+			}
+
+			// The heading is twice the size of the text, which is restored
+			// afterwards.
+			var sizes []string
+			for _, m := range fontSize.FindAllStringSubmatch(pages[0], -1) {
+				if len(sizes) == 0 || m[1] != sizes[len(sizes)-1] {
+					sizes = append(sizes, m[1])
+				}
+			}
+			if want := []string{"12", "24", "12"}; !slices.Equal(sizes, want) {
+				t.Errorf("got font sizes %q, want %q", sizes, want)
+			}
+
+			// List items are indented by twice the size of the text.
+			objs := doc.Text(t, pages[0])
+			var text, item TextObject
+			for _, obj := range objs {
+				switch obj.Text {
+				case "Some ":
+					text = obj
+				case "one":
+					item = obj
+				}
+			}
+			indent := 24 / doc.GetConversionRatio()
+			if dx := item.X - text.X; math.Abs(float64(dx-indent)) > 0.01 {
+				t.Errorf("got list items indented by %g, want %g", dx, indent)
+			}
+
+			if !strings.Contains(pdf, "/URI (https://example.com)") {
+				t.Error("no link to https://example.com")
+			}
+
+			// The position is left at the left margin, below the last line.
+			if x, _, _, _ := doc.GetMargins(); doc.GetX() != x {
+				t.Errorf("got x %g after the markup, want the left margin %g", doc.GetX(), x)
+			}
+			if _, pageHeight := doc.GetPageSize(); y <= pageHeight-objs[len(objs)-1].Y {
+				t.Errorf("got y %g after the markup, above the last line", y)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package pdftest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kofi-q/scribe-go"
+)
+
+var outlineRoot = regexp.MustCompile(`\n(\d+) 0 obj\n<</Type /Outlines /First (\d+) 0 R`)
+
+// outlineItems returns the object number of the outline root of pdf, and that
+// of the first of its n items, which are numbered in order, with the items.
+func outlineItems(t *testing.T, pdf string, n int) (root, first int, items []string) {
+	t.Helper()
+
+	m := outlineRoot.FindStringSubmatch(pdf)
+	if m == nil {
+		t.Fatal("no outline")
+	}
+	root, _ = strconv.Atoi(m[1])
+	first, _ = strconv.Atoi(m[2])
+	for i := range n {
+		items = append(items, Object(t, pdf, strconv.Itoa(first+i)))
+	}
+
+	return root, first, items
+}
+
+// hasEntry reports whether dict has an entry for key with value.
+func hasEntry(dict, key, value string) bool {
+	return strings.Contains(dict, "/"+key+" "+value+"\n")
+}
+
+func TestOutlineTree(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	for _, o := range []scribe.Outline{
+		{Title: "A", Open: true},
+		{Title: "A1", Level: 1},
+		{Title: "A1a", Level: 2},
+		{Title: "A2", Level: 1},
+		{Title: "B"},
+		{Title: "B1", Level: 1},
+	} {
+		doc.AddOutline(o)
+	}
+
+	pdf := doc.Output(t)
+	root, first, items := outlineItems(t, pdf, 6)
+	ref := func(i int) string { return fmt.Sprintf("%d 0 R", first+i) }
+
+	// Open entries count the entries shown below them, and closed ones count
+	// those shown when opened, as negative numbers.
+	if !strings.Contains(pdf, fmt.Sprintf("/Last %s /Count 4>>", ref(4))) {
+		t.Errorf("got root %s, want a count of 4", Object(t, pdf, strconv.Itoa(root)))
+	}
+	for i, want := range []struct {
+		parent, prev, next, first, count string
+	}{
+		{parent: fmt.Sprintf("%d 0 R", root), next: ref(4), first: ref(1), count: "2"},
+		{parent: ref(0), next: ref(3), first: ref(2), count: "-1"},
+		{parent: ref(1)},
+		{parent: ref(0), prev: ref(1)},
+		{parent: fmt.Sprintf("%d 0 R", root), prev: ref(0), first: ref(5), count: "-1"},
+		{parent: ref(4)},
+	} {
+		item := items[i]
+		for _, entry := range []struct{ key, value string }{
+			{"Parent", want.parent},
+			{"Prev", want.prev},
+			{"Next", want.next},
+			{"First", want.first},
+			{"Count", want.count},
+		} {
+			if entry.value == "" {
+				if strings.Contains(item, "/"+entry.key+" ") {
+					t.Errorf("got /%s in item %d, want none:\n%s", entry.key, i, item)
+				}
+			} else if !hasEntry(item, entry.key, entry.value) {
+				t.Errorf("got item %d, want /%s %s:\n%s", i, entry.key, entry.value, item)
+			}
+		}
+	}
+}
+
+func TestOutlineDest(t *testing.T) {
+	doc := New(t)
+	k := doc.GetConversionRatio()
+	_, pageHeight := doc.GetPageSize()
+	doc.AddPage()
+	doc.AddPageFormat("P", scribe.PageSize{Wd: 300, Ht: 400})
+	for _, o := range []scribe.Outline{
+		{Title: "XYZ", Dest: &scribe.Dest{X: 10, Y: 50, Zoom: 2}},
+		{Title: "Default page", Dest: &scribe.Dest{Page: 1, Y: 50}},
+		{Title: "FitH", Dest: &scribe.Dest{Mode: scribe.DestFitH, Y: 50}},
+		{Title: "FitR", Dest: &scribe.Dest{
+			Page: 1,
+			Mode: scribe.DestFitR,
+			X:    10,
+			Y:    20,
+			W:    30,
+			H:    40,
+		}},
+		{Title: "Fit", Dest: &scribe.Dest{Mode: scribe.DestFit}},
+	} {
+		doc.AddOutline(o)
+	}
+
+	// Positions are from the bottom of each page, in points.
+	_, _, items := outlineItems(t, doc.Output(t), 5)
+	height := pageHeight * k
+	for i, want := range []string{
+		fmt.Sprintf("[5 0 R /XYZ %g %g 2]", 10*k, 400*k-50*k),
+		fmt.Sprintf("[3 0 R /XYZ 0 %g null]", height-50*k),
+		fmt.Sprintf("[5 0 R /FitH %g]", 400*k-50*k),
+		fmt.Sprintf("[3 0 R /FitR %g %g %g %g]", 10*k, height-(20+40)*k, 10*k+30*k, height-20*k),
+		"[5 0 R /Fit]",
+	} {
+		if !hasEntry(items[i], "Dest", want) {
+			t.Errorf("got item %d, want /Dest %s:\n%s", i, want, items[i])
+		}
+	}
+}
+
+func TestOutlineStyle(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	doc.AddOutline(scribe.Outline{
+		Title:  "Styled",
+		Bold:   true,
+		Italic: true,
+		Color:  &scribe.RGBType{R: 255, B: 128},
+	})
+	doc.AddOutline(scribe.Outline{Title: "Plain"})
+
+	_, _, items := outlineItems(t, doc.Output(t), 2)
+	if !hasEntry(items[0], "F", "3") || !hasEntry(items[0], "C", "[1.000 0.000 0.502]") {
+		t.Errorf("got item without /F 3 and /C:\n%s", items[0])
+	}
+	if strings.Contains(items[1], "/F ") || strings.Contains(items[1], "/C ") {
+		t.Errorf("got /F or /C in plain item:\n%s", items[1])
+	}
+}
+
+func TestOutlineNamedDest(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	doc.AddOutline(scribe.Outline{Title: "Named", NamedDest: "later"})
+	doc.AddNamedDest("later")
+
+	_, _, items := outlineItems(t, doc.Output(t), 1)
+	if !hasEntry(items[0], "Dest", "(later)") {
+		t.Errorf("got item without /Dest (later):\n%s", items[0])
+	}
+
+	// Names that aren't added are rejected.
+	doc = New(t)
+	doc.AddPage()
+	doc.AddOutline(scribe.Outline{Title: "Named", NamedDest: "missing"})
+	err := doc.Scribe.Output(&strings.Builder{})
+	const want = `outline entry "Named" goes to named destination "missing", which wasn't added`
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
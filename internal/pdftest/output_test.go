@@ -0,0 +1,39 @@
+package pdftest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kofi-q/scribe-go"
+)
+
+// ClipPolygon() and page annotations are formatted in buffers that start out
+// empty, without being allocated.
+
+func TestClipPolygon(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	doc.ClipPolygon([]scribe.PointType{{X: 10, Y: 10}, {X: 20, Y: 10}, {X: 15, Y: 20}}, false)
+	doc.ClipEnd()
+
+	pages := Pages(t, doc.Output(t))
+	const want = "q 10 831.89 m 20 831.89 l 15 821.89 l h W n\n"
+	if !strings.Contains(pages[0], want) {
+		t.Errorf("no clipping path %q in page:\n%s", want, pages[0])
+	}
+}
+
+func TestPageAnnots(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	doc.LinkString(10, 10, 20, 5, "https://example.com")
+
+	pdf := doc.Output(t)
+	const want = "/Annots [<</Type /Annot /Subtype /Link /Rect ["
+	if !strings.Contains(pdf, want) {
+		t.Errorf("no annotation %q in:\n%s", want, pdf)
+	}
+	if !strings.Contains(pdf, "/A <</S /URI /URI (https://example.com)>>>>]") {
+		t.Errorf("no URI action in:\n%s", pdf)
+	}
+}
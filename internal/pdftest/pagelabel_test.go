@@ -0,0 +1,73 @@
+package pdftest
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kofi-q/scribe-go"
+)
+
+var pageLabelsTree = regexp.MustCompile(`(?s)/PageLabels << /Nums \[\n(.*?)\] >>`)
+
+func TestPageLabels(t *testing.T) {
+	doc := New(t)
+	doc.SetPageLabel(2, scribe.PageLabelRomanLower, "", 1)
+	doc.SetPageLabel(4, scribe.PageLabelDecimal, "A-", 3)
+	doc.SetPageLabel(6, scribe.PageLabelNone, "Index", 0)
+	for range 6 {
+		doc.AddPage()
+	}
+
+	// Ranges are listed from their first page, counted from 0, with the page
+	// numbers of any pages before the first, and prefixes in UTF-16.
+	m := pageLabelsTree.FindStringSubmatch(doc.Output(t))
+	if m == nil {
+		t.Fatal("no /PageLabels number tree")
+	}
+	entries := strings.Split(strings.TrimSuffix(m[1], "\n"), "\n")
+	want := []string{
+		"0 <</S /D>>",
+		"1 <</S /r>>",
+		"3 <</S /D /P (\xfe\xff\x00A\x00-) /St 3>>",
+		"5 <</P (\xfe\xff\x00I\x00n\x00d\x00e\x00x)>>",
+	}
+	if !slices.Equal(entries, want) {
+		t.Errorf("got entries %q, want %q", entries, want)
+	}
+
+	var labels []string
+	for page := 1; page <= 6; page++ {
+		labels = append(labels, doc.PageLabel(page))
+	}
+	if want := []string{"1", "i", "ii", "A-3", "A-4", "Index"}; !slices.Equal(labels, want) {
+		t.Errorf("got labels %q, want %q", labels, want)
+	}
+}
+
+func TestPageLabelAliases(t *testing.T) {
+	doc := New(t)
+	doc.AliasPageLabel("")
+	doc.AliasNbPages("")
+	doc.SetFooterFunc(func() {
+		doc.SetY(-15)
+		doc.CellFormat(0, 10, "Page {pl} of {nb}", "", 0, "C", false, 0, "")
+	})
+	for range 4 {
+		doc.AddPage()
+	}
+
+	// The labels of the front matter are only set once it's written.
+	doc.SetPageLabel(1, scribe.PageLabelRomanLower, "", 1)
+	doc.SetPageLabel(3, scribe.PageLabelDecimal, "", 1)
+
+	var footers []string
+	for _, page := range Pages(t, doc.Output(t)) {
+		footers = append(footers, doc.Lines(t, page)...)
+	}
+	want := []string{"Page i of 2", "Page ii of 2", "Page 1 of 2", "Page 2 of 2"}
+	if !slices.Equal(footers, want) {
+		t.Errorf("got footers %q, want %q", footers, want)
+	}
+}
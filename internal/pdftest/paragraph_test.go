@@ -0,0 +1,120 @@
+package pdftest
+
+import (
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestParagraphLineBreaks(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	width := doc.GetStringWidth("one two ") + 2*doc.GetCellMargin()
+
+	p := doc.NewParagraph().LineHeight(6)
+	p.Text("one two three\nfour")
+	p.Write(width)
+
+	pages := Pages(t, doc.Output(t))
+	want := []string{"one two", "three", "four"}
+	if rows := doc.Rows(t, pages[0]); !slices.Equal(rows, want) {
+		t.Errorf("got rows %q, want %q", rows, want)
+	}
+
+	// Lines are spaced by the line height.
+	objs := doc.Text(t, pages[0])
+	for i := 1; i < len(objs); i++ {
+		if dy := objs[i-1].Y - objs[i].Y; math.Abs(float64(dy-6)) > 0.01 {
+			t.Errorf("line %d is %g below the line above, want 6", i, dy)
+		}
+	}
+}
+
+// adjustment matches adjustments to glyph positions in TJ arrays.
+var adjustment = regexp.MustCompile(`\) ?(-?[\d.]+) ?\(`)
+
+func TestParagraphJustified(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	width := doc.GetStringWidth("one two three") + 2*doc.GetCellMargin()
+	slack := doc.GetStringWidth("one two three") - doc.GetStringWidth("one two")
+
+	p := doc.NewParagraph().Align("J")
+	p.Text("one two three four")
+	p.Write(width)
+
+	pages := Pages(t, doc.Output(t))
+	objs := doc.Text(t, pages[0])
+	if len(objs) != 2 {
+		t.Fatalf("got %d text objects, want 2", len(objs))
+	}
+
+	// The first line, "one two", is stretched to the width of "one two three"
+	// by spacing after its space, in thousandths of the font size, which
+	// moves glyphs back when negative.
+	m := adjustment.FindAllStringSubmatch(objs[0].Ops, -1)
+	if len(m) != 1 {
+		t.Fatalf("got adjustments %q, want 1 in %q", m, objs[0].Ops)
+	}
+	adjust, err := strconv.ParseFloat(m[0][1], 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fontSize := 12 / doc.GetConversionRatio()
+	want := -float64(slack * 1000 / fontSize)
+	if math.Abs(adjust-want) > 1 {
+		t.Errorf("got adjustment %g, want %g", adjust, want)
+	}
+
+	// The last line isn't justified.
+	if m := adjustment.FindAllString(objs[1].Ops, -1); len(m) > 0 {
+		t.Errorf("got adjustments %q in last line %q", m, objs[1].Ops)
+	}
+}
+
+func TestParagraphPageBreak(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	_, pageHeight := doc.GetPageSize()
+	_, _, _, bottom := doc.GetMargins()
+	doc.SetY(pageHeight - bottom - 15)
+
+	p := doc.NewParagraph().LineHeight(6)
+	p.Text("one\ntwo\nthree\nfour")
+	p.Write(0)
+
+	pages := Pages(t, doc.Output(t))
+	if len(pages) != 2 {
+		t.Fatalf("got %d pages, want 2", len(pages))
+	}
+	if rows := doc.Rows(t, pages[0]); !slices.Equal(rows, []string{"one", "two"}) {
+		t.Errorf("got rows %q on page 1", rows)
+	}
+	if rows := doc.Rows(t, pages[1]); !slices.Equal(rows, []string{"three", "four"}) {
+		t.Errorf("got rows %q on page 2", rows)
+	}
+	if y := doc.GetY(); y < 10 || y > 25 {
+		t.Errorf("got y %g after the paragraph, want just below the top margin", y)
+	}
+}
+
+func TestParagraphBidi(t *testing.T) {
+	doc := New(t)
+	doc.AddPage()
+	width := doc.GetStringWidth(bidiLine+" ") + 2*doc.GetCellMargin() + 1
+
+	// The Hebrew words are in different spans, ordered right to left, with
+	// the comma at the end of the first line ordered as in the paragraph.
+	p := doc.NewParagraph()
+	p.Text("abc ")
+	p.Text("אבג, ")
+	p.Add(p.Span("דהו 12"))
+	p.Write(width)
+
+	pages := Pages(t, doc.Output(t))
+	if rows := doc.Rows(t, pages[0]); !slices.Equal(rows, bidiLines) {
+		t.Errorf("got rows %q, want %q", rows, bidiLines)
+	}
+}
@@ -0,0 +1,231 @@
+// Package pdftest provides helpers for tests that check the documents written
+// by scribe-go: documents with an embedded font and uncompressed page content,
+// and the text shown on their pages.
+package pdftest
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kofi-q/scribe-go"
+	"github.com/kofi-q/scribe-go/ttf"
+)
+
+// fontFile is the font of documents created with New(), relative to the
+// directories of the tests.
+const fontFile = "../../font/DejaVuSansCondensed.ttf"
+
+// Doc is a document being tested.
+type Doc struct {
+	*scribe.Scribe
+
+	Fonts *scribe.FontSet
+
+	// The character of each glyph of the font, for reading text back.
+	chars map[uint16]rune
+}
+
+// New returns an A4 portrait document, in millimetres, with the DejaVu Sans
+// Condensed font set at 12 points, and page compression disabled.
+func New(t testing.TB) *Doc {
+	t.Helper()
+
+	data, err := os.ReadFile(fontFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fonts := ttf.NewFontSet(1)
+	font := fonts.MustAddTtf("DejaVu", ttf.StyleNone, data)
+
+	doc := &Doc{
+		Scribe: scribe.New("P", "mm", scribe.PageSizeA4, &fonts),
+		Fonts:  &fonts,
+		chars:  map[uint16]rune{},
+	}
+	doc.SetCompression(false)
+	doc.SetFont(font, scribe.FontStyleNone, 12)
+
+	for char := rune(0xffff); char > 0; char-- {
+		if gid := fonts.Get(font).Font().GlyphId(char); gid != 0 {
+			doc.chars[gid] = char
+		}
+	}
+
+	return doc
+}
+
+// Output closes the document and returns the PDF.
+func (d *Doc) Output(t testing.TB) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := d.Scribe.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+var contentsRef = regexp.MustCompile(`/Contents (\d+) 0 R>>`)
+
+// Pages returns the content streams of the pages of pdf, in page order.
+func Pages(t testing.TB, pdf string) (pages []string) {
+	t.Helper()
+
+	for _, ref := range contentsRef.FindAllStringSubmatch(pdf, -1) {
+		obj := Object(t, pdf, ref[1])
+		_, stream, ok := strings.Cut(obj, "stream\n")
+		if !ok {
+			t.Fatalf("no stream in object %s", ref[1])
+		}
+		length, err := strconv.Atoi(Value(t, obj, "Length"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, stream[:length])
+	}
+
+	return pages
+}
+
+// Object returns the object numbered num in pdf, without the endobj keyword.
+func Object(t testing.TB, pdf, num string) string {
+	t.Helper()
+
+	start := strings.Index(pdf, "\n"+num+" 0 obj\n")
+	if start < 0 {
+		t.Fatalf("no object %s", num)
+	}
+	obj := pdf[start+len(num)+7:]
+	end := strings.Index(obj, "\nendobj")
+	if end < 0 {
+		t.Fatalf("no end to object %s", num)
+	}
+
+	return obj[:end]
+}
+
+// Value returns the value of the first entry named key in dict, up to the
+// next entry or the end of the dictionary.
+func Value(t testing.TB, dict, key string) string {
+	t.Helper()
+
+	_, value, ok := strings.Cut(dict, "/"+key+" ")
+	if !ok {
+		t.Fatalf("no /%s in %q", key, dict)
+	}
+	if end := strings.IndexAny(value, "/>\n"); end >= 0 &&
+		!strings.HasPrefix(value, "[") && !strings.HasPrefix(value, "(") {
+		value = value[:end]
+	}
+
+	return strings.TrimSpace(value)
+}
+
+// TextObject is a text object on a page, written by CellFormat() and friends.
+type TextObject struct {
+	// X and Y are the position of the text, from the bottom left of the page,
+	// in the document's unit of measure.
+	X, Y float32
+
+	// Text is the text shown, in display order.
+	Text string
+
+	// Ops are the operators of the object, as written.
+	Ops string
+}
+
+var textObject = regexp.MustCompile(
+	`(?s)BT (?:0 Tw )?(?:/F\w+ [\d.]+ Tf )?(-?[\d.]+) (-?[\d.]+) Td (.*?) ET`,
+)
+
+// Text returns the text objects of a page, in the order they were written.
+func (d *Doc) Text(t testing.TB, page string) (objs []TextObject) {
+	t.Helper()
+
+	for _, m := range textObject.FindAllStringSubmatch(page, -1) {
+		x, err := strconv.ParseFloat(m[1], 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		y, err := strconv.ParseFloat(m[2], 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var text strings.Builder
+		for _, gid := range GlyphIds(m[3]) {
+			if char, ok := d.chars[gid]; ok {
+				text.WriteRune(char)
+			} else {
+				text.WriteRune('�')
+			}
+		}
+		objs = append(objs, TextObject{float32(x), float32(y), text.String(), m[3]})
+	}
+
+	return objs
+}
+
+// Lines returns the text of each text object of a page.
+func (d *Doc) Lines(t testing.TB, page string) (lines []string) {
+	t.Helper()
+
+	for _, obj := range d.Text(t, page) {
+		lines = append(lines, obj.Text)
+	}
+
+	return lines
+}
+
+// Rows returns the text of each row of text objects of a page, with the same
+// baseline, in the order the rows were written.
+func (d *Doc) Rows(t testing.TB, page string) (rows []string) {
+	t.Helper()
+
+	var y float32
+	for i, obj := range d.Text(t, page) {
+		if i == 0 || obj.Y != y {
+			rows = append(rows, "")
+			y = obj.Y
+		}
+		rows[len(rows)-1] += obj.Text
+	}
+
+	return rows
+}
+
+// GlyphIds returns the glyph ids in the strings of TJ operators in ops, two
+// bytes each.
+func GlyphIds(ops string) (gids []uint16) {
+	var str []byte
+	inString := false
+	for i := 0; i < len(ops); i++ {
+		c := ops[i]
+		switch {
+		case !inString:
+			inString = c == '('
+			continue
+		case c == ')':
+			for j := 0; j+1 < len(str); j += 2 {
+				gids = append(gids, uint16(str[j])<<8|uint16(str[j+1]))
+			}
+			str, inString = str[:0], false
+			continue
+		case c == '\\':
+			i++
+			c = ops[i]
+			if c == 'r' {
+				c = '\r'
+			}
+		}
+		str = append(str, c)
+	}
+
+	return gids
+}
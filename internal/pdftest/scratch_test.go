@@ -0,0 +1,30 @@
+package pdftest
+
+import (
+	"testing"
+
+	"github.com/kofi-q/scribe-go"
+)
+
+func TestScratchPadKerning(t *testing.T) {
+	const text = "AVAVAV AVAVAV"
+
+	doc := New(t)
+	doc.AddPage()
+	doc.SetLineBreaking(&scribe.LineBreaking{})
+	unkerned := doc.GetStringWidth(text)
+	doc.SetKerning(true)
+	kerned := doc.GetStringWidth(text)
+	if kerned >= unkerned {
+		t.Fatalf("kerned width %g isn't less than unkerned width %g", kerned, unkerned)
+	}
+
+	// The text only fits on one line with kerning.
+	sc, err := doc.Scratch((kerned + unkerned) / 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := sc.Text(5, text); len(lines) != 1 {
+		t.Errorf("got lines %q, want 1 line", lines)
+	}
+}
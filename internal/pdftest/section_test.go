@@ -0,0 +1,59 @@
+package pdftest
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestSections(t *testing.T) {
+	doc := New(t)
+	doc.AliasSectionPages("")
+	doc.SetFooterFunc(func() {
+		doc.SetY(-15)
+		doc.CellFormat(0, 10, fmt.Sprintf("%d/{snb}", doc.SectionPageNo()), "", 0, "C", false, 0, "")
+	})
+
+	// Pages 1 and 2, and 3 to 5, with the second section started while page 2
+	// is written.
+	doc.AddPage()
+	doc.AddPage()
+	doc.StartSection()
+	if n := doc.SectionPageNo(); n != 2 {
+		t.Errorf("got section page %d before the section starts, want 2", n)
+	}
+	for range 3 {
+		doc.AddPage()
+	}
+
+	// The alias is replaced in the first and last pages of each section, when
+	// the document is closed.
+	pages := Pages(t, doc.Output(t))
+	want := []string{"1/2", "2/2", "1/3", "2/3", "3/3"}
+	if len(pages) != len(want) {
+		t.Fatalf("got %d pages, want %d", len(pages), len(want))
+	}
+	for i, page := range pages {
+		if lines := doc.Lines(t, page); !slices.Equal(lines, want[i:i+1]) {
+			t.Errorf("got footer %q on page %d, want %q", lines, i+1, want[i])
+		}
+	}
+}
+
+func TestSectionAlias(t *testing.T) {
+	doc := New(t)
+	doc.AliasSectionPages("{section}")
+	doc.AddPage()
+	doc.CellFormat(0, 10, "{section} pages", "", 1, "L", false, 0, "")
+	doc.StartSection()
+	doc.AddPage()
+	doc.CellFormat(0, 10, "{section} pages", "", 1, "L", false, 0, "")
+	doc.AddPage()
+
+	pages := Pages(t, doc.Output(t))
+	for i, want := range [][]string{{"1 pages"}, {"2 pages"}, nil} {
+		if lines := doc.Lines(t, pages[i]); !slices.Equal(lines, want) {
+			t.Errorf("got lines %q on page %d, want %q", lines, i+1, want)
+		}
+	}
+}
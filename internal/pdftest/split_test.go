@@ -0,0 +1,63 @@
+package pdftest
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/kofi-q/scribe-go"
+	"github.com/kofi-q/scribe-go/ttf"
+)
+
+func TestSplitLines(t *testing.T) {
+	for _, tc := range []struct {
+		name, text string
+		sep        string
+		font       string
+	}{
+		{"CJK", strings.Repeat("日本語のテキスト", 4), "", "../../ttf/NotoSansSC-Regular.ttf"},
+		{"Accented", "Ärger über Übermäßige Größe, façade naïve déjà vu", " ", ""},
+	} {
+		for _, lb := range []struct {
+			name   string
+			params *scribe.LineBreaking
+		}{
+			{"FirstFit", nil},
+			{"TotalFit", &scribe.LineBreaking{}},
+		} {
+			t.Run(tc.name+lb.name, func(t *testing.T) {
+				doc := New(t)
+				if tc.font != "" {
+					data, err := os.ReadFile(tc.font)
+					if err != nil {
+						t.Fatal(err)
+					}
+					font := doc.Fonts.MustAddTtf("Font", ttf.StyleNone, data)
+					doc.SetFont(font, scribe.FontStyleNone, 12)
+				}
+				doc.SetLineBreaking(lb.params)
+				width := doc.GetStringWidth(tc.text)/3 + 2*doc.GetCellMargin()
+
+				// Lines are whole characters, which fit the width, and make up
+				// the text.
+				var lines []string
+				for _, line := range doc.SplitLines([]byte(tc.text), width) {
+					if !utf8.Valid(line) {
+						t.Fatalf("got invalid line %q", line)
+					}
+					if w := doc.GetStringWidth(string(line)); w > width-2*doc.GetCellMargin()+0.01 {
+						t.Errorf("got line %q %g wide, want at most %g", line, w, width)
+					}
+					lines = append(lines, string(line))
+				}
+				if len(lines) < 3 {
+					t.Errorf("got lines %q, want the text wrapped", lines)
+				}
+				if got := strings.Join(lines, tc.sep); got != tc.text {
+					t.Errorf("got lines %q, want %q", lines, tc.text)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package pdftest
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/kofi-q/scribe-go"
+)
+
+func TestTableLines(t *testing.T) {
+	const text = "Ärger über Übermäßige Größe"
+
+	doc := New(t)
+	doc.AddPage()
+	tbl := doc.NewTable(scribe.TableColumn{Width: 30}, scribe.TableColumn{Width: 30})
+	tbl.Row(scribe.TableCell{Text: text}, scribe.TableCell{Text: "one\n\ntwo"})
+	tbl.Write(0)
+
+	pages := Pages(t, doc.Output(t))
+	var left []string
+	var right []TextObject
+	objs := doc.Text(t, pages[0])
+	for _, obj := range objs {
+		if obj.X == objs[0].X {
+			left = append(left, obj.Text)
+		} else {
+			right = append(right, obj)
+		}
+	}
+
+	// The text is wrapped between words, in whole characters, within the
+	// padding of the cell.
+	if len(left) < 2 {
+		t.Fatalf("got lines %q, want the text wrapped", left)
+	}
+	maxWidth := 30 - 2*doc.GetCellMargin()
+	for _, line := range left {
+		if !utf8.ValidString(line) || strings.ContainsRune(line, '�') {
+			t.Errorf("got line %q, want whole characters", line)
+		}
+		if w := doc.GetStringWidth(line); w > maxWidth {
+			t.Errorf("got line %q %g wide, want at most %g", line, w, maxWidth)
+		}
+	}
+	if joined := strings.Join(left, " "); joined != text {
+		t.Errorf("got lines %q, want %q", left, text)
+	}
+
+	// Empty lines are kept.
+	var lines []string
+	for _, obj := range right {
+		lines = append(lines, obj.Text)
+	}
+	if want := []string{"one", "two"}; !slices.Equal(lines, want) {
+		t.Fatalf("got lines %q, want %q", lines, want)
+	}
+	lineHeight := 1.2 * 12 / doc.GetConversionRatio()
+	if dy := right[0].Y - right[1].Y; math.Abs(float64(dy-2*lineHeight)) > 0.01 {
+		t.Errorf("got lines %g apart, want %g", dy, 2*lineHeight)
+	}
+}
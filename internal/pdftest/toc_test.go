@@ -0,0 +1,140 @@
+package pdftest
+
+import (
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kofi-q/scribe-go"
+)
+
+func TestTOC(t *testing.T) {
+	doc := New(t)
+	doc.AddTOC(0, nil)
+	for _, title := range []string{"One", "Two"} {
+		doc.AddPage()
+		doc.Bookmark(title, 0, -1)
+	}
+
+	pages := Pages(t, doc.Output(t))
+	rows := doc.Rows(t, pages[0])
+	if len(rows) != 2 {
+		t.Fatalf("got rows %q, want 2", rows)
+	}
+
+	// Titles are followed by a leader, and the page number, at the right
+	// margin.
+	for i, want := range []*regexp.Regexp{
+		regexp.MustCompile(`^One\.+2$`),
+		regexp.MustCompile(`^Two\.+3$`),
+	} {
+		if !want.MatchString(rows[i]) {
+			t.Errorf("got row %q, want %s", rows[i], want)
+		}
+	}
+	objs := doc.Text(t, pages[0])
+	pageWidth, _ := doc.GetPageSize()
+	_, _, right, _ := doc.GetMargins()
+	num := objs[len(objs)-1]
+	end := num.X + doc.GetStringWidth("3") + doc.GetCellMargin()
+	if d := end - (pageWidth - right); d < -0.01 || d > 0.01 {
+		t.Errorf("page number ends at x %g, want the right margin, %g", end, pageWidth-right)
+	}
+	leader := objs[len(objs)-2]
+	if leader.X+doc.GetStringWidth(leader.Text) > num.X {
+		t.Errorf("leader %q at x %g overlaps the page number at %g", leader.Text, leader.X, num.X)
+	}
+}
+
+func TestTOCPageLabels(t *testing.T) {
+	doc := New(t)
+	doc.SetPageLabel(1, scribe.PageLabelRomanLower, "", 1)
+	doc.AddTOC(0, nil)
+	doc.AddPage()
+	doc.Bookmark("Preface", 0, -1)
+	doc.SetPageLabel(3, scribe.PageLabelDecimal, "", 1)
+	doc.AddPage()
+	doc.Bookmark("Chapter", 0, -1)
+
+	pages := Pages(t, doc.Output(t))
+	rows := doc.Rows(t, pages[0])
+	if len(rows) != 2 || !strings.HasSuffix(rows[0], ".ii") || !strings.HasSuffix(rows[1], ".1") {
+		t.Errorf("got rows %q, want the page labels", rows)
+	}
+}
+
+func TestTOCWrapped(t *testing.T) {
+	doc := New(t)
+	doc.AddTOC(0, &scribe.TOCStyle{Indent: 10})
+	doc.AddPage()
+	doc.Bookmark("Part", 0, -1)
+	title := strings.TrimSpace(strings.Repeat("A long title ", 40))
+	doc.Bookmark(title, 1, -1)
+
+	pages := Pages(t, doc.Output(t))
+	rows := doc.Rows(t, pages[0])
+	if len(rows) != 3 {
+		t.Fatalf("got rows %q, want the title wrapped onto 2", rows)
+	}
+
+	// The page number and leader follow the last line of the title, which is
+	// indented by level.
+	if strings.ContainsAny(rows[1], ".2") {
+		t.Errorf("got first line %q, want no leader or page number", rows[1])
+	}
+	if !strings.HasSuffix(rows[2], ".2") {
+		t.Errorf("got last line %q, want a leader and page number", rows[2])
+	}
+	got := strings.Join([]string{rows[1], strings.TrimRight(rows[2], ".2")}, " ")
+	if got != title {
+		t.Errorf("got title %q, want %q", got, title)
+	}
+	objs := doc.Text(t, pages[0])
+	if !slices.ContainsFunc(objs, func(obj TextObject) bool {
+		return strings.HasPrefix(obj.Text, "A long") && math.Abs(float64(obj.X-objs[0].X-10)) < 0.01
+	}) {
+		t.Errorf("got no entry indented by 10 from %g in %v", objs[0].X, objs)
+	}
+}
+
+func TestTOCOverflow(t *testing.T) {
+	doc := New(t)
+	doc.AddTOC(1, &scribe.TOCStyle{LineHeight: 100})
+	doc.AddPage()
+	for range 20 {
+		doc.Bookmark("Entry", 0, -1)
+	}
+
+	err := doc.Scribe.Output(&strings.Builder{})
+	const want = "table of contents doesn't fit in 2 reserved pages"
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
+
+func TestTOCPageGeometry(t *testing.T) {
+	doc := New(t)
+
+	// The table is written with the size and margins of its page, rather than
+	// those of the last page.
+	doc.SetRightMargin(50)
+	doc.AddPageFormat("L", scribe.PageSizeA4)
+	doc.AddTOC(0, nil)
+	tocWidth, _ := doc.GetPageSize()
+	doc.SetRightMargin(10)
+	doc.AddPageFormat("P", scribe.PageSizeA4)
+	doc.Bookmark("One", 0, -1)
+
+	pages := Pages(t, doc.Output(t))
+	objs := doc.Text(t, pages[0])
+	if len(objs) == 0 {
+		t.Fatal("no table of contents on page 1")
+	}
+	num := objs[len(objs)-1]
+	end := num.X + doc.GetStringWidth("2") + doc.GetCellMargin()
+	if d := end - (tocWidth - 50); d < -0.01 || d > 0.01 {
+		t.Errorf("page number ends at x %g, want the right margin, %g", end, tocWidth-50)
+	}
+}
@@ -0,0 +1,201 @@
+// Package table lays out the grid of a table: the positions of cells spanning
+// rows and columns, the widths of columns and the heights of rows.
+package table
+
+// Span is the number of rows and columns a cell spans. Values below 1 are
+// treated as 1.
+type Span struct {
+	Rows, Cols int
+}
+
+// Cell is the position of a cell in the grid.
+type Cell struct {
+	Row, Col         int
+	RowSpan, ColSpan int
+}
+
+// Place places rows of cells in a grid of cols columns. Each cell takes the
+// first free column of its row, after any columns covered by cells spanning
+// rows from above. Column spans are limited to the columns left in the row,
+// and row spans to the rows left in the table.
+//
+// It returns the positions of the cells, in order. Cells that don't fit in
+// their row have a ColSpan of 0.
+func Place(rows [][]Span, cols int) []Cell {
+	var cells []Cell
+
+	// The number of rows still covered in each column, by cells spanning
+	// rows from above.
+	covered := make([]int, cols)
+	for r, row := range rows {
+		col := 0
+		for _, span := range row {
+			for col < cols && covered[col] > 0 {
+				col++
+			}
+			if col == cols {
+				cells = append(cells, Cell{Row: r, Col: cols})
+				continue
+			}
+
+			cell := Cell{
+				Row:     r,
+				Col:     col,
+				RowSpan: min(max(span.Rows, 1), len(rows)-r),
+				ColSpan: max(span.Cols, 1),
+			}
+			for k := col; k < col+cell.ColSpan; k++ {
+				if k == cols || covered[k] > 0 {
+					cell.ColSpan = k - col
+					break
+				}
+			}
+			cells = append(cells, cell)
+
+			for k := col; k < col+cell.ColSpan; k++ {
+				covered[k] = cell.RowSpan
+			}
+			col += cell.ColSpan
+		}
+
+		for k := range covered {
+			covered[k] = max(covered[k]-1, 0)
+		}
+	}
+
+	return cells
+}
+
+// Column is the requested width of a column: a fixed Width, or a Percent of
+// the width of the table. Columns with neither are sized to fit their cells.
+type Column struct {
+	Width, Percent float32
+}
+
+// Widths returns the widths of columns in a table of the given width. Fixed
+// and percentage widths are kept, and the width left is shared by the other
+// columns, in proportion to the natural width of their cells, the widest line
+// of each. If there isn't enough width for that, columns are kept at least as
+// wide as their minimum width, the widest word of their cells, where
+// possible.
+func Widths(cols []Column, width float32, natural, minimum []float32) []float32 {
+	widths := make([]float32, len(cols))
+
+	left := width
+	var auto []int
+	for i, col := range cols {
+		switch {
+		case col.Width > 0:
+			widths[i] = col.Width
+		case col.Percent > 0:
+			widths[i] = width * col.Percent / 100
+		default:
+			auto = append(auto, i)
+			continue
+		}
+		left -= widths[i]
+	}
+	if len(auto) == 0 {
+		return widths
+	}
+	left = max(left, 0)
+
+	var sumNatural, sumMinimum float32
+	for _, i := range auto {
+		sumNatural += natural[i]
+		sumMinimum += minimum[i]
+	}
+
+	switch {
+	// Columns of empty cells share the width equally.
+	case sumNatural == 0:
+		for _, i := range auto {
+			widths[i] = left / float32(len(auto))
+		}
+
+	case sumNatural <= left || sumMinimum >= left:
+		sum := sumNatural
+		if sumNatural > left && sumMinimum > 0 {
+			sum = sumMinimum
+			natural = minimum
+		}
+		for _, i := range auto {
+			widths[i] = left * natural[i] / sum
+		}
+
+	// Columns get their minimum width, and share the rest in proportion to
+	// how much wider they'd naturally be.
+	default:
+		extra := left - sumMinimum
+		for _, i := range auto {
+			widths[i] = minimum[i] + extra*(natural[i]-minimum[i])/(sumNatural-sumMinimum)
+		}
+	}
+
+	return widths
+}
+
+// Heights returns the heights of the rows of a table, given the cells and the
+// height of the content of each. Rows are as tall as their tallest cell, and
+// the last row spanned by a cell is made taller if the rows aren't tall enough
+// for it.
+func Heights(cells []Cell, content []float32, rows int) []float32 {
+	heights := make([]float32, rows)
+	for i, cell := range cells {
+		if cell.ColSpan > 0 && cell.RowSpan == 1 {
+			heights[cell.Row] = max(heights[cell.Row], content[i])
+		}
+	}
+
+	// Cells spanning fewer rows are fitted first.
+	for span := 2; ; span++ {
+		more := false
+		for i, cell := range cells {
+			if cell.ColSpan == 0 || cell.RowSpan < span {
+				continue
+			}
+			if cell.RowSpan > span {
+				more = true
+				continue
+			}
+
+			var height float32
+			for _, h := range heights[cell.Row : cell.Row+cell.RowSpan] {
+				height += h
+			}
+			if height < content[i] {
+				heights[cell.Row+cell.RowSpan-1] += content[i] - height
+			}
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	return heights
+}
+
+// Groups returns the ends of the groups of rows that are kept together,
+// because cells span them. A table can be broken between pages before any
+// row that starts a group, i.e. after each of the ends.
+func Groups(cells []Cell, rows int) []int {
+	ends := make([]int, 0, rows)
+	end := 0
+	next := 0
+	for r := range rows {
+		for next < len(cells) && cells[next].Row == r {
+			if cells[next].ColSpan > 0 {
+				end = max(end, r+cells[next].RowSpan)
+			}
+			next++
+		}
+
+		end = max(end, r+1)
+		if end == r+1 {
+			ends = append(ends, end)
+		}
+	}
+
+	return ends
+}
@@ -0,0 +1,100 @@
+package table
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlace(t *testing.T) {
+	// | a (2 rows) | b (2 cols)  |
+	// |            | c    | d    |
+	// | e          | f (2 cols)  |
+	rows := [][]Span{
+		{{Rows: 2}, {Cols: 2}},
+		{{}, {}},
+		{{}, {Cols: 2}},
+	}
+	require.Equal(t, []Cell{
+		{Row: 0, Col: 0, RowSpan: 2, ColSpan: 1},
+		{Row: 0, Col: 1, RowSpan: 1, ColSpan: 2},
+		{Row: 1, Col: 1, RowSpan: 1, ColSpan: 1},
+		{Row: 1, Col: 2, RowSpan: 1, ColSpan: 1},
+		{Row: 2, Col: 0, RowSpan: 1, ColSpan: 1},
+		{Row: 2, Col: 1, RowSpan: 1, ColSpan: 2},
+	}, Place(rows, 3))
+}
+
+func TestPlaceLimits(t *testing.T) {
+	// Spans are limited to the grid, and cells that don't fit are dropped.
+	rows := [][]Span{
+		{{Rows: 5}, {Cols: 4}},
+		{{}, {}, {}},
+	}
+	require.Equal(t, []Cell{
+		{Row: 0, Col: 0, RowSpan: 2, ColSpan: 1},
+		{Row: 0, Col: 1, RowSpan: 1, ColSpan: 2},
+		{Row: 1, Col: 1, RowSpan: 1, ColSpan: 1},
+		{Row: 1, Col: 2, RowSpan: 1, ColSpan: 1},
+		{Row: 1, Col: 3},
+	}, Place(rows, 3))
+}
+
+func TestWidths(t *testing.T) {
+	cols := []Column{{Width: 20}, {Percent: 25}, {}, {}}
+
+	// Auto columns share the width left in proportion to their content.
+	require.Equal(t,
+		[]float32{20, 50, 100, 30},
+		Widths(cols, 200, []float32{0, 0, 10, 3}, []float32{0, 0, 5, 3}),
+	)
+
+	// Without enough width, columns keep their minimum width, sharing the
+	// rest in proportion to how much wider they'd be.
+	require.Equal(t,
+		[]float32{20, 50, 60, 70},
+		Widths(cols, 200, []float32{0, 0, 220, 150}, []float32{0, 0, 20, 50}),
+	)
+
+	// Without even that, they're shrunk in proportion to their minimums.
+	require.Equal(t,
+		[]float32{20, 50, 26, 104},
+		Widths(cols, 200, []float32{0, 0, 300, 400}, []float32{0, 0, 100, 400}),
+	)
+
+	require.Equal(t,
+		[]float32{20, 50, 65, 65},
+		Widths(cols, 200, []float32{0, 0, 0, 0}, []float32{0, 0, 0, 0}),
+	)
+}
+
+func TestHeights(t *testing.T) {
+	cells := Place([][]Span{
+		{{Rows: 3}, {}},
+		{{}},
+		{{}},
+	}, 2)
+
+	require.Equal(t,
+		[]float32{10, 10, 10},
+		Heights(cells, []float32{20, 10, 10, 10}, 3),
+	)
+
+	// The last row spanned is made taller to fit the cell.
+	require.Equal(t,
+		[]float32{10, 5, 25},
+		Heights(cells, []float32{40, 10, 5, 10}, 3),
+	)
+}
+
+func TestGroups(t *testing.T) {
+	cells := Place([][]Span{
+		{{}, {}},
+		{{Rows: 2}, {}},
+		{{}},
+		{{}, {Rows: 3}},
+		{{}},
+	}, 2)
+
+	require.Equal(t, []int{1, 3, 5}, Groups(cells, 5))
+}
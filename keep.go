@@ -0,0 +1,150 @@
+package scribe
+
+// SetOrphansWidows sets the fewest lines of a paragraph written with
+// MultiCell() or Paragraph.Write() that are left at the bottom of a page, the
+// orphans, or carried over to the top of the next one, the widows, when it's
+// broken between pages. Paragraphs are broken earlier, or moved to the next
+// page, to keep to them. Values below 2 allow single lines, as by default.
+//
+// In MultiCell(), paragraphs are separated by line feeds. Without total-fit
+// line breaking (see SetLineBreaking()), the text is laid out twice, to count
+// the lines of each paragraph, the first time without calling the header and
+// footer functions.
+func (f *Scribe) SetOrphansWidows(orphans, widows int) {
+	f.orphans, f.widows = max(orphans, 1), max(widows, 1)
+}
+
+// keepLines makes a page break, or moves to the next column, before line i of
+// a paragraph of n lines, if needed to keep to the orphans and widows set with
+// SetOrphansWidows(). height returns the height of each line.
+func (f *Scribe) keepLines(i, n int, height func(line int) float32) {
+	if f.orphans < 2 && f.widows < 2 || f.inHeader || f.inFooter {
+		return
+	}
+
+	// The lines from i that fit on the page.
+	fit := 0
+	y := f.y
+	for j := i; j < n && y+height(j) <= f.pageBreakTrigger; j++ {
+		y += height(j)
+		fit++
+	}
+	if fit == 0 || fit == n-i {
+		return
+	}
+
+	// Before the first line, the paragraph is moved to the next page if too
+	// few lines would be left on this one. Otherwise, it's broken before the
+	// widows.
+	brk := n-i == f.widows
+	if i == 0 {
+		lines := min(fit, n-f.widows)
+		brk = lines < f.orphans
+	}
+	if !brk || !f.acceptBreak() {
+		return
+	}
+
+	x := f.x
+	f.AddPageFormat(f.curOrientation, f.curPageSize)
+	f.x = x
+}
+
+// countLines returns the number of lines of each paragraph written by content,
+// which calls countLine for each line, without keeping its output. Pages are
+// added without their header and footer, so that they're only written once.
+func (f *Scribe) countLines(content func()) []int {
+	snap := f.snapshot()
+	counts := []int{0}
+	f.lineCounts = &counts
+	header, footer, footerLpi := f.headerFnc, f.footerFnc, f.footerFncLpi
+	f.headerFnc, f.footerFnc, f.footerFncLpi = nil, nil, nil
+	content()
+	f.headerFnc, f.footerFnc, f.footerFncLpi = header, footer, footerLpi
+	f.lineCounts = nil
+	err := f.err
+	f.restore(snap)
+	f.err = err
+
+	return counts
+}
+
+// countLine counts a line of a paragraph, when counting lines with
+// countLines(), and reports whether lines are being counted. end is true for
+// the last line of a paragraph.
+func (f *Scribe) countLine(end bool) bool {
+	if f.lineCounts == nil {
+		return false
+	}
+
+	counts := *f.lineCounts
+	counts[len(counts)-1]++
+	if end {
+		counts = append(counts, 0)
+	}
+	*f.lineCounts = counts
+
+	return true
+}
+
+// KeepTogether writes the output of content on a new page, or in the next
+// column, if it would otherwise be broken between pages, and it fits on the
+// new page. content is called again after the page break, with the document
+// restored to its state before the first call, so it should only write to
+// the document. See KeepWithNext().
+func (f *Scribe) KeepTogether(content func()) {
+	f.keep(0, content)
+}
+
+// KeepWithNext is like KeepTogether(), but also moves the output of content,
+// e.g. a heading, to a new page, or the next column, if less than height is
+// left below it on the page for what follows it, in the unit of measure
+// specified in New().
+func (f *Scribe) KeepWithNext(height float32, content func()) {
+	f.keep(height, content)
+}
+
+// keep writes the output of content, followed by next of space, on one page
+// or column if possible.
+func (f *Scribe) keep(next float32, content func()) {
+	if f.err != nil {
+		return
+	}
+	if f.inHeader || f.inFooter {
+		content()
+		return
+	}
+
+	snap := f.snapshot()
+	page, lMargin := f.page, f.lMargin
+	broken := func() bool {
+		return f.page != page || f.lMargin != lMargin ||
+			f.y+next > f.pageBreakTrigger
+	}
+
+	content()
+	if f.err != nil || !broken() {
+		return
+	}
+
+	// The content is written again after a break, or where it was if the
+	// break isn't accepted, or it doesn't fit on a page by itself.
+	f.restore(snap)
+	dx := f.x - f.lMargin
+	if f.acceptBreak() {
+		f.AddPageFormat(f.curOrientation, f.curPageSize)
+	}
+	f.x = f.lMargin + dx
+	if f.page == page && f.lMargin == lMargin {
+		content()
+		return
+	}
+
+	page, lMargin = f.page, f.lMargin
+	content()
+	if f.err != nil || !broken() {
+		return
+	}
+	f.restore(snap)
+	content()
+}
@@ -0,0 +1,260 @@
+package scribe
+
+import (
+	"cmp"
+	"strings"
+
+	"github.com/kofi-q/scribe-go/internal/bidi"
+	"github.com/kofi-q/scribe-go/internal/linebreak"
+)
+
+// LineBreaking configures total-fit line breaking, which chooses the line
+// breaks of each paragraph together, following Knuth and Plass, rather than
+// filling each line in turn. Lines are kept close to their natural width,
+// avoiding the very loose lines and rivers that first-fit breaking leaves in
+// justified text.
+//
+// Zero values select the defaults, noted below.
+//
+// See "Breaking Paragraphs into Lines" (Knuth & Plass, 1981).
+type LineBreaking struct {
+	// Stretch and Shrink are the amounts spaces can grow and shrink by, to
+	// justify lines, as fractions of the width of a space. Defaults: 1/2 and
+	// 1/3.
+	Stretch, Shrink float32
+
+	// Tolerance is the largest adjustment ratio allowed for a line: the
+	// stretch used by its spaces, as a multiple of their Stretch. Paragraphs
+	// that can't be broken within the tolerance are broken as well as
+	// possible without it. Default: 2.
+	Tolerance float32
+
+	// LinePenalty is added to the badness of each line, so that fewer lines
+	// are preferred. Default: 10.
+	LinePenalty float32
+
+	// FitnessPenalty is added to the demerits of a line that is much tighter
+	// or looser than the previous one. Default: 100.
+	FitnessPenalty float32
+
+	// HyphenPenalty is the penalty for breaking a line at a hyphenation
+	// point. See AddHyphenation(). Default: 50.
+	HyphenPenalty float32
+
+	// DoubleHyphenDemerits are added for consecutive hyphenated lines.
+	// Default: 10000.
+	DoubleHyphenDemerits float32
+}
+
+// SetLineBreaking enables total-fit line breaking, with the given parameters,
+// for MultiCell(), SplitLines() and ScratchPad.Text(). A nil value restores
+// the default first-fit line breaking.
+func (f *Scribe) SetLineBreaking(params *LineBreaking) {
+	if params == nil {
+		f.lineBreaking = nil
+		return
+	}
+
+	lb := *params
+	lb.Stretch = cmp.Or(lb.Stretch, 1.0/2)
+	lb.Shrink = cmp.Or(lb.Shrink, 1.0/3)
+	lb.Tolerance = cmp.Or(lb.Tolerance, 2)
+	lb.LinePenalty = cmp.Or(lb.LinePenalty, 10)
+	lb.FitnessPenalty = cmp.Or(lb.FitnessPenalty, 100)
+	lb.HyphenPenalty = cmp.Or(lb.HyphenPenalty, 50)
+	lb.DoubleHyphenDemerits = cmp.Or(lb.DoubleHyphenDemerits, 10000)
+	f.lineBreaking = &lb
+}
+
+// GetLineBreaking returns the total-fit line breaking parameters, or nil if
+// first-fit line breaking is used. See SetLineBreaking().
+func (f *Scribe) GetLineBreaking() *LineBreaking {
+	if f.lineBreaking == nil {
+		return nil
+	}
+
+	lb := *f.lineBreaking
+	return &lb
+}
+
+// breaker returns the parameters for the line breaker.
+func (lb *LineBreaking) breaker() *linebreak.Params {
+	return (*linebreak.Params)(lb)
+}
+
+// multiCellLines outputs the text of MultiCell(), with total-fit line
+// breaking. b and b2 are the borders of the first and following lines.
+func (f *Scribe) multiCellLines(
+	width, height, wmax float32,
+	srune []rune,
+	borderStr, b, b2, alignStr string,
+	fill bool,
+	baseDir bidi.Direction,
+) {
+	glyphWidth := func(prev, char rune) float32 {
+		charWidth := f.glyphWidth(f.currentFont, char)
+		if charWidth == 65535 { // Marker width 65535 used for zero width symbols
+			return 0
+		}
+		return charWidth + f.kern(f.currentFont, prev, char)
+	}
+
+	if f.ws > 0 {
+		f.ws = 0
+		f.out("0 Tw")
+	}
+
+	// Lines are shortened beside any exclusions on the page, keeping their
+	// offset from the left margin. See AddExclusion().
+	dx := f.x - f.lMargin
+
+	// Lines are reordered with the embedding levels of their paragraphs. See
+	// shape().
+	defer func(line *bidiLine) { f.bidiLine = line }(f.bidiLine)
+
+	paras := linebreak.Paragraphs(srune)
+	for ixPara, para := range paras {
+		paraDir := paragraphDirection(para, baseDir)
+		bidiPara := bidi.NewParagraph(para, paraDir)
+		var lines []linebreak.Line
+		shaped := len(f.exclusions) > 0
+		if !shaped {
+			lines = f.lineBreaking.breaker().Break(
+				para,
+				wmax,
+				0,
+				glyphWidth,
+				f.hyphenPoints,
+			)
+		}
+
+		// Shaped lines are broken again from the first line on each page or
+		// column, as the widths beside exclusions depend on where they are.
+		page, lMargin := 0, f.lMargin
+		moved := func() bool { return f.page != page || f.lMargin != lMargin }
+		for ixLine := 0; ixLine == 0 || ixLine < len(lines); ixLine++ {
+			if shaped && moved() {
+				start := 0
+				if ixLine > 0 {
+					start = lines[ixLine].Start
+				}
+				rest := f.lineBreaking.breaker().BreakShape(
+					para[start:],
+					f.lineWidths(dx, width, height),
+					0,
+					func(_ int, prev, char rune) float32 { return glyphWidth(prev, char) },
+					f.hyphenPoints,
+				)
+				for i := range rest {
+					rest[i].Start += start
+					rest[i].End += start
+				}
+				lines = append(lines[:ixLine], rest...)
+				page, lMargin = f.page, f.lMargin
+			}
+			if ixLine >= len(lines) {
+				break
+			}
+			line := lines[ixLine]
+
+			// The last line of each paragraph isn't justified.
+			align := alignStr
+			if align == "J" && ixLine == len(lines)-1 {
+				align = "L"
+				if paraDir == bidi.RightToLeft {
+					align = "R"
+				}
+			}
+
+			border := b
+			if ixPara == len(paras)-1 && ixLine == len(lines)-1 &&
+				strings.Contains(borderStr, "B") {
+				border += "B"
+			}
+
+			txt := string(para[line.Start:line.End])
+			if line.Hyphen {
+				txt += "-"
+			}
+
+			f.keepLines(ixLine, len(lines), func(int) float32 { return height })
+			if shaped && moved() {
+				ixLine--
+				continue
+			}
+
+			lineW := width
+			if shaped {
+				f.x, f.y, lineW = f.lineSpace(f.lMargin+dx, f.y, width, height)
+			}
+			f.bidiLine = &bidiLine{
+				para:  bidiPara,
+				start: line.Start,
+				end:   line.End,
+				text:  txt,
+			}
+			f.CellFormat(
+				lineW,
+				height,
+				txt,
+				border,
+				2,
+				align,
+				fill,
+				0,
+				"",
+				TextDirection(paraDir),
+			)
+
+			if len(borderStr) > 0 {
+				b = b2
+			}
+		}
+	}
+}
+
+// splitLinesOptimal splits text for SplitLines(), with total-fit line
+// breaking, measured as MultiCell() measures it.
+func (f *Scribe) splitLinesOptimal(text []rune, wmax float32) [][]byte {
+	glyphWidth := func(prev, char rune) float32 {
+		charWidth := f.glyphWidth(f.currentFont, char)
+		if charWidth == 65535 { // Marker width 65535 used for zero width symbols
+			return 0
+		}
+		return charWidth + f.kern(f.currentFont, prev, char)
+	}
+
+	lines := [][]byte{}
+	if len(text) == 0 {
+		return lines
+	}
+
+	for _, para := range linebreak.Paragraphs(text) {
+		spans := f.lineBreaking.breaker().Break(
+			para,
+			wmax,
+			0,
+			glyphWidth,
+			f.hyphenPoints,
+		)
+		for _, span := range spans {
+			line := string(para[span.Start:span.End])
+			if span.Hyphen {
+				line += "-"
+			}
+			lines = append(lines, []byte(line))
+		}
+	}
+
+	return lines
+}
+
+// lineEnd returns the end of a line of text, starting at start, that's broken
+// before text[brk]. Spaces at the end of the line are dropped.
+func lineEnd(text []rune, start, brk int) int {
+	for brk > start && linebreak.IsSpace(text[brk-1]) {
+		brk--
+	}
+
+	return brk
+}
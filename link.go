@@ -0,0 +1,195 @@
+package scribe
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// namedDest is a destination added with AddNamedDest().
+type namedDest struct {
+	name string
+	dest Dest
+}
+
+// AddNamedDest adds a destination named name at the current position, on the
+// current page. Names are stable anchors, which links in the document, outline
+// entries (see Outline.NamedDest) and other documents can go to. See
+// LinkNamedDest() and LinkRemote().
+func (f *Scribe) AddNamedDest(name string) {
+	f.AddNamedDestAt(name, Dest{Page: f.page, Y: f.y})
+}
+
+// AddNamedDestAt adds a destination named name, displaying the page as set in
+// dest, or the current page if dest.Page is 0, which is an error before the
+// first page is added. Close() fails if the page isn't added by then. See
+// AddNamedDest().
+func (f *Scribe) AddNamedDestAt(name string, dest Dest) {
+	if f.err != nil {
+		return
+	}
+	if slices.ContainsFunc(f.namedDests, func(d namedDest) bool {
+		return d.name == name
+	}) {
+		f.err = fmt.Errorf("named destination %q already added", name)
+		return
+	}
+
+	if dest.Page == 0 {
+		dest.Page = f.page
+	}
+	if dest.Page == 0 {
+		f.err = fmt.Errorf("named destination %q added before the first page", name)
+		return
+	}
+	f.namedDests = append(f.namedDests, namedDest{name, dest})
+}
+
+// linkAction is the action of a link, other than going to a destination in
+// the document added with AddLink(), or opening a URL.
+type linkAction struct {
+	kind string // GoTo, GoToR, Launch or Named
+
+	// The file of GoToR and Launch actions, the name of the destination of
+	// GoTo and GoToR actions, or that of Named actions, and the page of GoToR
+	// actions without a destination.
+	file, name string
+	page       int
+}
+
+// LinkNamedDest puts a link to the destination named name on a rectangular
+// area of the page, as with Link(). Close() fails if no destination named name
+// is added by then. See AddNamedDest().
+func (f *Scribe) LinkNamedDest(x, y, w, h float32, name string) {
+	f.newActionLink(x, y, w, h, linkAction{kind: "GoTo", name: name})
+}
+
+// LinkRemote puts a link to another PDF file on a rectangular area of the
+// page, as with Link(). The link goes to the destination named dest in the
+// file, if set, or otherwise to page, numbered from 1. file is relative to
+// the document's location, or absolute.
+func (f *Scribe) LinkRemote(x, y, w, h float32, file, dest string, page int) {
+	f.newActionLink(x, y, w, h, linkAction{
+		kind: "GoToR",
+		file: file,
+		name: dest,
+		page: max(page, 1),
+	})
+}
+
+// LinkLaunch puts a link that opens file, with the application the viewer's
+// system associates with it, on a rectangular area of the page, as with
+// Link(). Viewers commonly ask before opening files, or refuse to.
+func (f *Scribe) LinkLaunch(x, y, w, h float32, file string) {
+	f.newActionLink(x, y, w, h, linkAction{kind: "Launch", file: file})
+}
+
+// LinkNamedAction puts a link that performs a viewer action on a rectangular
+// area of the page, as with Link(). NextPage, PrevPage, FirstPage and LastPage
+// are supported by all viewers; others, such as Print, which opens the print
+// dialog, depend on the viewer.
+func (f *Scribe) LinkNamedAction(x, y, w, h float32, action string) {
+	if f.err != nil {
+		return
+	}
+	if action == "" || strings.ContainsFunc(action, isNameDelimiter) {
+		f.err = fmt.Errorf("invalid action name %q", action)
+		return
+	}
+
+	f.newActionLink(x, y, w, h, linkAction{kind: "Named", name: action})
+}
+
+// isNameDelimiter reports whether r can't be written as is in a PDF name.
+func isNameDelimiter(r rune) bool {
+	return r <= ' ' || r > '~' || strings.ContainsRune("()<>[]{}/%#", r)
+}
+
+// newActionLink adds a link performing an action on the current page.
+func (f *Scribe) newActionLink(x, y, w, h float32, action linkAction) {
+	f.newLink(x, y, w, h, 0, "")
+	links := f.pageLinks[f.page]
+	links[len(links)-1].action = &action
+}
+
+// checkNamedDests sets an error for named destinations on pages that weren't
+// added, and for links to names that weren't added, once all are known.
+func (f *Scribe) checkNamedDests() {
+	if f.err != nil {
+		return
+	}
+
+	for _, d := range f.namedDests {
+		if d.dest.Page > f.PageCount() {
+			f.err = fmt.Errorf(
+				"named destination %q is on page %d of %d",
+				d.name,
+				d.dest.Page,
+				f.PageCount(),
+			)
+			return
+		}
+	}
+
+	for page, links := range f.pageLinks {
+		for _, link := range links {
+			a := link.action
+			if a == nil || a.kind != "GoTo" || slices.ContainsFunc(f.namedDests, func(d namedDest) bool {
+				return d.name == a.name
+			}) {
+				continue
+			}
+
+			f.err = fmt.Errorf(
+				"link on page %d goes to named destination %q, which wasn't added",
+				page,
+				a.name,
+			)
+			return
+		}
+	}
+}
+
+// actionDict returns the PDF action dictionary of a link.
+func (f *Scribe) actionDict(a *linkAction) string {
+	switch a.kind {
+	case "GoTo":
+		return sprintf("<</S /GoTo /D %s>>", f.textstring(a.name))
+	case "GoToR":
+		dest := sprintf("[%d /Fit]", a.page-1)
+		if a.name != "" {
+			dest = f.textstring(a.name)
+		}
+		return sprintf(
+			"<</S /GoToR /F %s /D %s>>",
+			f.textstring(a.file),
+			dest,
+		)
+	case "Launch":
+		return sprintf("<</S /Launch /F %s>>", f.textstring(a.file))
+	}
+
+	return sprintf("<</S /Named /N /%s>>", a.name)
+}
+
+// putNamedDests writes the name tree of the named destinations, in the
+// catalog's name dictionary.
+func (f *Scribe) putNamedDests() {
+	if len(f.namedDests) == 0 {
+		return
+	}
+
+	dests := slices.Clone(f.namedDests)
+	slices.SortFunc(dests, func(a, b namedDest) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	var names strings.Builder
+	for _, d := range dests {
+		names.WriteString(f.textstring(d.name))
+		names.WriteByte(' ')
+		names.WriteString(f.destArray(d.dest))
+		names.WriteByte('\n')
+	}
+	f.outf("/Dests << /Names [\n%s] >>", names.String())
+}
@@ -0,0 +1,348 @@
+package scribe
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+
+	"github.com/kofi-q/scribe-go/internal/markup"
+)
+
+// MarkupStyle configures the rendering of HTML and Markdown with a Markup.
+//
+// Zero values select the defaults, noted below.
+type MarkupStyle struct {
+	// Fonts maps the styles of text to the fonts used for them: FontStyleNone
+	// for regular text, FontStyleB for bold text and headings, FontStyleI for
+	// italic text, and FontStyleB|FontStyleI for bold italic text. Bold
+	// italic text falls back to the bold font, and other missing styles to
+	// the regular font. Default: the current font, for all styles.
+	Fonts map[FontStyle]FontId
+
+	// CodeFont is the font of code spans. Default: the regular font.
+	CodeFont *FontId
+
+	// Size is the size of regular text, in points. Default: the current font
+	// size.
+	Size float32
+
+	// LineHeight is the height of lines, as a multiple of the size of their
+	// text. Default: 1.25.
+	LineHeight float32
+
+	// HeadingSizes are the sizes of headings, from h1 to h6, as multiples of
+	// Size. Default: 2, 1.5, 1.25, 1.1, 1 and 0.9.
+	HeadingSizes [6]float32
+
+	// BlockSpacing is the space between paragraphs, headings and lists, in the
+	// unit of measure specified in New(). Default: half the line height of
+	// regular text.
+	BlockSpacing float32
+
+	// ListIndent is the indent of each level of list items, in the unit of
+	// measure specified in New(). Default: twice the size of regular text.
+	ListIndent float32
+
+	// LinkColor is the color of links, which are underlined. Default: blue
+	// (0, 0, 238).
+	LinkColor *RGBType
+}
+
+var markupHeadingSizes = [6]float32{2, 1.5, 1.25, 1.1, 1, 0.9}
+
+// Markup renders formatted text, written in small subsets of HTML or Markdown,
+// into the flow of text with Write() and WriteLinkString(). Paragraphs,
+// headings, bullet and numbered lists, bold and italic text, code spans, links
+// and line breaks are supported.
+//
+// Rendering starts at the current position, on a new line if the text starts
+// with a block, e.g. a paragraph, and ends at the left margin below the last
+// line of text. The font, text color and margins are restored afterwards.
+type Markup struct {
+	f     *Scribe
+	style MarkupStyle
+}
+
+// markupState is the state of the text being rendered by a Markup.
+type markupState struct {
+	bold, italic, code int
+	links              []string
+	heading            int
+
+	// Lists being rendered, with the number of their next item, or -1 for
+	// bullet lists.
+	lists []int
+
+	// Spacing is added before the next block or text, after a block has
+	// ended.
+	spacing bool
+	started bool
+
+	// The regular font and the left margin, before rendering.
+	font    FontId
+	lMargin float32
+}
+
+// NewMarkup returns a renderer for HTML and Markdown, with the given style.
+// A nil style selects the defaults. See MarkupStyle.
+func (f *Scribe) NewMarkup(style *MarkupStyle) *Markup {
+	m := &Markup{f: f}
+	if style != nil {
+		m.style = *style
+	}
+
+	return m
+}
+
+// WriteHTML renders an HTML fragment. Supported elements are p and div,
+// h1 to h6, ul, ol and li, b and strong, i and em, code, a (with an href
+// attribute) and br. Other elements are ignored, but their text is rendered.
+// Whitespace is collapsed, and character references are decoded.
+func (m *Markup) WriteHTML(html string) {
+	m.write(markup.ParseHTML(html))
+}
+
+// WriteMarkdown renders Markdown, written in a subset of CommonMark:
+// paragraphs, ATX headings (#), bullet and numbered lists, emphasis with * or
+// _, code spans, inline links and autolinks, backslash escapes, and hard line
+// breaks. Other syntax is rendered as text.
+func (m *Markup) WriteMarkdown(md string) {
+	m.write(markup.ParseMarkdown(md))
+}
+
+// write renders markup tokens.
+func (m *Markup) write(tokens []markup.Token) {
+	f := m.f
+	if f.err != nil {
+		return
+	}
+
+	fontPrev, stylePrev, sizePrev := f.currentFont, f.fontStyle, f.fontSizePt
+	r, g, b := f.GetTextColor()
+	state := markupState{
+		font:    m.style.Fonts[FontStyleNone],
+		lMargin: f.lMargin,
+	}
+	if _, ok := m.style.Fonts[FontStyleNone]; !ok {
+		state.font = f.currentFont
+	}
+	size := cmp.Or(m.style.Size, f.fontSizePt)
+
+	for _, token := range tokens {
+		switch token.Kind {
+		case markup.Text:
+			m.setFont(&state, size)
+			m.text(&state, token.Text)
+
+		case markup.Break:
+			m.setFont(&state, size)
+			f.Ln(m.lineHeight(&state, size))
+
+		case markup.Open:
+			m.open(&state, token, size)
+
+		case markup.Close:
+			m.close(&state, token.Tag, size)
+		}
+
+		if f.err != nil {
+			return
+		}
+	}
+
+	if f.x != f.lMargin {
+		f.Ln(m.lineHeight(&state, size))
+	}
+
+	f.lMargin = state.lMargin
+	f.x = f.lMargin
+	f.SetFont(fontPrev, stylePrev, sizePrev)
+	f.SetTextColor(r, g, b)
+}
+
+// open starts an element.
+func (m *Markup) open(state *markupState, token markup.Token, size float32) {
+	f := m.f
+	tag := token.Tag
+	if tag.Block() {
+		m.setFont(state, size)
+		m.newLine(state, size)
+
+		// Items, and lists nested in them, aren't spaced.
+		nested := (tag == markup.UL || tag == markup.OL) && len(state.lists) > 0
+		if tag != markup.LI && !nested {
+			state.spacing = true
+			m.space(state, size)
+		}
+	}
+
+	switch tag {
+	case markup.B:
+		state.bold++
+	case markup.I:
+		state.italic++
+	case markup.Code:
+		state.code++
+	case markup.A:
+		state.links = append(state.links, token.Text)
+
+	case markup.H1, markup.H2, markup.H3, markup.H4, markup.H5, markup.H6:
+		state.heading = tag.Heading()
+
+	case markup.UL, markup.OL:
+		start := -1
+		if tag == markup.OL {
+			start = token.Start
+		}
+		state.lists = append(state.lists, start)
+		f.lMargin += m.listIndent(size)
+		f.x = f.lMargin
+
+	case markup.LI:
+		marker := "•"
+		if n := len(state.lists); n > 0 && state.lists[n-1] >= 0 {
+			marker = strconv.Itoa(state.lists[n-1]) + "."
+			state.lists[n-1]++
+		}
+
+		indent := m.listIndent(size)
+		m.setFont(state, size)
+		f.x = f.lMargin - indent
+		f.CellFormat(indent, m.lineHeight(state, size), marker, "", 0, "R", false, 0, "")
+		f.x = f.lMargin
+		state.started = true
+	}
+}
+
+// close ends an element.
+func (m *Markup) close(state *markupState, tag markup.Tag, size float32) {
+	f := m.f
+	if tag.Block() {
+		m.setFont(state, size)
+		m.newLine(state, size)
+	}
+
+	switch tag {
+	case markup.B:
+		state.bold--
+	case markup.I:
+		state.italic--
+	case markup.Code:
+		state.code--
+	case markup.A:
+		state.links = state.links[:len(state.links)-1]
+
+	case markup.UL, markup.OL:
+		state.lists = state.lists[:len(state.lists)-1]
+		f.lMargin -= m.listIndent(size)
+		f.x = f.lMargin
+
+	case markup.H1, markup.H2, markup.H3, markup.H4, markup.H5, markup.H6:
+		state.heading = 0
+	}
+
+	if tag.Block() && tag != markup.LI && (len(state.lists) == 0 || tag == markup.P) {
+		state.spacing = true
+	}
+}
+
+// text writes text in the current font, as a link if it's in one.
+func (m *Markup) text(state *markupState, text string) {
+	f := m.f
+	if f.x == f.lMargin {
+		text = strings.TrimLeft(text, " ")
+	}
+	if text == "" {
+		return
+	}
+
+	m.space(state, f.fontSizePt)
+	state.started = true
+
+	lh := m.lineHeight(state, f.fontSizePt)
+	if len(state.links) == 0 {
+		f.Write(lh, text)
+		return
+	}
+
+	clr := cmp.Or(m.style.LinkColor, &RGBType{0, 0, 238})
+	r, g, b := f.GetTextColor()
+	f.SetTextColor(clr.R, clr.G, clr.B)
+	f.WriteLinkString(lh, text, state.links[len(state.links)-1])
+	f.SetTextColor(r, g, b)
+}
+
+// setFont selects the font for the current text style.
+func (m *Markup) setFont(state *markupState, size float32) {
+	style := FontStyleNone
+	if state.bold > 0 || state.heading > 0 {
+		style |= FontStyleB
+	}
+	if state.italic > 0 {
+		style |= FontStyleI
+	}
+
+	font, ok := m.style.Fonts[style]
+	if !ok && style == FontStyleB|FontStyleI {
+		font, ok = m.style.Fonts[FontStyleB]
+	}
+	if !ok {
+		font = state.font
+	}
+	if state.code > 0 {
+		font = state.font
+		if m.style.CodeFont != nil {
+			font = *m.style.CodeFont
+		}
+	}
+
+	if len(state.links) > 0 {
+		style |= FontStyleU
+	}
+
+	if state.heading > 0 {
+		scale := m.style.HeadingSizes[state.heading-1]
+		size *= cmp.Or(scale, markupHeadingSizes[state.heading-1])
+	}
+
+	f := m.f
+	if font != f.currentFont || style != f.fontStyle || size != f.fontSizePt {
+		f.SetFont(font, style, size)
+	}
+}
+
+// newLine moves to the start of the next line, unless the current position
+// is already at the start of a line.
+func (m *Markup) newLine(state *markupState, size float32) {
+	if m.f.x != m.f.lMargin {
+		m.f.Ln(m.lineHeight(state, size))
+	}
+}
+
+// space adds the spacing between blocks, if a block has ended since the last
+// text was written.
+func (m *Markup) space(state *markupState, size float32) {
+	if !state.spacing {
+		return
+	}
+
+	state.spacing = false
+	if state.started {
+		m.f.y += cmp.Or(m.style.BlockSpacing, m.lineHeight(nil, size)/2)
+	}
+}
+
+// lineHeight returns the height of lines of text of the given size, in points,
+// or of headings if one is being rendered.
+func (m *Markup) lineHeight(state *markupState, size float32) float32 {
+	if state != nil && state.heading > 0 {
+		size = m.f.fontSizePt
+	}
+
+	return cmp.Or(m.style.LineHeight, 1.25) * size / m.f.k
+}
+
+// listIndent returns the indent of each level of list items.
+func (m *Markup) listIndent(size float32) float32 {
+	return cmp.Or(m.style.ListIndent, 2*size/m.f.k)
+}
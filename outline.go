@@ -0,0 +1,178 @@
+package scribe
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// DestMode selects how the page of a destination is displayed. See Dest.
+type DestMode int
+
+const (
+	// DestXYZ displays the page with the position Dest.X, Dest.Y at the top
+	// left of the window, magnified by Dest.Zoom.
+	DestXYZ DestMode = iota
+
+	// DestFit displays the whole page in the window.
+	DestFit
+
+	// DestFitH displays the page with its width fitted to the window, and
+	// Dest.Y at the top.
+	DestFitH
+
+	// DestFitR displays the rectangle Dest.X, Dest.Y, Dest.W, Dest.H fitted to
+	// the window.
+	DestFitR
+)
+
+// Dest is a destination in the document, for outline entries. See AddOutline().
+//
+// Positions are in the unit of measure specified in New(), from the top left
+// of the page, as elsewhere.
+type Dest struct {
+	// Page is the page number. Default: the current page.
+	Page int
+
+	// Mode is how the page is displayed. Default: DestXYZ.
+	Mode DestMode
+
+	X, Y float32
+
+	// W and H are the size of the rectangle with DestFitR.
+	W, H float32
+
+	// Zoom is the magnification with DestXYZ, where 1 is 100%. Default: the
+	// current magnification.
+	Zoom float32
+}
+
+// Outline is an entry of the document outline, shown in a sidebar by PDF
+// viewers. See AddOutline().
+type Outline struct {
+	Title string
+
+	// Level is the level of the entry in the outline; 0 is the top level, 1
+	// is just below, and so on.
+	Level int
+
+	Bold, Italic bool
+
+	// Color is the color of the title. nil selects the viewer's default.
+	Color *RGBType
+
+	// Open shows the entries below this one, rather than collapsing them.
+	Open bool
+
+	// Dest is the destination of the entry. nil selects the current position,
+	// on the current page.
+	Dest *Dest
+
+	// URI, if set, is opened by the entry, instead of going to Dest.
+	URI string
+
+	// NamedDest, if set, is the name of the destination of the entry, instead
+	// of Dest, which must be added with AddNamedDest() before the document is
+	// closed.
+	NamedDest string
+}
+
+// AddOutline adds an entry to the outline of the document, shown in a
+// sidebar by PDF viewers, after the entries already added. Entries are nested
+// by level, as with Bookmark(), which adds entries with the default style and
+// destination.
+func (f *Scribe) AddOutline(o Outline) {
+	dest := Dest{Page: f.page, Y: f.y}
+	if o.Dest != nil {
+		dest = *o.Dest
+		dest.Page = cmp.Or(dest.Page, f.page)
+	}
+
+	var flags int
+	if o.Italic {
+		flags |= 1
+	}
+	if o.Bold {
+		flags |= 2
+	}
+
+	// [TODO] Re-add support for built-in ASCII fonts
+	f.outlines = append(
+		f.outlines,
+		outlineType{
+			text:      f.utf8toutf16(o.Title),
+			title:     o.Title,
+			level:     o.Level,
+			flags:     flags,
+			color:     o.Color,
+			open:      o.Open,
+			dest:      dest,
+			uri:       o.URI,
+			namedDest: o.NamedDest,
+			prev:      -1,
+			last:      -1,
+			next:      -1,
+			first:     -1,
+		},
+	)
+}
+
+// checkOutlines sets an error for outline entries that go to named
+// destinations that weren't added, once all are known.
+func (f *Scribe) checkOutlines() {
+	for _, o := range f.outlines {
+		if o.namedDest == "" || slices.ContainsFunc(f.namedDests, func(d namedDest) bool {
+			return d.name == o.namedDest
+		}) {
+			continue
+		}
+
+		f.err = fmt.Errorf(
+			"outline entry %q goes to named destination %q, which wasn't added",
+			o.title,
+			o.namedDest,
+		)
+		return
+	}
+}
+
+// pageHeightPt returns the height of a page, in points.
+func (f *Scribe) pageHeightPt(page int) float32 {
+	if sz, ok := f.pageSizes[page]; ok {
+		return sz.Ht
+	}
+	if f.defOrientation == "P" {
+		return f.defPageSize.Ht * f.k
+	}
+
+	return f.defPageSize.Wd * f.k
+}
+
+// destArray returns the PDF destination array of d.
+func (f *Scribe) destArray(d Dest) string {
+	h := f.pageHeightPt(d.Page)
+	page := 1 + 2*d.Page
+	x, top := d.X*f.k, h-d.Y*f.k
+
+	switch d.Mode {
+	case DestFit:
+		return sprintf("[%d 0 R /Fit]", page)
+	case DestFitH:
+		return sprintf("[%d 0 R /FitH %g]", page, top)
+	case DestFitR:
+		return sprintf(
+			"[%d 0 R /FitR %g %g %g %g]",
+			page,
+			x,
+			h-(d.Y+d.H)*f.k,
+			x+d.W*f.k,
+			top,
+		)
+	}
+
+	zoom := "null"
+	if d.Zoom > 0 {
+		zoom = sprintf("%g", d.Zoom)
+	}
+	return sprintf("[%d 0 R /XYZ %g %g %s]", page, x, top, zoom)
+}
@@ -0,0 +1,139 @@
+package scribe
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kofi-q/scribe-go/internal/numbering"
+)
+
+// PageLabelStyle is the numbering style of page labels. See SetPageLabel().
+type PageLabelStyle int
+
+const (
+	PageLabelDecimal      PageLabelStyle = iota // 1, 2, 3
+	PageLabelRomanUpper                         // I, II, III
+	PageLabelRomanLower                         // i, ii, iii
+	PageLabelLettersUpper                       // A to Z, then AA to ZZ, ...
+	PageLabelLettersLower                       // a to z, then aa to zz, ...
+	PageLabelNone                               // the prefix alone
+)
+
+// pageLabel is a range of pages labelled with SetPageLabel().
+type pageLabel struct {
+	from   int
+	style  PageLabelStyle
+	prefix string
+	start  int
+}
+
+// SetPageLabel sets the labels shown for pages by PDF viewers, from page
+// fromPage up to the next page set, or the end of the document. Labels are
+// prefix followed by a number in style, counting from start (1 if 0) at
+// fromPage. Pages before the first page set are labelled with their page
+// numbers.
+//
+// For example, SetPageLabel(1, PageLabelRomanLower, "", 1) labels front matter
+// i, ii, iii, and SetPageLabel(40, PageLabelDecimal, "A-", 1) labels an
+// appendix A-1, A-2. See PageLabel() and AliasPageLabel() for writing labels
+// in the document.
+func (f *Scribe) SetPageLabel(
+	fromPage int,
+	style PageLabelStyle,
+	prefix string,
+	start int,
+) {
+	label := pageLabel{max(fromPage, 1), style, prefix, max(start, 1)}
+	i, found := slices.BinarySearchFunc(f.pageLabels, label.from, comparePageLabel)
+	if found {
+		f.pageLabels[i] = label
+	} else {
+		f.pageLabels = slices.Insert(f.pageLabels, i, label)
+	}
+}
+
+// comparePageLabel compares the first page of a range of labels to page.
+func comparePageLabel(label pageLabel, page int) int {
+	return label.from - page
+}
+
+// PageLabel returns the label of a page, as set with SetPageLabel(), e.g.
+// for footers. Labels set later for the page, before the document is closed,
+// aren't known yet; see AliasPageLabel().
+func (f *Scribe) PageLabel(page int) string {
+	i, found := slices.BinarySearchFunc(f.pageLabels, page, comparePageLabel)
+	if !found {
+		if i == 0 {
+			return strconv.Itoa(page)
+		}
+		i--
+	}
+
+	label := f.pageLabels[i]
+	n := label.start + page - label.from
+	switch label.style {
+	case PageLabelRomanUpper:
+		return label.prefix + numbering.Roman(n)
+	case PageLabelRomanLower:
+		return label.prefix + strings.ToLower(numbering.Roman(n))
+	case PageLabelLettersUpper:
+		return label.prefix + numbering.Letters(n)
+	case PageLabelLettersLower:
+		return label.prefix + strings.ToLower(numbering.Letters(n))
+	case PageLabelNone:
+		return label.prefix
+	}
+
+	return label.prefix + strconv.Itoa(n)
+}
+
+// AliasPageLabel defines an alias for the label of the page it's written on,
+// as set with SetPageLabel(). It will be substituted as the document is
+// closed, as with AliasNbPages(), which is replaced with the label of the last
+// page, so footers can write the labels of pages numbered later. An empty
+// string is replaced with the string "{pl}".
+func (f *Scribe) AliasPageLabel(aliasStr string) {
+	if aliasStr == "" {
+		aliasStr = "{pl}"
+	}
+	f.aliasLabelStr = aliasStr
+}
+
+// putPageLabels writes the number tree of the page labels, in the catalog.
+func (f *Scribe) putPageLabels() {
+	if len(f.pageLabels) == 0 {
+		return
+	}
+
+	var nums strings.Builder
+	if f.pageLabels[0].from > 1 {
+		nums.WriteString("0 <</S /D>>\n")
+	}
+	for _, label := range f.pageLabels {
+		var entries []string
+		if s := pageLabelStyles[label.style]; s != "" {
+			entries = append(entries, "/S /"+s)
+		}
+		if label.prefix != "" {
+			entries = append(entries, "/P "+f.textstring(f.utf8toutf16(label.prefix)))
+		}
+		if label.start > 1 {
+			entries = append(entries, "/St "+strconv.Itoa(label.start))
+		}
+		nums.WriteString(strconv.Itoa(label.from - 1))
+		nums.WriteString(" <<")
+		nums.WriteString(strings.Join(entries, " "))
+		nums.WriteString(">>\n")
+	}
+	f.outf("/PageLabels << /Nums [\n%s] >>", nums.String())
+}
+
+// pageLabelStyles are the PDF names of page label styles.
+var pageLabelStyles = map[PageLabelStyle]string{
+	PageLabelDecimal:      "D",
+	PageLabelRomanUpper:   "R",
+	PageLabelRomanLower:   "r",
+	PageLabelLettersUpper: "A",
+	PageLabelLettersLower: "a",
+}
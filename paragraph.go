@@ -0,0 +1,520 @@
+package scribe
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kofi-q/scribe-go/internal/bidi"
+	"github.com/kofi-q/scribe-go/internal/linebreak"
+)
+
+// Span is a run of text in a Paragraph, with its own font and styling.
+// Spans are usually created with Paragraph.Span(), which fills in the
+// paragraph's font, and then customized.
+type Span struct {
+	Text string
+
+	// Font, style and size, in points, of the text. A Size of zero selects
+	// the paragraph's font size.
+	Font  FontId
+	Style FontStyle
+	Size  float32
+
+	// Color of the text. nil selects the current text color. See
+	// SetTextColor().
+	Color *RGBType
+
+	// Link is an internal link, as returned by AddLink(), and LinkStr an
+	// external link, for the text. A non-zero Link takes precedence.
+	Link    int
+	LinkStr string
+
+	// Rise shifts the text up from the baseline, or down if negative, in the
+	// unit of measure specified in New(), e.g. for superscripts and
+	// subscripts.
+	Rise float32
+}
+
+// Paragraph lays out text in several fonts, sizes and colors, with links,
+// as a single block of wrapped text. Lines are broken as in MultiCell(),
+// including at \n characters, and across pages when they reach the bottom
+// margin. See SetLineBreaking() and AddHyphenation().
+//
+// Create paragraphs with NewParagraph(), add text with Text() and Add(), and
+// output them with Write():
+//
+//	para := pdf.NewParagraph().Align("J").Indent(5)
+//	para.Text("Plain, ")
+//	bold := para.Span("bold")
+//	bold.Style = scribe.FontStyleB
+//	para.Add(bold)
+//	para.Write(0)
+type Paragraph struct {
+	f     *Scribe
+	spans []Span
+
+	font  FontId
+	style FontStyle
+	size  float32
+
+	align      string
+	lineHeight float32
+	indent     float32
+}
+
+// paragraphLine is a line of a laid out paragraph, as rune indices into its
+// text.
+type paragraphLine struct {
+	linebreak.Line
+
+	height float32
+
+	// The line is the first of a paragraph, and is indented, or the last,
+	// and isn't justified.
+	first, last bool
+}
+
+// NewParagraph starts a paragraph in the current font, style and size, with
+// left aligned text. Lines are 1.2 times the size of the largest font on each
+// line, unless set with LineHeight().
+func (f *Scribe) NewParagraph() *Paragraph {
+	return &Paragraph{
+		f:     f,
+		font:  f.currentFont,
+		style: f.fontStyle,
+		size:  f.fontSizePt,
+		align: "L",
+	}
+}
+
+// Span returns a span of text in the paragraph's font, style and size, which
+// can be customized before adding it with Add().
+func (p *Paragraph) Span(text string) Span {
+	return Span{Text: text, Font: p.font, Style: p.style, Size: p.size}
+}
+
+// Text adds text in the paragraph's font, style and size.
+func (p *Paragraph) Text(text string) *Paragraph {
+	return p.Add(p.Span(text))
+}
+
+// Add adds spans of text to the paragraph.
+func (p *Paragraph) Add(spans ...Span) *Paragraph {
+	for _, span := range spans {
+		if span.Size == 0 {
+			span.Size = p.size
+		}
+		p.spans = append(p.spans, span)
+	}
+
+	return p
+}
+
+// Align sets the alignment of the paragraph's lines: "L", "C" or "R" (left,
+// center or right) or "J" (justified), in which case the last line, and any
+// line ending with \n, is aligned to the left.
+func (p *Paragraph) Align(alignStr string) *Paragraph {
+	p.align = alignStr
+	return p
+}
+
+// LineHeight sets the height of the paragraph's lines, in the unit of measure
+// specified in New(). A value of zero sizes lines to fit their text.
+func (p *Paragraph) LineHeight(height float32) *Paragraph {
+	p.lineHeight = height
+	return p
+}
+
+// Indent sets the indent of the first line of the paragraph, and of the first
+// line after each \n, in the unit of measure specified in New().
+func (p *Paragraph) Indent(indent float32) *Paragraph {
+	p.indent = indent
+	return p
+}
+
+// Height returns the height of the paragraph when written with the given
+// width, as with Write().
+func (p *Paragraph) Height(width float32) (height float32) {
+	if width == 0 {
+		width = p.f.w - p.f.rMargin - p.f.x
+	}
+
+	_, _, lines := p.layout(width)
+	for _, line := range lines {
+		height += line.height
+	}
+
+	return height
+}
+
+// Write outputs the paragraph at the current position, in a block of the
+// given width. A width of zero extends the block to the right margin. Text is
+// inset from the sides of the block by the cell margin. See SetCellMargin().
+//
+// If automatic page breaking is enabled, lines that would extend below the
+// bottom margin are moved to a new page, as with CellFormat(). The current
+// position after the call is the beginning of the line below the paragraph.
+func (p *Paragraph) Write(width float32) {
+	f := p.f
+	if f.err != nil {
+		return
+	}
+
+	if width == 0 {
+		width = f.w - f.rMargin - f.x
+	}
+
+	text, spanIx, lines := p.layout(width)
+	bidiText := &bidiText{text: text, dir: f.textDirection(nil)}
+
+	// The offset from the left margin is kept when lines move to a new page
+	// or column.
+	dx := f.x - f.lMargin
+
+	// Paragraphs, split at line feeds, aren't broken between pages where it'd
+	// leave fewer lines on either side than set with SetOrphansWidows().
+	start, end := 0, 0
+	height := func(i int) float32 { return lines[start+i].height }
+	for ix, line := range lines {
+		if line.first {
+			start, end = ix, ix
+			for !lines[end].last {
+				end++
+			}
+		}
+		f.keepLines(ix-start, end-start+1, height)
+
+		if f.y+line.height > f.pageBreakTrigger && !f.inHeader && !f.inFooter &&
+			f.acceptBreak() {
+			f.AddPageFormat(f.curOrientation, f.curPageSize)
+			if f.err != nil {
+				return
+			}
+		}
+
+		f.x = f.lMargin + dx
+		p.writeLine(text, spanIx, bidiText, line, width)
+		f.y += line.height
+	}
+
+	f.x = f.lMargin
+}
+
+// layout breaks the paragraph into lines of the given width. It returns the
+// text of all the spans, with the index of the span of each character, and
+// the lines.
+func (p *Paragraph) layout(
+	width float32,
+) (text []rune, spanIx []int, lines []paragraphLine) {
+	f := p.f
+	for i, span := range p.spans {
+		for _, char := range strings.ReplaceAll(span.Text, "\r", "") {
+			text = append(text, char)
+			spanIx = append(spanIx, i)
+		}
+	}
+
+	// Widths are measured in the unit of measure.
+	charWidth := func(i int, prev, char rune) float32 {
+		span := &p.spans[spanIx[i]]
+		if i > 0 && spanIx[i-1] != spanIx[i] {
+			prev = -1
+		}
+
+		w := f.glyphWidth(span.Font, char)
+		if w == 65535 { // Marker width 65535 used for zero width symbols
+			return 0
+		}
+		w += f.kern(span.Font, prev, char)
+		return w * span.Size / 1000 / f.k
+	}
+
+	wmax := width - 2*f.cMargin
+	start := 0
+	for _, para := range linebreak.Paragraphs(text) {
+		var spans []linebreak.Line
+		paraWidth := func(i int, prev, char rune) float32 {
+			return charWidth(start+i, prev, char)
+		}
+		if f.lineBreaking != nil {
+			spans = f.lineBreaking.breaker().BreakFunc(
+				para,
+				wmax,
+				p.indent,
+				paraWidth,
+				f.hyphenPoints,
+			)
+		} else {
+			spans = p.breakLines(para, wmax, paraWidth)
+		}
+
+		for i, span := range spans {
+			span.Start += start
+			span.End += start
+			lines = append(lines, paragraphLine{
+				Line:   span,
+				height: p.lineHeightOf(spanIx, span),
+				first:  i == 0,
+				last:   i == len(spans)-1,
+			})
+		}
+
+		start += len(para) + 1
+	}
+
+	return text, spanIx, lines
+}
+
+// breakLines breaks text into lines no wider than wmax, filling each line in
+// turn, as in MultiCell().
+func (p *Paragraph) breakLines(
+	text []rune,
+	wmax float32,
+	width func(i int, prev, char rune) float32,
+) (lines []linebreak.Line) {
+	ops := linebreak.Opportunities(text)
+
+	start := 0
+	brk := -1
+	indent := p.indent
+	lineWidth := indent
+	for i := 0; i < len(text); i++ {
+		char := text[i]
+		if i > start && ops[i] != linebreak.Prohibited {
+			brk = i
+		}
+
+		prev := rune(-1)
+		if i > start {
+			prev = text[i-1]
+		}
+
+		// Spaces at the end of a line are dropped, rather than breaking it.
+		lineWidth += width(i, prev, char)
+		if lineWidth <= wmax || linebreak.IsSpace(char) {
+			continue
+		}
+
+		if end, next, ok := p.f.hyphenBreakFunc(
+			text,
+			start,
+			i,
+			indent,
+			wmax,
+			width,
+		); ok {
+			lines = append(lines, linebreak.Line{Start: start, End: end, Hyphen: true})
+			start = next
+		} else if brk > start {
+			lines = append(lines, linebreak.Line{
+				Start: start,
+				End:   lineEnd(text, start, brk),
+			})
+			start = brk
+		} else {
+			// Words too long for a line are broken anywhere, with at least
+			// one character on each line.
+			end := max(i, start+1)
+			lines = append(lines, linebreak.Line{Start: start, End: end})
+			start = end
+		}
+
+		i = start - 1
+		brk = -1
+		indent = 0
+		lineWidth = 0
+	}
+
+	if start < len(text) || len(lines) == 0 {
+		lines = append(lines, linebreak.Line{
+			Start: start,
+			End:   lineEnd(text, start, len(text)),
+		})
+	}
+
+	return lines
+}
+
+// lineHeightOf returns the height of a line: the paragraph's line height, if
+// set, or 1.2 times the size of the largest font on the line.
+func (p *Paragraph) lineHeightOf(spanIx []int, line linebreak.Line) float32 {
+	if p.lineHeight > 0 {
+		return p.lineHeight
+	}
+
+	return 1.2 * p.lineFontSize(spanIx, line) / p.f.k
+}
+
+// lineFontSize returns the size, in points, of the largest font on a line, or
+// of the paragraph's font for empty lines.
+func (p *Paragraph) lineFontSize(spanIx []int, line linebreak.Line) float32 {
+	size := float32(0)
+	for _, ix := range spanIx[line.Start:line.End] {
+		size = max(size, p.spans[ix].Size)
+	}
+	if size == 0 {
+		return p.size
+	}
+
+	return size
+}
+
+// writeLine outputs a line of the paragraph at the current position, in a
+// block of the given width. The line is reordered for display with the
+// embedding levels of its paragraph, resolved by bidiText.
+func (p *Paragraph) writeLine(
+	text []rune,
+	spanIx []int,
+	bidiText *bidiText,
+	line paragraphLine,
+	width float32,
+) {
+	f := p.f
+
+	// Runs of characters from the same span and embedding level, in display
+	// order, with a hyphen added to the last character if the line is
+	// hyphenated.
+	type run struct {
+		span   *Span
+		text   shapedText
+		str    string
+		width  float32
+		spaces int
+	}
+
+	fontPrev, stylePrev, sizePrev := f.currentFont, f.fontStyle, f.fontSizePt
+	defer func(bidiLine *bidiLine) {
+		f.currentFont, f.fontStyle = fontPrev, stylePrev
+		f.fontSizePt, f.fontSize = sizePrev, sizePrev/f.k
+		f.bidiLine = bidiLine
+	}(f.bidiLine)
+
+	// Indices into the paragraph are offset from those into text.
+	paraLine := bidiText.line(line.Start, line.End, "")
+	offset := line.Start - paraLine.start
+
+	var runs []run
+	var lineWidth float32
+	var spaces int
+	var pieces [][2]int
+	for _, level := range paraLine.para.Line(paraLine.start, paraLine.end) {
+		pieces = pieces[:0]
+		for start := level.Start + offset; start < level.End+offset; {
+			end := start + 1
+			for end < level.End+offset && spanIx[end] == spanIx[start] {
+				end++
+			}
+			pieces = append(pieces, [2]int{start, end})
+			start = end
+		}
+		if level.Rtl() {
+			slices.Reverse(pieces)
+		}
+
+		for _, piece := range pieces {
+			start, end := piece[0], piece[1]
+			str := string(text[start:end])
+			if end == line.End && line.Hyphen {
+				str += "-"
+			}
+
+			span := &p.spans[spanIx[start]]
+			f.currentFont, f.fontStyle = span.Font, span.Style
+			f.fontSizePt, f.fontSize = span.Size, span.Size/f.k
+
+			// The piece is part of a run, at a single level.
+			f.bidiLine = &bidiLine{
+				text: str,
+				runs: []bidi.Run{{
+					End:   utf8.RuneCountInString(str),
+					Level: level.Level,
+				}},
+			}
+			r := run{
+				span:   span,
+				text:   f.shape(str, paraLine.para.Direction(), 0),
+				str:    str,
+				spaces: blankCount(str),
+			}
+			r.width = r.text.width() * f.fontSize / 1000
+			runs = append(runs, r)
+
+			lineWidth += r.width
+			spaces += r.spaces
+		}
+	}
+
+	indent := float32(0)
+	if line.first {
+		indent = p.indent
+	}
+	slack := width - 2*f.cMargin - indent - lineWidth
+
+	var dx, spacing float32
+	switch {
+	case strings.Contains(p.align, "R"):
+		dx = slack
+	case strings.Contains(p.align, "C"):
+		dx = slack / 2
+	case strings.Contains(p.align, "J") && !line.last && spaces > 0:
+		spacing = slack / float32(spaces)
+	}
+
+	fontSize := p.lineFontSize(spanIx, line.Line) / f.k
+	baseline := f.y + .5*line.height + .3*fontSize
+	x := f.x + f.cMargin + indent + dx
+	for _, r := range runs {
+		span := r.span
+		f.currentFont, f.fontStyle = span.Font, span.Style
+		f.fontSizePt, f.fontSize = span.Size, span.Size/f.k
+
+		var s strings.Builder
+		s.WriteString("q ")
+		switch {
+		case span.Color != nil:
+			s.WriteString(f.rgbColorValue(
+				span.Color.R,
+				span.Color.G,
+				span.Color.B,
+				"g",
+				"rg",
+			).str)
+			s.WriteByte(' ')
+		case f.colorFlag:
+			s.WriteString(f.color.text.str)
+			s.WriteByte(' ')
+		}
+
+		s.WriteString("BT ")
+		f.putFontSelect(&s, span.Font, false)
+		s.WriteByte(' ')
+		s.WriteString(f.fmtF64(x, -1))
+		s.WriteByte(' ')
+		s.WriteString(f.fmtF64(f.h-(baseline-span.Rise), -1))
+		s.WriteString(" Td ")
+		s.WriteString(f.textArray(r.text, spacing*1000/f.fontSize))
+		s.WriteString(" ET")
+
+		ws := f.ws
+		f.ws = spacing
+		if span.Style.Underline() {
+			s.WriteByte(' ')
+			s.WriteString(f.dounderline(x, baseline-span.Rise, r.str))
+		}
+		if span.Style.Strike() {
+			s.WriteByte(' ')
+			s.WriteString(f.dostrikeout(x, baseline-span.Rise, r.str))
+		}
+		f.ws = ws
+
+		s.WriteString(" Q")
+		f.out(s.String())
+
+		w := r.width + spacing*float32(r.spaces)
+		if span.Link > 0 || span.LinkStr != "" {
+			f.newLink(x, f.y, w, line.height, span.Link, span.LinkStr)
+		}
+		x += w
+	}
+}
@@ -3,8 +3,10 @@ package scribe
 import (
 	"fmt"
 	"math"
-	"unicode"
+	"strings"
 	"unicode/utf8"
+
+	"github.com/kofi-q/scribe-go/internal/linebreak"
 )
 
 func (f *Scribe) Scratch(width float32) (sc ScratchPad, err error) {
@@ -29,12 +31,18 @@ func (f *Scribe) Scratch(width float32) (sc ScratchPad, err error) {
 
 type ScratchPad struct {
 	font fontSpec
+	dir  TextDirection
 
 	width            float32
 	widthLongestLine float32
 	f                *Scribe
-	x                float32
-	y                float32
+
+	// The lines returned by Text() since the last reset, with their
+	// paragraphs, for CellFormat().
+	lines map[string]*bidiLine
+
+	x float32
+	y float32
 
 	widthBreakChar     uint16
 	widthCharsLine     uint16
@@ -68,6 +76,7 @@ func (sc *ScratchPad) Reset(width float32) {
 	sc.y = 0
 
 	sc.SetFont(sc.f.currentFont, sc.f.fontStyle, sc.f.fontSize)
+	sc.lines = nil
 }
 
 func (sc *ScratchPad) SetFont(id FontId, style FontStyle, size float32) {
@@ -77,43 +86,127 @@ func (sc *ScratchPad) SetFont(id FontId, style FontStyle, size float32) {
 	)
 }
 
-func (sc *ScratchPad) Text(lnHeight float32, text string) (lines []string) {
-	var char rune
-	var ixChar int
+// Text measures text from the current scratch pad position and returns the
+// lines it wraps into. Lines are returned in logical order - they're reordered
+// for display when printed with the scratch pad's CellFormat(), with the
+// embedding levels of their paragraphs, or with Scribe.CellFormat() and
+// friends, passed the paragraph direction reported by Direction().
+//
+// Lines are broken as MultiCell() would break them. See
+// Scribe.SetLineBreaking().
+//
+// dir optionally specifies the base direction of the text. See CellFormat().
+func (sc *ScratchPad) Text(
+	lnHeight float32,
+	text string,
+	dir ...TextDirection,
+) (lines []string) {
+	// Resolve the direction of the last paragraph in text, unless it
+	// continues a paragraph from a previous call.
+	para := text[strings.LastIndexByte(text, '\n')+1:]
+	if len(dir) > 0 || len(para) < len(text) || sc.x == 0 {
+		sc.dir = TextDirection(
+			paragraphDirection([]rune(para), sc.f.textDirection(dir)),
+		)
+	}
+
+	// Lines are reordered with the embedding levels of their paragraphs when
+	// they're printed with CellFormat(), until the scratch pad is reset.
+	bidiText := &bidiText{text: []rune(text), dir: sc.f.textDirection(dir)}
+
+	if sc.f.lineBreaking != nil {
+		return sc.textLines(lnHeight, text, bidiText)
+	}
+
+	// addLine adds the line from byte start up to byte end of text.
+	addLine := func(start, end int, line string) {
+		lines = sc.addLine(
+			lines,
+			bidiText,
+			utf8.RuneCountInString(text[:start]),
+			utf8.RuneCountInString(text[:end]),
+			line,
+		)
+	}
+
+	ops := linebreak.Opportunities([]rune(text))
+
+	// Lines are broken at ixBreak, before any spaces, which are dropped, with
+	// the next line starting at ixAfterBreak.
 	var ixBreak int
 	var ixAfterBreak int
 	var ixLine int
-	for ixChar, char = range text {
-		widthChar := uint16(sc.f.fonts.Get(sc.font.id).GlyphWidthOnly(char))
-		sc.widthCharsLine += widthChar
 
-		isSpace := unicode.IsSpace(char)
-		if isSpace || isChinese(char) {
+	// The run of spaces before the current character.
+	var ixSpaces int
+	var widthSpaces uint16
+	var inSpaces bool
+
+	ixRune := -1
+	for ixChar, char := range text {
+		ixRune++
+		widthChar := uint16(sc.f.glyphWidth(sc.font.id, char))
+
+		switch {
+		case char == '\n':
 			sc.widthCharsPreBreak = sc.widthCharsLine
+			sc.widthBreakChar = widthChar
 			ixBreak = ixChar
-			ixAfterBreak = ixBreak
-
-			if isSpace {
-				sc.widthBreakChar = widthChar
-				sc.widthCharsPreBreak -= widthChar
-				runeLen := utf8.RuneLen(char)
-				if runeLen > -1 {
-					ixAfterBreak += runeLen
+			ixAfterBreak = ixChar + 1
+
+		case ixChar > ixLine && ops[ixRune] != linebreak.Prohibited:
+			sc.widthCharsPreBreak = sc.widthCharsLine - widthSpaces
+			sc.widthBreakChar = widthSpaces
+			ixBreak = ixChar
+			if inSpaces {
+				ixBreak = ixSpaces
+			}
+			ixAfterBreak = ixChar
+		}
+
+		sc.widthCharsLine += widthChar
+
+		// Spaces at the end of a line are dropped, rather than breaking it.
+		if linebreak.IsSpace(char) {
+			if !inSpaces {
+				ixSpaces = ixChar
+				inSpaces = true
+			}
+			widthSpaces += widthChar
+			continue
+		}
+		inSpaces = false
+		widthSpaces = 0
+
+		if char != '\n' && sc.widthCharsLine > sc.widthCharMax {
+			if line, ixNext, ok := sc.hyphenate(text, ixLine, ixChar); ok {
+				sc.y += lnHeight
+				addLine(ixLine, ixLine+len(line)-1, line)
+				ixLine = ixNext
+
+				sc.widthCharsLine = 0
+				for _, char := range text[ixLine : ixChar+utf8.RuneLen(char)] {
+					sc.widthCharsLine += uint16(sc.f.glyphWidth(sc.font.id, char))
 				}
+				sc.widthCharsPreBreak = 0
+				sc.widthBreakChar = 0
+				ixBreak = 0
+				continue
 			}
 		}
 
 		if char == '\n' || sc.widthCharsLine > sc.widthCharMax {
-			// Scratch pad width is too small to fit a single character.
+			// There's nowhere to break the line before this character, e.g.
+			// if the scratch pad is too narrow to fit a single character.
 			// Skip line break until after this char, to avoid infinite line
 			// breaks.
-			if sc.widthCharsPreBreak == 0 && sc.x == 0 && char != '\n' {
+			if sc.widthCharsPreBreak == 0 && char != '\n' {
 				continue
 			}
 
 			sc.y += lnHeight
 
-			lines = append(lines, text[ixLine:ixBreak])
+			addLine(ixLine, ixBreak, text[ixLine:ixBreak])
 			ixLine = ixAfterBreak
 
 			sc.widthCharsLine -= (sc.widthCharsPreBreak + sc.widthBreakChar)
@@ -128,8 +221,15 @@ func (sc *ScratchPad) Text(lnHeight float32, text string) (lines []string) {
 		}
 	}
 
+	// Text that follows, in the next call, can start a new line after any
+	// spaces at the end of this text.
+	if inSpaces {
+		sc.widthCharsPreBreak = sc.widthCharsLine - widthSpaces
+		sc.widthBreakChar = widthSpaces
+	}
+
 	if len(text[ixLine:]) > 0 {
-		lines = append(lines, text[ixLine:])
+		addLine(ixLine, len(text), text[ixLine:])
 	}
 
 	sc.x = float32(sc.widthCharsLine) * sc.font.fontSizePt / 1000
@@ -141,6 +241,175 @@ func (sc *ScratchPad) Text(lnHeight float32, text string) (lines []string) {
 	return
 }
 
+// hyphenate hyphenates the word that overflows the current line at byte
+// ixChar of text, with the line starting at byte ixLine. It returns the line,
+// with its hyphen, and the start of the next line.
+func (sc *ScratchPad) hyphenate(
+	text string,
+	ixLine, ixChar int,
+) (line string, ixNext int, ok bool) {
+	glyphWidth := func(_, char rune) float32 {
+		return sc.f.glyphWidth(sc.font.id, char)
+	}
+
+	// The line may have started before this call, with text from a previous
+	// one.
+	indent := float32(sc.widthCharsLine)
+	_, size := utf8.DecodeRuneInString(text[ixChar:])
+	for _, char := range text[ixLine : ixChar+size] {
+		indent -= glyphWidth(-1, char)
+	}
+	indent = max(indent, 0)
+
+	line, ixNext, ok = sc.f.hyphenBreakString(
+		text,
+		ixLine,
+		ixChar,
+		indent,
+		float32(sc.widthCharMax),
+		glyphWidth,
+	)
+	if ok {
+		width := indent
+		for _, char := range line {
+			width += glyphWidth(-1, char)
+		}
+		sc.widthLongestLine = max(
+			sc.widthLongestLine,
+			width*sc.font.fontSizePt/1000,
+		)
+	}
+
+	return line, ixNext, ok
+}
+
+// textLines measures text for Text(), with total-fit line breaking. Text
+// already on the current line is kept there, with the text that follows
+// broken around it.
+func (sc *ScratchPad) textLines(
+	lnHeight float32,
+	text string,
+	bidiText *bidiText,
+) (lines []string) {
+	// Lines are measured as MultiCell() measures them, with kerning.
+	glyphWidth := func(prev, char rune) float32 {
+		charWidth := sc.f.glyphWidth(sc.font.id, char)
+		if charWidth == 65535 { // Marker width 65535 used for zero width symbols
+			return 0
+		}
+		return charWidth + sc.f.kern(sc.font.id, prev, char)
+	}
+
+	paras := linebreak.Paragraphs([]rune(text))
+	paraStart := 0
+	for ixPara, para := range paras {
+		if ixPara > 0 {
+			sc.y += lnHeight
+			sc.widthCharsLine = 0
+			paraStart += len(paras[ixPara-1]) + 1
+		}
+
+		indent := float32(sc.widthCharsLine)
+		spans := sc.f.lineBreaking.breaker().Break(
+			para,
+			float32(sc.widthCharMax),
+			indent,
+			glyphWidth,
+			sc.f.hyphenPoints,
+		)
+		for ixSpan, span := range spans {
+			width := span.Width
+			if ixSpan == 0 {
+				width += indent
+			} else {
+				sc.y += lnHeight
+			}
+
+			if ixPara < len(paras)-1 || len(para) > 0 {
+				line := string(para[span.Start:span.End])
+				if span.Hyphen {
+					line += "-"
+				}
+				lines = sc.addLine(
+					lines,
+					bidiText,
+					paraStart+span.Start,
+					paraStart+span.End,
+					line,
+				)
+			}
+			sc.widthLongestLine = max(
+				sc.widthLongestLine,
+				width*sc.font.fontSizePt/1000,
+			)
+			sc.widthCharsLine = uint16(width)
+		}
+	}
+
+	sc.widthCharsPreBreak = 0
+	sc.widthBreakChar = 0
+	sc.x = float32(sc.widthCharsLine) * sc.font.fontSizePt / 1000
+
+	return lines
+}
+
+// addLine adds line, from index start up to index end of the text passed to
+// Text(), to lines, and records its paragraph for CellFormat().
+func (sc *ScratchPad) addLine(
+	lines []string,
+	bidiText *bidiText,
+	start, end int,
+	line string,
+) []string {
+	if sc.lines == nil {
+		sc.lines = make(map[string]*bidiLine)
+	}
+	sc.lines[line] = bidiText.line(start, end, line)
+
+	return append(lines, line)
+}
+
+// CellFormat prints a line returned by Text(), as Scribe.CellFormat() does,
+// reordered for display with the embedding levels of its paragraph, in the
+// paragraph's direction. Other text is printed as with Scribe.CellFormat().
+func (sc *ScratchPad) CellFormat(
+	width, height float32,
+	line, borderStr string,
+	ln int,
+	alignStr string,
+	fill bool,
+	link int,
+	linkStr string,
+) {
+	f := sc.f
+	measured, ok := sc.lines[line]
+	if !ok {
+		f.CellFormat(width, height, line, borderStr, ln, alignStr, fill, link, linkStr)
+		return
+	}
+
+	defer func(line *bidiLine) { f.bidiLine = line }(f.bidiLine)
+	f.bidiLine = measured
+	f.CellFormat(
+		width,
+		height,
+		line,
+		borderStr,
+		ln,
+		alignStr,
+		fill,
+		link,
+		linkStr,
+		TextDirection(measured.para.Direction()),
+	)
+}
+
+// Direction returns the resolved base direction of the last paragraph of text
+// passed to Text().
+func (sc *ScratchPad) Direction() TextDirection {
+	return sc.dir
+}
+
 func (sc *ScratchPad) WidthLongestLine() float32 {
 	return sc.widthLongestLine
 }
@@ -43,8 +43,14 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf16"
 
 	"github.com/bits-and-blooms/bitset"
+	"github.com/kofi-q/scribe-go/internal/bidi"
+	"github.com/kofi-q/scribe-go/internal/exclusion"
+	"github.com/kofi-q/scribe-go/internal/hyphen"
+	"github.com/kofi-q/scribe-go/internal/linebreak"
 	"github.com/kofi-q/scribe-go/ttf"
 )
 
@@ -69,6 +75,9 @@ func newFmtBuffer(capacity uint32) fmtBuffer {
 }
 
 func (b *fmtBuffer) printf(fmtStr string, args ...interface{}) {
+	if b.Buffer == nil {
+		b.Buffer = new(bytes.Buffer)
+	}
 	b.Buffer.WriteString(fmt.Sprintf(fmtStr, args...))
 }
 
@@ -123,7 +132,10 @@ func scribeNew(
 	f.ws = 0
 	f.fonts = fontSet
 	f.fontObjIds = make([]uint32, f.fonts.Len())
-	f.usedRunes = make([]bitset.BitSet, f.fonts.Len())
+	f.fontVertObjIds = make([]uint32, f.fonts.Len())
+	f.glyphText = make([]map[uint16]string, f.fonts.Len())
+	f.usedGlyphs = make([]bitset.BitSet, f.fonts.Len())
+	f.usedVertical = make([]bool, f.fonts.Len())
 
 	// Scale factor
 	switch unitStr {
@@ -690,6 +702,11 @@ func (f *Scribe) AddOutputIntent(outputIntent OutputIntentType) {
 // substituted as the document is closed. An empty string is replaced with the
 // string "{nb}".
 //
+// If page labels are set with SetPageLabel(), the alias is replaced with the
+// label of the last page, e.g. "120" for a document with ten pages of front
+// matter labelled i to x, so it can follow the labels of pages written with
+// AliasPageLabel(): "Page {pl} of {nb}".
+//
 // See the example for AddPage() for a demonstration of this method.
 func (f *Scribe) AliasNbPages(aliasStr string) {
 	if aliasStr == "" {
@@ -737,6 +754,12 @@ func (f *Scribe) Close(writer io.Writer) error {
 			return f.err
 		}
 	}
+	// Footnotes carried over from the last page are written on new pages.
+	for len(f.footnotes.carry) > 0 && f.err == nil {
+		f.AddPageFormat(f.curOrientation, f.curPageSize)
+	}
+	f.putFootnotes()
+
 	// Page footer
 	f.inFooter = true
 	if f.footerFnc != nil {
@@ -746,6 +769,14 @@ func (f *Scribe) Close(writer io.Writer) error {
 	}
 	f.inFooter = false
 
+	// Tables of contents are written once the pages of all bookmarks are known.
+	f.putTOCs()
+	f.checkOutlines()
+	f.checkNamedDests()
+	if f.err != nil {
+		return f.err
+	}
+
 	f.writer = writer
 
 	// Close page
@@ -795,7 +826,15 @@ func (f *Scribe) AddPageFormat(orientationStr string, size PageSize) {
 	tc := f.color.text
 	cf := f.colorFlag
 
+	// Headers and footers are written with the page margins, outside any
+	// columns.
+	if f.columns != nil {
+		f.lMargin, f.rMargin = f.columns.lMargin, f.columns.rMargin
+	}
+
 	if f.page > 0 {
+		f.putFootnotes()
+
 		f.inFooter = true
 		// Page footer avoid double call on footer.
 		if f.footerFnc != nil {
@@ -808,6 +847,9 @@ func (f *Scribe) AddPageFormat(orientationStr string, size PageSize) {
 		// Close page
 		f.endpage()
 	}
+	if f.nextSection == f.page+1 {
+		f.endSection()
+	}
 	// Start new page
 	f.beginpage(orientationStr, size)
 	// 	Set line cap style to current value
@@ -849,6 +891,10 @@ func (f *Scribe) AddPageFormat(orientationStr string, size PageSize) {
 			f.SetHomeXY()
 		}
 	}
+	if f.columns != nil {
+		f.startColumns(f.y)
+	}
+	f.reserveFootnotes(f.y, true)
 }
 
 // AddPage adds a new page to the document. If a page is already present, the
@@ -1007,14 +1053,364 @@ func (f *Scribe) GetStringWidth(s string) float32 {
 // GetStringSymbolWidth returns the length of a string in glyf units. A font must be
 // currently selected.
 func (f *Scribe) GetStringSymbolWidth(str string) float32 {
-	font := f.font()
+	text := f.shape(str, f.textDirection(nil), 0)
+	return text.width()
+}
+
+// SetKerning enables or disables pair kerning. When enabled, text output and
+// string width measurement apply the pair adjustments defined in the font's
+// GPOS table or, if absent, its legacy kern table. Kerning is disabled by
+// default.
+func (f *Scribe) SetKerning(enabled bool) {
+	f.kerning = enabled
+}
+
+// GetKerning returns true if pair kerning is enabled. See SetKerning().
+func (f *Scribe) GetKerning() bool {
+	return f.kerning
+}
+
+// kern returns the pair kerning adjustment between prev and char, in glyph
+// units, in font id or in the fallback font used in its place for both. There's
+// no kerning between characters in different fonts. A negative prev indicates
+// the start of a line.
+func (f *Scribe) kern(id FontId, prev, char rune) float32 {
+	if !f.kerning || prev < 0 {
+		return 0
+	}
+
+	font := f.fonts.Fallback(id, char)
+	if f.fonts.Fallback(id, prev) != font {
+		return 0
+	}
+
+	return f.fonts.Get(font).Kern(prev, char)
+}
+
+// glyphWidth returns the width of char, in glyph units, in font id or in the
+// fallback font used in its place. See FontSet.SetFallbacks(). Invisible
+// characters have no width. See isInvisible().
+func (f *Scribe) glyphWidth(id FontId, char rune) float32 {
+	if isInvisible(char) {
+		return 0
+	}
+
+	return f.fonts.Get(f.fonts.Fallback(id, char)).GlyphWidthOnly(char)
+}
+
+// shapedText is text converted to positioned glyphs, in visual order.
+type shapedText struct {
+	runes  []rune
+	glyphs []ttf.Glyph
+
+	// Runs of glyphs by font, for text with characters missing from the
+	// current font that are provided by its fallbacks.
+	runs []fontRun
+
+	// Glyphs are laid out from top to bottom, with vertical advances.
+	vertical bool
+}
+
+type fontRun struct {
+	font FontId
+	end  int // Index just past the last glyph in the run.
+}
+
+// width returns the total advance of the text, in glyph units.
+func (t *shapedText) width() (width float32) {
+	for _, g := range t.glyphs {
+		width += g.Advance
+	}
+
+	return
+}
+
+// shape converts txt to positioned glyphs in the current font and its
+// fallbacks, in visual order. txt is reordered into runs with the Unicode
+// Bidirectional Algorithm, as a line of the paragraph it was broken from by
+// MultiCell() and friends, or otherwise as a paragraph with base direction
+// dir. Characters in right-to-left runs are replaced with
+// their mirrored forms. flags are passed on to the shaper, along with kerning,
+// if enabled.
+func (f *Scribe) shape(
+	txt string,
+	dir bidi.Direction,
+	flags ttf.ShapeFlags,
+) (text shapedText) {
+	if f.kerning {
+		flags |= ttf.ShapeKerning
+	}
+	text.vertical = flags&ttf.ShapeVertical != 0
+
+	// Soft hyphens are replaced with hyphens by line breaking, where they're
+	// used, and otherwise not displayed, like zero width spaces and word
+	// joiners.
+	runes := []rune(txt)
+	lineRuns := f.lineRuns(runes, txt, dir)
+	pos := make([]int, len(runes)+1)
+	n := 0
+	for i, char := range runes {
+		pos[i] = n
+		if !isInvisible(char) {
+			runes[n] = char
+			n++
+		}
+	}
+	pos[len(runes)] = n
+	runes = runes[:n]
+	text.runes = runes
+
+	runs := lineRuns[:0]
+	for _, run := range lineRuns {
+		run.Start, run.End = pos[run.Start], pos[run.End]
+		if run.End > run.Start {
+			runs = append(runs, run)
+		}
+	}
+
+	// Fonts for each character. Marks and other characters that combine with
+	// the preceding one stay in its font, so they can be shaped together.
+	fonts := make([]FontId, len(runes))
+	for i, char := range runes {
+		if i > 0 && isCombining(char) {
+			fonts[i] = fonts[i-1]
+			continue
+		}
+		fonts[i] = f.fonts.Fallback(f.currentFont, char)
+	}
+
+	var spans [][2]int
+	for _, run := range runs {
+		runFlags := flags
+		if run.Rtl() {
+			runFlags |= ttf.ShapeRtl
+			for i := run.Start; i < run.End; i++ {
+				runes[i] = bidi.Mirror(runes[i])
+			}
+		}
+
+		// Spans of the run in a single font, in visual order.
+		spans = spans[:0]
+		for start := run.Start; start < run.End; {
+			end := start + 1
+			for end < run.End && fonts[end] == fonts[start] {
+				end += 1
+			}
+			spans = append(spans, [2]int{start, end})
+			start = end
+		}
+		if run.Rtl() {
+			slices.Reverse(spans)
+		}
+
+		for _, span := range spans {
+			start, end := span[0], span[1]
+			font := fonts[start]
+
+			n := len(text.glyphs)
+			text.glyphs = f.fonts.Get(font).Shape(
+				runes[start:end],
+				runFlags,
+				text.glyphs,
+			)
+			for i := n; i < len(text.glyphs); i++ {
+				text.glyphs[i].Cluster += uint32(start)
+			}
+
+			if last := len(text.runs) - 1; last >= 0 &&
+				text.runs[last].font == font {
+				text.runs[last].end = len(text.glyphs)
+			} else {
+				text.runs = append(text.runs, fontRun{font, len(text.glyphs)})
+			}
+		}
+	}
+
+	return
+}
+
+// isCombining returns true for characters that are shaped together with the
+// preceding character.
+func isCombining(char rune) bool {
+	return unicode.In(char, unicode.Mn, unicode.Me) ||
+		char == 0x200d || // Zero width joiner
+		(char >= 0xfe00 && char <= 0xfe0f) || // Variation selectors
+		(char >= 0xe0100 && char <= 0xe01ef)
+}
+
+// isInvisible returns true for characters that only mark where lines can and
+// can't be broken, and aren't displayed: soft hyphens, zero width spaces and
+// word joiners.
+func isInvisible(char rune) bool {
+	return char == hyphen.SoftHyphen ||
+		char == 0x200b || // Zero width space
+		char == 0x2060 // Word joiner
+}
+
+// textArray formats shaped glyphs as TJ operators, with adjustments for glyph
+// positions that differ from the fonts' default advances. spacing, in glyph
+// units, is added after each space character. Glyphs from fallback fonts are
+// output with the font selected for them, after which the current font is
+// restored. The glyphs are recorded as used, for font subsetting.
+//
+// Vertical text is output with the vertical variant of each font, with spacing
+// added below spaces.
+func (f *Scribe) textArray(text shapedText, spacing float32) string {
+	runes, glyphs := text.runes, text.glyphs
+
+	// Sorted cluster start indices, for finding the text of each glyph.
+	clusters := make([]uint32, len(glyphs))
+	for i, g := range glyphs {
+		clusters[i] = g.Cluster
+	}
+	slices.Sort(clusters)
+	clusters = slices.Compact(clusters)
+	seen := make([]bool, len(clusters))
+
+	var buf strings.Builder
+	buf.Grow(4*len(glyphs) + 8)
+
+	// Adjustments in TJ arrays move the next glyph back in horizontal text,
+	// but further down in vertical text.
+	vertical := text.vertical
+	adjustSign := float32(-1)
+	if vertical {
+		adjustSign = 1
+	}
+
+	var adjust, rise float32
+	fontCur := f.currentFont
+	start := 0
+	for ixRun, run := range text.runs {
+		if run.font != fontCur || (vertical && ixRun == 0) {
+			if ixRun > 0 {
+				buf.WriteByte(' ')
+			}
+			f.putFontSelect(&buf, run.font, vertical)
+			buf.WriteByte(' ')
+			fontCur = run.font
+		}
+
+		font := f.fonts.Get(run.font).Font()
+		used := &f.usedGlyphs[run.font]
+		if vertical {
+			f.usedVertical[run.font] = true
+		}
+		if f.glyphText[run.font] == nil {
+			f.glyphText[run.font] = map[uint16]string{}
+		}
+		glyphText := f.glyphText[run.font]
+
+		buf.WriteByte('[')
+		inString := false
+		for _, g := range glyphs[start:run.end] {
+			if y := g.YOffset * f.fontSize / 1000; y != rise {
+				if inString {
+					buf.WriteByte(')')
+					inString = false
+				}
+				buf.WriteString("] TJ ")
+				buf.WriteString(f.fmtF64(y, -1))
+				buf.WriteString(" Ts [")
+				rise = y
+			}
+
+			adjust += g.XOffset
+			if adjust != 0 {
+				if inString {
+					buf.WriteByte(')')
+					inString = false
+				}
+				buf.WriteString(f.fmtF64(adjustSign*adjust, -1))
+			}
+			if !inString {
+				buf.WriteByte('(')
+				inString = true
+			}
+			putGlyphId(&buf, g.Id)
 
-	var total float32 = 0
-	for _, c := range str {
-		total += font.GlyphWidthOnly(c)
+			if vertical {
+				adjust = g.Advance - font.Height(g.Id)
+			} else {
+				adjust = g.Advance - font.Width(g.Id) - g.XOffset
+			}
+			if int(g.Cluster) < len(runes) && runes[g.Cluster] == ' ' {
+				adjust += spacing
+			}
+
+			used.Set(uint(g.Id))
+			if i, _ := slices.BinarySearch(clusters, g.Cluster); !seen[i] {
+				seen[i] = true
+				end := uint32(len(runes))
+				if i+1 < len(clusters) {
+					end = clusters[i+1]
+				}
+				if _, ok := glyphText[g.Id]; !ok && g.Cluster < end {
+					glyphText[g.Id] = string(runes[g.Cluster:end])
+				}
+			}
+		}
+
+		if inString {
+			buf.WriteByte(')')
+		}
+
+		// Carry the last glyph's adjustment over to the next run.
+		if ixRun < len(text.runs)-1 && adjust != 0 {
+			buf.WriteString(f.fmtF64(adjustSign*adjust, -1))
+			adjust = 0
+		}
+		buf.WriteString("] TJ")
+
+		start = run.end
 	}
 
-	return total
+	if len(text.runs) == 0 {
+		buf.WriteString("[] TJ")
+	}
+	if rise != 0 {
+		buf.WriteString(" 0 Ts")
+	}
+	if fontCur != f.currentFont || vertical {
+		buf.WriteByte(' ')
+		f.putFontSelect(&buf, f.currentFont, false)
+	}
+
+	return buf.String()
+}
+
+// putFontSelect writes a Tf operator selecting font id at the current size.
+func (f *Scribe) putFontSelect(
+	buf *strings.Builder,
+	id FontId,
+	vertical bool,
+) {
+	buf.WriteString("/F")
+	buf.WriteString(strconv.FormatUint(uint64(id), 10))
+	if vertical {
+		buf.WriteByte('V')
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(f.fmtF64(f.fontSizePt, -1))
+	buf.WriteString(" Tf")
+}
+
+// putGlyphId writes gid to buf as an escaped, 2-byte string code.
+func putGlyphId(buf *strings.Builder, gid uint16) {
+	for _, b := range [2]byte{byte(gid >> 8), byte(gid)} {
+		// [TODO] See if \b and \f are needed - not escaping them doesn't seem
+		// to affect readers
+		switch b {
+		case '\\', '(', ')':
+			buf.WriteByte('\\')
+			buf.WriteByte(b)
+		case '\r':
+			buf.WriteByte('\\')
+			buf.WriteByte('r')
+		default:
+			buf.WriteByte(b)
+		}
+	}
 }
 
 // SetLineWidth defines the line width. By default, the value equals 0.2 mm.
@@ -1648,9 +2044,9 @@ func (f *Scribe) ClipText(x, y float32, txtStr string, outline bool) {
 	f.putF64((f.h - y), prec)
 	f.put(" Td ")
 	f.putInt(intIf(outline, 5, 7))
-	f.put(" Tr (")
-	f.put(f.escape(txtStr))
-	f.put(") Tj ET\n")
+	f.put(" Tr ")
+	f.put(f.textArray(f.shape(txtStr, f.textDirection(nil), 0), 0))
+	f.put(" ET\n")
 }
 
 func (f *Scribe) clipArc(x1, y1, x2, y2, x3, y3 float32) {
@@ -2119,7 +2515,7 @@ func (f *Scribe) newLink(x, y, w, h float32, link int, linkStr string) {
 	// f.pageLinks[f.page] = linkList
 	// }
 	f.pageLinks[f.page] = append(f.pageLinks[f.page],
-		linkType{x * f.k, f.hPt - y, w * f.k, h * f.k, link, linkStr})
+		linkType{x * f.k, f.hPt - y, w * f.k, h * f.k, link, linkStr, nil})
 }
 
 // Link puts a link on a rectangular area of the page. Text or image links are
@@ -2142,28 +2538,14 @@ func (f *Scribe) LinkString(x, y, w, h float32, linkStr string) {
 // is the title of the bookmark. level specifies the level of the bookmark in
 // the outline; 0 is the top level, 1 is just below, and so on. y specifies the
 // vertical position of the bookmark destination in the current page; -1
-// indicates the current position.
+// indicates the current position. See AddOutline() for styled entries and
+// other destinations.
 func (f *Scribe) Bookmark(txtStr string, level int, y float32) {
 	if y == -1 {
 		y = f.y
 	}
 
-	// [TODO] Re-add support for built-in ASCII fonts
-	txtStr = f.utf8toutf16(txtStr)
-
-	f.outlines = append(
-		f.outlines,
-		outlineType{
-			text:  txtStr,
-			level: level,
-			y:     y,
-			p:     f.PageNo(),
-			prev:  -1,
-			last:  -1,
-			next:  -1,
-			first: -1,
-		},
-	)
+	f.AddOutline(Outline{Title: txtStr, Level: level, Dest: &Dest{Y: y}})
 }
 
 // Text prints a character string. The origin (x, y) is on the left of the
@@ -2171,20 +2553,13 @@ func (f *Scribe) Bookmark(txtStr string, level int, y float32) {
 // precisely on the page, but it is usually easier to use Cell(), MultiCell()
 // or Write() which are the standard methods to print text.
 func (f *Scribe) Text(x, y float32, txtStr string) {
-	var txt2 string
 	// [TODO] Re-add support for built-in ASCII fonts
+	text := f.shape(txtStr, f.textDirection(nil), 0)
 	if f.isRTL {
-		txtStr = reverseText(txtStr)
-		x -= f.GetStringWidth(txtStr)
+		x -= text.width() * f.fontSize / 1000
 	}
 
-	txt2 = f.utf8toutf16(txtStr, false)
-
-	for _, uni := range txtStr {
-		f.usedRunes[f.currentFont].Set(uint(uni))
-	}
-
-	s := sprintf("BT %g %g Td (%s) Tj ET", x, (f.h - y), txt2)
+	s := sprintf("BT %g %g Td %s ET", x, (f.h - y), f.textArray(text, 0))
 
 	if f.fontStyle.Underline() && txtStr != "" {
 		s += " " + f.dounderline(x, y, txtStr)
@@ -2284,6 +2659,10 @@ func (f *Scribe) SetAcceptPageBreakFunc(fnc func() bool) {
 //
 // linkStr is a target URL or empty for no external link. A non--zero value for
 // link takes precedence over linkStr.
+//
+// dir optionally specifies the base direction of the text. By default, it's
+// right-to-left if RTL() has been called, or detected from the text
+// otherwise. See TextDirection.
 func (f *Scribe) CellFormat(
 	width, height float32,
 	txtStr, borderStr string,
@@ -2292,6 +2671,7 @@ func (f *Scribe) CellFormat(
 	fill bool,
 	link int,
 	linkStr string,
+	dir ...TextDirection,
 ) {
 	if f.err != nil {
 		return
@@ -2300,7 +2680,7 @@ func (f *Scribe) CellFormat(
 	font := f.font()
 
 	if f.y+height > f.pageBreakTrigger && !f.inHeader && !f.inFooter &&
-		f.acceptPageBreak() {
+		f.acceptBreak() {
 		// Automatic page break
 		x := f.x
 		ws := f.ws
@@ -2374,7 +2754,8 @@ func (f *Scribe) CellFormat(
 	if len(txtStr) > 0 {
 		hasContent = true
 		var dx, dy float32
-		strGlyphWidth := f.GetStringSymbolWidth(txtStr)
+		text := f.shape(txtStr, f.textDirection(dir), 0)
+		strGlyphWidth := text.width()
 		strWidth := float32(strGlyphWidth) * f.fontSize / 1000
 		// Horizontal alignment
 		switch {
@@ -2412,54 +2793,29 @@ func (f *Scribe) CellFormat(
 		}
 		// If multibyte, Tw has no effect - do word spacing using an adjustment before each space
 		if f.ws != 0 || alignStr == "J" {
-			if f.isRTL {
-				txtStr = reverseText(txtStr)
-			}
 			wmax := float32((width - 2*f.cMargin) * 1000 / f.fontSize)
-			for _, uni := range txtStr {
-				f.usedRunes[f.currentFont].Set(uint(uni))
+			var shift float32
+			if spaces := strings.Count(txtStr, " "); spaces > 0 {
+				shift = (wmax - strGlyphWidth) / float32(spaces)
 			}
-			strSize := strGlyphWidth
 			f.put("BT 0 Tw ")
 			f.put(f.fmtF64((f.x + dx), -1))
 			f.put(" ")
 			f.put(f.fmtF64((f.h - (f.y + .5*height + .3*f.fontSize)), -1))
-			f.put(" Td [")
-
-			t := strings.Split(txtStr, " ")
-			shift := (wmax - strSize) / float32(len(t)-1)
-			numt := len(t)
-			for i := 0; i < numt; i++ {
-				f.put("(")
-				f.putUtf16(t[i])
-				f.put(")")
-				f.put(" ")
-				if (i + 1) < numt {
-					f.put(f.fmtF64(-shift, -1))
-					f.putUtf16(" ")
-				}
-			}
-			f.put("] TJ ET")
+			f.put(" Td ")
+			f.put(f.textArray(text, shift))
+			f.put(" ET")
 		} else {
 			// [TODO] Re-add support for built-in ASCII fonts
-
-			if f.isRTL {
-				txtStr = reverseText(txtStr)
-			}
-
-			for _, uni := range txtStr {
-				f.usedRunes[f.currentFont].Set(uint(uni))
-			}
-
 			bt := (f.x + dx)
 			td := (f.h - (f.y + dy + .5*height + .3*f.fontSize))
 			f.put("BT ")
 			f.put(f.fmtF64(bt, -1))
 			f.put(" ")
 			f.put(f.fmtF64(td, -1))
-			f.put(" Td (")
-			f.putUtf16(txtStr)
-			f.put(")Tj ET")
+			f.put(" Td ")
+			f.put(f.textArray(text, 0))
+			f.put(" ET")
 		}
 
 		if f.fontStyle.Underline() {
@@ -2500,17 +2856,6 @@ func (f *Scribe) CellFormat(
 	}
 }
 
-// Revert string to use in RTL languages
-func reverseText(text string) string {
-	oldText := []rune(text)
-	newText := make([]rune, len(oldText))
-	length := len(oldText) - 1
-	for i, r := range oldText {
-		newText[length-i] = r
-	}
-	return string(newText)
-}
-
 // Cell is a simpler version of CellFormat with no fill, border, links or
 // special alignment. The Cell_strikeout() example demonstrates this method.
 func (f *Scribe) Cell(w, h float32, txtStr string) {
@@ -2524,57 +2869,90 @@ func (f *Scribe) Cellf(w, h float32, fmtStr string, args ...interface{}) {
 	f.CellFormat(w, h, sprintf(fmtStr, args...), "", 0, "L", false, 0, "")
 }
 
-// SplitLines splits text into several lines using the current font. Each line
-// has its length limited to a maximum width given by w. This function can be
-// used to determine the total height of wrapped text for vertical placement
-// purposes.
-//
-// This method is useful for codepage-based fonts only. For UTF-8 encoded text,
-// use SplitText().
+// SplitLines splits UTF-8 encoded text into several lines using the current
+// font. Each line has its length limited to a maximum width given by w. This
+// function can be used to determine the total height of wrapped text for
+// vertical placement purposes.
 //
 // You can use MultiCell if you want to print a text on several lines in a
 // simple way.
+//
+// Lines are broken as MultiCell() would break them. See SetLineBreaking().
 func (f *Scribe) SplitLines(txt []byte, w float32) [][]byte {
 	// Function contributed by Bruno Michel
 	lines := [][]byte{}
-	font := f.font()
-	wmax := int(math.Ceil(float64((w - 2*f.cMargin) * 1000 / f.fontSize)))
-	s := bytes.Replace(txt, []byte("\r"), []byte{}, -1)
-	strlen := len(s)
-	for strlen > 0 && s[strlen-1] == '\n' {
+	wmax := float32(math.Ceil(float64((w - 2*f.cMargin) * 1000 / f.fontSize)))
+	text := []rune(strings.ReplaceAll(string(txt), "\r", ""))
+	strlen := len(text)
+	for strlen > 0 && text[strlen-1] == '\n' {
 		strlen--
 	}
-	s = s[0:strlen]
+	text = text[0:strlen]
+
+	if f.lineBreaking != nil {
+		return f.splitLinesOptimal(text, wmax)
+	}
+
+	measure := func(prev, c rune) float32 {
+		charWidth := f.glyphWidth(f.currentFont, c)
+		if charWidth == 65535 { // Marker width 65535 used for zero width symbols
+			return 0
+		}
+		return charWidth + f.kern(f.currentFont, prev, c)
+	}
+	ops := linebreak.Opportunities(text)
+
 	sep := -1
 	i := 0
 	j := 0
-	l := 0
+	l := float32(0)
+	prev := rune(-1)
 	for i < strlen {
-		c := s[i]
-		_, width := font.GlyphWidth(rune(c))
-		l += int(width)
-		if c == ' ' || c == '\t' || c == '\n' {
+		c := text[i]
+		l += measure(prev, c)
+		prev = c
+		if i > j && ops[i] != linebreak.Prohibited {
 			sep = i
 		}
-		if c == '\n' || l > wmax {
+		// Spaces at the end of a line are dropped, rather than breaking it.
+		overflow := l > wmax && !linebreak.IsSpace(c)
+		if overflow {
+			if end, next, ok := f.hyphenBreak(text, j, i, 0, wmax, measure); ok {
+				lines = append(lines, []byte(string(text[j:end])+"-"))
+				sep = -1
+				i = next
+				j = i
+				l = 0
+				prev = -1
+				continue
+			}
+		}
+		if c == '\n' {
+			lines = append(lines, []byte(string(text[j:i])))
+			i++
+			sep = -1
+			j = i
+			l = 0
+			prev = -1
+		} else if overflow {
 			if sep == -1 {
 				if i == j {
 					i++
 				}
 				sep = i
-			} else {
-				i = sep + 1
 			}
-			lines = append(lines, s[j:sep])
+			i = sep
+			lines = append(lines, []byte(string(text[j:lineEnd(text, j, sep)])))
 			sep = -1
 			j = i
 			l = 0
+			prev = -1
 		} else {
 			i++
 		}
 	}
 	if i != j {
-		lines = append(lines, s[j:i])
+		lines = append(lines, []byte(string(text[j:i])))
 	}
 	return lines
 }
@@ -2583,6 +2961,11 @@ func (f *Scribe) SplitLines(txt []byte, w float32) [][]byte {
 // soon as the text reaches the right border of the cell) or explicit (via the
 // \n character). As many cells as necessary are output, one below the other.
 //
+// Lines are broken where the Unicode Line Breaking Algorithm (UAX #14) allows:
+// after spaces, between ideographs, after hyphens and slashes, and at zero
+// width spaces (U+200B), but not at no-break spaces (U+00A0) or word joiners
+// (U+2060). Spaces at the ends of lines are dropped.
+//
 // Text can be aligned, centered or justified. The cell block can be framed and
 // the background painted. See CellFormat() for more details.
 //
@@ -2606,6 +2989,7 @@ func (f *Scribe) MultiCell(
 	width, height float32,
 	txtStr, borderStr, alignStr string,
 	fill bool,
+	dir ...TextDirection,
 ) {
 	if f.err != nil {
 		return
@@ -2615,7 +2999,6 @@ func (f *Scribe) MultiCell(
 	if alignStr == "" {
 		alignStr = "J"
 	}
-	font := f.font()
 	if width == 0 {
 		width = f.w - f.rMargin - f.x
 	}
@@ -2630,6 +3013,11 @@ func (f *Scribe) MultiCell(
 	}
 	srune = srune[0:runeCount]
 
+	// Each paragraph's direction is resolved up front and applied to all of
+	// its lines.
+	baseDir := f.textDirection(dir)
+	paraDir := paragraphDirection(srune, baseDir)
+
 	var b, b2 string
 	b = "0"
 	if len(borderStr) > 0 {
@@ -2653,15 +3041,71 @@ func (f *Scribe) MultiCell(
 		}
 	}
 
+	if f.lineBreaking != nil {
+		f.multiCellLines(
+			width,
+			height,
+			wmax,
+			srune,
+			borderStr,
+			b,
+			b2,
+			alignStr,
+			fill,
+			baseDir,
+		)
+		f.x = f.lMargin
+		return
+	}
+
 	// [TODO] Perf audit
 
+	// Lines are reordered with the embedding levels of their paragraphs. See
+	// shape().
+	bidiText := bidiText{text: srune, dir: baseDir}
+	defer func(line *bidiLine) { f.bidiLine = line }(f.bidiLine)
+
+	// Paragraphs aren't broken between pages where it'd leave fewer lines on
+	// either side than set with SetOrphansWidows(), once their lines are
+	// counted.
+	var counts []int
+	if (f.orphans > 1 || f.widows > 1) && f.lineCounts == nil {
+		counts = f.countLines(func() {
+			f.MultiCell(width, height, txtStr, borderStr, alignStr, fill, dir...)
+		})
+	}
+	para, line := 0, 0
+	lineHeight := func(int) float32 { return height }
+
+	// Lines are shortened beside any exclusions on the page, keeping their
+	// offset from the left margin. See AddExclusion().
+	dx, lineW := f.x-f.lMargin, width
+	startLine := func() {
+		if para < len(counts) {
+			f.keepLines(line, counts[para], lineHeight)
+		}
+		if len(f.exclusions) == 0 && lineW == width {
+			return
+		}
+		f.x, f.y, lineW = f.lineSpace(f.lMargin+dx, f.y, width, height)
+		wmax = (lineW - 2*f.cMargin) * 1000 / f.fontSize
+	}
+	startLine()
+
+	ops := linebreak.Opportunities(srune)
 	sep := -1
 	i := 0
 	j := 0
 	l := float32(0)
-	ls := float32(0)
-	ns := 0
 	nl := 1
+	prev := rune(-1)
+	measure := func(prev, c rune) float32 {
+		charWidth := f.glyphWidth(f.currentFont, c)
+		if charWidth == 65535 { //Marker width 65535 used for zero width symbols
+			return 0
+		}
+		return charWidth + f.kern(f.currentFont, prev, c)
+	}
 	for i < runeCount {
 		// Get next character
 		c := srune[i]
@@ -2674,47 +3118,67 @@ func (f *Scribe) MultiCell(
 
 			newAlignStr := alignStr
 			if newAlignStr == "J" {
-				if f.isRTL {
+				if paraDir == bidi.RightToLeft {
 					newAlignStr = "R"
 				} else {
 					newAlignStr = "L"
 				}
 			}
+			f.bidiLine = bidiText.line(j, i, string(srune[j:i]))
 			f.CellFormat(
-				width,
+				lineW,
 				height,
-				string(srune[j:i]),
+				f.bidiLine.text,
 				b,
 				2,
 				newAlignStr,
 				fill,
 				0,
 				"",
+				TextDirection(paraDir),
 			)
+			f.countLine(true)
 			i++
 			sep = -1
 			j = i
+			paraDir = paragraphDirection(srune[j:], baseDir)
 			l = 0
-			ns = 0
 			nl++
+			para, line = para+1, 0
+			prev = -1
 			if len(borderStr) > 0 && nl == 2 {
 				b = b2
 			}
+			startLine()
 			continue
 		}
-		if c == ' ' || isChinese(c) {
+		if i > j && ops[i] != linebreak.Prohibited {
 			sep = i
-			ls = l
-			ns++
 		}
 
-		_, charWidth := font.GlyphWidth(c)
+		charWidth := f.glyphWidth(f.currentFont, c)
 		if charWidth != 65535 { //Marker width 65535 used for zero width symbols
-			l += charWidth
+			l += charWidth + f.kern(f.currentFont, prev, c)
 		}
-		if l > wmax {
+		prev = c
+		if l > wmax && !linebreak.IsSpace(c) {
 			// Automatic line break
-			if sep == -1 {
+			if end, next, ok := f.hyphenBreak(srune, j, i, 0, wmax, measure); ok {
+				f.bidiLine = bidiText.line(j, end, string(srune[j:end])+"-")
+				f.CellFormat(
+					lineW,
+					height,
+					f.bidiLine.text,
+					b,
+					2,
+					alignStr,
+					fill,
+					0,
+					"",
+					TextDirection(paraDir),
+				)
+				i = next
+			} else if sep == -1 {
 				if i == j {
 					i++
 				}
@@ -2722,38 +3186,61 @@ func (f *Scribe) MultiCell(
 					f.ws = 0
 					f.out("0 Tw")
 				}
+				f.bidiLine = bidiText.line(j, i, string(srune[j:i]))
 				f.CellFormat(
-					width,
+					lineW,
 					height,
-					string(srune[j:i]),
+					f.bidiLine.text,
 					b,
 					2,
 					alignStr,
 					fill,
 					0,
 					"",
+					TextDirection(paraDir),
 				)
 			} else {
+				line := srune[j:lineEnd(srune, j, sep)]
 				if alignStr == "J" {
-					if ns > 1 {
-						f.ws = float32((wmax-ls)/1000) * f.fontSize / float32(ns-1)
-					} else {
-						f.ws = 0
+					f.ws = 0
+					if ns := blankCount(string(line)); ns > 0 {
+						ls := float32(0)
+						prevLs := rune(-1)
+						for _, c := range line {
+							ls += measure(prevLs, c)
+							prevLs = c
+						}
+						f.ws = float32((wmax-ls)/1000) * f.fontSize / float32(ns)
 					}
 					f.putF64(f.ws, 3)
 					f.put(" Tw\n")
 				}
-				f.CellFormat(width, height, string(srune[j:sep]), b, 2, alignStr, fill, 0, "")
-				i = sep + 1
+				f.bidiLine = bidiText.line(j, j+len(line), string(line))
+				f.CellFormat(
+					lineW,
+					height,
+					f.bidiLine.text,
+					b,
+					2,
+					alignStr,
+					fill,
+					0,
+					"",
+					TextDirection(paraDir),
+				)
+				i = sep
 			}
+			f.countLine(false)
 			sep = -1
 			j = i
 			l = 0
-			ns = 0
 			nl++
+			line++
+			prev = -1
 			if len(borderStr) > 0 && nl == 2 {
 				b = b2
 			}
+			startLine()
 		} else {
 			i++
 		}
@@ -2768,35 +3255,53 @@ func (f *Scribe) MultiCell(
 	}
 
 	if alignStr == "J" {
-		if f.isRTL {
+		if paraDir == bidi.RightToLeft {
 			alignStr = "R"
 		} else {
 			alignStr = ""
 		}
 	}
 
+	f.bidiLine = bidiText.line(j, i, string(srune[j:i]))
 	f.CellFormat(
-		width,
+		lineW,
 		height,
-		string(srune[j:i]),
+		f.bidiLine.text,
 		b,
 		2,
 		alignStr,
 		fill,
 		0,
 		"",
+		TextDirection(paraDir),
 	)
+	f.countLine(true)
 
 	f.x = f.lMargin
 }
 
 // write outputs text in flowing mode
-func (f *Scribe) write(lnHeight float32, txt string, link int, linkStr string) {
+func (f *Scribe) write(
+	lnHeight float32,
+	txt string,
+	link int,
+	linkStr string,
+	dir bidi.Direction,
+) {
 	// [TODO] Per audit
 
-	font := f.font()
-	w := f.w - f.rMargin - f.x
-	wmax := (w - 2*f.cMargin) * 1000 / f.fontSize
+	var w, wmax float32
+
+	// startLine starts a line at x, shortened beside any exclusions on the
+	// page. See AddExclusion().
+	startLine := func(x float32) {
+		f.x, w = x, f.w-f.rMargin-x
+		if len(f.exclusions) > 0 {
+			f.x, f.y, w = f.lineSpace(x, f.y, w, lnHeight)
+		}
+		wmax = (w - 2*f.cMargin) * 1000 / f.fontSize
+	}
+	startLine(f.x)
 	s := strings.Replace(txt, "\r", "", -1)
 
 	nb := len([]rune(s))
@@ -2804,54 +3309,87 @@ func (f *Scribe) write(lnHeight float32, txt string, link int, linkStr string) {
 		f.x += f.GetStringWidth(s)
 		return
 	}
+
+	paraDir := paragraphDirection([]rune(s), dir)
+
+	// Lines are reordered with the embedding levels of their paragraphs. See
+	// shape().
+	bidiText := bidiText{text: []rune(s), dir: dir}
+	defer func(line *bidiLine) { f.bidiLine = line }(f.bidiLine)
+	ops := linebreak.Opportunities([]rune(s))
 	sep := -1
 	i := 0
 	j := 0
 	l := float32(0.0)
 	nl := 1
+	prev := rune(-1)
+	measure := func(prev, c rune) float32 {
+		return f.glyphWidth(f.currentFont, c) + f.kern(f.currentFont, prev, c)
+	}
 	for i < nb {
 		// Get next character
 		var c rune
 		c = []rune(s)[i]
 		if c == '\n' {
 			// Explicit line break
+			f.bidiLine = bidiText.line(j, i, string([]rune(s)[j:i]))
 			f.CellFormat(
 				w,
 				lnHeight,
-				string([]rune(s)[j:i]),
+				f.bidiLine.text,
 				"",
 				2,
 				"",
 				false,
 				link,
 				linkStr,
+				TextDirection(paraDir),
 			)
 			i++
 			sep = -1
 			j = i
+			paraDir = paragraphDirection([]rune(s)[j:], dir)
 			l = 0.0
-			if nl == 1 {
-				f.x = f.lMargin
-				w = f.w - f.rMargin - f.x
-				wmax = (w - 2*f.cMargin) * 1000 / f.fontSize
-			}
+			prev = -1
+			startLine(f.lMargin)
 			nl++
 			continue
 		}
-		if c == ' ' {
+		if i > j && ops[i] != linebreak.Prohibited {
 			sep = i
 		}
-		_, glyphWidth := font.GlyphWidth(c)
-		l += glyphWidth
-		if l > wmax {
+		glyphWidth := f.glyphWidth(f.currentFont, c)
+		l += glyphWidth + f.kern(f.currentFont, prev, c)
+		prev = c
+		if l > wmax && !linebreak.IsSpace(c) {
 			// Automatic line break
-			if sep == -1 {
+			if end, next, ok := f.hyphenBreak(
+				[]rune(s),
+				j,
+				i,
+				0,
+				wmax,
+				measure,
+			); ok {
+				f.bidiLine = bidiText.line(j, end, string([]rune(s)[j:end])+"-")
+				f.CellFormat(
+					w,
+					lnHeight,
+					f.bidiLine.text,
+					"",
+					2,
+					"",
+					false,
+					link,
+					linkStr,
+					TextDirection(paraDir),
+				)
+				i = next
+			} else if sep == -1 {
 				if f.x > f.lMargin {
 					// Move to next line
-					f.x = f.lMargin
 					f.y += lnHeight
-					w = f.w - f.rMargin - f.x
-					wmax = (w - 2*f.cMargin) * 1000 / f.fontSize
+					startLine(f.lMargin)
 					i++
 					nl++
 					continue
@@ -2859,39 +3397,41 @@ func (f *Scribe) write(lnHeight float32, txt string, link int, linkStr string) {
 				if i == j {
 					i++
 				}
+				f.bidiLine = bidiText.line(j, i, string([]rune(s)[j:i]))
 				f.CellFormat(
 					w,
 					lnHeight,
-					string([]rune(s)[j:i]),
+					f.bidiLine.text,
 					"",
 					2,
 					"",
 					false,
 					link,
 					linkStr,
+					TextDirection(paraDir),
 				)
 			} else {
+				end := lineEnd([]rune(s), j, sep)
+				f.bidiLine = bidiText.line(j, end, string([]rune(s)[j:end]))
 				f.CellFormat(
 					w,
 					lnHeight,
-					string([]rune(s)[j:sep]),
+					f.bidiLine.text,
 					"",
 					2,
 					"",
 					false,
 					link,
 					linkStr,
+					TextDirection(paraDir),
 				)
-				i = sep + 1
+				i = sep
 			}
 			sep = -1
 			j = i
 			l = 0.0
-			if nl == 1 {
-				f.x = f.lMargin
-				w = f.w - f.rMargin - f.x
-				wmax = (w - 2*f.cMargin) * 1000 / f.fontSize
-			}
+			prev = -1
+			startLine(f.lMargin)
 			nl++
 		} else {
 			i++
@@ -2899,16 +3439,18 @@ func (f *Scribe) write(lnHeight float32, txt string, link int, linkStr string) {
 	}
 	// Last chunk
 	if i != j {
+		f.bidiLine = bidiText.line(j, nb, string([]rune(s)[j:]))
 		f.CellFormat(
 			l/1000*f.fontSize,
 			lnHeight,
-			string([]rune(s)[j:]),
+			f.bidiLine.text,
 			"",
 			0,
 			"",
 			false,
 			link,
 			linkStr,
+			TextDirection(paraDir),
 		)
 	}
 }
@@ -2921,27 +3463,29 @@ func (f *Scribe) write(lnHeight float32, txt string, link int, linkStr string) {
 // It is possible to put a link on the text.
 //
 // h indicates the line height in the unit of measure specified in New().
-func (f *Scribe) Write(h float32, txtStr string) {
-	f.write(h, txtStr, 0, "")
+//
+// dir optionally specifies the base direction of the text. See CellFormat().
+func (f *Scribe) Write(h float32, txtStr string, dir ...TextDirection) {
+	f.write(h, txtStr, 0, "", f.textDirection(dir))
 }
 
 // Writef is like Write but uses printf-style formatting. See the documentation
 // for package fmt for more details on fmtStr and args.
 func (f *Scribe) Writef(h float32, fmtStr string, args ...interface{}) {
-	f.write(h, sprintf(fmtStr, args...), 0, "")
+	f.write(h, sprintf(fmtStr, args...), 0, "", f.textDirection(nil))
 }
 
 // WriteLinkString writes text that when clicked launches an external URL. See
 // Write() for argument details.
 func (f *Scribe) WriteLinkString(h float32, displayStr, targetStr string) {
-	f.write(h, displayStr, 0, targetStr)
+	f.write(h, displayStr, 0, targetStr, f.textDirection(nil))
 }
 
 // WriteLinkID writes text that when clicked jumps to another location in the
 // PDF. linkID is an identifier returned by AddLink(). See Write() for argument
 // details.
 func (f *Scribe) WriteLinkID(h float32, displayStr string, linkID int) {
-	f.write(h, displayStr, linkID, "")
+	f.write(h, displayStr, linkID, "", f.textDirection(nil))
 }
 
 // WriteAligned is an implementation of Write that makes it possible to align
@@ -3026,10 +3570,17 @@ func (f *Scribe) imageOut(
 	name string,
 	info *ImageInfoType,
 	x, y, w, h float32,
-	allowNegativeX, flow bool,
+	options ImageOptions,
+	flow bool,
 	link int,
 	linkStr string,
 ) {
+	float := strings.ToUpper(options.Float)
+	if float != "" && float != "L" && float != "R" {
+		f.err = fmt.Errorf("invalid image float: %q", options.Float)
+		return
+	}
+
 	// Automatic width and height calculation if needed
 	if w == 0 && h == 0 {
 		// Put image at 96 dpi
@@ -3061,7 +3612,7 @@ func (f *Scribe) imageOut(
 	// Flowing mode
 	if flow {
 		if f.y+h > f.pageBreakTrigger && !f.inHeader && !f.inFooter &&
-			f.acceptPageBreak() {
+			f.acceptBreak() {
 			// Automatic page break
 			x2 := f.x
 			f.AddPageFormat(f.curOrientation, f.curPageSize)
@@ -3071,10 +3622,17 @@ func (f *Scribe) imageOut(
 			f.x = x2
 		}
 		y = f.y
-		f.y += h
+		if float == "" {
+			f.y += h
+		}
 	}
-	if !allowNegativeX {
-		if x < 0 {
+	if !options.AllowNegativePosition && x < 0 {
+		switch float {
+		case "L":
+			x = f.lMargin
+		case "R":
+			x = f.w - f.rMargin - w
+		default:
 			x = f.x
 		}
 	}
@@ -3095,6 +3653,10 @@ func (f *Scribe) imageOut(
 	if link > 0 || len(linkStr) > 0 {
 		f.newLink(x, y, w, h, link, linkStr)
 	}
+	if float != "" {
+		m := options.FloatMargin
+		f.exclusions = append(f.exclusions, exclusion.Rect(x-m, y-m, w+2*m, h+2*m))
+	}
 }
 
 // Image puts a JPEG, PNG or GIF image in the current page.
@@ -3174,7 +3736,7 @@ func (f *Scribe) ImageOptions(
 		y,
 		w,
 		h,
-		options.AllowNegativePosition,
+		options,
 		flow,
 		link,
 		linkStr,
@@ -3211,10 +3773,18 @@ func (f *Scribe) RegisterImageReader(
 //
 // AllowNegativePosition can be set to true in order to prevent the default
 // coercion of negative x values to the current x position.
+//
+// Float can be set to "L" or "R" to float the image at the left or right
+// margin, where a negative x is given, with text written by MultiCell(),
+// Write() and related functions wrapping around it. The current y position
+// isn't advanced past floating images in flowing mode. FloatMargin is the
+// space kept clear around the image. See AddExclusion().
 type ImageOptions struct {
 	ImageType             string
 	ReadDpi               bool
 	AllowNegativePosition bool
+	Float                 string
+	FloatMargin           float32
 }
 
 // RegisterImageOptionsReader registers an image, reading it from Reader r, adding it
@@ -3599,6 +4169,7 @@ func (f *Scribe) beginpage(orientationStr string, size PageSize) {
 	// f.pages = append(f.pages, bytes.NewBuffer(make([]byte, 0)))
 	f.pageLinks = append(f.pageLinks, make([]linkType, 0))
 	f.pageAttachments = append(f.pageAttachments, []annotationAttach{})
+	f.exclusions = nil
 	f.state = 2
 	f.x = f.lMargin
 	f.y = f.tMargin
@@ -3935,31 +4506,6 @@ func (f *Scribe) putBytes(b []byte) {
 	}
 }
 
-func (f *Scribe) putUtf16(s string) {
-	f.BufferGrow(2*len(s) + 2)
-
-	buf := [2]byte{}
-	for _, char := range s {
-		binary.BigEndian.PutUint16(buf[:], uint16(char))
-
-		// Escape special bytes before appending to the buffer.
-		// [TODO] See if \b and \f are needed - not escaping them doesn't seem
-		// to affect readers
-		for _, b := range buf {
-			switch b {
-			case '\\', '(', ')':
-				f.putByte('\\')
-				f.putByte(b)
-			case '\r':
-				f.putByte('\\')
-				f.putByte('r')
-			default:
-				f.putByte(b)
-			}
-		}
-	}
-}
-
 // outbuf adds a buffered line to the document
 func (f *Scribe) outbuf(r io.Reader) {
 	switch f.state {
@@ -4124,24 +4670,85 @@ func (f *Scribe) RegisterAlias(alias, replacement string) {
 }
 
 func (f *Scribe) replaceAliases() {
-	for mode := 0; mode < 2; mode++ {
-		for alias, replacement := range f.aliasMap {
-			if mode == 1 {
-				alias = f.utf8toutf16(alias, false)
-				replacement = f.utf8toutf16(replacement, false)
-			}
-			for n := 1; n <= f.page; n++ {
-				s := f.pages[n].String()
-				if strings.Contains(s, alias) {
-					s = strings.Replace(s, alias, replacement, -1)
-					f.pages[n].Truncate(0)
-					f.pages[n].WriteString(s)
-				}
+	f.endSection()
+	for _, sec := range f.sections {
+		f.replaceAlias(sec.from, sec.to, sec.alias, sprintf("%d", sec.to-sec.from+1))
+	}
+	for alias, replacement := range f.aliasMap {
+		f.replaceAlias(1, f.page, alias, replacement)
+	}
+	if f.aliasLabelStr != "" {
+		for n := 1; n <= f.page; n++ {
+			f.replaceAlias(n, n, f.aliasLabelStr, f.PageLabel(n))
+		}
+	}
+}
+
+// replaceAlias replaces all occurrences of alias in the content of pages
+// first to last with replacement, in plain text and in the glyph IDs of each
+// font used.
+func (f *Scribe) replaceAlias(first, last int, alias, replacement string) {
+	f.replacePageText(first, last, alias, replacement)
+
+	// Text output is encoded as glyph IDs, which differ between fonts.
+	for id := range f.fonts.Len() {
+		if f.usedGlyphs[id].Count() == 0 {
+			continue
+		}
+
+		font := f.fonts.Get(ttf.Id(id)).Font()
+		if !f.replacePageText(
+			first,
+			last,
+			glyphString(font, alias),
+			glyphString(font, replacement),
+		) {
+			continue
+		}
+
+		if f.glyphText[id] == nil {
+			f.glyphText[id] = map[uint16]string{}
+		}
+		for _, char := range replacement {
+			gid := font.GlyphId(char)
+			f.usedGlyphs[id].Set(uint(gid))
+			if _, ok := f.glyphText[id][gid]; !ok {
+				f.glyphText[id][gid] = string(char)
 			}
 		}
 	}
 }
 
+// replacePageText replaces all occurrences of alias in the content of pages
+// first to last with replacement and returns true if there were any.
+func (f *Scribe) replacePageText(
+	first, last int,
+	alias, replacement string,
+) (replaced bool) {
+	for n := first; n <= last; n++ {
+		s := f.pages[n].String()
+		if strings.Contains(s, alias) {
+			s = strings.Replace(s, alias, replacement, -1)
+			f.pages[n].Truncate(0)
+			f.pages[n].WriteString(s)
+			replaced = true
+		}
+	}
+
+	return
+}
+
+// glyphString encodes s as an escaped string of glyph IDs in font, as written
+// by textArray() for unshaped text.
+func glyphString(font *ttf.Font, s string) string {
+	var buf strings.Builder
+	for _, char := range s {
+		putGlyphId(&buf, font.GlyphId(char))
+	}
+
+	return buf.String()
+}
+
 func (f *Scribe) putpages() {
 	var wPt, hPt float32
 	var pageSize PageSize
@@ -4149,7 +4756,7 @@ func (f *Scribe) putpages() {
 	nb := f.page
 	if len(f.aliasNbPagesStr) > 0 {
 		// Replace number of pages
-		f.RegisterAlias(f.aliasNbPagesStr, sprintf("%d", nb))
+		f.RegisterAlias(f.aliasNbPagesStr, f.PageLabel(nb))
 	}
 	f.replaceAliases()
 	if f.defOrientation == "P" {
@@ -4200,23 +4807,16 @@ func (f *Scribe) putpages() {
 					pl.x+pl.wd,
 					pl.y-pl.ht,
 				)
-				if pl.link == 0 {
+				if pl.action != nil {
+					annots.printf("/A %s>>", f.actionDict(pl.action))
+				} else if pl.link == 0 {
 					annots.printf(
 						"/A <</S /URI /URI %s>>>>",
 						f.textstring(pl.linkStr),
 					)
 				} else {
 					l := f.links[pl.link]
-					var sz PageSize
-					var h float32
-					sz, ok = f.pageSizes[l.page]
-					if ok {
-						h = sz.Ht
-					} else {
-						h = hPt
-					}
-					// dbg("h [%g], l.y [%g] f.k [%g]\n", h, l.y, f.k)
-					annots.printf("/Dest [%d 0 R /XYZ 0 %g null]>>", 1+2*l.page, h-l.y)
+					annots.printf("/Dest %s>>", f.destArray(Dest{Page: l.page, Y: l.y}))
 				}
 			}
 			f.putAttachmentAnnotationLinks(&annots, n)
@@ -4272,8 +4872,9 @@ func (f *Scribe) putpages() {
 	f.out("endobj")
 }
 
-// CID map Init
-const toUnicode = `/CIDInit /ProcSet findresource begin
+// ToUnicode CMap, with glyph mappings inserted between the header and
+// trailer.
+const toUnicodeHeader = `/CIDInit /ProcSet findresource begin
 12 dict begin
 begincmap
 /CIDSystemInfo
@@ -4286,16 +4887,18 @@ begincmap
 1 begincodespacerange
 <0000> <FFFF>
 endcodespacerange
-1 beginbfrange
-<0000> <FFFF> <0000>
-endbfrange
-endcmap
+`
+
+const toUnicodeTrailer = `endcmap
 CMapName currentdict /CMap defineresource pop
 end
 end`
 
 const cidSystemInfo = `<</Registry (Adobe) /Ordering (UCS) /Supplement 0>> `
 
+// CIDSystemInfo for CFF fonts, which are subset with an identity charset.
+const cidSystemInfoIdentity = `<</Registry (Adobe) /Ordering (Identity) /Supplement 0>>`
+
 func (f *Scribe) putfonts() {
 	if f.err != nil {
 		return
@@ -4309,12 +4912,6 @@ func (f *Scribe) putfonts() {
 		f.out("endobj")
 	}
 	{
-		f.newobj()
-		toUnicodeObjId := f.n
-		f.out("<</Length " + strconv.Itoa(len(toUnicode)) + " >>")
-		f.putstream([]byte(toUnicode))
-		f.out("endobj")
-
 		f.newobj()
 		cidSystemInfoObjId := f.n
 		f.out(cidSystemInfo)
@@ -4324,10 +4921,36 @@ func (f *Scribe) putfonts() {
 			fontInfo := f.fonts.Get(ttf.Id(id))
 			font := fontInfo.Font()
 
-			if f.usedRunes[id].Count() == 0 {
+			if f.usedGlyphs[id].Count() == 0 {
 				continue
 			}
 
+			usedGlyphs := f.usedGlyphs[id].AsSlice(
+				make([]uint, f.usedGlyphs[id].Count()),
+			)
+
+			toUnicode := f.toUnicode(usedGlyphs, f.glyphText[id])
+			f.newobj()
+			toUnicodeObjId := f.n
+			f.out("<</Length " + strconv.Itoa(len(toUnicode)) + " >>")
+			f.putstream(toUnicode)
+			f.out("endobj")
+
+			// Fonts used for vertical text get a second Type0 font, with
+			// vertical encoding, sharing the same CIDFont.
+			if f.usedVertical[id] {
+				f.newobj()
+				f.fontVertObjIds[id] = f.n
+				f.out(
+					fmt.Sprintf(
+						"<</Type /Font\n/Subtype /Type0\n/BaseFont /utf8%s\n/Encoding /Identity-V\n/DescendantFonts [%d 0 R]\n/ToUnicode %d 0 R>>\nendobj",
+						fontInfo.String(),
+						f.n+2,
+						toUnicodeObjId,
+					),
+				)
+			}
+
 			f.fontObjIds[id] = f.n + 1
 			tp := "UTF8"
 			switch tp {
@@ -4338,7 +4961,7 @@ func (f *Scribe) putfonts() {
 				var gidRemap []uint16
 				utf8FontStream, gidRemap, f.err = ttf.Generate(
 					font,
-					&f.usedRunes[id],
+					&f.usedGlyphs[id],
 					utf8FontStream,
 				)
 				if f.err != nil {
@@ -4346,10 +4969,6 @@ func (f *Scribe) putfonts() {
 				}
 				utf8FontSize := len(utf8FontStream)
 
-				usedRunes := f.usedRunes[id].AsSlice(
-					make([]uint, f.usedRunes[id].Count()),
-				)
-
 				f.newobj()
 				f.out(
 					fmt.Sprintf(
@@ -4360,12 +4979,20 @@ func (f *Scribe) putfonts() {
 					),
 				)
 
+				// CFF fonts are embedded as CID-keyed CFF, with glyph IDs as
+				// CIDs. TrueType fonts map CIDs to glyph IDs via CIDToGIDMap.
+				cidSystemInfoRef := strconv.Itoa(int(cidSystemInfoObjId)) + " 0 R"
+				cidFontType := "CIDFontType2"
+				if font.Cff() {
+					cidSystemInfoRef = cidSystemInfoIdentity
+					cidFontType = "CIDFontType0"
+				}
+
 				f.newobj()
 				f.out(
-					"<</Type /Font\n/Subtype /CIDFontType2\n/BaseFont /" + fontName + "\n" +
-						"/CIDSystemInfo " + strconv.Itoa(
-						int(cidSystemInfoObjId),
-					) + " 0 R\n/FontDescriptor " + strconv.Itoa(
+					"<</Type /Font\n/Subtype /" + cidFontType + "\n/BaseFont /" + fontName + "\n" +
+						"/CIDSystemInfo " + cidSystemInfoRef +
+						"\n/FontDescriptor " + strconv.Itoa(
 						int(f.n)+1,
 					) + " 0 R",
 				)
@@ -4375,26 +5002,30 @@ func (f *Scribe) putfonts() {
 				}
 
 				{
-					lastChar := uint16(usedRunes[0])
-					f.put("/W [ " + strconv.Itoa(int(lastChar)) + " [ ")
-					for _, c := range usedRunes {
-						char := uint16(c)
-						if char < lastChar || char-lastChar > 1 {
-							f.put("] " + strconv.Itoa(int(char)) + " [ ")
+					lastGid := uint16(usedGlyphs[0])
+					f.put("/W [ " + strconv.Itoa(int(lastGid)) + " [ ")
+					for _, g := range usedGlyphs {
+						gid := uint16(g)
+						if gid < lastGid || gid-lastGid > 1 {
+							f.put("] " + strconv.Itoa(int(gid)) + " [ ")
 						}
 
-						gid := font.GlyphId(rune(char))
-						f.put(
-							f.fmtF64(font.Width(uint16(gid)), -1) + " ",
-						)
+						f.put(f.fmtF64(font.Width(gid), -1) + " ")
 
-						lastChar = char
-						gid += 1
+						lastGid = gid
 					}
 					f.put("] ]\n")
 				}
 
-				f.out("/CIDToGIDMap " + strconv.Itoa(int(f.n)+2) + " 0 R>>")
+				if f.usedVertical[id] {
+					f.putVerticalMetrics(font, usedGlyphs)
+				}
+
+				if font.Cff() {
+					f.out(">>")
+				} else {
+					f.out("/CIDToGIDMap " + strconv.Itoa(int(f.n)+2) + " 0 R>>")
+				}
 				f.out("endobj")
 
 				// Font descriptor
@@ -4407,10 +5038,10 @@ func (f *Scribe) putfonts() {
 				)
 				s.printf(" /Descent %g", font.Descent)
 				s.printf(" /CapHeight %g", font.CapHeight)
-				v := font.Flags
-				v = v | 4
-				v = v &^ 32
-				s.printf(" /Flags %d", v)
+				if font.XHeight != 0 {
+					s.printf(" /XHeight %g", font.XHeight)
+				}
+				s.printf(" /Flags %d", font.Flags)
 				s.printf(
 					"/FontBBox [%g %g %g %g] ",
 					font.Bounds.Min[0],
@@ -4419,25 +5050,45 @@ func (f *Scribe) putfonts() {
 					font.Bounds.Max[1],
 				)
 				s.printf(" /ItalicAngle %g", font.ItalicAngle)
-				// s.printf(" /StemV %d", font.StemV)
-				s.printf(" /StemV %d", 80) // [TODO] Derive from font metrics
+				s.printf(" /StemV %g", font.StemV)
 				s.printf(" /MissingWidth %g", defaultWidth)
-				s.printf("/FontFile2 %d 0 R", f.n+2)
+				if font.Cff() {
+					s.printf("/FontFile3 %d 0 R", f.n+1)
+				} else {
+					s.printf("/FontFile2 %d 0 R", f.n+2)
+				}
 				s.printf(">>")
 				f.out(s.String())
 				f.out("endobj")
 
+				if font.Cff() {
+					mem := xmem.compress(utf8FontStream)
+					compressedFontStream := mem.bytes()
+					f.newobj()
+					f.out(
+						"<</Length " + strconv.Itoa(len(compressedFontStream)) +
+							"/Filter /FlateDecode /Subtype /CIDFontType0C>>",
+					)
+					f.putstream(compressedFontStream)
+					f.out("endobj")
+					mem.release()
+					continue
+				}
+
 				// Embed CIDToGIDMap
-				cidToGidMap := [256 * 256 * 2]byte{}
-				for _, char := range usedRunes {
-					gidOld := font.GlyphId(rune(char))
+				// CIDs are the glyph IDs of the original font.
+				cidToGidMap := make(
+					[]byte,
+					2*(usedGlyphs[len(usedGlyphs)-1]+1),
+				)
+				for _, gid := range usedGlyphs {
 					binary.BigEndian.PutUint16(
-						cidToGidMap[uint32(char)*2:],
-						gidRemap[gidOld],
+						cidToGidMap[gid*2:],
+						gidRemap[gid],
 					)
 				}
 
-				mem := xmem.compress(cidToGidMap[:])
+				mem := xmem.compress(cidToGidMap)
 				cidToGidMapCompressed := mem.bytes()
 				f.newobj()
 				f.out(
@@ -4472,6 +5123,38 @@ func (f *Scribe) putfonts() {
 	}
 }
 
+// toUnicode returns a ToUnicode CMap mapping the given glyph IDs to the text
+// they represent, for text extraction.
+func (f *Scribe) toUnicode(gids []uint, text map[uint16]string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(toUnicodeHeader)
+
+	const chunkLen = 100 // Maximum entries per bfchar block.
+
+	var mapped []uint16
+	for _, gid := range gids {
+		if text[uint16(gid)] != "" {
+			mapped = append(mapped, uint16(gid))
+		}
+	}
+
+	for chunk := range slices.Chunk(mapped, chunkLen) {
+		fmt.Fprintf(&buf, "%d beginbfchar\n", len(chunk))
+		for _, gid := range chunk {
+			fmt.Fprintf(&buf, "<%04X> <", gid)
+			for _, unit := range utf16.Encode([]rune(text[gid])) {
+				fmt.Fprintf(&buf, "%04X", unit)
+			}
+			buf.WriteString(">\n")
+		}
+		buf.WriteString("endbfchar\n")
+	}
+
+	buf.WriteString(toUnicodeTrailer)
+
+	return buf.Bytes()
+}
+
 func (f *Scribe) putimages() {
 	keyList := make([]string, len(f.images))
 	var key string
@@ -4641,7 +5324,7 @@ func (f *Scribe) putresourcedict() {
 	f.out("/ProcSet [/PDF /Text /ImageB /ImageC /ImageI]")
 	f.out("/Font <<")
 	for id := range f.fonts.Len() {
-		if f.usedRunes[id].Count() == 0 {
+		if f.usedGlyphs[id].Count() == 0 {
 			continue
 		}
 
@@ -4650,6 +5333,14 @@ func (f *Scribe) putresourcedict() {
 		f.put(" ")
 		f.put(strconv.Itoa(int(f.fontObjIds[id])))
 		f.out(" 0 R")
+
+		if f.fontVertObjIds[id] != 0 {
+			f.put("/F")
+			f.put(strconv.Itoa(id))
+			f.put("V ")
+			f.put(strconv.Itoa(int(f.fontVertObjIds[id])))
+			f.out(" 0 R")
+		}
 	}
 	f.out(">>")
 	f.out("/XObject <<")
@@ -4872,6 +5563,8 @@ func (f *Scribe) putcatalog() {
 		}
 		f.out("/PageLayout /" + f.layoutMode)
 	}
+	// Page labels
+	f.putPageLabels()
 	// Bookmarks
 	if len(f.outlines) > 0 {
 		f.outf("/Outlines %d 0 R", f.outlineRoot)
@@ -4886,6 +5579,7 @@ func (f *Scribe) putcatalog() {
 	// Name dictionary :
 	//	-> Javascript
 	//	-> Embedded files
+	//	-> Named destinations
 	f.out("/Names <<")
 	// JavaScript
 	if f.javascript != nil {
@@ -4893,6 +5587,8 @@ func (f *Scribe) putcatalog() {
 	}
 	// Embedded files
 	f.outf("/EmbeddedFiles %s", f.getEmbeddedFiles())
+	// Named destinations
+	f.putNamedDests()
 	f.out(">>")
 }
 
@@ -4946,12 +5642,23 @@ func (f *Scribe) putbookmarks() {
 			lru[o.level] = i
 			level = o.level
 		}
+		// The number of entries below each entry shown when it's open, with
+		// the entries below them that are open too, and those at the top level.
+		count := make([]int, nb+1)
+		for i := nb - 1; i >= 0; i-- {
+			o := f.outlines[i]
+			count[o.parent]++
+			if o.open {
+				count[o.parent] += count[i]
+			}
+		}
+
 		n := f.n + 1
-		for _, o := range f.outlines {
+		for i, o := range f.outlines {
 			f.newobj()
 			f.outf("<</Title %s", f.textstring(o.text))
 			f.outf("/Parent %d 0 R", n+uint32(o.parent))
-			if o.prev > 0 {
+			if o.prev >= 0 {
 				f.outf("/Prev %d 0 R", n+uint32(o.prev))
 			}
 			if o.next > 0 {
@@ -4963,14 +5670,34 @@ func (f *Scribe) putbookmarks() {
 			if o.last > 0 {
 				f.outf("/Last %d 0 R", n+uint32(o.last))
 			}
-			f.outf("/Dest [%d 0 R /XYZ 0 %g null]", 1+2*o.p, (f.h - o.y))
-			f.out("/Count 0>>")
+			switch {
+			case o.uri != "":
+				f.outf("/A <</S /URI /URI %s>>", f.textstring(o.uri))
+			case o.namedDest != "":
+				f.outf("/Dest %s", f.textstring(o.namedDest))
+			default:
+				f.outf("/Dest %s", f.destArray(o.dest))
+			}
+			if o.flags != 0 {
+				f.outf("/F %d", o.flags)
+			}
+			if c := o.color; c != nil {
+				f.outf("/C [%.3f %.3f %.3f]", float32(c.R)/255, float32(c.G)/255, float32(c.B)/255)
+			}
+			if count[i] > 0 {
+				if o.open {
+					f.outf("/Count %d", count[i])
+				} else {
+					f.outf("/Count %d", -count[i])
+				}
+			}
+			f.out(">>")
 			f.out("endobj")
 		}
 		f.newobj()
 		f.outlineRoot = f.n
 		f.outf("<</Type /Outlines /First %d 0 R", n)
-		f.outf("/Last %d 0 R>>", n+uint32(lru[0]))
+		f.outf("/Last %d 0 R /Count %d>>", n+uint32(lru[0]), count[nb])
 		f.out("endobj")
 	}
 }
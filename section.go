@@ -0,0 +1,44 @@
+package scribe
+
+// StartSection starts a section of the document from the next page added,
+// e.g. for each of several documents bundled in one. Pages are numbered from 1
+// in each section, by SectionPageNo(), and the alias set with
+// AliasSectionPages() is replaced with the number of pages in the section
+// when the document is closed. The document starts with a section from its
+// first page.
+func (f *Scribe) StartSection() {
+	f.nextSection = f.page + 1
+}
+
+// SectionPageNo returns the current page number within the current section.
+// See StartSection().
+func (f *Scribe) SectionPageNo() int {
+	return f.page - max(f.sectionFrom, 1) + 1
+}
+
+// AliasSectionPages defines an alias for the number of pages in the section
+// it's written in. See StartSection(). It should be set before the first
+// section ends. An empty string is replaced with the string "{snb}".
+func (f *Scribe) AliasSectionPages(aliasStr string) {
+	if aliasStr == "" {
+		aliasStr = "{snb}"
+	}
+	f.aliasSectionStr = aliasStr
+}
+
+// section is a range of pages started with StartSection(), with the alias
+// that's replaced with its number of pages.
+type section struct {
+	from, to int
+	alias    string
+}
+
+// endSection ends the current section with the current page, for its page
+// count alias to be replaced on Close().
+func (f *Scribe) endSection() {
+	from := max(f.sectionFrom, 1)
+	if f.aliasSectionStr != "" && f.page >= from {
+		f.sections = append(f.sections, section{from, f.page, f.aliasSectionStr})
+	}
+	f.sectionFrom = f.page + 1
+}
@@ -0,0 +1,155 @@
+package scribe
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/kofi-q/scribe-go/internal/exclusion"
+)
+
+// snapshot is the state of a document, for restoring it after output is
+// written. Glyphs used by the output removed stay in the font subsets, which
+// is harmless, rather than copying the sets of used glyphs for each snapshot.
+type snapshot struct {
+	page, pages int
+	content     int
+
+	outlines, namedDests   int
+	tocs, sections         int
+	pageLinks, attachments int
+
+	sectionFrom, nextSection int
+
+	links      []intLinkType
+	pageLabels []pageLabel
+	aliases    map[string]string
+	pageSizes  map[int]PageSize
+	pageBoxes  map[int]map[string]PageBox
+	exclusions []exclusion.Polygon
+	columns    *columnLayout
+	footnotes  footnoteState
+
+	x, y, lasth      float32
+	lMargin, rMargin float32
+	pageBreakTrigger float32
+	bMargin          float32
+	autoPageBreak    bool
+
+	w, h, wPt, hPt float32
+	curOrientation string
+	curPageSize    PageSize
+
+	font      FontId
+	fontStyle FontStyle
+	fontSize  float32
+	color     struct{ draw, fill, text colorType }
+	colorFlag bool
+	lineWidth float32
+	ws        float32
+
+	capStyle, joinStyle int
+	dashArray           []float32
+	dashPhase           float32
+}
+
+// snapshot returns the state of the document.
+func (f *Scribe) snapshot() snapshot {
+	return snapshot{
+		page:             f.page,
+		pages:            len(f.pages),
+		content:          f.pages[f.page].Len(),
+		outlines:         len(f.outlines),
+		namedDests:       len(f.namedDests),
+		tocs:             len(f.tocs),
+		sections:         len(f.sections),
+		pageLinks:        len(f.pageLinks[f.page]),
+		attachments:      len(f.pageAttachments[f.page]),
+		sectionFrom:      f.sectionFrom,
+		nextSection:      f.nextSection,
+		links:            slices.Clone(f.links),
+		pageLabels:       slices.Clone(f.pageLabels),
+		aliases:          maps.Clone(f.aliasMap),
+		pageSizes:        maps.Clone(f.pageSizes),
+		pageBoxes:        maps.Clone(f.pageBoxes),
+		exclusions:       slices.Clone(f.exclusions),
+		columns:          f.columns.clone(),
+		footnotes:        f.footnotes.clone(),
+		x:                f.x,
+		y:                f.y,
+		lasth:            f.lasth,
+		lMargin:          f.lMargin,
+		rMargin:          f.rMargin,
+		pageBreakTrigger: f.pageBreakTrigger,
+		bMargin:          f.bMargin,
+		autoPageBreak:    f.autoPageBreak,
+		w:                f.w,
+		h:                f.h,
+		wPt:              f.wPt,
+		hPt:              f.hPt,
+		curOrientation:   f.curOrientation,
+		curPageSize:      f.curPageSize,
+		font:             f.currentFont,
+		fontStyle:        f.fontStyle,
+		fontSize:         f.fontSizePt,
+		color:            f.color,
+		colorFlag:        f.colorFlag,
+		lineWidth:        f.lineWidth,
+		ws:               f.ws,
+		capStyle:         f.capStyle,
+		joinStyle:        f.joinStyle,
+		dashArray:        slices.Clone(f.dashArray),
+		dashPhase:        f.dashPhase,
+	}
+}
+
+// restore restores the document to a snapshot, removing any output written
+// since.
+func (f *Scribe) restore(s snapshot) {
+	f.err = nil
+	f.page = s.page
+	f.pages = f.pages[:s.pages]
+	f.pages[f.page].Truncate(s.content)
+	f.pageLinks = f.pageLinks[:s.pages]
+	f.pageLinks[f.page] = f.pageLinks[f.page][:s.pageLinks]
+	f.pageAttachments = f.pageAttachments[:s.pages]
+	f.pageAttachments[f.page] = f.pageAttachments[f.page][:s.attachments]
+	f.outlines = f.outlines[:s.outlines]
+	f.namedDests = f.namedDests[:s.namedDests]
+	f.tocs = f.tocs[:s.tocs]
+	f.sections = f.sections[:s.sections]
+	f.sectionFrom, f.nextSection = s.sectionFrom, s.nextSection
+	f.links = slices.Clone(s.links)
+	f.pageLabels = slices.Clone(s.pageLabels)
+	f.aliasMap = maps.Clone(s.aliases)
+	f.pageSizes = maps.Clone(s.pageSizes)
+	f.pageBoxes = maps.Clone(s.pageBoxes)
+	f.exclusions = slices.Clone(s.exclusions)
+	f.footnotes = s.footnotes.clone()
+	if s.columns != nil {
+		*f.columns = *s.columns
+	}
+
+	f.x, f.y, f.lasth = s.x, s.y, s.lasth
+	f.lMargin, f.rMargin = s.lMargin, s.rMargin
+	f.pageBreakTrigger, f.bMargin = s.pageBreakTrigger, s.bMargin
+	f.autoPageBreak = s.autoPageBreak
+	f.w, f.h, f.wPt, f.hPt = s.w, s.h, s.wPt, s.hPt
+	f.curOrientation, f.curPageSize = s.curOrientation, s.curPageSize
+
+	f.currentFont, f.fontStyle = s.font, s.fontStyle
+	f.fontSizePt, f.fontSize = s.fontSize, s.fontSize/f.k
+	f.color, f.colorFlag = s.color, s.colorFlag
+	f.lineWidth, f.ws = s.lineWidth, s.ws
+	f.capStyle, f.joinStyle = s.capStyle, s.joinStyle
+	f.dashArray, f.dashPhase = slices.Clone(s.dashArray), s.dashPhase
+}
+
+// clone returns a copy of the layout, or nil outside columns.
+func (c *columnLayout) clone() *columnLayout {
+	if c == nil {
+		return nil
+	}
+
+	clone := *c
+	return &clone
+}
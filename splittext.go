@@ -7,7 +7,8 @@ package scribe
 import (
 	"fmt"
 	"math"
-	"unicode"
+
+	"github.com/kofi-q/scribe-go/internal/linebreak"
 )
 
 // TextSplit splits UTF-8 encoded text into several lines using the current
@@ -31,51 +32,70 @@ func (f *Scribe) TextSplit(text string, width float32) (lines []string) {
 	)
 	lines = make([]string, 0, lineCountEstimate)
 
-	ixChar := 0
+	glyphWidth := func(_, char rune) float32 {
+		return f.glyphWidth(font.id, char)
+	}
+
+	runes := []rune(text)
+	ops := linebreak.Opportunities(runes)
+
 	ixBreak := -1
-	ixBreakIsSpace := true
 	ixLineStart := 0
 	lenLine := 0
 
-	var char rune
-	for ixChar, char = range text {
-		lenLine += int(f.fonts.Get(font.id).GlyphWidthOnly(char))
+	for ixChar := 0; ixChar < len(runes); ixChar++ {
+		char := runes[ixChar]
+		if char == '\n' {
+			lines = append(lines, string(runes[ixLineStart:ixChar]))
+			ixLineStart = ixChar + 1
+			ixBreak = -1
+			lenLine = 0
+			continue
+		}
 
-		isSpace := unicode.IsSpace(char)
-		if isSpace || isChinese(char) {
+		if ixChar > ixLineStart && ops[ixChar] != linebreak.Prohibited {
 			ixBreak = ixChar
-			ixBreakIsSpace = isSpace
 		}
 
-		if char == '\n' || lenLine > widthMax {
-			nextIxLineStart := ixBreak
-			if ixBreakIsSpace {
-				nextIxLineStart += 1
-			}
-			if ixBreak == -1 && char != '\n' {
-				if ixChar == ixLineStart {
-					continue
-				}
-
-				ixBreak = ixChar
-				nextIxLineStart = ixBreak
-			}
-
-			lines = append(lines, text[ixLineStart:ixBreak])
-			ixLineStart = nextIxLineStart
-
-			lenLine = 0
-			for _, char := range text[ixLineStart : ixChar+1] {
-				lenLine += int(f.fonts.Get(font.id).GlyphWidthOnly(char))
-			}
+		// Spaces at the end of a line are dropped, rather than breaking it.
+		lenLine += int(f.glyphWidth(font.id, char))
+		if lenLine <= widthMax || linebreak.IsSpace(char) {
+			continue
+		}
 
-			ixBreak = -1
-			ixBreakIsSpace = true
+		end, next, ok := f.hyphenBreak(
+			runes,
+			ixLineStart,
+			ixChar,
+			0,
+			float32(widthMax),
+			glyphWidth,
+		)
+		switch {
+		case ok:
+			lines = append(lines, string(runes[ixLineStart:end])+"-")
+			ixLineStart = next
+		case ixBreak != -1:
+			end = lineEnd(runes, ixLineStart, ixBreak)
+			lines = append(lines, string(runes[ixLineStart:end]))
+			ixLineStart = ixBreak
+		case ixChar > ixLineStart:
+			lines = append(lines, string(runes[ixLineStart:ixChar]))
+			ixLineStart = ixChar
+		default:
+			// Too narrow for a single character.
+			lines = append(lines, string(char))
+			ixLineStart = ixChar + 1
 		}
+
+		// Measure the next line from its start.
+		ixChar = ixLineStart - 1
+		ixBreak = -1
+		lenLine = 0
 	}
 
-	if len(text[ixLineStart:]) > 0 {
-		lines = append(lines, text[ixLineStart:])
+	if ixLineStart < len(runes) {
+		lines = append(lines, string(runes[ixLineStart:]))
 	}
 
 	return
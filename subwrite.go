@@ -35,7 +35,7 @@ func (f *Scribe) SubWrite(
 	subY := f.y
 	f.SetXY(subX, subY-subOffset)
 	//Output text
-	f.write(ht, str, link, linkStr)
+	f.write(ht, str, link, linkStr, f.textDirection(nil))
 	// restore y position
 	subX = f.x
 	subY = f.y
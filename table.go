@@ -0,0 +1,540 @@
+package scribe
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+
+	"github.com/kofi-q/scribe-go/internal/table"
+)
+
+// TableColumn is the width of a column of a Table: a fixed Width, in the unit
+// of measure specified in New(), or a Percent of the width of the table.
+// Columns with neither share the width left, in proportion to the width of
+// their text, measured with GetStringWidth().
+type TableColumn struct {
+	Width   float32
+	Percent float32
+}
+
+// CellStyle is the style of the cells of a Table. Zero values select the
+// style of the table. See Table.Style().
+type CellStyle struct {
+	// Border is the border of the cell, as in CellFormat(): "1" for a full
+	// border, or any of "L", "T", "R" and "B" for its sides. Use "0" for no
+	// border.
+	Border string
+
+	// Fill is the background color of the cell. nil leaves it transparent.
+	Fill *RGBType
+
+	// Padding is the space between the border and the text of the cell, in
+	// the unit of measure specified in New().
+	Padding float32
+
+	// Align is the alignment of the text of the cell: "L", "C", "R" or "J"
+	// horizontally (left, center, right or justified), and "T", "M" or "B"
+	// vertically (top, middle or bottom).
+	Align string
+}
+
+// TableCell is a cell of a Table. Text is wrapped to the width of the cell,
+// and broken into lines at \n characters.
+type TableCell struct {
+	Text string
+
+	// ColSpan and RowSpan are the numbers of columns and rows the cell
+	// spans. Zero values are treated as 1.
+	ColSpan, RowSpan int
+
+	CellStyle
+}
+
+// Table lays out rows of cells in columns, in the current font. Rows are as
+// tall as their tallest cell, and cells span rows and columns.
+//
+// When a table reaches the bottom margin, it's continued on a new page, with
+// its header rows repeated. Rows are moved to the new page whole, unless
+// SplitRows() is set, in which case they're split between lines of text. Rows
+// spanned by a cell are kept together, and rows taller than a page are always
+// split.
+//
+//	tbl := pdf.NewTable(scribe.TableColumn{Width: 30}, scribe.TableColumn{})
+//	tbl.Header(scribe.TableCell{Text: "Name"}, scribe.TableCell{Text: "Notes"})
+//	tbl.Row(scribe.TableCell{Text: "a"}, scribe.TableCell{Text: "..."})
+//	tbl.Write(0)
+type Table struct {
+	f    *Scribe
+	cols []TableColumn
+	rows [][]TableCell
+
+	headerRows int
+	style      CellStyle
+	lineHeight float32
+	splitRows  bool
+}
+
+// tableCell is a cell of a Table laid out in its grid.
+type tableCell struct {
+	table.Cell
+	CellStyle
+
+	lines []tableLine
+}
+
+// tableLine is a line of text in a cell, with the paragraph it's reordered
+// with for display. The last line of each paragraph isn't justified.
+type tableLine struct {
+	text string
+	last bool
+	bidi *bidiLine
+}
+
+// NewTable starts a table with the given columns. Cells have a full border,
+// the cell margin as their padding (see SetCellMargin()), and text aligned to
+// the top left, unless set with Style().
+func (f *Scribe) NewTable(cols ...TableColumn) *Table {
+	return &Table{
+		f:    f,
+		cols: cols,
+		style: CellStyle{
+			Border:  "1",
+			Padding: f.cMargin,
+			Align:   "LT",
+		},
+	}
+}
+
+// Header adds a header row, which is repeated at the top of each page the
+// table continues on. Header rows must be added before other rows.
+func (t *Table) Header(cells ...TableCell) *Table {
+	if len(t.rows) > t.headerRows {
+		t.f.err = fmt.Errorf("table header rows must be added before other rows")
+		return t
+	}
+
+	t.headerRows++
+	return t.Row(cells...)
+}
+
+// Row adds a row of cells. Cells take the next columns not covered by cells
+// spanning rows from above, and cells beyond the last column are dropped.
+func (t *Table) Row(cells ...TableCell) *Table {
+	t.rows = append(t.rows, cells)
+	return t
+}
+
+// Style sets the style of the table's cells, for any fields not set by the
+// cells themselves.
+func (t *Table) Style(style CellStyle) *Table {
+	t.style.Border = cmp.Or(style.Border, t.style.Border)
+	t.style.Fill = cmp.Or(style.Fill, t.style.Fill)
+	t.style.Padding = cmp.Or(style.Padding, t.style.Padding)
+	t.style.Align = cmp.Or(style.Align, t.style.Align)
+	return t
+}
+
+// LineHeight sets the height of lines of text in cells, in the unit of
+// measure specified in New(). The default is 1.2 times the font size.
+func (t *Table) LineHeight(height float32) *Table {
+	t.lineHeight = height
+	return t
+}
+
+// SplitRows sets whether rows that don't fit at the bottom of a page are split
+// between lines of text, rather than moved to the next page.
+func (t *Table) SplitRows(split bool) *Table {
+	t.splitRows = split
+	return t
+}
+
+// Write outputs the table at the current position, with the given width. A
+// width of zero extends the table to the right margin. The current position
+// after the call is the left margin, below the table.
+func (t *Table) Write(width float32) {
+	f := t.f
+	if f.err != nil {
+		return
+	}
+
+	if width == 0 {
+		width = f.w - f.rMargin - f.x
+	}
+	lh := cmp.Or(t.lineHeight, 1.2*f.fontSize)
+
+	cells, heights, colX := t.layout(width, lh)
+	groups := table.Groups(t.cellGrid(cells), len(t.rows))
+
+	// Page breaks are made between rows here, rather than in CellFormat().
+	accept := f.acceptPageBreak
+	never := func() bool { return false }
+	f.acceptPageBreak = never
+	cMargin := f.cMargin
+	defer func() {
+		f.acceptPageBreak = accept
+		f.cMargin = cMargin
+	}()
+
+	// The offset of the table from the left margin, which is kept when it
+	// moves to a new page or column.
+	dx := f.x - f.lMargin
+	headerEnd := 0
+	for _, end := range groups {
+		if headerEnd < t.headerRows {
+			headerEnd = end
+		}
+	}
+
+	var headerHeight float32
+	for _, h := range heights[:headerEnd] {
+		headerHeight += h
+	}
+	pageHeight := f.pageBreakTrigger - f.tMargin
+
+	// breakTable moves to the next column or page, if accepted, and reports
+	// whether it did.
+	breakTable := func() bool {
+		page, lMargin := f.page, f.lMargin
+		f.acceptPageBreak = accept
+		if f.acceptBreak() {
+			f.AddPageFormat(f.curOrientation, f.curPageSize)
+		}
+		f.acceptPageBreak = never
+		f.x = f.lMargin + dx
+
+		return f.page != page || f.lMargin != lMargin
+	}
+	newPage := func() bool {
+		if !breakTable() {
+			return false
+		}
+		t.writeRows(cells, heights, colX, f.x, 0, headerEnd, nil)
+		return true
+	}
+
+	start := 0
+	for ix, end := range groups {
+		var height float32
+		for _, h := range heights[start:end] {
+			height += h
+		}
+
+		// Header rows are kept with the rows after them, where they fit on a
+		// page together.
+		need := height
+		if end == headerEnd && ix+1 < len(groups) {
+			var next float32
+			for _, h := range heights[end:groups[ix+1]] {
+				next += h
+			}
+			if height+next <= pageHeight {
+				need += next
+			}
+		}
+
+		// Rows taller than a page are split, as they'd never fit on one.
+		split := end-start == 1 && start >= headerEnd &&
+			(t.splitRows || height > pageHeight-headerHeight)
+
+		if f.y+need > f.pageBreakTrigger && !f.inHeader && !f.inFooter {
+			switch {
+			case split:
+				t.splitRow(cells, heights, colX, dx, start, lh, newPage)
+			case start < headerEnd:
+				breakTable()
+			default:
+				newPage()
+			}
+			if f.err != nil {
+				return
+			}
+		}
+
+		t.writeRows(cells, heights, colX, f.lMargin+dx, start, end, nil)
+		if f.err != nil {
+			return
+		}
+		start = end
+	}
+
+	f.x = f.lMargin
+}
+
+// layout places the table's cells, breaks their text into lines as a Paragraph
+// does, and returns them with the heights of the rows and the positions of the
+// columns, relative to the left of the table, with an extra position for its
+// right.
+func (t *Table) layout(
+	width, lh float32,
+) (cells []tableCell, heights, colX []float32) {
+	f := t.f
+
+	spans := make([][]table.Span, len(t.rows))
+	for r, row := range t.rows {
+		for _, cell := range row {
+			spans[r] = append(spans[r], table.Span{Rows: cell.RowSpan, Cols: cell.ColSpan})
+		}
+	}
+	grid := table.Place(spans, len(t.cols))
+
+	// The natural and minimum widths of the columns of cells that span one
+	// column: their widest lines and words.
+	natural := make([]float32, len(t.cols))
+	minimum := make([]float32, len(t.cols))
+	i := 0
+	for _, row := range t.rows {
+		for _, src := range row {
+			cell := tableCell{Cell: grid[i], CellStyle: t.cellStyle(src.CellStyle)}
+			i++
+			cells = append(cells, cell)
+			if cell.ColSpan != 1 {
+				continue
+			}
+
+			for line := range strings.SplitSeq(src.Text, "\n") {
+				w := f.GetStringWidth(line) + 2*cell.Padding
+				natural[cell.Col] = max(natural[cell.Col], w)
+				for word := range strings.FieldsSeq(line) {
+					w := f.GetStringWidth(word) + 2*cell.Padding
+					minimum[cell.Col] = max(minimum[cell.Col], w)
+				}
+			}
+		}
+	}
+
+	cols := make([]table.Column, len(t.cols))
+	for i, col := range t.cols {
+		cols[i] = table.Column{Width: col.Width, Percent: col.Percent}
+	}
+	widths := table.Widths(cols, width, natural, minimum)
+	colX = make([]float32, len(widths)+1)
+	for i, w := range widths {
+		colX[i+1] = colX[i] + w
+	}
+
+	cMargin := f.cMargin
+	defer func() { f.cMargin = cMargin }()
+
+	content := make([]float32, len(cells))
+	i = 0
+	for _, row := range t.rows {
+		for _, src := range row {
+			cell := &cells[i]
+			if cell.ColSpan > 0 {
+				f.cMargin = cell.Padding
+				w := colX[cell.Col+cell.ColSpan] - colX[cell.Col]
+				text, _, lines := f.NewParagraph().Text(src.Text).layout(w)
+				bidiText := &bidiText{text: text, dir: f.textDirection(nil)}
+				for _, line := range lines {
+					str := string(text[line.Start:line.End])
+					if line.Hyphen {
+						str += "-"
+					}
+					cell.lines = append(cell.lines, tableLine{
+						text: str,
+						last: line.last,
+						bidi: bidiText.line(line.Start, line.End, str),
+					})
+				}
+				content[i] = float32(len(cell.lines))*lh + 2*cell.Padding
+			}
+			i++
+		}
+	}
+	heights = table.Heights(t.cellGrid(cells), content, len(t.rows))
+	return cells, heights, colX
+}
+
+// cellStyle returns the style of a cell, with the table's style for any
+// fields it doesn't set.
+func (t *Table) cellStyle(style CellStyle) CellStyle {
+	style.Border = cmp.Or(style.Border, t.style.Border)
+	style.Fill = cmp.Or(style.Fill, t.style.Fill)
+	style.Padding = cmp.Or(style.Padding, t.style.Padding)
+	style.Align = cmp.Or(style.Align, t.style.Align)
+	return style
+}
+
+// cellGrid returns the positions of cells.
+func (t *Table) cellGrid(cells []tableCell) []table.Cell {
+	grid := make([]table.Cell, len(cells))
+	for i, cell := range cells {
+		grid[i] = cell.Cell
+	}
+
+	return grid
+}
+
+// writeRows outputs rows from start to end, at the current vertical position,
+// with the table's left at x. lines, if set, are the lines to write in each
+// cell, in place of all of them.
+func (t *Table) writeRows(
+	cells []tableCell,
+	heights, colX []float32,
+	x float32,
+	start, end int,
+	lines [][]tableLine,
+) {
+	f := t.f
+	y := f.y
+	rowY := make([]float32, end-start+1)
+	rowY[0] = y
+	for r := start; r < end; r++ {
+		rowY[r-start+1] = rowY[r-start] + heights[r]
+	}
+
+	for i := range cells {
+		cell := &cells[i]
+		if cell.ColSpan == 0 || cell.Row < start || cell.Row >= end {
+			continue
+		}
+
+		cellLines := cell.lines
+		if lines != nil {
+			cellLines = lines[i]
+		}
+		rowEnd := min(cell.Row+cell.RowSpan, end)
+		t.writeCell(
+			cell,
+			cellLines,
+			x+colX[cell.Col],
+			rowY[cell.Row-start],
+			colX[cell.Col+cell.ColSpan]-colX[cell.Col],
+			rowY[rowEnd-start]-rowY[cell.Row-start],
+		)
+	}
+
+	f.x = x
+	f.y = rowY[len(rowY)-1]
+}
+
+// writeCell outputs a cell, with its top left corner at (x, y).
+func (t *Table) writeCell(
+	cell *tableCell,
+	lines []tableLine,
+	x, y, w, h float32,
+) {
+	f := t.f
+	fill := cell.Fill != nil
+	if fill {
+		r, g, b := f.GetFillColor()
+		defer f.SetFillColor(r, g, b)
+		f.SetFillColor(cell.Fill.R, cell.Fill.G, cell.Fill.B)
+	}
+
+	border := cell.Border
+	if border == "0" {
+		border = ""
+	}
+	if fill || border != "" {
+		f.x, f.y = x, y
+		f.CellFormat(w, h, "", border, 0, "", fill, 0, "")
+	}
+
+	lh := cmp.Or(t.lineHeight, 1.2*f.fontSize)
+	height := float32(len(lines)) * lh
+	top := y + cell.Padding
+	switch {
+	case strings.Contains(cell.Align, "M"):
+		top = y + (h-height)/2
+	case strings.Contains(cell.Align, "B"):
+		top = y + h - cell.Padding - height
+	}
+
+	align := "L"
+	switch {
+	case strings.Contains(cell.Align, "C"):
+		align = "C"
+	case strings.Contains(cell.Align, "R"):
+		align = "R"
+	}
+
+	defer func(line *bidiLine) { f.bidiLine = line }(f.bidiLine)
+	f.cMargin = cell.Padding
+	for k, line := range lines {
+		lineAlign := align
+		if strings.Contains(cell.Align, "J") && !line.last {
+			lineAlign = "J"
+		}
+
+		f.x, f.y = x, top+float32(k)*lh
+		f.bidiLine = line.bidi
+		f.CellFormat(
+			w,
+			lh,
+			line.text,
+			"",
+			0,
+			lineAlign,
+			false,
+			0,
+			"",
+			TextDirection(line.bidi.para.Direction()),
+		)
+	}
+}
+
+// splitRow writes the lines of the cells of the row at start that fit on
+// each page, calling newPage to start each new page or column, until the rest
+// of the row fits, or no more breaks are accepted, leaving it to be written.
+// The table is written at dx from the left margin.
+func (t *Table) splitRow(
+	cells []tableCell,
+	heights, colX []float32,
+	dx float32,
+	start int,
+	lh float32,
+	newPage func() bool,
+) {
+	f := t.f
+	fresh, stuck := false, false
+	for {
+		// Lines that fit in the space left on the page. At least one line of
+		// each cell is written on a new page, however small.
+		fits := make([][]tableLine, len(cells))
+		more, fitsAny := false, false
+		for i, cell := range cells {
+			if cell.Row != start || cell.ColSpan == 0 {
+				continue
+			}
+
+			n := int((f.pageBreakTrigger - f.y - 2*cell.Padding) / lh)
+			if fresh {
+				n = max(n, 1)
+			}
+			n = max(min(n, len(cell.lines)), 0)
+
+			fits[i] = cell.lines[:n]
+			more = more || n < len(cell.lines)
+			fitsAny = fitsAny || n > 0
+		}
+
+		if !more || stuck {
+			var height float32
+			for _, cell := range cells {
+				if cell.Row == start && cell.ColSpan > 0 {
+					height = max(height, float32(len(cell.lines))*lh+2*cell.Padding)
+				}
+			}
+			heights[start] = height
+			return
+		}
+
+		if fitsAny {
+			height := heights[start]
+			heights[start] = f.pageBreakTrigger - f.y
+			t.writeRows(cells, heights, colX, f.lMargin+dx, start, start+1, fits)
+			heights[start] = height
+
+			for i := range cells {
+				cells[i].lines = cells[i].lines[len(fits[i]):]
+			}
+		}
+
+		stuck = !newPage()
+		if f.err != nil {
+			return
+		}
+		fresh = !stuck
+	}
+}
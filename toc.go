@@ -0,0 +1,206 @@
+package scribe
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+)
+
+// TOCStyle configures a table of contents. See AddTOC().
+//
+// Zero values select the defaults, noted below.
+type TOCStyle struct {
+	// Levels is the number of levels of bookmarks listed, from level 0.
+	// Default: all levels.
+	Levels int
+
+	// LineHeight is the height of lines of entries, in the unit of measure
+	// specified in New(). Default: 1.5 times the font size.
+	LineHeight float32
+
+	// Indent is the indent of each level of entries, in the unit of measure
+	// specified in New(). Default: twice the font size.
+	Indent float32
+
+	// Leader is repeated between each entry and its page number. Default:
+	// ".".
+	Leader string
+}
+
+// tocType is a table of contents, to be written on pages reserved for it.
+type tocType struct {
+	style TOCStyle
+
+	font      FontId
+	fontStyle FontStyle
+	size      float32
+
+	// The pages reserved, in order.
+	pages []tocPage
+}
+
+// tocPage is a page reserved for a table of contents, with the top of the
+// space reserved on it, and its size, margins and page break trigger, which
+// are restored to write on it.
+type tocPage struct {
+	page             int
+	top              float32
+	w, h, wPt, hPt   float32
+	lMargin, rMargin float32
+	pageBreakTrigger float32
+}
+
+// AddTOC reserves space for a table of contents, listing the bookmarks added
+// with Bookmark() and their page numbers, with each entry linked to its
+// bookmark. The table starts at the current position, and continues on the
+// given number of pages, which are added after the current page. It's written
+// by Close(), when the pages of all bookmarks are known, in the current font,
+// with the size and margins of each page as they were reserved.
+//
+// Entries are indented by level, with long titles wrapped, and page numbers,
+// or labels set with SetPageLabel(), aligned to the right margin, after a
+// leader. Close() fails if the entries don't fit in the space reserved. See
+// TOCStyle.
+func (f *Scribe) AddTOC(pages int, style *TOCStyle) {
+	if f.err != nil {
+		return
+	}
+	if f.page == 0 {
+		f.AddPage()
+	}
+
+	toc := &tocType{
+		font:      f.currentFont,
+		fontStyle: f.fontStyle,
+		size:      f.fontSizePt,
+		pages:     []tocPage{f.tocPage()},
+	}
+	if style != nil {
+		toc.style = *style
+	}
+
+	for range pages {
+		f.AddPageFormat(f.curOrientation, f.curPageSize)
+		toc.pages = append(toc.pages, f.tocPage())
+	}
+	f.tocs = append(f.tocs, toc)
+}
+
+// tocPage returns the current page, reserved for a table of contents from the
+// current position.
+func (f *Scribe) tocPage() tocPage {
+	return tocPage{
+		page:             f.page,
+		top:              f.y,
+		w:                f.w,
+		h:                f.h,
+		wPt:              f.wPt,
+		hPt:              f.hPt,
+		lMargin:          f.lMargin,
+		rMargin:          f.rMargin,
+		pageBreakTrigger: f.pageBreakTrigger,
+	}
+}
+
+// setTOCPage moves to the top of a page reserved for a table of contents.
+func (f *Scribe) setTOCPage(p tocPage) {
+	f.page, f.y = p.page, p.top
+	f.w, f.h, f.wPt, f.hPt = p.w, p.h, p.wPt, p.hPt
+	f.lMargin, f.rMargin = p.lMargin, p.rMargin
+	f.pageBreakTrigger = p.pageBreakTrigger
+}
+
+// putTOCs writes the tables of contents on the pages reserved for them.
+func (f *Scribe) putTOCs() {
+	if len(f.tocs) == 0 {
+		return
+	}
+
+	page, x := f.tocPage(), f.x
+	font, fontStyle, size := f.currentFont, f.fontStyle, f.fontSizePt
+	for _, toc := range f.tocs {
+		f.putTOC(toc)
+	}
+	f.setTOCPage(page)
+	f.x = x
+	f.SetFont(font, fontStyle, size)
+}
+
+// putTOC writes a table of contents.
+func (f *Scribe) putTOC(toc *tocType) {
+	f.setTOCPage(toc.pages[0])
+	f.SetFont(toc.font, toc.fontStyle, toc.size)
+
+	lh := cmp.Or(toc.style.LineHeight, 1.5*f.fontSize)
+	indent := cmp.Or(toc.style.Indent, 2*f.fontSize)
+	leader := cmp.Or(toc.style.Leader, ".")
+
+	next := 1
+	for _, o := range f.outlines {
+		if toc.style.Levels > 0 && o.level >= toc.style.Levels ||
+			o.uri != "" || o.namedDest != "" {
+			continue
+		}
+
+		// The page number, and the space for it, with a leader.
+		num := f.PageLabel(o.dest.Page)
+		numWidth := f.GetStringWidth(num) + 2*f.cMargin
+		leaderWidth := f.GetStringWidth(leader)
+
+		p := f.NewParagraph()
+		p.Text(o.title)
+		p.LineHeight(lh)
+
+		// Entries are laid out with the margins of the page they start on.
+		if f.y+lh > f.pageBreakTrigger && !f.nextTOCPage(toc, &next) {
+			return
+		}
+		right := f.w - f.rMargin
+		x := f.lMargin + float32(o.level)*indent
+		width := right - x - numWidth - 2*leaderWidth
+		text, spanIx, lines := p.layout(width)
+		bidiText := &bidiText{text: text, dir: f.textDirection(nil)}
+
+		link := f.AddLink()
+		f.SetLink(link, o.dest.Y, o.dest.Page)
+		for i, line := range lines {
+			if f.y+lh > f.pageBreakTrigger && !f.nextTOCPage(toc, &next) {
+				return
+			}
+
+			f.x = x
+			p.writeLine(text, spanIx, bidiText, line, width)
+			f.Link(x, f.y, right-x, lh, link)
+
+			if i == len(lines)-1 {
+				// The leader fills the space between the title and the page
+				// number, in whole repeats.
+				end := x + f.cMargin + leaderWidth/2 +
+					f.GetStringWidth(string(text[line.Start:line.End]))
+				if n := int((right - numWidth - end) / leaderWidth); n > 0 {
+					f.x = right - numWidth - float32(n)*leaderWidth
+					f.CellFormat(float32(n)*leaderWidth, lh, strings.Repeat(leader, n), "", 0, "R", false, 0, "")
+				}
+				f.x = right - numWidth
+				f.CellFormat(numWidth, lh, num, "", 0, "R", false, 0, "")
+			}
+			f.y += lh
+		}
+	}
+}
+
+// nextTOCPage moves to the next page reserved for a table of contents, and
+// reports whether there is one.
+func (f *Scribe) nextTOCPage(toc *tocType, next *int) bool {
+	if *next == len(toc.pages) {
+		f.err = fmt.Errorf(
+			"table of contents doesn't fit in %d reserved pages",
+			len(toc.pages),
+		)
+		return false
+	}
+
+	f.setTOCPage(toc.pages[*next])
+	*next++
+	return true
+}
@@ -0,0 +1,568 @@
+package ttf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+
+	"github.com/bits-and-blooms/bitset"
+)
+
+// cffOp is a CFF DICT operator. Two-byte operators, escaped with 12, are
+// stored as 0x0c00 | the second byte.
+type cffOp u16
+
+const (
+	cffOpCharset        cffOp = 15
+	cffOpEncoding       cffOp = 16
+	cffOpCharStrings    cffOp = 17
+	cffOpPrivate        cffOp = 18
+	cffOpSubrs          cffOp = 19
+	cffOpCharStringType cffOp = 0x0c06
+	cffOpRos            cffOp = 0x0c1e
+	cffOpCidCount       cffOp = 0x0c22
+	cffOpFdArray        cffOp = 0x0c24
+	cffOpFdSelect       cffOp = 0x0c25
+)
+
+// Number of predefined strings, which precede the String INDEX in SID space.
+const cffStdStringCount = 391
+
+// Type 2 charstring for an empty glyph, used in place of glyphs excluded
+// from a subset.
+var cffEmptyCharString = []byte{14} // endchar
+
+// cffFont holds the structures of a 'CFF ' table needed for subsetting.
+//
+// https://adobe-type-tools.github.io/font-tech-notes/pdfs/5176.CFF.pdf
+type cffFont struct {
+	name        []byte // Raw Name INDEX.
+	top         cffDict
+	strings     cffIndex
+	globalSubrs []byte // Raw Global Subr INDEX.
+	charStrings cffIndex
+
+	// Font DICTs and FDSelect of CID-keyed fonts, with a Private DICT for
+	// each Font DICT. Name-keyed fonts have only the one Private DICT.
+	fonts    []cffDict
+	fdSelect []byte
+	privates []cffPrivate
+}
+
+type cffPrivate struct {
+	dict  cffDict
+	subrs []byte // Raw local Subr INDEX, if any.
+}
+
+type cffIndex struct {
+	items [][]byte
+}
+
+// parseCffIndex parses the INDEX at pos in data and returns it, along with
+// the position just past its end.
+func parseCffIndex(data []byte, pos u32) (index cffIndex, end u32, err error) {
+	if int(pos)+2 > len(data) {
+		return index, 0, fmt.Errorf("CFF INDEX out of bounds at %d", pos)
+	}
+
+	count := u32(binary.BigEndian.Uint16(data[pos:]))
+	if count == 0 {
+		return index, pos + 2, nil
+	}
+
+	if int(pos)+3 > len(data) {
+		return index, 0, fmt.Errorf("CFF INDEX out of bounds at %d", pos)
+	}
+	offSize := u32(data[pos+2])
+	if offSize < 1 || offSize > 4 {
+		return index, 0, fmt.Errorf("invalid CFF INDEX offset size: %d", offSize)
+	}
+
+	ptrOffsets := pos + 3
+	ptrData := ptrOffsets + (count+1)*offSize - 1
+	if int(ptrData) >= len(data) {
+		return index, 0, fmt.Errorf("CFF INDEX out of bounds at %d", pos)
+	}
+
+	offset := func(i u32) u32 {
+		var val u32
+		for _, b := range data[ptrOffsets+i*offSize:][:offSize] {
+			val = val<<8 | u32(b)
+		}
+		return val
+	}
+
+	index.items = make([][]byte, count)
+	start := offset(0)
+	for i := range count {
+		end := offset(i + 1)
+		if end < start || int(ptrData+end) > len(data) {
+			return cffIndex{}, 0, fmt.Errorf("invalid CFF INDEX offset at %d", pos)
+		}
+		index.items[i] = data[ptrData+start : ptrData+end]
+		start = end
+	}
+
+	return index, ptrData + start, nil
+}
+
+// appendCffIndex appends an INDEX of items to dst.
+func appendCffIndex(dst []byte, items [][]byte) []byte {
+	dst = binary.BigEndian.AppendUint16(dst, u16(len(items)))
+	if len(items) == 0 {
+		return dst
+	}
+
+	lenData := 0
+	for _, item := range items {
+		lenData += len(item)
+	}
+
+	offSize := 1
+	for maxOffset := lenData + 1; maxOffset >= 1<<(8*offSize); {
+		offSize += 1
+	}
+	dst = append(dst, u8(offSize))
+
+	offset := 1
+	appendOffset := func() {
+		for i := offSize - 1; i >= 0; i-- {
+			dst = append(dst, u8(offset>>(8*i)))
+		}
+	}
+
+	appendOffset()
+	for _, item := range items {
+		offset += len(item)
+		appendOffset()
+	}
+
+	for _, item := range items {
+		dst = append(dst, item...)
+	}
+
+	return dst
+}
+
+type cffDictEntry struct {
+	op       cffOp
+	operands []byte // Raw, encoded operands.
+}
+
+type cffDict []cffDictEntry
+
+func parseCffDict(data []byte) (dict cffDict, err error) {
+	start := 0
+	for i := 0; i < len(data); {
+		b0 := data[i]
+		switch {
+		case b0 <= 21: // Operator
+			operands := data[start:i]
+
+			op := cffOp(b0)
+			if b0 == 12 {
+				if i+1 >= len(data) {
+					return nil, fmt.Errorf("truncated CFF DICT operator")
+				}
+				op = 0x0c00 | cffOp(data[i+1])
+				i += 1
+			}
+			i += 1
+
+			dict = append(dict, cffDictEntry{op: op, operands: operands})
+			start = i
+
+		case b0 == 28:
+			i += 3
+		case b0 == 29:
+			i += 5
+		case b0 == 30: // Real number, terminated by a 0xf nibble.
+			for i += 1; i < len(data); i++ {
+				if data[i]&0x0f == 0x0f || data[i]>>4 == 0x0f {
+					break
+				}
+			}
+			i += 1
+		case b0 >= 247 && b0 <= 254:
+			i += 2
+		default:
+			i += 1
+		}
+	}
+
+	if start != len(data) {
+		return nil, fmt.Errorf("CFF DICT has trailing operands")
+	}
+
+	return dict, nil
+}
+
+// ints returns the operands of op, decoded as integers. Real operands are
+// returned as 0, since they don't occur for any of the operators used here.
+func (d cffDict) ints(op cffOp) (vals []int32, ok bool) {
+	i := slices.IndexFunc(d, func(e cffDictEntry) bool { return e.op == op })
+	if i < 0 {
+		return nil, false
+	}
+
+	b := d[i].operands
+	for i := 0; i < len(b); {
+		b0 := b[i]
+		switch {
+		case b0 == 28 && i+2 < len(b):
+			vals = append(vals, int32(int16(binary.BigEndian.Uint16(b[i+1:]))))
+			i += 3
+		case b0 == 29 && i+4 < len(b):
+			vals = append(vals, int32(binary.BigEndian.Uint32(b[i+1:])))
+			i += 5
+		case b0 == 30:
+			for i += 1; i < len(b); i++ {
+				if b[i]&0x0f == 0x0f || b[i]>>4 == 0x0f {
+					break
+				}
+			}
+			vals = append(vals, 0)
+			i += 1
+		case b0 >= 32 && b0 <= 246:
+			vals = append(vals, int32(b0)-139)
+			i += 1
+		case b0 >= 247 && b0 <= 250 && i+1 < len(b):
+			vals = append(vals, (int32(b0)-247)*256+int32(b[i+1])+108)
+			i += 2
+		case b0 >= 251 && b0 <= 254 && i+1 < len(b):
+			vals = append(vals, -(int32(b0)-251)*256-int32(b[i+1])-108)
+			i += 2
+		default:
+			return vals, false
+		}
+	}
+
+	return vals, true
+}
+
+func (d cffDict) has(op cffOp) bool {
+	return slices.ContainsFunc(d, func(e cffDictEntry) bool { return e.op == op })
+}
+
+// appendTo appends the encoded entries of d to dst, except for those with
+// the given operators.
+func (d cffDict) appendTo(dst []byte, omit ...cffOp) []byte {
+	for _, entry := range d {
+		if slices.Contains(omit, entry.op) {
+			continue
+		}
+		dst = append(dst, entry.operands...)
+		dst = appendCffOp(dst, entry.op)
+	}
+
+	return dst
+}
+
+// appendCffInt appends val to dst in the fixed-size, 5-byte encoding, so
+// that offsets can be filled in without changing the length of a DICT.
+func appendCffInt(dst []byte, val int32) []byte {
+	return binary.BigEndian.AppendUint32(append(dst, 29), u32(val))
+}
+
+func appendCffOp(dst []byte, op cffOp) []byte {
+	if op > 0xff {
+		return append(dst, 12, u8(op))
+	}
+
+	return append(dst, u8(op))
+}
+
+// parseCff parses the 'CFF ' table of an OpenType font.
+//
+// https://adobe-type-tools.github.io/font-tech-notes/pdfs/5176.CFF.pdf
+func parseCff(data []byte) (*cffFont, error) {
+	if len(data) < 4 || data[0] != 1 {
+		return nil, fmt.Errorf("unsupported CFF table version")
+	}
+
+	var cff cffFont
+
+	posName := u32(data[2]) // hdrSize
+	_, posTop, err := parseCffIndex(data, posName)
+	if err != nil {
+		return nil, err
+	}
+	cff.name = data[posName:posTop]
+
+	topIndex, posStrings, err := parseCffIndex(data, posTop)
+	if err != nil {
+		return nil, err
+	}
+	if len(topIndex.items) != 1 {
+		return nil, fmt.Errorf(
+			"expected 1 font in CFF table, found %d",
+			len(topIndex.items),
+		)
+	}
+	if cff.top, err = parseCffDict(topIndex.items[0]); err != nil {
+		return nil, err
+	}
+
+	var posGlobalSubrs u32
+	cff.strings, posGlobalSubrs, err = parseCffIndex(data, posStrings)
+	if err != nil {
+		return nil, err
+	}
+
+	_, posEnd, err := parseCffIndex(data, posGlobalSubrs)
+	if err != nil {
+		return nil, err
+	}
+	cff.globalSubrs = data[posGlobalSubrs:posEnd]
+
+	if vals, ok := cff.top.ints(cffOpCharStringType); ok &&
+		(len(vals) != 1 || vals[0] != 2) {
+		return nil, fmt.Errorf("unsupported CFF charstring type: %v", vals)
+	}
+
+	vals, ok := cff.top.ints(cffOpCharStrings)
+	if !ok || len(vals) != 1 {
+		return nil, fmt.Errorf("missing CFF CharStrings offset")
+	}
+	if cff.charStrings, _, err = parseCffIndex(data, u32(vals[0])); err != nil {
+		return nil, err
+	}
+
+	if !cff.top.has(cffOpRos) {
+		private, err := parseCffPrivate(data, cff.top)
+		if err != nil {
+			return nil, err
+		}
+		cff.privates = []cffPrivate{private}
+
+		return &cff, nil
+	}
+
+	vals, ok = cff.top.ints(cffOpFdArray)
+	if !ok || len(vals) != 1 {
+		return nil, fmt.Errorf("missing CFF FDArray offset")
+	}
+	fdArray, _, err := parseCffIndex(data, u32(vals[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range fdArray.items {
+		dict, err := parseCffDict(item)
+		if err != nil {
+			return nil, err
+		}
+
+		private, err := parseCffPrivate(data, dict)
+		if err != nil {
+			return nil, err
+		}
+
+		cff.fonts = append(cff.fonts, dict)
+		cff.privates = append(cff.privates, private)
+	}
+
+	vals, ok = cff.top.ints(cffOpFdSelect)
+	if !ok || len(vals) != 1 {
+		return nil, fmt.Errorf("missing CFF FDSelect offset")
+	}
+	if cff.fdSelect, err = cffFdSelect(
+		data,
+		u32(vals[0]),
+		len(cff.charStrings.items),
+	); err != nil {
+		return nil, err
+	}
+
+	return &cff, nil
+}
+
+// parseCffPrivate parses the Private DICT referenced by a Top or Font DICT,
+// along with its local subroutines.
+func parseCffPrivate(data []byte, dict cffDict) (private cffPrivate, err error) {
+	vals, ok := dict.ints(cffOpPrivate)
+	if !ok || len(vals) != 2 {
+		return private, nil
+	}
+
+	size, offset := vals[0], vals[1]
+	if size < 0 || offset < 0 || int(offset)+int(size) > len(data) {
+		return private, fmt.Errorf("CFF Private DICT out of bounds")
+	}
+
+	if private.dict, err = parseCffDict(data[offset : offset+size]); err != nil {
+		return private, err
+	}
+
+	vals, ok = private.dict.ints(cffOpSubrs)
+	if !ok || len(vals) != 1 {
+		return private, nil
+	}
+
+	posSubrs := u32(offset + vals[0])
+	_, posEnd, err := parseCffIndex(data, posSubrs)
+	if err != nil {
+		return private, err
+	}
+	private.subrs = data[posSubrs:posEnd]
+
+	return private, nil
+}
+
+// cffFdSelect returns the raw FDSelect structure at pos.
+func cffFdSelect(data []byte, pos u32, glyphCount int) ([]byte, error) {
+	if int(pos) >= len(data) {
+		return nil, fmt.Errorf("CFF FDSelect out of bounds")
+	}
+
+	var size int
+	switch format := data[pos]; format {
+	case 0:
+		size = 1 + glyphCount
+	case 3:
+		if int(pos)+3 > len(data) {
+			return nil, fmt.Errorf("CFF FDSelect out of bounds")
+		}
+		rangeCount := int(binary.BigEndian.Uint16(data[pos+1:]))
+		size = 3 + 3*rangeCount + 2
+	default:
+		return nil, fmt.Errorf("unsupported CFF FDSelect format: %d", format)
+	}
+
+	if int(pos)+size > len(data) {
+		return nil, fmt.Errorf("CFF FDSelect out of bounds")
+	}
+
+	return data[pos : int(pos)+size], nil
+}
+
+// generateCff writes a subset of the font's CFF table as a bare, CID-keyed
+// CFF font, for embedding in PDFs as a CIDFontType0C font file.
+//
+// Glyphs excluded from the subset are replaced with empty charstrings rather
+// than removed, so glyph IDs, which are also used as CIDs, are unchanged.
+// Subroutines are kept as-is, since they may be shared by any glyph.
+func generateCff(font *Font, gids *bitset.BitSet, out []byte) []byte {
+	cff := font.cff
+
+	charStrings := make([][]byte, len(cff.charStrings.items))
+	for gid, charString := range cff.charStrings.items {
+		if gid == 0 || gids.Test(uint(gid)) {
+			charStrings[gid] = charString
+		} else {
+			charStrings[gid] = cffEmptyCharString
+		}
+	}
+	charStringsIndex := appendCffIndex(nil, charStrings)
+
+	// Registry and ordering strings for the ROS operator.
+	sidRegistry := int32(cffStdStringCount + len(cff.strings.items))
+	stringsIndex := appendCffIndex(nil, append(
+		slices.Clip(cff.strings.items),
+		[]byte("Adobe"),
+		[]byte("Identity"),
+	))
+
+	// Identity charset (GID == CID), as a single format 2 range.
+	glyphCount := len(charStrings)
+	charset := []byte{0}
+	if glyphCount > 1 {
+		charset = []byte{2, 0, 1}
+		charset = binary.BigEndian.AppendUint16(charset, u16(glyphCount-2))
+	}
+
+	fonts, fdSelect := cff.fonts, cff.fdSelect
+	if fonts == nil {
+		// Name-keyed fonts get a single Font DICT for all glyphs.
+		fonts = []cffDict{nil}
+		fdSelect = []byte{3, 0, 1, 0, 0, 0}
+		fdSelect = binary.BigEndian.AppendUint16(fdSelect, u16(glyphCount))
+	}
+
+	// Private DICTs, each immediately followed by its local subroutines.
+	privates := make([][]byte, len(cff.privates))
+	for i, private := range cff.privates {
+		dict := private.dict.appendTo(nil, cffOpSubrs)
+		if private.subrs != nil {
+			const lenSubrsEntry = 6
+			dict = appendCffInt(dict, int32(len(dict)+lenSubrsEntry))
+			dict = appendCffOp(dict, cffOpSubrs)
+		}
+		privates[i] = dict
+	}
+
+	fdArrayIndex := func(posPrivates int) []byte {
+		dicts := make([][]byte, len(fonts))
+		for i, font := range fonts {
+			dict := font.appendTo(nil, cffOpPrivate)
+			dict = appendCffInt(dict, int32(len(privates[i])))
+			dict = appendCffInt(dict, int32(posPrivates))
+			dicts[i] = appendCffOp(dict, cffOpPrivate)
+
+			posPrivates += len(privates[i]) + len(cff.privates[i].subrs)
+		}
+		return appendCffIndex(nil, dicts)
+	}
+
+	topIndex := func(posCharset, posFdSelect, posCharStrings, posFdArray int) []byte {
+		dict := appendCffInt(nil, sidRegistry)
+		dict = appendCffInt(dict, sidRegistry+1)
+		dict = appendCffInt(dict, 0) // Supplement
+		dict = appendCffOp(dict, cffOpRos)
+		dict = appendCffInt(dict, int32(glyphCount))
+		dict = appendCffOp(dict, cffOpCidCount)
+
+		dict = cff.top.appendTo(dict,
+			cffOpRos,
+			cffOpCidCount,
+			cffOpCharset,
+			cffOpEncoding,
+			cffOpCharStrings,
+			cffOpPrivate,
+			cffOpFdArray,
+			cffOpFdSelect,
+		)
+
+		dict = appendCffInt(dict, int32(posCharset))
+		dict = appendCffOp(dict, cffOpCharset)
+		dict = appendCffInt(dict, int32(posFdSelect))
+		dict = appendCffOp(dict, cffOpFdSelect)
+		dict = appendCffInt(dict, int32(posCharStrings))
+		dict = appendCffOp(dict, cffOpCharStrings)
+		dict = appendCffInt(dict, int32(posFdArray))
+		dict = appendCffOp(dict, cffOpFdArray)
+
+		return appendCffIndex(nil, [][]byte{dict})
+	}
+
+	// Offsets are encoded with a fixed size, so the lengths of the Top and
+	// Font DICTs can be measured before the offsets are known.
+	header := []byte{1, 0, 4, 4} // major, minor, hdrSize, offSize
+	posCharset := len(header) + len(cff.name) + len(topIndex(0, 0, 0, 0)) +
+		len(stringsIndex) + len(cff.globalSubrs)
+	posFdSelect := posCharset + len(charset)
+	posCharStrings := posFdSelect + len(fdSelect)
+	posFdArray := posCharStrings + len(charStringsIndex)
+	posPrivates := posFdArray + len(fdArrayIndex(0))
+
+	out = append(out[:0], header...)
+	out = append(out, cff.name...)
+	out = append(out, topIndex(
+		posCharset,
+		posFdSelect,
+		posCharStrings,
+		posFdArray,
+	)...)
+	out = append(out, stringsIndex...)
+	out = append(out, cff.globalSubrs...)
+	out = append(out, charset...)
+	out = append(out, fdSelect...)
+	out = append(out, charStringsIndex...)
+	out = append(out, fdArrayIndex(posPrivates)...)
+	for i, private := range privates {
+		out = append(out, private...)
+		out = append(out, cff.privates[i].subrs...)
+	}
+
+	return out
+}
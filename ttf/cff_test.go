@@ -0,0 +1,176 @@
+package ttf
+
+import (
+	"encoding/binary"
+	"slices"
+	"testing"
+
+	"github.com/bits-and-blooms/bitset"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCff(t *testing.T) {
+	var ttf, otf Font
+	require.NoError(t, Parse(robotoI, &ttf))
+	require.NoError(t, Parse(testOtf(t, robotoI), &otf))
+
+	require.False(t, ttf.Cff())
+	require.True(t, otf.Cff())
+
+	require.Equal(t, ttf.GlyphCount, otf.GlyphCount)
+	require.Equal(t, ttf.GlyphId('A'), otf.GlyphId('A'))
+	require.Equal(t, ttf.Width(ttf.GlyphId('A')), otf.Width(otf.GlyphId('A')))
+
+	require.Len(t, otf.cff.privates, 1)
+	require.NotEmpty(t, otf.cff.privates[0].subrs)
+}
+
+func TestGenerateCff(t *testing.T) {
+	var font Font
+	require.NoError(t, Parse(testOtf(t, robotoI), &font))
+
+	gidA, gidB, gidC := font.GlyphId('A'), font.GlyphId('B'), font.GlyphId('C')
+	var gids bitset.BitSet
+	gids.Set(uint(gidA)).Set(uint(gidB))
+
+	subsetBytes, gidRemap, err := Generate(&font, &gids, nil)
+	require.NoError(t, err)
+	require.Equal(t, gidA, gidRemap[gidA])
+
+	subset, err := parseCff(subsetBytes)
+	require.NoError(t, err)
+
+	require.True(t, subset.top.has(cffOpRos))
+	require.Len(t, subset.charStrings.items, int(font.GlyphCount))
+	require.Equal(t,
+		font.cff.charStrings.items[gidA],
+		subset.charStrings.items[gidA],
+	)
+	require.Equal(t, cffEmptyCharString, subset.charStrings.items[gidC])
+
+	require.Len(t, subset.fonts, 1)
+	require.Equal(t, font.cff.privates[0].subrs, subset.privates[0].subrs)
+	require.Equal(t, font.cff.globalSubrs, subset.globalSubrs)
+
+	// Subsets of CID-keyed fonts keep their Font DICTs and FDSelect.
+	cidFont := Font{cff: subset, GlyphCount: font.GlyphCount}
+	gids.Clear(uint(gidB))
+	subsetBytes, _, err = Generate(&cidFont, &gids, nil)
+	require.NoError(t, err)
+
+	subset, err = parseCff(subsetBytes)
+	require.NoError(t, err)
+	require.Equal(t, cidFont.cff.fdSelect, subset.fdSelect)
+	require.Equal(t, cidFont.cff.privates, subset.privates)
+	require.Equal(t,
+		font.cff.charStrings.items[gidA],
+		subset.charStrings.items[gidA],
+	)
+	require.Equal(t, cffEmptyCharString, subset.charStrings.items[gidB])
+}
+
+// testOtf converts a TrueType font to a CFF-flavoured OpenType font, with the
+// same box outline, drawn via a local subroutine, for every glyph.
+func testOtf(t *testing.T, ttfBytes []byte) []byte {
+	t.Helper()
+
+	var font Font
+	require.NoError(t, Parse(ttfBytes, &font))
+
+	box := []byte{
+		139, 28, 0x01, 0xf4, 5, // 0 500 rlineto
+		11, // return
+	}
+	charStrings := make([][]byte, font.GlyphCount)
+	charStrings[0] = cffEmptyCharString
+	for gid := 1; gid < len(charStrings); gid++ {
+		charStrings[gid] = []byte{
+			u8(139 + gid%100), 139, 21, // rmoveto
+			139 - 107, 10, // callsubr 0
+			14, // endchar
+		}
+	}
+
+	name := appendCffIndex(nil, [][]byte{[]byte("Test")})
+	subrs := appendCffIndex(nil, [][]byte{box})
+	charStringsIndex := appendCffIndex(nil, charStrings)
+
+	private := appendCffInt(nil, 6)
+	private = appendCffOp(private, cffOpSubrs)
+
+	topIndex := func(posCharStrings, posPrivate int) []byte {
+		dict := appendCffInt(nil, int32(posCharStrings))
+		dict = appendCffOp(dict, cffOpCharStrings)
+		dict = appendCffInt(dict, int32(len(private)))
+		dict = appendCffInt(dict, int32(posPrivate))
+		dict = appendCffOp(dict, cffOpPrivate)
+		return appendCffIndex(nil, [][]byte{dict})
+	}
+
+	const lenEmptyIndex = 2 // Strings, Global Subrs
+	posCharStrings := 4 + len(name) + len(topIndex(0, 0)) + 2*lenEmptyIndex
+	posPrivate := posCharStrings + len(charStringsIndex)
+
+	cff := []byte{1, 0, 4, 4}
+	cff = append(cff, name...)
+	cff = append(cff, topIndex(posCharStrings, posPrivate)...)
+	cff = append(cff, 0, 0, 0, 0)
+	cff = append(cff, charStringsIndex...)
+	cff = append(cff, private...)
+	cff = append(cff, subrs...)
+
+	// Copy all tables except for TrueType outlines.
+	tables := append(
+		[]testTable{{tag: u32(TableNameCff), data: cff}},
+		testTables(ttfBytes, TableNameGlyf, TableNameLoca)...,
+	)
+
+	return testFont(0x4f54_544f, tables) // 'OTTO'
+}
+
+type testTable struct {
+	tag  u32
+	data []byte
+}
+
+// testTables returns the tables in a font file, except for those in skip.
+func testTables(fontBytes []byte, skip ...tableName) []testTable {
+	var tables []testTable
+	for i := range int(binary.BigEndian.Uint16(fontBytes[4:])) {
+		entry := fontBytes[12+16*i:]
+		tag := binary.BigEndian.Uint32(entry)
+		if slices.Contains(skip, tableName(tag)) {
+			continue
+		}
+
+		ptr := binary.BigEndian.Uint32(entry[8:])
+		tables = append(tables, testTable{
+			tag:  tag,
+			data: fontBytes[ptr:][:binary.BigEndian.Uint32(entry[12:])],
+		})
+	}
+
+	return tables
+}
+
+// testFont assembles a font file from tables.
+func testFont(sfntVersion u32, tables []testTable) []byte {
+	font := binary.BigEndian.AppendUint32(nil, sfntVersion)
+	font = binary.BigEndian.AppendUint16(font, u16(len(tables)))
+	font = append(font, make([]byte, 6)...)
+
+	ptr := len(font) + 16*len(tables)
+	for _, table := range tables {
+		font = binary.BigEndian.AppendUint32(font, table.tag)
+		font = binary.BigEndian.AppendUint32(font, 0) // checksum
+		font = binary.BigEndian.AppendUint32(font, u32(ptr))
+		font = binary.BigEndian.AppendUint32(font, u32(len(table.data)))
+		ptr += (len(table.data) + 3) &^ 3
+	}
+	for _, table := range tables {
+		font = append(font, table.data...)
+		font = append(font, make([]byte, (4-len(table.data)%4)%4)...)
+	}
+
+	return font
+}
@@ -0,0 +1,155 @@
+package ttf
+
+import (
+	"fmt"
+	"unicode/utf16"
+)
+
+// Face describes one of the fonts in a font file.
+type Face struct {
+	// Index of the face, for [ParseFace] and [FontSet.AddTtc].
+	Index int
+
+	Family    string
+	Subfamily string
+}
+
+// Faces lists the fonts in a TrueType or OpenType collection (.ttc, .otc)
+// file. For standalone font files, the single font is listed.
+func Faces(bytes []byte) ([]Face, error) {
+	offsets, err := faceOffsets(bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	faces := make([]Face, len(offsets))
+	for i, offset := range offsets {
+		r := NewReader(bytes)
+		r.seekTo(offset)
+		if err := r.parseIndex(); err != nil {
+			return nil, fmt.Errorf("unable to parse face %d: %w", i, err)
+		}
+
+		faces[i] = Face{
+			Index:     i,
+			Family:    r.name(nameIdTypoFamily, nameIdFamily),
+			Subfamily: r.name(nameIdTypoSubfamily, nameIdSubfamily),
+		}
+	}
+
+	return faces, nil
+}
+
+// faceOffsets returns the offsets of the table directories in a font file: one
+// for each face in a collection, or a single 0 offset for standalone fonts.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/otff#font-collections
+func faceOffsets(bytes []byte) ([]u32, error) {
+	const lenHeader = 12
+	if len(bytes) < lenHeader {
+		return nil, fmt.Errorf("font file too short (%d bytes)", len(bytes))
+	}
+
+	r := NewReader(bytes)
+	if r.u32() != 0x7474_6366 { // Four-char code: 'ttcf'
+		return []u32{0}, nil
+	}
+
+	r.skip(4) // majorVersion, minorVersion (both u16)
+	faceCount := r.u32()
+	if faceCount == 0 || lenHeader+4*u64(faceCount) > u64(len(bytes)) {
+		return nil, fmt.Errorf("invalid font collection face count (%d)", faceCount)
+	}
+
+	offsets := make([]u32, faceCount)
+	for i := range offsets {
+		offsets[i] = r.u32()
+		if offsets[i] >= r.Len() {
+			return nil, fmt.Errorf("face %d offset out of range", i)
+		}
+	}
+
+	return offsets, nil
+}
+
+const (
+	nameIdFamily        = 1
+	nameIdSubfamily     = 2
+	nameIdTypoFamily    = 16
+	nameIdTypoSubfamily = 17
+)
+
+// name returns the first of the name IDs present in the 'name' table,
+// preferring US English Windows names to other Unicode or Macintosh names.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/name
+func (r *Reader) name(ids ...u16) string {
+	ptrName := r.Tables.Name.Ptr
+	r.seekTo(ptrName + 2) // Skip version.
+	count := r.u16()
+	ptrStrings := ptrName + u32(r.u16())
+	ptrRecords := r.pos
+
+	const recordSize = 12
+
+	for _, id := range ids {
+		var bestRank int
+		var best []byte
+		var bestUnicode bool
+
+		for i := range u32(count) {
+			r.seekTo(ptrRecords + i*recordSize)
+			platform, encoding, language := r.u16(), r.u16(), r.u16()
+			if r.u16() != id {
+				continue
+			}
+
+			var rank int
+			switch {
+			case platform == platformMicrosoft && language == 0x0409 &&
+				(encoding == codeMsUnicodeBmp || encoding == codeMsUnicodeFull):
+				rank = 3
+			case platform == platformMicrosoft &&
+				(encoding == codeMsUnicodeBmp || encoding == codeMsUnicodeFull),
+				platform == platformUnicode:
+				rank = 2
+			case platform == platformMacintosh && encoding == 0 && language == 0:
+				rank = 1
+			default:
+				continue
+			}
+
+			if rank > bestRank {
+				length, offset := u32(r.u16()), u32(r.u16())
+				if ptrStrings+offset+length > r.Len() {
+					continue
+				}
+
+				bestRank = rank
+				best = r.readAt(ptrStrings+offset, length)
+				bestUnicode = platform != platformMacintosh
+			}
+		}
+
+		if bestRank == 0 {
+			continue
+		}
+
+		if !bestUnicode {
+			// Mac Roman, which matches ASCII for typical names.
+			runes := make([]rune, len(best))
+			for i, b := range best {
+				runes[i] = rune(b)
+			}
+			return string(runes)
+		}
+
+		units := make([]u16, len(best)/2)
+		for i := range units {
+			units[i] = u16(best[2*i])<<8 | u16(best[2*i+1])
+		}
+		return string(utf16.Decode(units))
+	}
+
+	return ""
+}
@@ -0,0 +1,77 @@
+package ttf
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/bits-and-blooms/bitset"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaces(t *testing.T) {
+	faces, err := Faces(testCollection(robotoI, notoSc))
+	require.NoError(t, err)
+	require.Equal(t, []Face{
+		{Index: 0, Family: "Roboto", Subfamily: "Italic"},
+		{Index: 1, Family: "DejaVu Sans", Subfamily: "Book"},
+	}, faces)
+
+	faces, err = Faces(robotoI)
+	require.NoError(t, err)
+	require.Equal(t, []Face{{Index: 0, Family: "Roboto", Subfamily: "Italic"}}, faces)
+
+	_, err = Faces([]byte("ttcf"))
+	require.Error(t, err)
+}
+
+func TestParseFace(t *testing.T) {
+	collection := testCollection(robotoI, notoSc)
+
+	var standalone, face Font
+	require.NoError(t, Parse(notoSc, &standalone))
+	require.NoError(t, ParseFace(collection, 1, &face))
+
+	require.Equal(t, standalone.GlyphCount, face.GlyphCount)
+	require.Equal(t, standalone.GlyphId('中'), face.GlyphId('中'))
+	require.Equal(t, standalone.widths, face.widths)
+
+	gids := bitset.New(uint(face.GlyphCount))
+	gids.Set(uint(face.GlyphId('A'))).Set(uint(face.GlyphId('ж')))
+
+	subsetStandalone, _, err := Generate(&standalone, gids, nil)
+	require.NoError(t, err)
+	subsetFace, _, err := Generate(&face, gids, nil)
+	require.NoError(t, err)
+	require.Equal(t, subsetStandalone, subsetFace)
+
+	require.ErrorContains(t, ParseFace(collection, 2, &face), "out of range")
+}
+
+// testCollection assembles a font collection from standalone font files.
+func testCollection(fonts ...[]byte) []byte {
+	be := binary.BigEndian
+
+	ttc := be.AppendUint32(nil, 0x7474_6366) // 'ttcf'
+	ttc = be.AppendUint32(ttc, 0x0001_0000)
+	ttc = be.AppendUint32(ttc, u32(len(fonts)))
+
+	ptr := len(ttc) + 4*len(fonts)
+	for _, font := range fonts {
+		ttc = be.AppendUint32(ttc, u32(ptr))
+		ptr += (len(font) + 3) &^ 3
+	}
+
+	for _, font := range fonts {
+		base := len(ttc)
+		ttc = append(ttc, font...)
+		ttc = append(ttc, make([]byte, (4-len(font)%4)%4)...)
+
+		// Table offsets are relative to the start of the collection.
+		for i := range int(be.Uint16(font[4:])) {
+			entry := ttc[base+12+16*i+8:]
+			be.PutUint32(entry, be.Uint32(entry)+u32(base))
+		}
+	}
+
+	return ttc
+}
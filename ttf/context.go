@@ -0,0 +1,234 @@
+package ttf
+
+// Sequences matched by a (chained) sequence context rule.
+type contextSeq u8
+
+const (
+	seqBacktrack contextSeq = iota
+	seqInput
+	seqLookahead
+)
+
+// Rule from a GSUB/GPOS sequence context or chained sequence context
+// subtable. Values are glyph IDs, class values or coverage table offsets,
+// depending on the subtable format.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/chapter2#sequence-context-format-1-simple-glyph-contexts
+type contextRule struct {
+	// Backtrack sequence, in reverse logical order.
+	backtrack []u16
+
+	// Input sequence, excluding the first glyph, which is matched against
+	// the subtable coverage table.
+	input []u16
+
+	lookahead []u16
+	records   []seqLookupRecord
+}
+
+// https://learn.microsoft.com/en-us/typography/opentype/spec/chapter2#sequence-lookup-record
+type seqLookupRecord struct {
+	sequenceIndex u16
+	lookupIndex   u16
+}
+
+// contextMatcher returns true if the glyph at index i matches value v from
+// the given sequence of a context rule.
+type contextMatcher func(seq contextSeq, i int, v u16) bool
+
+// nestedLookup applies the lookup at the given lookup list index at index i
+// and returns the resulting change in buffer length.
+type nestedLookup func(index u16, i int) int
+
+// applyContext applies the first matching rule of a (chained) sequence
+// context subtable at index i. Returns the index after the matched input
+// sequence, or -1 if no rule matches.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/chapter2#sequence-context-format-1-simple-glyph-contexts
+// https://learn.microsoft.com/en-us/typography/opentype/spec/chapter2#chained-sequence-context-format-1-simple-glyph-contexts
+func (b *shapeBuffer) applyContext(
+	l *lookup,
+	ptr u32,
+	i int,
+	chained bool,
+	apply nestedLookup,
+) int {
+	r := &b.reader
+	gid := b.glyphs[i].gid
+
+	r.seekTo(ptr)
+	switch format := r.u16(); format {
+	case 1, 2:
+		ptrCoverage := ptr + u32(r.u16())
+
+		var classDefs [3]u32
+		if format == 2 {
+			if chained {
+				for seq := range classDefs {
+					if offset := r.u16(); offset != 0 {
+						classDefs[seq] = ptr + u32(offset)
+					}
+				}
+			} else if offset := r.u16(); offset != 0 {
+				classDefs = [3]u32{ptr + u32(offset), ptr + u32(offset), ptr + u32(offset)}
+			}
+		}
+
+		setCount := int(r.u16())
+		ptrSets := r.pos
+
+		index := r.coverageIndex(ptrCoverage, gid)
+		if index < 0 {
+			return -1
+		}
+		if format == 2 {
+			index = int(r.classOf(classDefs[seqInput], gid))
+		}
+		if index >= setCount {
+			return -1
+		}
+
+		r.seekTo(ptrSets + u32(index)*2)
+		offset := r.u16()
+		if offset == 0 {
+			return -1
+		}
+		ptrSet := ptr + u32(offset)
+
+		match := func(seq contextSeq, i int, v u16) bool {
+			if format == 1 {
+				return b.glyphs[i].gid == v
+			}
+			return r.classOf(classDefs[seq], b.glyphs[i].gid) == v
+		}
+
+		r.seekTo(ptrSet)
+		ruleCount := u32(r.u16())
+		for k := range ruleCount {
+			r.seekTo(ptrSet + 2 + k*2)
+			r.seekTo(ptrSet + u32(r.u16()))
+
+			rule := r.contextRule(chained)
+			if end := b.applyRule(l, i, &rule, match, apply); end >= 0 {
+				return end
+			}
+		}
+
+	case 3:
+		var rule contextRule
+		if chained {
+			rule.backtrack = r.u16s(u32(r.u16()))
+			rule.input = r.u16s(u32(r.u16()))
+			rule.lookahead = r.u16s(u32(r.u16()))
+			rule.records = r.seqLookupRecords(u32(r.u16()))
+		} else {
+			glyphCount := u32(r.u16())
+			recordCount := u32(r.u16())
+			rule.input = r.u16s(glyphCount)
+			rule.records = r.seqLookupRecords(recordCount)
+		}
+
+		if len(rule.input) == 0 || r.coverageIndex(ptr+u32(rule.input[0]), gid) < 0 {
+			return -1
+		}
+		rule.input = rule.input[1:]
+
+		match := func(_ contextSeq, i int, v u16) bool {
+			return r.coverageIndex(ptr+u32(v), b.glyphs[i].gid) >= 0
+		}
+
+		return b.applyRule(l, i, &rule, match, apply)
+	}
+
+	return -1
+}
+
+// applyRule matches a context rule at index i and, if it matches, applies its
+// nested lookups. Returns the index after the matched input sequence, or -1.
+func (b *shapeBuffer) applyRule(
+	l *lookup,
+	i int,
+	rule *contextRule,
+	match contextMatcher,
+	apply nestedLookup,
+) int {
+	positions := make([]int, 1, len(rule.input)+1)
+	positions[0] = i
+
+	j := i
+	for _, v := range rule.input {
+		if j = b.next(l, j); j < 0 || !match(seqInput, j, v) {
+			return -1
+		}
+		positions = append(positions, j)
+	}
+
+	k := i
+	for _, v := range rule.backtrack {
+		if k = b.prev(l, k); k < 0 || !match(seqBacktrack, k, v) {
+			return -1
+		}
+	}
+
+	for _, v := range rule.lookahead {
+		if j = b.next(l, j); j < 0 || !match(seqLookahead, j, v) {
+			return -1
+		}
+	}
+
+	end := positions[len(positions)-1] + 1
+	for _, record := range rule.records {
+		seq := int(record.sequenceIndex)
+		if seq >= len(positions) || positions[seq] >= len(b.glyphs) {
+			continue
+		}
+
+		delta := apply(record.lookupIndex, positions[seq])
+		for p := seq + 1; p < len(positions); p += 1 {
+			positions[p] += delta
+		}
+		end += delta
+	}
+
+	return max(end, i)
+}
+
+// contextRule reads a sequence rule (or chained sequence rule) from a format
+// 1 or 2 context subtable, at the current position.
+func (r *Reader) contextRule(chained bool) (rule contextRule) {
+	if chained {
+		rule.backtrack = r.u16s(u32(r.u16()))
+		rule.input = r.u16s(max(u32(r.u16()), 1) - 1)
+		rule.lookahead = r.u16s(u32(r.u16()))
+		rule.records = r.seqLookupRecords(u32(r.u16()))
+		return
+	}
+
+	glyphCount := u32(r.u16())
+	recordCount := u32(r.u16())
+	rule.input = r.u16s(max(glyphCount, 1) - 1)
+	rule.records = r.seqLookupRecords(recordCount)
+
+	return
+}
+
+func (r *Reader) seqLookupRecords(count u32) []seqLookupRecord {
+	records := make([]seqLookupRecord, count)
+	for i := range records {
+		records[i] = seqLookupRecord{
+			sequenceIndex: r.u16(),
+			lookupIndex:   r.u16(),
+		}
+	}
+
+	return records
+}
+
+func (r *Reader) u16s(count u32) []u16 {
+	vals := make([]u16, count)
+	for i := range vals {
+		vals[i] = r.u16()
+	}
+
+	return vals
+}
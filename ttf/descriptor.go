@@ -0,0 +1,184 @@
+package ttf
+
+import (
+	"encoding/binary"
+	"math"
+	"slices"
+)
+
+// cffOpStdVW is the Private DICT operator for the dominant vertical stem
+// width.
+const cffOpStdVW cffOp = 11
+
+// classFlags returns the font descriptor flags implied by the OS/2 family
+// class and PANOSE classification of a font.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/ibmfc
+// https://monotype.github.io/panose/pan2.htm
+func classFlags(familyClass u8, panose []byte) (flags flag) {
+	const (
+		classSansSerif = 8
+		classScript    = 10
+		classSymbolic  = 12
+
+		panoseLatinText      = 2
+		panoseLatinHand      = 3
+		panoseLatinSymbol    = 5
+		panoseSerifCove      = 2
+		panoseSerifRounded   = 10
+		panoseProportionMono = 9
+	)
+
+	switch familyClass {
+	case 1, 2, 3, 4, 5, 7: // Old style, transitional, modern, clarendon, slab and free-form serifs
+		flags |= FlagSerif
+	case classScript:
+		flags |= FlagScript
+	case classSymbolic:
+		flags |= FlagSymbolic
+	}
+
+	switch panose[0] {
+	case panoseLatinText:
+		if familyClass != classSansSerif &&
+			panose[1] >= panoseSerifCove && panose[1] <= panoseSerifRounded {
+			flags |= FlagSerif
+		}
+		if panose[3] == panoseProportionMono {
+			flags |= FlagFixedWidth
+		}
+	case panoseLatinHand:
+		flags |= FlagScript
+	case panoseLatinSymbol:
+		flags |= FlagSymbolic
+	}
+
+	return flags
+}
+
+// parseDescriptorMetrics fills in the PDF font descriptor metrics that aren't
+// recorded directly in the font, or are missing from older fonts, from glyph
+// outlines.
+func (p *Parser) parseDescriptorMetrics() {
+	// Fonts are non-symbolic if they cover (at least) the Latin alphabet and
+	// aren't classified as symbol fonts.
+	if p.font.Flags&FlagSymbolic == 0 {
+		p.font.Flags |= FlagAdobeStandard
+		for char := range rune(26) {
+			if p.font.GlyphId('A'+char) == 0 || p.font.GlyphId('a'+char) == 0 {
+				p.font.Flags &^= FlagAdobeStandard
+				p.font.Flags |= FlagSymbolic
+				break
+			}
+		}
+	}
+
+	if p.font.CapHeight == 0 {
+		p.font.CapHeight = p.font.Ascent
+		if yMax, ok := p.glyphTop('H'); ok {
+			p.font.CapHeight = yMax
+		}
+	}
+
+	if p.font.XHeight == 0 {
+		if yMax, ok := p.glyphTop('x'); ok {
+			p.font.XHeight = yMax
+		}
+	}
+
+	p.font.StemV = p.stemV()
+}
+
+// glyphTop returns the top of the outline of the glyph for char, for
+// TrueType outlines.
+func (p *Parser) glyphTop(char rune) (yMax f32, ok bool) {
+	gid := p.font.GlyphId(char)
+	if gid == 0 {
+		return 0, false
+	}
+
+	return p.glyphYMax(gid)
+}
+
+// stemV returns the dominant vertical stem width: from the CFF Private DICT,
+// if available, or measured across the stem of 'l' or 'I'. Failing those, the
+// width is estimated from the font's weight class.
+func (p *Parser) stemV() f32 {
+	if p.font.Cff() {
+		if vals, ok := p.font.cff.privates[0].dict.ints(cffOpStdVW); ok &&
+			len(vals) == 1 && vals[0] > 0 {
+			return f32(math.Round(f64(vals[0]) * f64(p.font.Scale)))
+		}
+	} else {
+		for _, char := range "lI" {
+			if width := p.stemWidth(p.font.GlyphId(char)); width > 0 {
+				return f32(math.Round(f64(width * p.font.Scale)))
+			}
+		}
+	}
+
+	weight := f64(p.font.WeightClass)
+	if weight == 0 {
+		weight = 400
+	}
+
+	return f32(math.Round(50 + math.Pow(weight/65, 2)))
+}
+
+// stemWidth returns the width, in font units, of the leftmost stem crossed by
+// a horizontal line through the middle of a simple glyph, or 0 if the glyph
+// has no such stem.
+func (p *Parser) stemWidth(gid u16) f32 {
+	if gid == 0 {
+		return 0
+	}
+
+	offset, size := p.reader.glyfLocation(gid, p.font.LocaFormat)
+	if size < 10 {
+		return 0
+	}
+
+	data := p.reader.readAt(p.reader.Tables.Glyf.Ptr+offset, size)
+	if contourCount := i16(binary.BigEndian.Uint16(data)); contourCount <= 0 {
+		return 0
+	}
+
+	glyph := parseSimpleGlyph(data)
+	yMin, yMax := glyph.points[0].y, glyph.points[0].y
+	for _, point := range glyph.points {
+		yMin, yMax = min(yMin, point.y), max(yMax, point.y)
+	}
+
+	// Offset slightly, to avoid passing exactly through points.
+	y := (yMin+yMax)/2 + 0.25
+
+	// Crossings of the outline, approximated with straight lines between
+	// consecutive points.
+	var xs []f32
+	start := 0
+	for _, e := range glyph.ends {
+		end := int(e) + 1
+		for i := start; i < end; i++ {
+			p1, p2 := glyph.points[i], glyph.points[start]
+			if i+1 < end {
+				p2 = glyph.points[i+1]
+			}
+
+			if (p1.y < y) != (p2.y < y) {
+				xs = append(xs, p1.x+(y-p1.y)*(p2.x-p1.x)/(p2.y-p1.y))
+			}
+		}
+
+		start = end
+	}
+
+	if len(xs) < 2 {
+		return 0
+	}
+	slices.Sort(xs)
+
+	// Horizontal crossings of slanted stems are wider than the stems.
+	angle := f64(p.font.ItalicAngle) * math.Pi / 180
+
+	return (xs[1] - xs[0]) * f32(math.Cos(angle))
+}
@@ -0,0 +1,45 @@
+package ttf
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescriptorMetrics(t *testing.T) {
+	dejaVu, err := os.ReadFile("../font/DejaVuSansCondensed.ttf")
+	require.NoError(t, err)
+
+	var font Font
+	require.NoError(t, Parse(dejaVu, &font))
+	require.Equal(t, FlagAdobeStandard, font.Flags)
+	require.Equal(t, f32(81), font.StemV)
+	require.InDelta(t, 547, font.XHeight, 1)
+	require.InDelta(t, 729, font.CapHeight, 1)
+
+	var italic Font
+	require.NoError(t, Parse(robotoI, &italic))
+	require.Equal(t, FlagAdobeStandard|FlagItalic, italic.Flags)
+	require.InDelta(t, 528, italic.XHeight, 1)
+
+	// CFF fonts without a StdVW fall back to an estimate from the weight class.
+	var cff Font
+	require.NoError(t, Parse(testOtf(t, robotoI), &cff))
+	require.Equal(t, f32(88), cff.StemV)
+	require.Equal(t, FlagAdobeStandard|FlagItalic, cff.Flags)
+}
+
+func TestClassFlags(t *testing.T) {
+	latin := func(serif, proportion u8) []byte {
+		return []byte{2, serif, 0, proportion, 0, 0, 0, 0, 0, 0}
+	}
+
+	require.Equal(t, FlagSerif, classFlags(1, latin(0, 0)))
+	require.Equal(t, FlagSerif, classFlags(0, latin(2, 3)))
+	require.Equal(t, flag(0), classFlags(8, latin(2, 3)))
+	require.Equal(t, flag(0), classFlags(0, latin(11, 3)))
+	require.Equal(t, FlagFixedWidth, classFlags(0, latin(11, 9)))
+	require.Equal(t, FlagScript, classFlags(10, make([]byte, 10)))
+	require.Equal(t, FlagSymbolic, classFlags(0, []byte{5, 0, 0, 0, 0, 0, 0, 0, 0, 0}))
+}
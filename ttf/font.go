@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"slices"
+	"unicode"
 
 	"github.com/bits-and-blooms/bitset"
 )
@@ -33,31 +34,48 @@ func (t tag) String() string {
 type tableName tag
 
 const (
+	TableNameAvar tableName = 0x61766172 // 'avar'
+	TableNameCff  tableName = 0x43464620 // 'CFF '
 	TableNameCmap tableName = 0x636d6170 // 'cmap'
 	TableNameCvt  tableName = 0x63767420 // 'cvt '
 	TableNameFpgm tableName = 0x6670676d // 'fpgm'
+	TableNameFvar tableName = 0x66766172 // 'fvar'
 	TableNameGasp tableName = 0x67617370 // 'gasp'
+	TableNameGdef tableName = 0x47444546 // 'GDEF'
 	TableNameGlyf tableName = 0x676c7966 // 'glyf'
+	TableNameGpos tableName = 0x47504f53 // 'GPOS'
+	TableNameGsub tableName = 0x47535542 // 'GSUB'
+	TableNameGvar tableName = 0x67766172 // 'gvar'
 	TableNameHead tableName = 0x68656164 // 'head'
 	TableNameHhea tableName = 0x68686561 // 'hhea'
 	TableNameHmtx tableName = 0x686d7478 // 'hmtx'
+	TableNameHvar tableName = 0x48564152 // 'HVAR'
+	TableNameKern tableName = 0x6b65726e // 'kern'
 	TableNameLoca tableName = 0x6c6f6361 // 'loca'
 	TableNameMaxp tableName = 0x6d617870 // 'maxp'
 	TableNameName tableName = 0x6e616d65 // 'name'
 	TableNameOs2  tableName = 0x4f532f32 // 'OS/2'
 	TableNamePost tableName = 0x706f7374 // 'post'
 	TableNamePrep tableName = 0x70726570 // 'prep'
+	TableNameVhea tableName = 0x76686561 // 'vhea'
+	TableNameVmtx tableName = 0x766d7478 // 'vmtx'
+	TableNameVorg tableName = 0x564f5247 // 'VORG'
 )
 
 const (
+	platformMacintosh = 1
 	platformMicrosoft = 3
 	platformUnicode   = 0
 
-	codeMsUnicodeBmp = 1
-	codeUnicodeExt   = 3
+	codeMsUnicodeBmp  = 1
+	codeMsUnicodeFull = 10
+	codeUnicodeExt    = 3
+	codeUnicodeFull   = 4
+	codeUnicodeCmap13 = 6
 
 	cmapFormat4  = 4
 	cmapFormat12 = 12
+	cmapFormat13 = 13
 )
 
 type flag u32
@@ -110,9 +128,30 @@ type ufword u16
 type Font struct {
 	gids [256 * 256]u16
 
-	file   []byte
+	// Mappings for supplementary-plane characters, sorted by character.
+	gidsExt []cmapGroup
+
+	// Normalized variation coordinates, for instances of variable fonts, along
+	// with the instance's advance widths in font units.
+	coords   []f32
+	advances []u16
+
+	cff  *cffFont
+	file []byte
+
+	// Offset of the font's table directory in file, for collections.
+	faceOffset u32
+
+	kern   kerning
+	layout layoutTables
 	widths []f32
 
+	// Vertical advances and origins, for fonts with vertical metrics, along
+	// with the GSUB lookups for vertical alternates.
+	heights     []f32
+	vertOrigins []f32
+	vertLookups []planLookup
+
 	Bounds Bounds
 
 	Ascent        f32
@@ -121,10 +160,12 @@ type Font struct {
 	Flags         flag
 	ItalicAngle   f32
 	Scale         f32
+	StemV         f32
 	StrikeoutPos  f32
 	StrikeoutSize f32
 	UnderlinePos  f32
 	UnderlineSize f32
+	XHeight       f32
 
 	GlyphCount  u16
 	MetricCount u16
@@ -134,7 +175,52 @@ type Font struct {
 }
 
 func (f *Font) GlyphId(char rune) u16 {
-	return f.gids[char]
+	if char >= 0 && int(char) < len(f.gids) {
+		return f.gids[char]
+	}
+
+	i, found := slices.BinarySearchFunc(f.gidsExt, char, cmapGroup.compare)
+	if !found {
+		return 0
+	}
+
+	return f.gidsExt[i].glyphId(char)
+}
+
+// cmapGroup maps a range of characters to glyphs, as in cmap format 12 and 13
+// subtables.
+type cmapGroup struct {
+	start rune
+	end   rune
+	gid   u16
+
+	// All characters in the range map to gid, as in cmap format 13.
+	constant bool
+}
+
+func (g cmapGroup) compare(char rune) int {
+	switch {
+	case g.end < char:
+		return -1
+	case g.start > char:
+		return 1
+	}
+
+	return 0
+}
+
+func (g cmapGroup) glyphId(char rune) u16 {
+	if g.constant {
+		return g.gid
+	}
+
+	return g.gid + u16(char-g.start)
+}
+
+// Cff returns true if the font has CFF outlines, rather than TrueType
+// outlines. Subsets of CFF fonts are generated as bare CID-keyed CFF fonts.
+func (f *Font) Cff() bool {
+	return f.cff != nil
 }
 
 func (f *Font) Scaled(val fword) f32 {
@@ -150,29 +236,69 @@ type Bounds struct {
 	Min [2]f32
 }
 
+// Generate writes a subset of font containing the glyphs in gids, along with
+// any glyphs they reference, and returns the mapping from original to subset
+// glyph IDs.
+//
+// For CFF fonts, the subset is a bare CID-keyed CFF font, in which glyph IDs
+// are unchanged.
 func Generate(
 	font *Font,
-	chars *bitset.BitSet,
+	gids *bitset.BitSet,
 	out []byte,
 ) (subset []byte, gidRemap []u16, err error) {
+	if font.Cff() {
+		gidRemap = make([]u16, font.GlyphCount)
+		for gid := range gidRemap {
+			gidRemap[gid] = u16(gid)
+		}
+
+		return generateCff(font, gids, out), gidRemap, nil
+	}
+
+	// Characters mapped to the subset glyphs, for the subset cmap table.
+	var chars []uint
+	for char, gid := range font.gids {
+		if gid != 0 && gids.Test(uint(gid)) {
+			chars = append(chars, uint(char))
+		}
+	}
+	for _, group := range font.gidsExt {
+		// Many-to-one ranges are only found in last-resort fonts and would
+		// bloat the subset cmap for no benefit, since glyphs are embedded by ID.
+		if group.constant {
+			continue
+		}
+
+		for char := group.start; char <= group.end; char++ {
+			if gid := group.glyphId(char); gid != 0 && gids.Test(uint(gid)) {
+				chars = append(chars, uint(char))
+			}
+		}
+	}
+
 	gen := Generator{
-		chars:    chars.AsSlice(make([]uint, chars.Count())),
+		chars:    chars,
 		font:     font,
+		gids:     gids,
 		glyphIds: []uint{},
 		reader:   NewReader(font.file),
 		writer:   NewWriter(out),
 	}
+	gen.reader.seekTo(font.faceOffset)
 
 	return gen.generate()
 }
 
 type Generator struct {
-	chars    []uint
-	font     *Font
-	gidRemap []u16
-	glyphIds []uint
-	reader   Reader
-	writer   Writer
+	chars     []uint
+	font      *Font
+	gidRemap  []u16
+	gids      *bitset.BitSet
+	glyphIds  []uint
+	instances map[u16]instancedGlyph
+	reader    Reader
+	writer    Writer
 }
 
 func (g *Generator) copy(tableIn *Table, tableOut *Table) {
@@ -272,61 +398,78 @@ func (g *Generator) editMaxp() {
 	g.writer.u16(u16(len(g.glyphIds)))
 }
 
+// genCmap writes a format 4 subtable for BMP characters, along with a format 12
+// subtable for all characters if there are any the former can't represent.
+//
 // https://developer.apple.com/fonts/TrueType-Reference-Manual/RM06/Chap6cmap.html
 func (g *Generator) genCmap() {
 	if g.glyphIds[0] != 0 {
 		panic("expected 0 as first glyph in subset")
 	}
 
-	endCodes := [512]u16{}
-	startCodes := [512]u16{u16(g.chars[0])}
-	deltas := [512]u16{1 - startCodes[0]} // Delta arithmetic is modulo 0xffff
-
-	seg := u16(0)
-	charPrev := u16(g.chars[0])
+	// Ranges of consecutive characters mapped to consecutive subset glyphs.
+	var groups []cmapGroup
 	for _, c := range g.chars {
-		char := u16(c)
-		gidOld := g.font.GlyphId(rune(char))
-		gidNew := g.gidRemap[gidOld]
-
-		if char-charPrev > 1 {
-			endCodes[seg] = u16(charPrev)
-			seg += 1
-			startCodes[seg] = char
-			// Delta arithmetic is modulo 0xffff:
-			deltas[seg] = u16(gidNew) - startCodes[seg]
+		char := rune(c)
+		gid := g.gidRemap[g.font.GlyphId(char)]
+
+		if n := len(groups); n > 0 {
+			last := &groups[n-1]
+			if char == last.end+1 && gid == last.glyphId(char) {
+				last.end = char
+				continue
+			}
 		}
 
-		charPrev = char
+		groups = append(groups, cmapGroup{start: char, end: char, gid: gid})
 	}
-	endCodes[seg] = u16(charPrev)
 
-	seg += 1
-	endCodes[seg] = 0xffff
-	startCodes[seg] = 0xffff
-	deltas[seg] = 1 // Maps char code 0xffff to GID 0
+	// Format 4 segments, including the terminating 0xffff segment.
+	const maxSegments = 512
+	segCount := 1
+	for _, group := range groups {
+		if group.end >= 0xffff || segCount == maxSegments {
+			break
+		}
+		segCount += 1
+	}
+	groupsBmp := groups[:segCount-1]
+	full := len(groupsBmp) < len(groups)
 
-	const headerLen = 4     // version, numberSubtables
-	const subtableLen = 8   // platformId, platformSpecificId, offset
-	const segArrayCount = 4 // endCodes, startCodes, deltas, offsets
-	const paddingBytes = 2
+	const headerLen = 4   // version, numberSubtables
+	const subtableLen = 8 // platformId, platformSpecificId, offset
 
-	segCount := seg + 1
-	segCount2x := 2 * segCount
-	mappingTableLen := 14 + segCount2x*segArrayCount + paddingBytes
+	subtableCount := u16(1)
+	if full {
+		subtableCount += 1
+	}
+
+	offsetFormat4 := headerLen + subtableLen*u32(subtableCount)
+	lenFormat4 := 16 + 8*u32(segCount)
+	lenFormat12 := u32(0)
+	if full {
+		lenFormat12 = 16 + 12*u32(len(groups))
+	}
 
 	g.writer.Tables.Cmap.Ptr = g.writer.pos
-	g.writer.Tables.Cmap.Len = u32(headerLen + subtableLen + mappingTableLen)
+	g.writer.Tables.Cmap.Len = offsetFormat4 + lenFormat4 + lenFormat12
 	tableLenPadded := g.writer.Tables.Cmap.LenPadded()
 
 	g.writer.ensureCapRemaining(tableLenPadded)
 	g.writer.u16(0) // version
-	g.writer.u16(1) // numberSubtables
+	g.writer.u16(subtableCount)
 	g.writer.u16(platformMicrosoft)
 	g.writer.u16(codeMsUnicodeBmp)
-	g.writer.u32(headerLen + subtableLen) // offset
+	g.writer.u32(offsetFormat4)
+	if full {
+		g.writer.u16(platformMicrosoft)
+		g.writer.u16(codeMsUnicodeFull)
+		g.writer.u32(offsetFormat4 + lenFormat4)
+	}
+
+	segCount2x := u16(2 * segCount)
 	g.writer.u16(cmapFormat4)
-	g.writer.u16(mappingTableLen)
+	g.writer.u16(u16(lenFormat4))
 	g.writer.u16(0) // language
 	g.writer.u16(segCount2x)
 
@@ -337,12 +480,35 @@ func (g *Generator) genCmap() {
 	g.writer.u16(u16(math.Log2(f64(searchRange) / 2)))
 	g.writer.u16(u16(segCount2x - searchRange))
 
-	g.writer.u16Array(endCodes[:segCount])
+	for _, group := range groupsBmp {
+		g.writer.u16(u16(group.end))
+	}
+	g.writer.u16(0xffff)
 	g.writer.u16(0) // reservedPad
-	g.writer.u16Array(startCodes[:segCount])
-	g.writer.u16Array(deltas[:segCount])
+	for _, group := range groupsBmp {
+		g.writer.u16(u16(group.start))
+	}
+	g.writer.u16(0xffff)
+	for _, group := range groupsBmp {
+		// Delta arithmetic is modulo 0x10000:
+		g.writer.u16(group.gid - u16(group.start))
+	}
+	g.writer.u16(1)                // Maps char code 0xffff to GID 0
 	g.writer.skip(u32(segCount2x)) // idRangeOffset (leave all as 0)
 
+	if full {
+		g.writer.u16(cmapFormat12)
+		g.writer.u16(0) // reserved
+		g.writer.u32(lenFormat12)
+		g.writer.u32(0) // language
+		g.writer.u32(u32(len(groups)))
+		for _, group := range groups {
+			g.writer.u32(u32(group.start))
+			g.writer.u32(u32(group.end))
+			g.writer.u32(u32(group.gid))
+		}
+	}
+
 	g.writer.seekTo(g.writer.Tables.Cmap.Ptr + tableLenPadded)
 }
 
@@ -392,6 +558,9 @@ func (flag glyfFlag) Test(flags u16) bool {
 }
 
 type GlyfEntry struct {
+	// Glyph data for variable font instances, replacing the original data.
+	data []byte
+
 	len u32
 	ptr u32
 
@@ -406,19 +575,14 @@ func (g *Generator) genGlyfAndLoca() u16 {
 	g.writer.Tables.Glyf.Ptr = g.writer.pos
 	g.writer.Tables.Glyf.Len = 0
 
-	glyphCountEstimate := len(g.chars) + 1
+	glyphCountEstimate := int(g.gids.Count()) + 1
 	glyfs := make([]GlyfEntry, 0, glyphCountEstimate)
-	var seenGids bitset.BitSet
+	seenGids := g.gids.Clone()
 
 	// Glyph 0 is required:
 	// https://developer.apple.com/fonts/TrueType-Reference-Manual/RM07/appendixB.html
 	seenGids.Set(0)
 
-	for _, char := range g.chars {
-		gid := g.font.GlyphId(rune(char))
-		seenGids.Set(uint(gid))
-	}
-
 	gidStack := seenGids.AsSlice(make([]uint, glyphCountEstimate))
 
 	for len(gidStack) > 0 {
@@ -433,6 +597,9 @@ func (g *Generator) genGlyfAndLoca() u16 {
 			len: len,
 			ptr: ptr,
 		}
+		if g.font.coords != nil {
+			g.instanceGlyfEntry(&glyf)
+		}
 		glyfs = append(glyfs, glyf)
 
 		g.writer.Tables.Glyf.Len += glyf.lenPadded()
@@ -499,7 +666,11 @@ func (g *Generator) genGlyfAndLoca() u16 {
 		g.reader.seekTo(glyf.ptr)
 
 		ptrGlyf := g.writer.pos
-		g.writer.write(g.reader.read(glyf.len))
+		if g.font.coords != nil {
+			g.writer.write(glyf.data)
+		} else {
+			g.writer.write(g.reader.read(glyf.len))
+		}
 
 		glyfReader := NewReader(g.writer.buf[ptrGlyf : ptrGlyf+glyf.len])
 		if contourCount := glyfReader.i16(); contourCount >= 0 {
@@ -556,6 +727,19 @@ func (g *Generator) genHmtx() (metricCount u16) {
 	var lastWidth u16
 	var i int
 
+	// Variable font instances have their own advance widths and side bearings
+	// for every glyph.
+	if g.font.coords != nil {
+		for _, gid := range g.glyphIds {
+			g.writer.u16(g.font.advances[gid])
+			g.writer.u16(u16(g.instances[u16(gid)].xMin))
+		}
+
+		g.writer.seekTo(g.writer.Tables.Hmtx.Ptr + tableLenPadded)
+
+		return u16(len(g.glyphIds))
+	}
+
 	metricCountOrig := g.font.MetricCount
 
 	for i, gid = range g.glyphIds {
@@ -713,14 +897,52 @@ func (g *Generator) genPost() {
 	g.writer.seekTo(g.writer.Tables.Post.Ptr + tableLenPadded)
 }
 
-func Parse(bytes []byte, font *Font) error {
+// Parse parses a font file into font. For variable fonts, variations select
+// the instance to use, with unspecified axes left at their defaults. Subsets
+// of the instance are generated as static fonts.
+//
+// For collections, the first face is parsed. See [ParseFace].
+func Parse(bytes []byte, font *Font, variations ...Variation) error {
+	return ParseFace(bytes, 0, font, variations...)
+}
+
+// ParseFace parses the face at index in a font collection (.ttc, .otc) file,
+// as listed by [Faces]. Subsets of the face are generated as standalone fonts.
+func ParseFace(
+	bytes []byte,
+	index int,
+	font *Font,
+	variations ...Variation,
+) error {
+	offsets, err := faceOffsets(bytes)
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= len(offsets) {
+		return fmt.Errorf(
+			"face index %d out of range for %d face(s)",
+			index,
+			len(offsets),
+		)
+	}
+
 	font.file = bytes
+	font.faceOffset = offsets[index]
 	parser := Parser{
 		font:   font,
 		reader: NewReader(bytes),
 	}
+	parser.reader.seekTo(font.faceOffset)
+
+	if err := parser.parse(); err != nil {
+		return err
+	}
 
-	return parser.parse()
+	if err := parser.parseVariations(variations); err != nil {
+		return fmt.Errorf("unable to apply font variations: %w", err)
+	}
+
+	return nil
 }
 
 type Parser struct {
@@ -764,33 +986,102 @@ func (p *Parser) parse() error {
 		return err
 	}
 
+	if err = p.parseCff(); err != nil {
+		return err
+	}
+
+	p.parseDescriptorMetrics()
+
+	p.parseKerning()
+	p.parseLayout()
+	p.parseVertical()
+
+	return nil
+}
+
+func (p *Parser) parseCff() (err error) {
+	table := p.reader.Tables.Cff
+	if table.Ptr == 0 {
+		return nil
+	}
+
+	p.font.cff, err = parseCff(p.reader.readAt(table.Ptr, table.Len))
+	if err != nil {
+		return fmt.Errorf("unable to parse CFF table: %w", err)
+	}
+
+	if len(p.font.cff.charStrings.items) != int(p.font.GlyphCount) {
+		return fmt.Errorf(
+			"CFF glyph count (%d) doesn't match maxp glyph count (%d)",
+			len(p.font.cff.charStrings.items),
+			p.font.GlyphCount,
+		)
+	}
+
 	return nil
 }
 
 // https://developer.apple.com/fonts/TrueType-Reference-Manual/RM06/Chap6cmap.html
 func (p *Parser) parseCmap() error {
-	p.reader.seekTo(p.reader.Tables.Cmap.Ptr + 2) // Skip version
+	ptrCmap := p.reader.Tables.Cmap.Ptr
+	p.reader.seekTo(ptrCmap + 2) // Skip version
 
 	subtableCount := p.reader.u16()
 
-	var offset u32
-	for range subtableCount {
+	// Prefer a full-repertoire subtable, for supplementary-plane characters,
+	// falling back to a BMP-only subtable. Format 13 subtables map ranges of
+	// characters to a single glyph, e.g. in last resort fonts, and are only
+	// used in fonts without either.
+	var offsetBmp, offsetFull, offsetMany u32
+	for i := range u32(subtableCount) {
+		p.reader.seekTo(ptrCmap + 4 + i*8)
 		platform := p.reader.u16()
 		code := p.reader.u16()
+		offset := p.reader.u32()
 
-		if (platform == platformUnicode && code == codeUnicodeExt) ||
-			(platform == platformMicrosoft && code == codeMsUnicodeBmp) {
-			offset = p.reader.u32()
-			break
+		switch {
+		case (platform == platformUnicode && code == codeUnicodeExt) ||
+			(platform == platformMicrosoft && code == codeMsUnicodeBmp):
+			if offsetBmp == 0 {
+				offsetBmp = offset
+			}
+
+		case (platform == platformUnicode &&
+			(code == codeUnicodeFull || code == codeUnicodeCmap13)) ||
+			(platform == platformMicrosoft && code == codeMsUnicodeFull):
+			p.reader.seekTo(ptrCmap + offset)
+			switch p.reader.u16() {
+			case cmapFormat12:
+				if offsetFull == 0 {
+					offsetFull = offset
+				}
+			case cmapFormat13:
+				if offsetMany == 0 {
+					offsetMany = offset
+				}
+			}
 		}
+	}
+
+	if offsetFull != 0 {
+		p.reader.seekTo(ptrCmap + offsetFull + 2)
+		p.parseCmapGroups(cmapFormat12)
 
-		p.reader.skip(4) // Skip offset
+		return nil
 	}
-	if offset == 0 {
+
+	if offsetBmp == 0 {
+		if offsetMany != 0 {
+			p.reader.seekTo(ptrCmap + offsetMany + 2)
+			p.parseCmapGroups(cmapFormat13)
+
+			return nil
+		}
+
 		return fmt.Errorf("no supported unicode character map table found")
 	}
 
-	p.reader.seekTo(p.reader.Tables.Cmap.Ptr + offset)
+	p.reader.seekTo(ptrCmap + offsetBmp)
 
 	format := p.reader.u16()
 	if format != cmapFormat4 {
@@ -859,6 +1150,54 @@ func (p *Parser) parseCmap() error {
 	return nil
 }
 
+// parseCmapGroups parses a format 12 or 13 cmap subtable, from just after the
+// format field. BMP characters are mapped directly, for fast lookups, and
+// groups of supplementary-plane characters are kept for searching.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/cmap#format-12-segmented-coverage
+// https://learn.microsoft.com/en-us/typography/opentype/spec/cmap#format-13-many-to-one-range-mappings
+func (p *Parser) parseCmapGroups(format u16) {
+	p.reader.skip(10) // reserved, length, language
+
+	groupCount := min(
+		p.reader.u32(),
+		u32(len(p.reader.buf)-int(p.reader.pos))/12,
+	)
+
+	p.font.gidsExt = p.font.gidsExt[:0]
+	for range groupCount {
+		start := p.reader.u32()
+		end := min(p.reader.u32(), unicode.MaxRune)
+		gid := p.reader.u32()
+		if start > end || gid > math.MaxUint16 {
+			continue
+		}
+
+		group := cmapGroup{
+			start:    rune(start),
+			end:      rune(end),
+			gid:      u16(gid),
+			constant: format == cmapFormat13,
+		}
+
+		for char := group.start; char <= min(group.end, 0xffff); char++ {
+			p.font.gids[char] = group.glyphId(char)
+		}
+
+		if group.end > 0xffff {
+			group.start = max(group.start, 0x10000)
+			if !group.constant {
+				group.gid += u16(group.start - rune(start))
+			}
+			p.font.gidsExt = append(p.font.gidsExt, group)
+		}
+	}
+
+	slices.SortFunc(p.font.gidsExt, func(a, b cmapGroup) int {
+		return int(a.start - b.start)
+	})
+}
+
 // https://developer.apple.com/fonts/TrueType-Reference-Manual/RM06/Chap6head.html
 func (p *Parser) parseHead() error {
 	p.reader.seekTo(p.reader.Tables.Head.Ptr + 18)
@@ -943,9 +1282,11 @@ func (r *Reader) parseIndex() error {
 	case 0x7472_7565: // Four-char code: 'true'
 		fallthrough
 	case 0x0001_0000: // TrueType identifier
+		fallthrough
+	case 0x4f54_544f: // Four-char code: 'OTTO', for CFF outlines
 		break
 	default:
-		return fmt.Errorf("expected TrueType font, got type %x", typ)
+		return fmt.Errorf("expected TrueType or OpenType font, got type %x", typ)
 	}
 
 	tableCount := r.u16()
@@ -957,22 +1298,40 @@ func (r *Reader) parseIndex() error {
 		var table *Table
 
 		switch name {
+		case TableNameAvar:
+			table = &r.Tables.Avar
+		case TableNameCff:
+			table = &r.Tables.Cff
 		case TableNameCmap:
 			table = &r.Tables.Cmap
 		case TableNameCvt:
 			table = &r.Tables.Cvt
 		case TableNameFpgm:
 			table = &r.Tables.Fpgm
+		case TableNameFvar:
+			table = &r.Tables.Fvar
 		case TableNameGasp:
 			table = &r.Tables.Gasp
+		case TableNameGdef:
+			table = &r.Tables.Gdef
 		case TableNameGlyf:
 			table = &r.Tables.Glyf
+		case TableNameGpos:
+			table = &r.Tables.Gpos
+		case TableNameGsub:
+			table = &r.Tables.Gsub
+		case TableNameGvar:
+			table = &r.Tables.Gvar
 		case TableNameHead:
 			table = &r.Tables.Head
 		case TableNameHhea:
 			table = &r.Tables.Hhea
 		case TableNameHmtx:
 			table = &r.Tables.Hmtx
+		case TableNameHvar:
+			table = &r.Tables.Hvar
+		case TableNameKern:
+			table = &r.Tables.Kern
 		case TableNameLoca:
 			table = &r.Tables.Loca
 		case TableNameMaxp:
@@ -985,6 +1344,12 @@ func (r *Reader) parseIndex() error {
 			table = &r.Tables.Post
 		case TableNamePrep:
 			table = &r.Tables.Prep
+		case TableNameVhea:
+			table = &r.Tables.Vhea
+		case TableNameVmtx:
+			table = &r.Tables.Vmtx
+		case TableNameVorg:
+			table = &r.Tables.Vorg
 
 		default:
 			r.skip(12) // checksum + position + length (all u32)
@@ -999,17 +1364,20 @@ func (r *Reader) parseIndex() error {
 	}
 
 	if r.Tables.Cmap.Ptr == 0 ||
-		r.Tables.Glyf.Ptr == 0 ||
 		r.Tables.Head.Ptr == 0 ||
 		r.Tables.Hhea.Ptr == 0 ||
 		r.Tables.Hmtx.Ptr == 0 ||
-		r.Tables.Loca.Ptr == 0 ||
 		r.Tables.Maxp.Ptr == 0 ||
 		r.Tables.Name.Ptr == 0 ||
 		r.Tables.Post.Ptr == 0 {
 		return fmt.Errorf("missing one or more required TTF tables")
 	}
 
+	if r.Tables.Cff.Ptr == 0 &&
+		(r.Tables.Glyf.Ptr == 0 || r.Tables.Loca.Ptr == 0) {
+		return fmt.Errorf("missing glyph outline tables")
+	}
+
 	return nil
 }
 
@@ -1081,9 +1449,11 @@ func (p *Parser) parseOs2() error {
 	p.font.StrikeoutSize = p.fwordScaled()
 	p.font.StrikeoutPos = p.fwordScaled()
 
+	familyClass := u8(p.reader.u16() >> 8) // Class ID, without subclass
+	panose := p.reader.read(10)
+	p.font.Flags |= classFlags(familyClass, panose)
+
 	p.reader.skip(0 +
-		2 + // familyClass
-		10 + // panose
 		16 + // ulUnicodeRange
 		4 + // achVendID
 		2 + // fsSelection
@@ -1095,7 +1465,6 @@ func (p *Parser) parseOs2() error {
 	if p.font.Ascent == 0 {
 		p.font.Ascent = typoAscender
 	}
-	p.font.CapHeight = p.font.Ascent
 
 	typoDescender := p.fwordScaled()
 	if p.font.Descent == 0 {
@@ -1106,7 +1475,13 @@ func (p *Parser) parseOs2() error {
 		return nil
 	}
 
-	p.reader.skip(16)
+	p.reader.skip(0 +
+		2 + // sTypoLineGap
+		2 + // usWinAscent
+		2 + // usWinDescent
+		8, // ulCodePageRange
+	)
+	p.font.XHeight = p.fwordScaled()
 	p.font.CapHeight = p.fwordScaled()
 
 	return nil
@@ -1129,20 +1504,32 @@ func (p *Parser) parsePost() {
 }
 
 type Tables struct {
+	Avar Table
+	Cff  Table
 	Cmap Table
 	Cvt  Table
 	Fpgm Table
+	Fvar Table
 	Gasp Table
+	Gdef Table
 	Glyf Table
+	Gpos Table
+	Gsub Table
+	Gvar Table
 	Head Table
 	Hhea Table
 	Hmtx Table
+	Hvar Table
+	Kern Table
 	Loca Table
 	Maxp Table
 	Name Table
 	Os2  Table
 	Post Table
 	Prep Table
+	Vhea Table
+	Vmtx Table
+	Vorg Table
 }
 
 type Table struct {
@@ -2,9 +2,11 @@ package ttf
 
 import (
 	_ "embed"
+	"encoding/binary"
 	"testing"
 
 	"github.com/bits-and-blooms/bitset"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -67,3 +69,122 @@ func BenchmarkGenerate(b *testing.B) {
 		_ = subset
 	}
 }
+
+func TestGlyphIdSupplementary(t *testing.T) {
+	var font Font
+	require.NoError(t, Parse(notoSc, &font))
+
+	// Mathematical double-struck capitals, from a format 12 cmap subtable.
+	require.NotZero(t, font.GlyphId(0x1d538))
+	require.Equal(t, font.GlyphId(0x1d538)+1, font.GlyphId(0x1d539))
+
+	require.Zero(t, font.GlyphId(0x1d53a)) // Unassigned in the font.
+	require.Zero(t, font.GlyphId(0x10ffff))
+	require.Zero(t, font.GlyphId(-1))
+
+	// BMP characters are still mapped.
+	require.NotZero(t, font.GlyphId('A'))
+}
+
+func TestParseCmapPreference(t *testing.T) {
+	be := binary.BigEndian
+
+	// A format 4 subtable mapping 'A' to glyph 5.
+	format4 := be.AppendUint16(nil, cmapFormat4)
+	format4 = be.AppendUint16(format4, 32) // length
+	format4 = be.AppendUint16(format4, 0)  // language
+	format4 = be.AppendUint16(format4, 4)  // segCountX2
+	format4 = append(format4, make([]byte, 6)...)
+	for _, v := range []u16{'A', 0xffff, 0, 'A', 0xffff, 0x10000 + 5 - 'A', 1, 0, 0} {
+		format4 = be.AppendUint16(format4, v)
+	}
+
+	// Format 12 and 13 subtables with a single group.
+	groups := func(format u16, start, end, gid u32) []byte {
+		b := be.AppendUint16(nil, format)
+		b = be.AppendUint16(b, 0)  // reserved
+		b = be.AppendUint32(b, 28) // length
+		b = be.AppendUint32(b, 0)  // language
+		b = be.AppendUint32(b, 1)  // numGroups
+		b = be.AppendUint32(b, start)
+		b = be.AppendUint32(b, end)
+		return be.AppendUint32(b, gid)
+	}
+	format12 := groups(cmapFormat12, 'A', 'B', 7)
+	format13 := groups(cmapFormat13, 0, 0x10ffff, 1)
+
+	type subtable struct {
+		platform, code u16
+		data           []byte
+	}
+	parse := func(subtables ...subtable) *Font {
+		cmap := be.AppendUint16(nil, 0) // version
+		cmap = be.AppendUint16(cmap, u16(len(subtables)))
+		offset := u32(4 + 8*len(subtables))
+		for _, sub := range subtables {
+			cmap = be.AppendUint16(cmap, sub.platform)
+			cmap = be.AppendUint16(cmap, sub.code)
+			cmap = be.AppendUint32(cmap, offset)
+			offset += u32(len(sub.data))
+		}
+		for _, sub := range subtables {
+			cmap = append(cmap, sub.data...)
+		}
+
+		font := new(Font)
+		p := Parser{font: font, reader: NewReader(cmap)}
+		p.reader.Tables.Cmap = Table{Len: u32(len(cmap))}
+		require.NoError(t, p.parseCmap())
+		return font
+	}
+
+	bmp := subtable{platformMicrosoft, codeMsUnicodeBmp, format4}
+	full := subtable{platformMicrosoft, codeMsUnicodeFull, format12}
+	lastResort := subtable{platformUnicode, codeUnicodeCmap13, format13}
+
+	// Format 13 subtables are only used in fonts without others.
+	font := parse(lastResort, bmp)
+	require.Equal(t, u16(5), font.GlyphId('A'))
+	require.Zero(t, font.GlyphId('B'))
+	require.Zero(t, font.GlyphId(0x1d538))
+
+	font = parse(lastResort, full, bmp)
+	require.Equal(t, u16(7), font.GlyphId('A'))
+	require.Equal(t, u16(8), font.GlyphId('B'))
+	require.Zero(t, font.GlyphId('C'))
+
+	font = parse(lastResort)
+	require.Equal(t, u16(1), font.GlyphId('A'))
+	require.Equal(t, u16(1), font.GlyphId(0x1d538))
+}
+
+func TestGenerateCmap(t *testing.T) {
+	var font Font
+	require.NoError(t, Parse(notoSc, &font))
+
+	// BMP-only subsets get a single format 4 subtable.
+	testGenerateCmap(t, &font, []rune{'A', 'B', 'z', 0x4e00})
+
+	testGenerateCmap(t, &font, []rune{'A', 'B', 'z', 0x1d538, 0x1d539, 0x1d53b})
+}
+
+func testGenerateCmap(t *testing.T, font *Font, chars []rune) {
+	var gids bitset.BitSet
+	for _, char := range chars {
+		gids.Set(uint(font.GlyphId(char)))
+	}
+
+	subsetBytes, gidRemap, err := Generate(font, &gids, nil)
+	require.NoError(t, err)
+
+	var subset Font
+	require.NoError(t, Parse(subsetBytes, &subset))
+	for _, char := range chars {
+		require.Equal(t,
+			gidRemap[font.GlyphId(char)],
+			subset.GlyphId(char),
+			"char %U", char,
+		)
+	}
+	require.Zero(t, subset.GlyphId('C'))
+}
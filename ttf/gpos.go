@@ -0,0 +1,367 @@
+package ttf
+
+// positionStage applies the given GPOS lookups, in order, across the whole
+// buffer.
+func (b *shapeBuffer) positionStage(stage []planLookup) {
+	lookups := b.font.layout.gposLookups
+
+	for _, pl := range stage {
+		if int(pl.index) >= len(lookups) {
+			continue
+		}
+		l := &lookups[pl.index]
+
+		for i := 0; i < len(b.glyphs); {
+			if b.glyphs[i].mask&pl.mask == 0 || b.skip(l, i) {
+				i += 1
+				continue
+			}
+
+			i = max(b.position(l, i, 0), i+1)
+		}
+	}
+}
+
+// position applies the first matching subtable of lookup l at index i.
+// Returns the index at which to continue applying the lookup, or -1 if no
+// subtable applies.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gpos
+func (b *shapeBuffer) position(l *lookup, i int, depth int) int {
+	nested := func(index u16, pos int) int {
+		lookups := b.font.layout.gposLookups
+		if depth >= maxLookupDepth || int(index) >= len(lookups) {
+			return 0
+		}
+
+		if nl := &lookups[index]; !b.skip(nl, pos) {
+			b.position(nl, pos, depth+1)
+		}
+
+		return 0
+	}
+
+	for _, ptr := range l.subtables {
+		end := -1
+
+		switch l.typ {
+		case gposLookupSingle:
+			end = b.positionSingle(ptr, i)
+		case gposLookupPair:
+			end = b.positionPair(l, ptr, i)
+		case gposLookupMarkToBase:
+			end = b.positionMarkToBase(ptr, i)
+		case gposLookupMarkToLig:
+			end = b.positionMarkToLig(ptr, i)
+		case gposLookupMarkToMark:
+			end = b.positionMarkToMark(l, ptr, i)
+		case gposLookupContext:
+			end = b.applyContext(l, ptr, i, false, nested)
+		case gposLookupChainContext:
+			end = b.applyContext(l, ptr, i, true, nested)
+		}
+
+		if end >= 0 {
+			return end
+		}
+	}
+
+	return -1
+}
+
+// applyValue reads a value record at the current position and applies it to
+// the glyph at index i. Device and variation tables are ignored.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gpos#value-record
+func (b *shapeBuffer) applyValue(i int, format u16) {
+	r := &b.reader
+	g := &b.glyphs[i]
+
+	if valueFormat(format)&valueXPlacement != 0 {
+		g.xOffset += b.font.Scaled(r.fword())
+	}
+	if valueFormat(format)&valueYPlacement != 0 {
+		g.yOffset += b.font.Scaled(r.fword())
+	}
+	if valueFormat(format)&valueXAdvance != 0 {
+		g.advance += b.font.Scaled(r.fword())
+	}
+}
+
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gpos#lookup-type-1-single-adjustment-positioning-subtable
+func (b *shapeBuffer) positionSingle(ptr u32, i int) int {
+	r := &b.reader
+
+	r.seekTo(ptr)
+	format := r.u16()
+	ptrCoverage := ptr + u32(r.u16())
+	valueFormat := r.u16()
+
+	index := r.coverageIndex(ptrCoverage, b.glyphs[i].gid)
+	if index < 0 {
+		return -1
+	}
+
+	switch format {
+	case 1:
+		r.seekTo(ptr + 6)
+	case 2:
+		r.seekTo(ptr + 6)
+		if index >= int(r.u16()) {
+			return -1
+		}
+		r.skip(u32(index) * valueRecordLen(valueFormat))
+	default:
+		return -1
+	}
+
+	b.applyValue(i, valueFormat)
+
+	return i + 1
+}
+
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gpos#lookup-type-2-pair-adjustment-positioning-subtable
+func (b *shapeBuffer) positionPair(l *lookup, ptr u32, i int) int {
+	j := b.next(l, i)
+	if j < 0 {
+		return -1
+	}
+
+	r := &b.reader
+	left, right := b.glyphs[i].gid, b.glyphs[j].gid
+
+	r.seekTo(ptr)
+	format := r.u16()
+	ptrCoverage := ptr + u32(r.u16())
+	valueFormat1 := r.u16()
+	valueFormat2 := r.u16()
+
+	index := r.coverageIndex(ptrCoverage, left)
+	if index < 0 {
+		return -1
+	}
+
+	switch format {
+	case 1:
+		if index >= int(r.u16()) {
+			return -1
+		}
+		r.seekTo(ptr + 10 + u32(index)*2)
+		ptrSet := ptr + u32(r.u16())
+
+		recordLen := 2 + valueRecordLen(valueFormat1) + valueRecordLen(valueFormat2)
+		r.seekTo(ptrSet)
+		count := int(r.u16())
+		k, found := binarySearch(count, func(k int) int {
+			r.seekTo(ptrSet + 2 + u32(k)*recordLen)
+			return int(r.u16()) - int(right)
+		})
+		if !found {
+			return -1
+		}
+		r.seekTo(ptrSet + 2 + u32(k)*recordLen + 2)
+
+	case 2:
+		ptrClassDef1 := ptr + u32(r.u16())
+		ptrClassDef2 := ptr + u32(r.u16())
+		class1Count := r.u16()
+		class2Count := r.u16()
+
+		class1 := r.classOf(ptrClassDef1, left)
+		class2 := r.classOf(ptrClassDef2, right)
+		if class1 >= class1Count || class2 >= class2Count {
+			return -1
+		}
+
+		recordLen := valueRecordLen(valueFormat1) + valueRecordLen(valueFormat2)
+		r.seekTo(ptr + 16 + (u32(class1)*u32(class2Count)+u32(class2))*recordLen)
+
+	default:
+		return -1
+	}
+
+	b.applyValue(i, valueFormat1)
+	b.applyValue(j, valueFormat2)
+
+	if valueFormat2 == 0 {
+		return j
+	}
+
+	return j + 1
+}
+
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gpos#lookup-type-4-mark-to-base-attachment-positioning-subtable
+func (b *shapeBuffer) positionMarkToBase(ptr u32, i int) int {
+	base := i - 1
+	for base >= 0 && b.glyphs[base].class == glyphClassMark {
+		base -= 1
+	}
+	if base < 0 {
+		return -1
+	}
+
+	att, ok := b.markAttachment(ptr, i, base)
+	if !ok {
+		return -1
+	}
+
+	r := &b.reader
+	r.seekTo(att.ptrBaseArray)
+	if att.baseIndex >= int(r.u16()) {
+		return -1
+	}
+
+	r.seekTo(att.ptrBaseArray + 2 +
+		(u32(att.baseIndex)*u32(att.classCount)+u32(att.class))*2)
+
+	return b.attachMark(i, base, &att, att.ptrBaseArray)
+}
+
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gpos#lookup-type-5-mark-to-ligature-attachment-positioning-subtable
+func (b *shapeBuffer) positionMarkToLig(ptr u32, i int) int {
+	lig := i - 1
+	for lig >= 0 && b.glyphs[lig].class == glyphClassMark {
+		lig -= 1
+	}
+	if lig < 0 {
+		return -1
+	}
+
+	att, ok := b.markAttachment(ptr, i, lig)
+	if !ok {
+		return -1
+	}
+
+	r := &b.reader
+	r.seekTo(att.ptrBaseArray)
+	if att.baseIndex >= int(r.u16()) {
+		return -1
+	}
+
+	r.seekTo(att.ptrBaseArray + 2 + u32(att.baseIndex)*2)
+	ptrAttach := att.ptrBaseArray + u32(r.u16())
+
+	// Marks are attached to the last component of the ligature.
+	r.seekTo(ptrAttach)
+	componentCount := u32(r.u16())
+	if componentCount == 0 {
+		return -1
+	}
+
+	r.seekTo(ptrAttach + 2 +
+		((componentCount-1)*u32(att.classCount)+u32(att.class))*2)
+
+	return b.attachMark(i, lig, &att, ptrAttach)
+}
+
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gpos#lookup-type-6-mark-to-mark-attachment-positioning-subtable
+func (b *shapeBuffer) positionMarkToMark(l *lookup, ptr u32, i int) int {
+	base := b.prev(l, i)
+	if base < 0 || b.glyphs[base].class != glyphClassMark {
+		return -1
+	}
+
+	att, ok := b.markAttachment(ptr, i, base)
+	if !ok {
+		return -1
+	}
+
+	r := &b.reader
+	r.seekTo(att.ptrBaseArray)
+	if att.baseIndex >= int(r.u16()) {
+		return -1
+	}
+
+	r.seekTo(att.ptrBaseArray + 2 +
+		(u32(att.baseIndex)*u32(att.classCount)+u32(att.class))*2)
+
+	return b.attachMark(i, base, &att, att.ptrBaseArray)
+}
+
+// Mark attachment subtable data for a mark glyph and the glyph it attaches
+// to. The three mark attachment subtable types share a common header layout.
+type markAttachment struct {
+	ptrBaseArray u32
+	ptrMarkArray u32
+
+	baseIndex int
+	markIndex int
+
+	class      u16
+	classCount u16
+}
+
+func (b *shapeBuffer) markAttachment(
+	ptr u32,
+	mark, base int,
+) (att markAttachment, ok bool) {
+	r := &b.reader
+
+	r.seekTo(ptr)
+	if format := r.u16(); format != 1 {
+		return att, false
+	}
+	ptrMarkCoverage := ptr + u32(r.u16())
+	ptrBaseCoverage := ptr + u32(r.u16())
+	att.classCount = r.u16()
+	att.ptrMarkArray = ptr + u32(r.u16())
+	att.ptrBaseArray = ptr + u32(r.u16())
+
+	if att.markIndex = r.coverageIndex(ptrMarkCoverage, b.glyphs[mark].gid); att.markIndex < 0 {
+		return att, false
+	}
+	if att.baseIndex = r.coverageIndex(ptrBaseCoverage, b.glyphs[base].gid); att.baseIndex < 0 {
+		return att, false
+	}
+
+	// https://learn.microsoft.com/en-us/typography/opentype/spec/gpos#mark-array-table
+	r.seekTo(att.ptrMarkArray)
+	if att.markIndex >= int(r.u16()) {
+		return att, false
+	}
+	r.seekTo(att.ptrMarkArray + 2 + u32(att.markIndex)*4)
+	att.class = r.u16()
+
+	return att, att.class < att.classCount
+}
+
+// attachMark positions the mark at index i relative to the glyph at index
+// base. The reader must be positioned at the offset of the base anchor,
+// relative to ptrAnchorBase.
+func (b *shapeBuffer) attachMark(
+	i, base int,
+	att *markAttachment,
+	ptrAnchorBase u32,
+) int {
+	r := &b.reader
+
+	offset := r.u16()
+	if offset == 0 {
+		return -1
+	}
+	baseX, baseY := b.anchor(ptrAnchorBase + u32(offset))
+
+	r.seekTo(att.ptrMarkArray + 2 + u32(att.markIndex)*4 + 2)
+	markX, markY := b.anchor(att.ptrMarkArray + u32(r.u16()))
+
+	g := &b.glyphs[i]
+	g.attach = base
+	g.xOffset = baseX - markX
+	g.yOffset = baseY - markY
+
+	return i + 1
+}
+
+// anchor returns the coordinates of the anchor table at ptr, ignoring contour
+// points and device tables.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gpos#anchor-tables
+func (b *shapeBuffer) anchor(ptr u32) (x, y f32) {
+	r := &b.reader
+	r.seekTo(ptr + 2) // Skip format
+
+	x = b.font.Scaled(r.fword())
+	y = b.font.Scaled(r.fword())
+
+	return
+}
@@ -0,0 +1,257 @@
+package ttf
+
+import (
+	"slices"
+)
+
+// Maximum nesting of lookups applied from contextual lookups.
+const maxLookupDepth = 6
+
+// substituteStage applies the given GSUB lookups, in order, across the whole
+// buffer.
+func (b *shapeBuffer) substituteStage(stage []planLookup) {
+	lookups := b.font.layout.gsubLookups
+
+	for _, pl := range stage {
+		if int(pl.index) >= len(lookups) {
+			continue
+		}
+		l := &lookups[pl.index]
+
+		for i := 0; i < len(b.glyphs); {
+			if b.glyphs[i].mask&pl.mask == 0 || b.skip(l, i) {
+				i += 1
+				continue
+			}
+
+			n := len(b.glyphs)
+			switch end := b.substitute(l, i, 0); {
+			case end > i:
+				i = end
+			case end == i && len(b.glyphs) < n:
+				// Glyph deleted - the next glyph is now at index i.
+			default:
+				i += 1
+			}
+		}
+	}
+}
+
+// substitute applies the first matching subtable of lookup l at index i.
+// Returns the index after the last glyph affected by the substitution, or -1
+// if no subtable applies.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gsub
+func (b *shapeBuffer) substitute(l *lookup, i int, depth int) int {
+	nested := func(index u16, pos int) int {
+		lookups := b.font.layout.gsubLookups
+		if depth >= maxLookupDepth || int(index) >= len(lookups) {
+			return 0
+		}
+
+		n := len(b.glyphs)
+		if nl := &lookups[index]; pos < n && !b.skip(nl, pos) {
+			b.substitute(nl, pos, depth+1)
+		}
+
+		return len(b.glyphs) - n
+	}
+
+	for _, ptr := range l.subtables {
+		end := -1
+
+		switch l.typ {
+		case gsubLookupSingle:
+			end = b.substituteSingle(ptr, i)
+		case gsubLookupMultiple:
+			end = b.substituteMultiple(ptr, i)
+		case gsubLookupAlternate:
+			end = b.substituteAlternate(ptr, i)
+		case gsubLookupLigature:
+			end = b.substituteLigature(l, ptr, i)
+		case gsubLookupContext:
+			end = b.applyContext(l, ptr, i, false, nested)
+		case gsubLookupChainContext:
+			end = b.applyContext(l, ptr, i, true, nested)
+		}
+
+		if end >= 0 {
+			return end
+		}
+	}
+
+	return -1
+}
+
+// setGlyph replaces the glyph ID at index i.
+func (b *shapeBuffer) setGlyph(i int, gid u16) {
+	g := &b.glyphs[i]
+	g.gid = gid
+	g.class = b.glyphClass(gid, g.char)
+}
+
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gsub#lookuptype-1-single-substitution-subtable
+func (b *shapeBuffer) substituteSingle(ptr u32, i int) int {
+	r := &b.reader
+	gid := b.glyphs[i].gid
+
+	r.seekTo(ptr)
+	format := r.u16()
+	ptrCoverage := ptr + u32(r.u16())
+
+	switch format {
+	case 1:
+		delta := r.i16()
+		if r.coverageIndex(ptrCoverage, gid) < 0 {
+			return -1
+		}
+		b.setGlyph(i, u16(i32(gid)+i32(delta)))
+
+	case 2:
+		count := r.u16()
+		index := r.coverageIndex(ptrCoverage, gid)
+		if index < 0 || index >= int(count) {
+			return -1
+		}
+		r.seekTo(ptr + 6 + u32(index)*2)
+		b.setGlyph(i, r.u16())
+
+	default:
+		return -1
+	}
+
+	return i + 1
+}
+
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gsub#lookuptype-2-multiple-substitution-subtable
+func (b *shapeBuffer) substituteMultiple(ptr u32, i int) int {
+	ptrSequence := b.coveredOffset(ptr, i)
+	if ptrSequence == 0 {
+		return -1
+	}
+
+	r := &b.reader
+	r.seekTo(ptrSequence)
+	gids := make([]u16, r.u16())
+	for k := range gids {
+		gids[k] = r.u16()
+	}
+
+	if len(gids) == 0 {
+		b.glyphs = slices.Delete(b.glyphs, i, i+1)
+		return i
+	}
+
+	g := b.glyphs[i]
+	b.glyphs = slices.Insert(b.glyphs, i+1, make([]glyphInfo, len(gids)-1)...)
+	for k, gid := range gids {
+		b.glyphs[i+k] = g
+		b.setGlyph(i+k, gid)
+	}
+
+	return i + len(gids)
+}
+
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gsub#lookuptype-3-alternate-substitution-subtable
+func (b *shapeBuffer) substituteAlternate(ptr u32, i int) int {
+	ptrSet := b.coveredOffset(ptr, i)
+	if ptrSet == 0 {
+		return -1
+	}
+
+	r := &b.reader
+	r.seekTo(ptrSet)
+	if r.u16() == 0 {
+		return -1
+	}
+
+	b.setGlyph(i, r.u16())
+
+	return i + 1
+}
+
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gsub#lookuptype-4-ligature-substitution-subtable
+func (b *shapeBuffer) substituteLigature(l *lookup, ptr u32, i int) int {
+	ptrSet := b.coveredOffset(ptr, i)
+	if ptrSet == 0 {
+		return -1
+	}
+
+	r := &b.reader
+	r.seekTo(ptrSet)
+	ligatureCount := u32(r.u16())
+
+	var positions []int
+	for k := range ligatureCount {
+		r.seekTo(ptrSet + 2 + k*2)
+		ptrLigature := ptrSet + u32(r.u16())
+
+		r.seekTo(ptrLigature)
+		ligature := r.u16()
+		componentCount := r.u16()
+		if componentCount == 0 {
+			continue
+		}
+
+		positions = append(positions[:0], i)
+		for c := range u32(componentCount - 1) {
+			j := b.next(l, positions[len(positions)-1])
+			if j < 0 {
+				break
+			}
+
+			r.seekTo(ptrLigature + 4 + c*2)
+			if r.u16() != b.glyphs[j].gid {
+				break
+			}
+			positions = append(positions, j)
+		}
+
+		if len(positions) != int(componentCount) {
+			continue
+		}
+
+		g := &b.glyphs[i]
+		for _, j := range positions[1:] {
+			g.cluster = min(g.cluster, b.glyphs[j].cluster)
+		}
+		g.ligComponents = componentCount
+		b.setGlyph(i, ligature)
+
+		for _, j := range slices.Backward(positions[1:]) {
+			b.glyphs = slices.Delete(b.glyphs, j, j+1)
+		}
+
+		return i + 1
+	}
+
+	return -1
+}
+
+// coveredOffset reads a subtable with the common layout of a format number,
+// a coverage table offset, and an array of offsets indexed by coverage index.
+// Returns the absolute position of the entry for the glyph at index i, or 0
+// if the glyph isn't covered.
+func (b *shapeBuffer) coveredOffset(ptr u32, i int) u32 {
+	r := &b.reader
+
+	r.seekTo(ptr)
+	if format := r.u16(); format != 1 {
+		return 0
+	}
+	ptrCoverage := ptr + u32(r.u16())
+	count := r.u16()
+
+	index := r.coverageIndex(ptrCoverage, b.glyphs[i].gid)
+	if index < 0 || index >= int(count) {
+		return 0
+	}
+
+	r.seekTo(ptr + 6 + u32(index)*2)
+	offset := r.u16()
+	if offset == 0 {
+		return 0
+	}
+
+	return ptr + u32(offset)
+}
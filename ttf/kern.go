@@ -0,0 +1,254 @@
+package ttf
+
+import (
+	"github.com/bits-and-blooms/bitset"
+)
+
+// kerning holds pair adjustments from the GPOS 'kern' feature or, for fonts
+// without one, the legacy 'kern' table. Values are scaled to 1/1000 em, like
+// glyph widths.
+type kerning struct {
+	// Adjustments for individual glyph pairs, keyed by kernKey(). These take
+	// precedence over class-based adjustments, which allows fonts to specify
+	// exceptions to class kerning (including zero-value exceptions).
+	pairs map[u32]f32
+
+	// Class-based adjustments, in lookup order.
+	classes []kernClasses
+}
+
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gpos#pair-adjustment-positioning-format-2-class-pair-adjustment
+type kernClasses struct {
+	coverage bitset.BitSet
+	class1   []u16
+	class2   []u16
+	values   []f32
+
+	class1Count, class2Count u16
+}
+
+func kernKey(left, right u16) u32 {
+	return u32(left)<<16 | u32(right)
+}
+
+func (k *kerning) adjustment(left, right u16) f32 {
+	if val, ok := k.pairs[kernKey(left, right)]; ok {
+		return val
+	}
+
+	for i := range k.classes {
+		c := &k.classes[i]
+		if !c.coverage.Test(uint(left)) {
+			continue
+		}
+
+		// Class definitions of malformed fonts may use classes beyond the
+		// counts of the subtable, which have no values.
+		class1 := glyphClass(c.class1, left)
+		class2 := glyphClass(c.class2, right)
+		if class1 >= c.class1Count || class2 >= c.class2Count {
+			return 0
+		}
+
+		return c.values[u32(class1)*u32(c.class2Count)+u32(class2)]
+	}
+
+	return 0
+}
+
+func (k *kerning) empty() bool {
+	return len(k.pairs) == 0 && len(k.classes) == 0
+}
+
+func (k *kerning) setPair(left, right u16, val f32) {
+	if k.pairs == nil {
+		k.pairs = map[u32]f32{}
+	}
+
+	key := kernKey(left, right)
+	if _, exists := k.pairs[key]; !exists {
+		k.pairs[key] = val
+	}
+}
+
+// HasKerning returns true if the font contains pair kerning data.
+func (f *Font) HasKerning() bool {
+	return !f.kern.empty()
+}
+
+// Kern returns the horizontal adjustment, in 1/1000 em, to apply between the
+// glyphs left and right, when set next to each other in that order. Negative
+// values move the glyphs closer together.
+func (f *Font) Kern(left, right u16) f32 {
+	if f.kern.empty() {
+		return 0
+	}
+
+	return f.kern.adjustment(left, right)
+}
+
+func (p *Parser) parseKerning() {
+	if p.reader.Tables.Gpos.Ptr != 0 {
+		p.parseGposKerning()
+		if !p.font.kern.empty() {
+			return
+		}
+	}
+
+	if p.reader.Tables.Kern.Ptr != 0 {
+		p.parseKern()
+	}
+}
+
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gpos
+func (p *Parser) parseGposKerning() {
+	ptrGpos := p.reader.Tables.Gpos.Ptr
+
+	for _, index := range p.reader.featureLookups(ptrGpos, featureKern) {
+		lookup := p.reader.lookup(ptrGpos, index, gposLookupExtension)
+		if lookup.typ != gposLookupPair {
+			continue
+		}
+
+		for _, ptr := range lookup.subtables {
+			p.reader.seekTo(ptr)
+			switch format := p.reader.u16(); format {
+			case 1:
+				p.parseGposPairs(ptr)
+			case 2:
+				p.parseGposPairClasses(ptr)
+			}
+		}
+	}
+}
+
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gpos#pair-adjustment-positioning-format-1-adjustments-for-glyph-pairs
+func (p *Parser) parseGposPairs(ptr u32) {
+	p.reader.seekTo(ptr + 2) // Skip format
+	ptrCoverage := ptr + u32(p.reader.u16())
+	valueFormat1 := p.reader.u16()
+	valueFormat2 := p.reader.u16()
+
+	offsetAdvance := valueOffset(valueFormat1, valueXAdvance)
+	if offsetAdvance < 0 {
+		return
+	}
+
+	pairSetCount := p.reader.u16()
+	ptrPairSets := p.reader.pos
+	recordLen := 2 + valueRecordLen(valueFormat1) + valueRecordLen(valueFormat2)
+
+	lefts := p.reader.coverage(ptrCoverage)
+	for i, left := range lefts[:min(len(lefts), int(pairSetCount))] {
+		p.reader.seekTo(ptrPairSets + u32(i)*2)
+		ptrPairSet := ptr + u32(p.reader.u16())
+
+		p.reader.seekTo(ptrPairSet)
+		pairCount := u32(p.reader.u16())
+		for j := range pairCount {
+			p.reader.seekTo(ptrPairSet + 2 + j*recordLen)
+			right := p.reader.u16()
+
+			p.reader.skip(u32(offsetAdvance))
+			p.font.kern.setPair(left, right, p.fwordScaled())
+		}
+	}
+}
+
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gpos#pair-adjustment-positioning-format-2-class-pair-adjustment
+func (p *Parser) parseGposPairClasses(ptr u32) {
+	p.reader.seekTo(ptr + 2) // Skip format
+	ptrCoverage := ptr + u32(p.reader.u16())
+	valueFormat1 := p.reader.u16()
+	valueFormat2 := p.reader.u16()
+	ptrClassDef1 := ptr + u32(p.reader.u16())
+	ptrClassDef2 := ptr + u32(p.reader.u16())
+	class1Count := p.reader.u16()
+	class2Count := p.reader.u16()
+	ptrRecords := p.reader.pos
+
+	offsetAdvance := valueOffset(valueFormat1, valueXAdvance)
+	if offsetAdvance < 0 {
+		return
+	}
+
+	classes := kernClasses{
+		class1:      p.reader.classDef(ptrClassDef1),
+		class2:      p.reader.classDef(ptrClassDef2),
+		class1Count: class1Count,
+		class2Count: class2Count,
+		values:      make([]f32, u32(class1Count)*u32(class2Count)),
+	}
+
+	for _, gid := range p.reader.coverage(ptrCoverage) {
+		classes.coverage.Set(uint(gid))
+	}
+
+	recordLen := valueRecordLen(valueFormat1) + valueRecordLen(valueFormat2)
+	for i := range classes.values {
+		p.reader.seekTo(ptrRecords + u32(i)*recordLen + u32(offsetAdvance))
+		classes.values[i] = p.fwordScaled()
+	}
+
+	p.font.kern.classes = append(p.font.kern.classes, classes)
+}
+
+// https://developer.apple.com/fonts/TrueType-Reference-Manual/RM06/Chap6kern.html
+// https://learn.microsoft.com/en-us/typography/opentype/spec/kern
+func (p *Parser) parseKern() {
+	p.reader.seekTo(p.reader.Tables.Kern.Ptr)
+
+	const formatPairs = 0
+
+	// Apple's version 1.0 table has a 32-bit version and table count, and a
+	// different subtable header layout.
+	var subtableCount u32
+	isApple := p.reader.u16() == 1
+	if isApple {
+		p.reader.skip(2)
+		subtableCount = p.reader.u32()
+	} else {
+		subtableCount = u32(p.reader.u16())
+	}
+
+	for range subtableCount {
+		ptrSubtable := p.reader.pos
+
+		var subtableLen u32
+		var format u8
+		var horizontal, crossStream bool
+		if isApple {
+			subtableLen = p.reader.u32()
+			coverage := p.reader.u16()
+			p.reader.skip(2) // tupleIndex
+
+			format = u8(coverage)
+			horizontal = coverage&0x8000 == 0
+			crossStream = coverage&0x4000 != 0
+		} else {
+			p.reader.skip(2) // version
+			subtableLen = u32(p.reader.u16())
+			coverage := p.reader.u16()
+
+			format = u8(coverage >> 8)
+			horizontal = coverage&0x1 != 0
+			crossStream = coverage&0x4 != 0
+		}
+
+		if format != formatPairs || !horizontal || crossStream {
+			p.reader.seekTo(ptrSubtable + subtableLen)
+			continue
+		}
+
+		// The 16-bit length field of large Microsoft subtables overflows, so
+		// rely on the pair count to find the end of the subtable instead.
+		pairCount := p.reader.u16()
+		p.reader.skip(6) // Search helper params
+
+		for range pairCount {
+			left := p.reader.u16()
+			right := p.reader.u16()
+			p.font.kern.setPair(left, right, p.fwordScaled())
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package ttf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKernGpos(t *testing.T) {
+	var font Font
+	require.NoError(t, Parse(robotoI, &font))
+	require.True(t, font.HasKerning())
+
+	gid := font.GlyphId
+
+	require.Less(t, font.Kern(gid('A'), gid('V')), f32(0))
+	require.Less(t, font.Kern(gid('T'), gid('o')), f32(0))
+	require.Equal(t, f32(0), font.Kern(gid('o'), gid('T')))
+	require.Equal(t, f32(0), font.Kern(gid('a'), gid('b')))
+}
+
+func TestKernLegacyTable(t *testing.T) {
+	var gpos, legacy Font
+	require.NoError(t, Parse(robotoI, &gpos))
+
+	// Roboto doesn't have a 'kern' table - build one from its GPOS pairs:
+	gid := gpos.GlyphId
+	pairs := [][2]u16{
+		{gid('A'), gid('V')},
+		{gid('T'), gid('o')},
+	}
+
+	table := []byte{
+		0, 0, // version
+		0, 1, // nTables
+		0, 0, // subtable version
+		0, byte(14 + 6*len(pairs)), // length
+		0, 1, // coverage: format 0, horizontal
+		0, byte(len(pairs)), // nPairs
+		0, 0, 0, 0, 0, 0, // searchRange, entrySelector, rangeShift
+	}
+	for _, pair := range pairs {
+		val := fword(gpos.Kern(pair[0], pair[1]) / gpos.Scale)
+		table = append(table,
+			byte(pair[0]>>8), byte(pair[0]),
+			byte(pair[1]>>8), byte(pair[1]),
+			byte(u16(val)>>8), byte(u16(val)),
+		)
+	}
+
+	legacy.Scale = gpos.Scale
+	parser := Parser{font: &legacy, reader: NewReader(table)}
+	parser.reader.Tables.Kern = Table{Len: u32(len(table))}
+	parser.parseKern()
+
+	for _, pair := range pairs {
+		require.InDelta(t, gpos.Kern(pair[0], pair[1]), legacy.Kern(pair[0], pair[1]), 1)
+	}
+	require.Equal(t, f32(0), legacy.Kern(gid('o'), gid('T')))
+}
+
+func TestKernClassesOutOfRange(t *testing.T) {
+	// Glyph 1 is in class 2 of the first glyphs, and glyph 2 in class 3 of the
+	// second, beyond the counts of the subtable.
+	classes := kernClasses{
+		class1:      []u16{0, 2, 1},
+		class2:      []u16{0, 1, 3},
+		values:      []f32{-10, -20, -30, -40},
+		class1Count: 2,
+		class2Count: 2,
+	}
+	classes.coverage.Set(1)
+	classes.coverage.Set(2)
+	font := Font{kern: kerning{classes: []kernClasses{classes}}}
+
+	require.Equal(t, f32(0), font.Kern(1, 1))
+	require.Equal(t, f32(0), font.Kern(2, 2))
+	require.Equal(t, f32(-40), font.Kern(2, 1))
+}
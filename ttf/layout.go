@@ -0,0 +1,553 @@
+package ttf
+
+import (
+	"slices"
+)
+
+// Feature tags used to select OpenType layout lookups:
+// https://learn.microsoft.com/en-us/typography/opentype/spec/featuretags
+const (
+	featureAbvm tag = 0x6162766d // 'abvm'
+	featureAbvs tag = 0x61627673 // 'abvs'
+	featureAkhn tag = 0x616b686e // 'akhn'
+	featureBlwf tag = 0x626c7766 // 'blwf'
+	featureBlwm tag = 0x626c776d // 'blwm'
+	featureBlws tag = 0x626c7773 // 'blws'
+	featureCalt tag = 0x63616c74 // 'calt'
+	featureCcmp tag = 0x63636d70 // 'ccmp'
+	featureCjct tag = 0x636a6374 // 'cjct'
+	featureDist tag = 0x64697374 // 'dist'
+	featureFina tag = 0x66696e61 // 'fina'
+	featureHalf tag = 0x68616c66 // 'half'
+	featureHaln tag = 0x68616c6e // 'haln'
+	featureInit tag = 0x696e6974 // 'init'
+	featureIsol tag = 0x69736f6c // 'isol'
+	featureKern tag = 0x6b65726e // 'kern'
+	featureLiga tag = 0x6c696761 // 'liga'
+	featureLocl tag = 0x6c6f636c // 'locl'
+	featureMark tag = 0x6d61726b // 'mark'
+	featureMedi tag = 0x6d656469 // 'medi'
+	featureMkmk tag = 0x6d6b6d6b // 'mkmk'
+	featureNukt tag = 0x6e756b74 // 'nukt'
+	featurePres tag = 0x70726573 // 'pres'
+	featurePsts tag = 0x70737473 // 'psts'
+	featureRkrf tag = 0x726b7266 // 'rkrf'
+	featureRlig tag = 0x726c6967 // 'rlig'
+	featureRphf tag = 0x72706866 // 'rphf'
+	featureVatu tag = 0x76617475 // 'vatu'
+)
+
+// Script tags used to select OpenType language systems:
+// https://learn.microsoft.com/en-us/typography/opentype/spec/scripttags
+const (
+	scriptArabic      tag = 0x61726162 // 'arab'
+	scriptDefault     tag = 0x44464c54 // 'DFLT'
+	scriptDevanagari  tag = 0x64657661 // 'deva'
+	scriptDevanagari2 tag = 0x64657632 // 'dev2'
+	scriptLatin       tag = 0x6c61746e // 'latn'
+	scriptThai        tag = 0x74686169 // 'thai'
+)
+
+const (
+	gsubLookupSingle       = 1
+	gsubLookupMultiple     = 2
+	gsubLookupAlternate    = 3
+	gsubLookupLigature     = 4
+	gsubLookupContext      = 5
+	gsubLookupChainContext = 6
+	gsubLookupExtension    = 7
+)
+
+const (
+	gposLookupSingle       = 1
+	gposLookupPair         = 2
+	gposLookupMarkToBase   = 4
+	gposLookupMarkToLig    = 5
+	gposLookupMarkToMark   = 6
+	gposLookupContext      = 7
+	gposLookupChainContext = 8
+	gposLookupExtension    = 9
+)
+
+type lookupFlag u16
+
+const (
+	lookupIgnoreBaseGlyphs    lookupFlag = 0x0002
+	lookupIgnoreLigatures     lookupFlag = 0x0004
+	lookupIgnoreMarks         lookupFlag = 0x0008
+	lookupUseMarkFilteringSet lookupFlag = 0x0010
+	lookupMarkAttachmentType  lookupFlag = 0xff00
+)
+
+// Glyph classes from the GDEF glyph class definition table.
+const (
+	glyphClassBase      = 1
+	glyphClassLigature  = 2
+	glyphClassMark      = 3
+	glyphClassComponent = 4
+)
+
+// OpenType layout data used for shaping. Zero-valued positions indicate
+// missing tables.
+type layoutTables struct {
+	gpos u32
+	gsub u32
+
+	// https://learn.microsoft.com/en-us/typography/opentype/spec/gdef
+	glyphClasses u32
+	markClasses  u32
+	markSets     u32
+
+	gposLookups []lookup
+	gsubLookups []lookup
+
+	plans [shapingScriptCount]shapePlan
+}
+
+func (p *Parser) parseLayout() {
+	layout := &p.font.layout
+	layout.gpos = p.reader.Tables.Gpos.Ptr
+	layout.gsub = p.reader.Tables.Gsub.Ptr
+
+	if ptrGdef := p.reader.Tables.Gdef.Ptr; ptrGdef != 0 {
+		offset := func(ptr u32) u32 {
+			p.reader.seekTo(ptr)
+			if offset := p.reader.u16(); offset != 0 {
+				return ptrGdef + u32(offset)
+			}
+			return 0
+		}
+
+		p.reader.seekTo(ptrGdef + 2)
+		minorVersion := p.reader.u16()
+
+		layout.glyphClasses = offset(ptrGdef + 4)
+		layout.markClasses = offset(ptrGdef + 10)
+		if minorVersion >= 2 {
+			layout.markSets = offset(ptrGdef + 12)
+		}
+	}
+
+	layout.gposLookups = p.reader.lookups(layout.gpos, gposLookupExtension)
+	layout.gsubLookups = p.reader.lookups(layout.gsub, gsubLookupExtension)
+
+	for script := range shapingScriptCount {
+		layout.plans[script] = p.reader.shapePlan(layout, script)
+	}
+}
+
+// Lookup table from a GSUB or GPOS lookup list. Subtable pointers are absolute
+// positions in the font file, with any extension subtables already resolved.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/chapter2#lookup-table
+type lookup struct {
+	subtables []u32
+	flags     lookupFlag
+	markSet   u16
+	typ       u16
+}
+
+// featureLookups returns the sorted, de-duplicated lookup list indices for
+// all features with the given tag in the GSUB or GPOS table at ptrTable.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/chapter2#feature-list-table
+func (r *Reader) featureLookups(ptrTable u32, feature tag) []u16 {
+	r.seekTo(ptrTable + 6)
+	ptrFeatures := ptrTable + u32(r.u16())
+
+	r.seekTo(ptrFeatures)
+	featureCount := r.u16()
+
+	var indices []u16
+	for i := range featureCount {
+		indices = r.appendFeatureLookups(indices, ptrFeatures, i, feature)
+	}
+
+	slices.Sort(indices)
+
+	return indices
+}
+
+// langSys returns the position of the default language system table of the
+// first of the given scripts present in the GSUB or GPOS table at ptrTable,
+// falling back to the 'DFLT' script. Returns 0 if there's no match.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/chapter2#script-list-table-and-script-record
+func (r *Reader) langSys(ptrTable u32, scripts []tag) u32 {
+	if ptrTable == 0 {
+		return 0
+	}
+
+	r.seekTo(ptrTable + 4)
+	ptrScripts := ptrTable + u32(r.u16())
+
+	r.seekTo(ptrScripts)
+	scriptCount := u32(r.u16())
+
+	find := func(script tag) u32 {
+		for i := range scriptCount {
+			r.seekTo(ptrScripts + 2 + i*6)
+			if r.tag() != script {
+				continue
+			}
+
+			ptrScript := ptrScripts + u32(r.u16())
+			r.seekTo(ptrScript)
+			if offset := r.u16(); offset != 0 {
+				return ptrScript + u32(offset)
+			}
+		}
+
+		return 0
+	}
+
+	for _, script := range scripts {
+		if ptr := find(script); ptr != 0 {
+			return ptr
+		}
+	}
+
+	return find(scriptDefault)
+}
+
+// langSysLookups returns the sorted, de-duplicated lookup list indices for the
+// features with the given tag, out of those enabled by the language system
+// table at ptrLangSys.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/chapter2#language-system-table
+func (r *Reader) langSysLookups(ptrTable, ptrLangSys u32, feature tag) []u16 {
+	if ptrLangSys == 0 {
+		return nil
+	}
+
+	r.seekTo(ptrTable + 6)
+	ptrFeatures := ptrTable + u32(r.u16())
+
+	r.seekTo(ptrLangSys + 4) // Skip lookupOrderOffset, requiredFeatureIndex
+	featureCount := u32(r.u16())
+
+	var indices []u16
+	for i := range featureCount {
+		r.seekTo(ptrLangSys + 6 + i*2)
+		indices = r.appendFeatureLookups(indices, ptrFeatures, r.u16(), feature)
+	}
+
+	slices.Sort(indices)
+
+	return indices
+}
+
+// appendFeatureLookups appends the lookup indices of the feature record at
+// the given index in the feature list at ptrFeatures, if its tag matches.
+func (r *Reader) appendFeatureLookups(
+	indices []u16,
+	ptrFeatures u32,
+	index u16,
+	feature tag,
+) []u16 {
+	r.seekTo(ptrFeatures + 2 + u32(index)*6)
+	if r.tag() != feature {
+		return indices
+	}
+
+	ptrFeature := ptrFeatures + u32(r.u16())
+	r.seekTo(ptrFeature + 2) // Skip featureParams
+
+	for range r.u16() {
+		lookup := r.u16()
+		if !slices.Contains(indices, lookup) {
+			indices = append(indices, lookup)
+		}
+	}
+
+	return indices
+}
+
+// lookup reads the lookup at the given index in the lookup list of the GSUB
+// or GPOS table at ptrTable. Extension subtables (lookup type extType) are
+// replaced with the subtables they point to.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/chapter2#lookup-list-table
+func (r *Reader) lookup(ptrTable u32, index u16, extType u16) lookup {
+	r.seekTo(ptrTable + 8)
+	ptrLookups := ptrTable + u32(r.u16())
+
+	r.seekTo(ptrLookups + 2 + u32(index)*2)
+	ptrLookup := ptrLookups + u32(r.u16())
+
+	r.seekTo(ptrLookup)
+	l := lookup{
+		typ:   r.u16(),
+		flags: lookupFlag(r.u16()),
+	}
+
+	subtableCount := r.u16()
+	l.subtables = make([]u32, subtableCount)
+	for i := range l.subtables {
+		l.subtables[i] = ptrLookup + u32(r.u16())
+	}
+
+	if l.flags&lookupUseMarkFilteringSet != 0 {
+		l.markSet = r.u16()
+	}
+
+	if l.typ != extType {
+		return l
+	}
+
+	// https://learn.microsoft.com/en-us/typography/opentype/spec/gpos#lookup-type-9-extension-positioning
+	for i, ptr := range l.subtables {
+		r.seekTo(ptr + 2) // Skip format
+		l.typ = r.u16()
+		l.subtables[i] = ptr + r.u32()
+	}
+
+	return l
+}
+
+// lookups reads all lookups in the lookup list of the GSUB or GPOS table at
+// ptrTable. See [Reader.lookup].
+func (r *Reader) lookups(ptrTable u32, extType u16) []lookup {
+	if ptrTable == 0 {
+		return nil
+	}
+
+	r.seekTo(ptrTable + 8)
+	r.seekTo(ptrTable + u32(r.u16()))
+
+	lookups := make([]lookup, r.u16())
+	for i := range lookups {
+		lookups[i] = r.lookup(ptrTable, u16(i), extType)
+	}
+
+	return lookups
+}
+
+// coverage returns the glyph IDs in the coverage table at ptr, ordered by
+// coverage index.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/chapter2#coverage-table
+func (r *Reader) coverage(ptr u32) []u16 {
+	r.seekTo(ptr)
+
+	var gids []u16
+	switch format := r.u16(); format {
+	case 1:
+		gids = make([]u16, r.u16())
+		for i := range gids {
+			gids[i] = r.u16()
+		}
+
+	case 2:
+		for range r.u16() {
+			start := r.u16()
+			end := r.u16()
+			r.skip(2) // startCoverageIndex
+
+			for gid := start; gid <= end && gid >= start; gid += 1 {
+				gids = append(gids, gid)
+			}
+		}
+	}
+
+	return gids
+}
+
+// coverageIndex returns the coverage index of gid in the coverage table at
+// ptr, or -1 if the glyph isn't covered.
+func (r *Reader) coverageIndex(ptr u32, gid u16) int {
+	r.seekTo(ptr)
+
+	switch format := r.u16(); format {
+	case 1:
+		count := int(r.u16())
+		ptrGlyphs := r.pos
+		i, found := binarySearch(count, func(i int) int {
+			r.seekTo(ptrGlyphs + u32(i)*2)
+			return int(r.u16()) - int(gid)
+		})
+		if found {
+			return i
+		}
+
+	case 2:
+		count := int(r.u16())
+		ptrRanges := r.pos
+		i, found := binarySearch(count, func(i int) int {
+			r.seekTo(ptrRanges + u32(i)*6)
+			if start := r.u16(); gid < start {
+				return 1
+			}
+			if end := r.u16(); gid > end {
+				return -1
+			}
+			return 0
+		})
+		if found {
+			r.seekTo(ptrRanges + u32(i)*6)
+			start := r.u16()
+			r.skip(2) // end
+			return int(r.u16()) + int(gid-start)
+		}
+	}
+
+	return -1
+}
+
+// classOf returns the class of gid in the class definition table at ptr.
+func (r *Reader) classOf(ptr u32, gid u16) u16 {
+	if ptr == 0 {
+		return 0
+	}
+
+	r.seekTo(ptr)
+
+	switch format := r.u16(); format {
+	case 1:
+		start := r.u16()
+		count := r.u16()
+		if gid < start || gid-start >= count {
+			return 0
+		}
+		r.skip(u32(gid-start) * 2)
+		return r.u16()
+
+	case 2:
+		count := int(r.u16())
+		ptrRanges := r.pos
+		i, found := binarySearch(count, func(i int) int {
+			r.seekTo(ptrRanges + u32(i)*6)
+			if start := r.u16(); gid < start {
+				return 1
+			}
+			if end := r.u16(); gid > end {
+				return -1
+			}
+			return 0
+		})
+		if found {
+			r.seekTo(ptrRanges + u32(i)*6 + 4)
+			return r.u16()
+		}
+	}
+
+	return 0
+}
+
+// inMarkSet returns true if gid is in the mark glyph set at the given index
+// in the GDEF mark glyph sets table at ptrSets.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gdef#mark-glyph-sets-table
+func (r *Reader) inMarkSet(ptrSets u32, set u16, gid u16) bool {
+	if ptrSets == 0 {
+		return false
+	}
+
+	r.seekTo(ptrSets + 2) // Skip format
+	if set >= r.u16() {
+		return false
+	}
+
+	r.seekTo(ptrSets + 4 + u32(set)*4)
+	return r.coverageIndex(ptrSets+r.u32(), gid) >= 0
+}
+
+// binarySearch returns the index in [0, n) for which cmp returns 0, where cmp
+// returns a negative value for indices before the target and a positive
+// value for indices after it.
+func binarySearch(n int, cmp func(i int) int) (int, bool) {
+	lo, hi := 0, n
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		switch c := cmp(mid); {
+		case c < 0:
+			lo = mid + 1
+		case c > 0:
+			hi = mid
+		default:
+			return mid, true
+		}
+	}
+
+	return lo, false
+}
+
+// classDef returns the glyph classes defined in the class definition table at
+// ptr, indexed by glyph ID. Glyphs beyond the end of the returned slice are in
+// class 0.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/chapter2#class-definition-table
+func (r *Reader) classDef(ptr u32) []u16 {
+	r.seekTo(ptr)
+
+	var classes []u16
+	switch format := r.u16(); format {
+	case 1:
+		start := r.u16()
+		count := r.u16()
+		classes = make([]u16, u32(start)+u32(count))
+		for i := range u32(count) {
+			classes[u32(start)+i] = r.u16()
+		}
+
+	case 2:
+		for range r.u16() {
+			start := r.u16()
+			end := r.u16()
+			class := r.u16()
+			if end < start {
+				continue
+			}
+
+			if int(end) >= len(classes) {
+				classes = append(classes, make([]u16, int(end)+1-len(classes))...)
+			}
+			for gid := u32(start); gid <= u32(end); gid += 1 {
+				classes[gid] = class
+			}
+		}
+	}
+
+	return classes
+}
+
+// glyphClass returns the class of gid in a table returned by classDef.
+func glyphClass(classes []u16, gid u16) u16 {
+	if int(gid) >= len(classes) {
+		return 0
+	}
+
+	return classes[gid]
+}
+
+// valueRecordLen returns the size in bytes of a GPOS value record with the
+// given format.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gpos#value-record
+func valueRecordLen(format u16) u32 {
+	var n u32
+	for ; format != 0; format &= format - 1 {
+		n += 2
+	}
+
+	return n
+}
+
+type valueFormat u16
+
+const (
+	valueXPlacement valueFormat = 1 << iota
+	valueYPlacement
+	valueXAdvance
+	valueYAdvance
+)
+
+// valueOffset returns the offset of field within a GPOS value record with
+// the given format, or -1 if the field isn't present.
+func valueOffset(format u16, field valueFormat) i32 {
+	if valueFormat(format)&field == 0 {
+		return -1
+	}
+
+	return i32(valueRecordLen(format & (u16(field) - 1)))
+}
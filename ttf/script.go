@@ -0,0 +1,349 @@
+package ttf
+
+import (
+	"slices"
+	"unicode"
+)
+
+// Arabic joining types:
+// https://www.unicode.org/versions/latest/core-spec/chapter-9/#G49949
+type joiningType u8
+
+const (
+	joinNone joiningType = iota
+	joinCausing
+	joinDual
+	joinRight
+	joinTransparent
+)
+
+// Right-joining Arabic letters. All other Arabic letters, outside of
+// presentation forms, are dual-joining or non-joining.
+var arabicRightJoining = [][2]rune{
+	{0x0622, 0x0625}, {0x0627, 0x0627}, {0x0629, 0x0629}, {0x062f, 0x0632},
+	{0x0648, 0x0648}, {0x0671, 0x0673}, {0x0675, 0x0677}, {0x0688, 0x0699},
+	{0x06c0, 0x06c0}, {0x06c3, 0x06cb}, {0x06cd, 0x06cd}, {0x06cf, 0x06cf},
+	{0x06d2, 0x06d3}, {0x06d5, 0x06d5}, {0x06ee, 0x06ef}, {0x0759, 0x075b},
+	{0x076b, 0x076c}, {0x0771, 0x0771}, {0x0773, 0x0774}, {0x0778, 0x0779},
+	{0x08aa, 0x08ac}, {0x08ae, 0x08ae}, {0x08b1, 0x08b2}, {0x08b9, 0x08b9},
+}
+
+func isArabic(char rune) bool {
+	switch {
+	case char >= 0x0600 && char <= 0x06ff,
+		char >= 0x0750 && char <= 0x077f,
+		char >= 0x08a0 && char <= 0x08ff,
+		char >= 0xfb50 && char <= 0xfdff,
+		char >= 0xfe70 && char <= 0xfeff:
+		return true
+	}
+
+	return false
+}
+
+func arabicJoiningType(char rune) joiningType {
+	switch char {
+	case 0x0640, 0x200d: // Tatweel, ZWJ
+		return joinCausing
+	case 0x0621, 0x0674, 0x200c: // Hamza, high hamza, ZWNJ
+		return joinNone
+	}
+
+	if unicode.In(char, unicode.Mn, unicode.Me, unicode.Cf) {
+		return joinTransparent
+	}
+
+	if !isArabic(char) || char >= 0xfb50 || !unicode.IsLetter(char) {
+		return joinNone
+	}
+
+	for _, r := range arabicRightJoining {
+		if char >= r[0] && char <= r[1] {
+			return joinRight
+		}
+	}
+
+	return joinDual
+}
+
+// setArabicForms assigns the isol/fina/medi/init feature masks, based on the
+// joining types of adjacent characters.
+//
+// https://learn.microsoft.com/en-us/typography/script-development/arabic#analyze
+func (b *shapeBuffer) setArabicForms() {
+	forms := make([]featureMask, len(b.glyphs))
+	types := make([]joiningType, len(b.glyphs))
+
+	prev := -1
+	for i := range b.glyphs {
+		types[i] = arabicJoiningType(b.glyphs[i].char)
+		if types[i] == joinTransparent {
+			continue
+		}
+
+		forms[i] = maskIsol
+		if prev >= 0 && joinsNext(types[prev]) && joinsPrev(types[i]) {
+			forms[i] = maskFina
+			switch forms[prev] {
+			case maskIsol:
+				forms[prev] = maskInit
+			case maskFina:
+				forms[prev] = maskMedi
+			}
+		}
+
+		prev = i
+	}
+
+	for i := range b.glyphs {
+		if types[i] == joinDual || types[i] == joinRight {
+			b.glyphs[i].mask |= forms[i]
+		}
+	}
+}
+
+// joinsNext returns true if characters of the given joining type connect to
+// the following character, in logical order.
+func joinsNext(t joiningType) bool {
+	return t == joinDual || t == joinCausing
+}
+
+// joinsPrev returns true if characters of the given joining type connect to
+// the preceding character, in logical order.
+func joinsPrev(t joiningType) bool {
+	return t == joinDual || t == joinRight || t == joinCausing
+}
+
+// Devanagari character categories used for syllable analysis.
+type indicCategory u8
+
+const (
+	indicOther indicCategory = iota
+	indicConsonant
+	indicHalant
+	indicMatra
+	indicModifier
+	indicNukta
+	indicVowel
+	indicZwj
+	indicZwnj
+)
+
+const (
+	devaHalant       = 0x094d
+	devaRa           = 0x0930
+	devaPrebaseMatra = 0x093f
+)
+
+// https://www.unicode.org/charts/PDF/U0900.pdf
+func devanagariCategory(char rune) indicCategory {
+	switch {
+	case char == 0x200c:
+		return indicZwnj
+	case char == 0x200d:
+		return indicZwj
+	case char == devaHalant:
+		return indicHalant
+	case char == 0x093c:
+		return indicNukta
+	case char >= 0x0900 && char <= 0x0903,
+		char >= 0x0951 && char <= 0x0954:
+		return indicModifier
+	case char >= 0x0904 && char <= 0x0914,
+		char >= 0x0960 && char <= 0x0961,
+		char >= 0x0972 && char <= 0x0977:
+		return indicVowel
+	case char >= 0x0915 && char <= 0x0939,
+		char >= 0x0958 && char <= 0x095f,
+		char >= 0x0978 && char <= 0x097f:
+		return indicConsonant
+	case char >= 0x093a && char <= 0x094f,
+		char >= 0x0955 && char <= 0x0957,
+		char >= 0x0962 && char <= 0x0963:
+		return indicMatra
+	}
+
+	return indicOther
+}
+
+// reorderDevanagari splits the buffer into syllables and, for each consonant
+// syllable, moves the reph and pre-base matras into visual order and assigns
+// the feature masks for pre- and post-base forms.
+//
+// https://learn.microsoft.com/en-us/typography/script-development/devanagari#reorder-characters
+func (b *shapeBuffer) reorderDevanagari() {
+	cat := func(i int) indicCategory {
+		if i >= len(b.glyphs) {
+			return indicOther
+		}
+		return devanagariCategory(b.glyphs[i].char)
+	}
+
+	for start := 0; start < len(b.glyphs); {
+		end := start + 1
+
+		switch cat(start) {
+		case indicConsonant:
+			for {
+				if cat(end) == indicNukta {
+					end += 1
+				}
+				if cat(end) != indicHalant {
+					break
+				}
+				end += 1
+				if c := cat(end); c == indicZwj || c == indicZwnj {
+					end += 1
+				}
+				if cat(end) != indicConsonant {
+					break
+				}
+				end += 1
+			}
+
+			for {
+				if c := cat(end); c != indicMatra && c != indicNukta &&
+					c != indicHalant && c != indicModifier {
+					break
+				}
+				end += 1
+			}
+
+			b.reorderSyllable(start, end)
+
+		case indicVowel:
+			for {
+				if c := cat(end); c != indicMatra && c != indicNukta &&
+					c != indicModifier {
+					break
+				}
+				end += 1
+			}
+		}
+
+		start = end
+	}
+}
+
+func (b *shapeBuffer) reorderSyllable(start, end int) {
+	syllable := b.glyphs[start:end]
+	isConsonant := func(i int) bool {
+		return devanagariCategory(syllable[i].char) == indicConsonant
+	}
+
+	// An initial Ra+Halant forms a reph, if followed by another consonant.
+	reph := len(syllable) > 2 &&
+		syllable[0].char == devaRa &&
+		syllable[1].char == devaHalant &&
+		slices.IndexFunc(syllable[2:], func(g glyphInfo) bool {
+			return devanagariCategory(g.char) == indicConsonant
+		}) >= 0
+
+	first := 0
+	if reph {
+		first = 2
+	}
+
+	// The base consonant is the last consonant, unless it's a Ra following a
+	// halant, which takes a below-base form instead.
+	base := -1
+	for i := first; i < len(syllable); i += 1 {
+		if isConsonant(i) {
+			base = i
+		}
+	}
+	if base < 0 {
+		return
+	}
+	if base > first+1 && syllable[base].char == devaRa &&
+		syllable[base-1].char == devaHalant {
+		for i := base - 2; i >= first; i -= 1 {
+			if isConsonant(i) {
+				base = i
+				break
+			}
+		}
+	}
+
+	for i := range syllable {
+		switch {
+		case i < first:
+			syllable[i].mask |= maskRphf
+		case i < base:
+			syllable[i].mask |= maskHalf
+		case i > base:
+			syllable[i].mask |= maskBlwf
+		}
+	}
+
+	// Glyphs in reordered syllables form a single cluster.
+	cluster := syllable[0].cluster
+	for i := range syllable {
+		syllable[i].cluster = cluster
+	}
+
+	// Pre-base matras move to the start of the syllable, after any reph.
+	for i := base + 1; i < len(syllable); i += 1 {
+		if syllable[i].char != devaPrebaseMatra && syllable[i].char != 0x094e {
+			continue
+		}
+
+		matra := syllable[i]
+		copy(syllable[first+1:i+1], syllable[first:i])
+		syllable[first] = matra
+		base += 1
+	}
+
+	// The reph moves to the end of the syllable, before any modifiers.
+	if reph {
+		pos := len(syllable)
+		for pos > base+1 &&
+			devanagariCategory(syllable[pos-1].char) == indicModifier {
+			pos -= 1
+		}
+
+		ra, halant := syllable[0], syllable[1]
+		copy(syllable, syllable[2:pos])
+		syllable[pos-2], syllable[pos-1] = ra, halant
+	}
+}
+
+const (
+	thaiNikhahit = 0x0e4d
+	thaiSaraAa   = 0x0e32
+	thaiSaraAm   = 0x0e33
+)
+
+// decomposeThai splits SARA AM into NIKHAHIT and SARA AA, moving the
+// NIKHAHIT before any preceding tone marks, so that fonts without
+// precomposed forms can position it.
+//
+// https://learn.microsoft.com/en-us/typography/script-development/thai
+func (b *shapeBuffer) decomposeThai() {
+	if b.font.GlyphId(thaiNikhahit) == 0 || b.font.GlyphId(thaiSaraAa) == 0 {
+		return
+	}
+
+	for i := 0; i < len(b.glyphs); i += 1 {
+		if b.glyphs[i].char != thaiSaraAm {
+			continue
+		}
+
+		g := b.glyphs[i]
+		g.char = thaiSaraAa
+		b.glyphs[i] = g
+		g.char = thaiNikhahit
+
+		pos := i
+		for pos > 0 && b.glyphs[pos-1].char >= 0x0e48 && b.glyphs[pos-1].char <= 0x0e4b {
+			pos -= 1
+			g.cluster = min(g.cluster, b.glyphs[pos].cluster)
+		}
+		for k := pos; k < i+1; k += 1 {
+			b.glyphs[k].cluster = g.cluster
+		}
+
+		b.glyphs = slices.Insert(b.glyphs, pos, g)
+		i += 1
+	}
+}
@@ -8,8 +8,9 @@ import (
 )
 
 type FontInfo struct {
-	font Font
-	key  Key
+	font      Font
+	key       Key
+	fallbacks []Id
 }
 
 func (i *FontInfo) Font() *Font {
@@ -28,6 +29,17 @@ func (i *FontInfo) GlyphWidthOnly(char rune) float32 {
 	return width
 }
 
+// Kern returns the pair kerning adjustment, in 1/1000 em, between the glyphs
+// for the characters left and right. See [Font.Kern].
+func (i *FontInfo) Kern(left, right rune) float32 {
+	return i.font.Kern(i.font.GlyphId(left), i.font.GlyphId(right))
+}
+
+// Shape converts text to positioned glyphs. See [Font.Shape].
+func (i *FontInfo) Shape(text []rune, flags ShapeFlags, out []Glyph) []Glyph {
+	return i.font.Shape(text, flags, out)
+}
+
 func (i *FontInfo) String() string {
 	return i.key.String()
 }
@@ -39,7 +51,8 @@ func (i FontInfo) Key() Key {
 type Id uint8
 
 type FontSet struct {
-	fonts []FontInfo
+	fonts     []FontInfo
+	fallbacks []Id
 }
 
 func NewFontSet(capacity uint8) FontSet {
@@ -56,13 +69,36 @@ func (f *FontSet) Key(id Id) Key {
 	return f.fonts[id].key
 }
 
-func (f *FontSet) AddTtf(family string, style Style, bytes []byte) (Id, error) {
+// AddTtf parses and adds a font file, which may have either TrueType or CFF
+// (.otf) outlines.
+//
+// For variable fonts, variations select the instance to use, e.g.
+// {Axis: "wght", Value: 650}, which is embedded as a static font. The same file
+// may be added more than once, with different variations.
+func (f *FontSet) AddTtf(
+	family string,
+	style Style,
+	bytes []byte,
+	variations ...Variation,
+) (Id, error) {
+	return f.AddTtc(family, style, bytes, 0, variations...)
+}
+
+// AddTtc parses and adds the face at index in a font collection (.ttc, .otc)
+// file. Use [Faces] to list the faces in a collection.
+func (f *FontSet) AddTtc(
+	family string,
+	style Style,
+	bytes []byte,
+	index int,
+	variations ...Variation,
+) (Id, error) {
 	id := len(f.fonts)
 	f.fonts = append(f.fonts, FontInfo{
 		key: Key{Family: strings.ToLower(family), Style: style},
 	})
 
-	err := Parse(bytes, &f.fonts[id].font)
+	err := ParseFace(bytes, index, &f.fonts[id].font, variations...)
 	if err != nil {
 		return Id(id), fmt.Errorf("unable to parse font file: %w", err)
 	}
@@ -70,6 +106,43 @@ func (f *FontSet) AddTtf(family string, style Style, bytes []byte) (Id, error) {
 	return Id(id), nil
 }
 
+// Fallback returns the font to use for char in place of font id: id itself if
+// it has a glyph for char, otherwise the first of its fallbacks that does.
+// If none do, id is returned.
+//
+// See [FontSet.SetFallbacks] and [FontSet.SetDefaultFallbacks].
+func (f *FontSet) Fallback(id Id, char rune) Id {
+	if f.fonts[id].font.GlyphId(char) != 0 {
+		return id
+	}
+
+	fallbacks := f.fonts[id].fallbacks
+	if fallbacks == nil {
+		fallbacks = f.fallbacks
+	}
+
+	for _, fallback := range fallbacks {
+		if fallback != id && f.fonts[fallback].font.GlyphId(char) != 0 {
+			return fallback
+		}
+	}
+
+	return id
+}
+
+// SetDefaultFallbacks sets the fonts to try, in order, for characters missing
+// from any font without fallbacks of its own.
+func (f *FontSet) SetDefaultFallbacks(fallbacks ...Id) {
+	f.fallbacks = fallbacks
+}
+
+// SetFallbacks sets the fonts to try, in order, for characters missing from
+// font id. This overrides any default fallbacks - an empty list disables
+// fallback for the font.
+func (f *FontSet) SetFallbacks(id Id, fallbacks ...Id) {
+	f.fonts[id].fallbacks = append([]Id{}, fallbacks...)
+}
+
 func (f *FontSet) Grow(amt uint8) {
 	f.fonts = slices.Grow(f.fonts, int(amt))
 }
@@ -78,8 +151,13 @@ func (f *FontSet) Len() int {
 	return len(f.fonts)
 }
 
-func (f *FontSet) MustAddTtf(family string, style Style, bytes []byte) Id {
-	id, err := f.AddTtf(family, style, bytes)
+func (f *FontSet) MustAddTtf(
+	family string,
+	style Style,
+	bytes []byte,
+	variations ...Variation,
+) Id {
+	id, err := f.AddTtf(family, style, bytes, variations...)
 	if err != nil {
 		log.Panicf(
 			"unable to add font family(%s), style(%s): %v",
@@ -92,6 +170,27 @@ func (f *FontSet) MustAddTtf(family string, style Style, bytes []byte) Id {
 	return id
 }
 
+func (f *FontSet) MustAddTtc(
+	family string,
+	style Style,
+	bytes []byte,
+	index int,
+	variations ...Variation,
+) Id {
+	id, err := f.AddTtc(family, style, bytes, index, variations...)
+	if err != nil {
+		log.Panicf(
+			"unable to add font family(%s), style(%s), face(%d): %v",
+			family,
+			style,
+			index,
+			err,
+		)
+	}
+
+	return id
+}
+
 type Key struct {
 	Family string
 	Style  Style
@@ -0,0 +1,36 @@
+package ttf
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFontSetFallback(t *testing.T) {
+	dejaVu, err := os.ReadFile("../font/DejaVuSansCondensed.ttf")
+	require.NoError(t, err)
+
+	set := NewFontSet(2)
+	roboto := set.MustAddTtf("roboto", StyleI, robotoI)
+	fallback := set.MustAddTtf("dejavu", StyleNone, dejaVu)
+
+	const alef = 0x05d0
+	require.Zero(t, set.Get(roboto).Font().GlyphId(alef))
+
+	// No fallbacks registered.
+	require.Equal(t, roboto, set.Fallback(roboto, alef))
+
+	set.SetDefaultFallbacks(fallback)
+	require.Equal(t, roboto, set.Fallback(roboto, 'a'))
+	require.Equal(t, fallback, set.Fallback(roboto, alef))
+	require.Equal(t, fallback, set.Fallback(fallback, alef))
+
+	// Per-font fallbacks override the defaults.
+	set.SetFallbacks(roboto)
+	require.Equal(t, roboto, set.Fallback(roboto, alef))
+
+	// Characters missing from all fonts stay in the original font.
+	set.SetFallbacks(roboto, fallback)
+	require.Equal(t, roboto, set.Fallback(roboto, 0xe000))
+}
@@ -0,0 +1,541 @@
+package ttf
+
+import (
+	"slices"
+	"unicode"
+)
+
+// Glyph is a glyph produced by [Font.Shape]. Distances are in 1/1000 em, like
+// glyph widths.
+type Glyph struct {
+	// Distance to move the pen after drawing the glyph.
+	Advance f32
+
+	// Offsets from the pen position at which to draw the glyph.
+	XOffset f32
+	YOffset f32
+
+	// Index of the first character in the shaped text that is represented by
+	// the glyph. Glyphs for ligatures represent all characters up to the next
+	// cluster in the text.
+	Cluster u32
+
+	Id u16
+}
+
+type ShapeFlags u8
+
+const (
+	// Apply pair kerning between glyphs. See [Font.Kern].
+	ShapeKerning ShapeFlags = 1 << iota
+
+	// Lay out text from right to left. Glyphs are still returned in visual,
+	// left-to-right order.
+	ShapeRtl
+
+	// Lay out text from top to bottom, with vertical alternate glyphs. Glyph
+	// advances are vertical advances (see [Font.Height]), and kerning and
+	// GPOS positioning, which are designed for horizontal text, aren't
+	// applied.
+	ShapeVertical
+)
+
+// Scripts with distinct shaping requirements.
+type shapingScript u8
+
+const (
+	shapingDefault shapingScript = iota
+	shapingArabic
+	shapingDevanagari
+	shapingThai
+
+	shapingScriptCount
+)
+
+// Script tags for the language systems used to shape each script, in order
+// of preference.
+var shapingScriptTags = [shapingScriptCount][]tag{
+	shapingDefault:    {scriptLatin},
+	shapingArabic:     {scriptArabic},
+	shapingDevanagari: {scriptDevanagari2, scriptDevanagari},
+	shapingThai:       {scriptThai},
+}
+
+// featureMask selects the glyphs that a feature is applied to. Glyphs are
+// assigned masks when the shaping buffer is set up.
+type featureMask u16
+
+const (
+	maskGlobal featureMask = 1 << iota
+	maskIsol
+	maskFina
+	maskMedi
+	maskInit
+	maskRphf
+	maskHalf
+	maskBlwf
+)
+
+type planFeature struct {
+	tag  tag
+	mask featureMask
+}
+
+// GSUB features applied for each script, in stages. Each stage is completed
+// before the next one starts.
+//
+// https://learn.microsoft.com/en-us/typography/script-development/standard
+// https://learn.microsoft.com/en-us/typography/script-development/arabic
+// https://learn.microsoft.com/en-us/typography/script-development/devanagari
+// https://learn.microsoft.com/en-us/typography/script-development/thai
+var gsubFeatures = [shapingScriptCount][][]planFeature{
+	shapingDefault: {
+		{{featureCcmp, maskGlobal}, {featureLocl, maskGlobal}},
+		{{featureRlig, maskGlobal}},
+		{{featureCalt, maskGlobal}, {featureLiga, maskGlobal}},
+	},
+	shapingArabic: {
+		{{featureCcmp, maskGlobal}, {featureLocl, maskGlobal}},
+		{{featureIsol, maskIsol}},
+		{{featureFina, maskFina}},
+		{{featureMedi, maskMedi}},
+		{{featureInit, maskInit}},
+		{{featureRlig, maskGlobal}},
+		{{featureCalt, maskGlobal}, {featureLiga, maskGlobal}},
+	},
+	shapingDevanagari: {
+		{{featureCcmp, maskGlobal}, {featureLocl, maskGlobal}},
+		{{featureNukt, maskGlobal}},
+		{{featureAkhn, maskGlobal}},
+		{{featureRphf, maskRphf}},
+		{{featureRkrf, maskGlobal}},
+		{{featureBlwf, maskBlwf}},
+		{{featureHalf, maskHalf}},
+		{{featureVatu, maskGlobal}},
+		{{featureCjct, maskGlobal}},
+		{
+			{featurePres, maskGlobal},
+			{featureAbvs, maskGlobal},
+			{featureBlws, maskGlobal},
+			{featurePsts, maskGlobal},
+			{featureHaln, maskGlobal},
+		},
+		{{featureCalt, maskGlobal}, {featureLiga, maskGlobal}},
+	},
+	shapingThai: {
+		{{featureCcmp, maskGlobal}, {featureLocl, maskGlobal}},
+		{{featureCalt, maskGlobal}, {featureLiga, maskGlobal}},
+	},
+}
+
+// GPOS features applied for each script. Pair kerning is applied separately,
+// from the data returned by [Font.Kern].
+var gposFeatures = [shapingScriptCount][]planFeature{
+	shapingDefault: {{featureMark, maskGlobal}, {featureMkmk, maskGlobal}},
+	shapingArabic:  {{featureMark, maskGlobal}, {featureMkmk, maskGlobal}},
+	shapingDevanagari: {
+		{featureDist, maskGlobal},
+		{featureAbvm, maskGlobal},
+		{featureBlwm, maskGlobal},
+		{featureMark, maskGlobal},
+		{featureMkmk, maskGlobal},
+	},
+	shapingThai: {{featureMark, maskGlobal}, {featureMkmk, maskGlobal}},
+}
+
+// Lookups applied to shape text in a given script, by lookup list index.
+type shapePlan struct {
+	gsub [][]planLookup
+	gpos []planLookup
+}
+
+type planLookup struct {
+	index u16
+	mask  featureMask
+}
+
+func (r *Reader) shapePlan(
+	layout *layoutTables,
+	script shapingScript,
+) (plan shapePlan) {
+	tags := shapingScriptTags[script]
+
+	if ptrLangSys := r.langSys(layout.gsub, tags); ptrLangSys != 0 {
+		for _, features := range gsubFeatures[script] {
+			stage := r.planStage(layout.gsub, ptrLangSys, features)
+			if len(stage) > 0 {
+				plan.gsub = append(plan.gsub, stage)
+			}
+		}
+	}
+
+	if ptrLangSys := r.langSys(layout.gpos, tags); ptrLangSys != 0 {
+		plan.gpos = r.planStage(layout.gpos, ptrLangSys, gposFeatures[script])
+	}
+
+	return
+}
+
+// planStage returns the lookups for the given features, in lookup list order.
+// Lookups shared between features are applied to the union of their masks.
+func (r *Reader) planStage(
+	ptrTable, ptrLangSys u32,
+	features []planFeature,
+) []planLookup {
+	var stage []planLookup
+	for _, feature := range features {
+		for _, index := range r.langSysLookups(ptrTable, ptrLangSys, feature.tag) {
+			i := slices.IndexFunc(stage, func(l planLookup) bool {
+				return l.index == index
+			})
+			if i < 0 {
+				stage = append(stage, planLookup{index: index, mask: feature.mask})
+			} else {
+				stage[i].mask |= feature.mask
+			}
+		}
+	}
+
+	slices.SortFunc(stage, func(a, b planLookup) int {
+		return int(a.index) - int(b.index)
+	})
+
+	return stage
+}
+
+// Shape converts text to a sequence of positioned glyphs, applying the GSUB
+// substitutions and GPOS positioning defined in the font for the script of the
+// text. Scripts that require it (Arabic, Devanagari, Thai) are first
+// reordered and assigned contextual forms. Text mixing scripts is shaped in
+// runs of each script.
+//
+// Glyphs are appended to out, which may be nil, in visual order.
+func (f *Font) Shape(text []rune, flags ShapeFlags, out []Glyph) []Glyph {
+	runs := scriptRuns(text)
+	if flags&ShapeRtl != 0 {
+		slices.Reverse(runs)
+	}
+
+	for _, run := range runs {
+		out = f.shapeRun(text[run.start:run.end], u32(run.start), run.script, flags, out)
+	}
+
+	return out
+}
+
+// shapeRun shapes a run of text in a single script, with clusters numbered
+// from offset.
+func (f *Font) shapeRun(
+	text []rune,
+	offset u32,
+	script shapingScript,
+	flags ShapeFlags,
+	out []Glyph,
+) []Glyph {
+	b := shapeBuffer{
+		font:   f,
+		reader: NewReader(f.file),
+		glyphs: make([]glyphInfo, len(text)),
+		rtl:    flags&ShapeRtl != 0,
+	}
+
+	for i, char := range text {
+		b.glyphs[i] = glyphInfo{
+			attach:  -1,
+			char:    char,
+			cluster: offset + u32(i),
+			mask:    maskGlobal,
+		}
+	}
+
+	switch script {
+	case shapingArabic:
+		b.setArabicForms()
+	case shapingDevanagari:
+		b.reorderDevanagari()
+	case shapingThai:
+		b.decomposeThai()
+	}
+
+	for i := range b.glyphs {
+		g := &b.glyphs[i]
+		g.gid = f.GlyphId(g.char)
+		g.class = b.glyphClass(g.gid, g.char)
+	}
+
+	plan := &f.layout.plans[script]
+	for _, stage := range plan.gsub {
+		b.substituteStage(stage)
+	}
+
+	vertical := flags&ShapeVertical != 0
+	if vertical && len(f.vertLookups) > 0 {
+		b.substituteStage(f.vertLookups)
+	}
+
+	b.glyphs = slices.DeleteFunc(b.glyphs, func(g glyphInfo) bool {
+		return isDefaultIgnorable(g.char)
+	})
+
+	for i := range b.glyphs {
+		g := &b.glyphs[i]
+		if g.class != glyphClassMark && int(g.gid) < len(f.widths) {
+			g.advance = f.Width(g.gid)
+			if vertical {
+				g.advance = f.Height(g.gid)
+			}
+		}
+	}
+
+	if vertical {
+		return b.output(out)
+	}
+
+	if flags&ShapeKerning != 0 && !f.kern.empty() {
+		b.kern()
+	}
+
+	b.positionStage(plan.gpos)
+
+	return b.output(out)
+}
+
+// Glyph state during shaping, in logical order.
+type glyphInfo struct {
+	// Source character. For glyphs produced by substitutions, this is the
+	// first character of the substituted sequence.
+	char rune
+
+	cluster u32
+	mask    featureMask
+
+	gid   u16
+	class u16
+
+	// Number of components in a ligature glyph.
+	ligComponents u16
+
+	advance f32
+	xOffset f32
+	yOffset f32
+
+	// Index of the glyph that this glyph is attached to by a mark
+	// positioning lookup, or -1. The attached glyph's offsets are relative to
+	// the origin of that glyph.
+	attach int
+}
+
+type shapeBuffer struct {
+	font   *Font
+	reader Reader
+	glyphs []glyphInfo
+	rtl    bool
+}
+
+// glyphClass returns the GDEF class of gid or, for fonts without one, a class
+// derived from the Unicode category of char.
+func (b *shapeBuffer) glyphClass(gid u16, char rune) u16 {
+	if ptr := b.font.layout.glyphClasses; ptr != 0 {
+		return b.reader.classOf(ptr, gid)
+	}
+
+	if unicode.In(char, unicode.Mn, unicode.Me) {
+		return glyphClassMark
+	}
+
+	return glyphClassBase
+}
+
+// skip returns true if the glyph at index i should be ignored by lookup l,
+// based on the lookup flags.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/chapter2#lookupFlags
+func (b *shapeBuffer) skip(l *lookup, i int) bool {
+	g := &b.glyphs[i]
+
+	switch g.class {
+	case glyphClassBase:
+		return l.flags&lookupIgnoreBaseGlyphs != 0
+
+	case glyphClassLigature:
+		return l.flags&lookupIgnoreLigatures != 0
+
+	case glyphClassMark:
+		if l.flags&lookupIgnoreMarks != 0 {
+			return true
+		}
+
+		if l.flags&lookupUseMarkFilteringSet != 0 {
+			return !b.reader.inMarkSet(b.font.layout.markSets, l.markSet, g.gid)
+		}
+
+		if markType := u16(l.flags&lookupMarkAttachmentType) >> 8; markType != 0 {
+			return b.reader.classOf(b.font.layout.markClasses, g.gid) != markType
+		}
+	}
+
+	return false
+}
+
+// next returns the index of the first glyph after index i that isn't
+// skipped by lookup l, or -1.
+func (b *shapeBuffer) next(l *lookup, i int) int {
+	for i += 1; i < len(b.glyphs); i += 1 {
+		if !b.skip(l, i) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// prev returns the index of the last glyph before index i that isn't skipped
+// by lookup l, or -1.
+func (b *shapeBuffer) prev(l *lookup, i int) int {
+	for i -= 1; i >= 0; i -= 1 {
+		if !b.skip(l, i) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// kern applies pair kerning between consecutive non-mark glyphs.
+func (b *shapeBuffer) kern() {
+	prev := -1
+	for i := range b.glyphs {
+		if b.glyphs[i].class == glyphClassMark {
+			continue
+		}
+
+		if prev >= 0 {
+			b.adjustGap(prev, i, b.font.Kern(b.glyphs[prev].gid, b.glyphs[i].gid))
+		}
+		prev = i
+	}
+}
+
+// adjustGap adds amt to the space between the logically consecutive glyphs
+// at indices first and second.
+func (b *shapeBuffer) adjustGap(first, second int, amt f32) {
+	if b.rtl {
+		b.glyphs[second].advance += amt
+	} else {
+		b.glyphs[first].advance += amt
+	}
+}
+
+// output resolves mark attachments and appends the glyphs to out, in visual
+// order.
+func (b *shapeBuffer) output(out []Glyph) []Glyph {
+	n := len(b.glyphs)
+
+	visual := func(i int) int {
+		if b.rtl {
+			return n - 1 - i
+		}
+		return i
+	}
+
+	pen := make([]f32, n)
+	var x f32
+	for i := range n {
+		pen[visual(i)] = x
+		x += b.glyphs[visual(i)].advance
+	}
+
+	// Attached glyphs always follow the glyphs they're attached to, in logical
+	// order, so offsets can be resolved in a single pass.
+	for i := range b.glyphs {
+		g := &b.glyphs[i]
+		if g.attach < 0 {
+			continue
+		}
+
+		base := &b.glyphs[g.attach]
+		g.xOffset += pen[g.attach] + base.xOffset - pen[i]
+		g.yOffset += base.yOffset
+	}
+
+	out = slices.Grow(out, n)
+	for i := range n {
+		g := &b.glyphs[visual(i)]
+		out = append(out, Glyph{
+			Advance: g.advance,
+			Cluster: g.cluster,
+			Id:      g.gid,
+			XOffset: g.xOffset,
+			YOffset: g.yOffset,
+		})
+	}
+
+	return out
+}
+
+// scriptRun is a run of text shaped with the same script.
+type scriptRun struct {
+	start, end int
+	script     shapingScript
+}
+
+// scriptRuns splits text into runs of characters of the same shaping script.
+// Characters common to several scripts, e.g. spaces, digits, punctuation and
+// joiners, and combining marks, belong to the run before them, or to the
+// first run at the start of the text.
+func scriptRuns(text []rune) []scriptRun {
+	var runs []scriptRun
+
+	// The runs so far contain a character of a specific script.
+	known := false
+	for i, char := range text {
+		script, ok := charScript(char)
+		n := len(runs)
+		switch {
+		case n == 0:
+			runs = append(runs, scriptRun{i, i + 1, script})
+		case !ok || script == runs[n-1].script:
+			runs[n-1].end = i + 1
+		case !known:
+			runs[n-1].end, runs[n-1].script = i+1, script
+		default:
+			runs = append(runs, scriptRun{i, i + 1, script})
+		}
+		known = known || ok
+	}
+
+	return runs
+}
+
+// charScript returns the shaping script of a character, and false for
+// characters common to several scripts.
+func charScript(char rune) (shapingScript, bool) {
+	switch {
+	case isArabic(char):
+		return shapingArabic, true
+	case char >= 0x0900 && char <= 0x097f:
+		return shapingDevanagari, devanagariCategory(char) != indicOther ||
+			unicode.IsLetter(char)
+	case char >= 0x0e00 && char <= 0x0e7f:
+		return shapingThai, true
+	case unicode.IsLetter(char):
+		return shapingDefault, true
+	}
+
+	return shapingDefault, false
+}
+
+// isDefaultIgnorable returns true for invisible formatting characters that
+// are removed from shaped output.
+func isDefaultIgnorable(char rune) bool {
+	switch {
+	case char == 0x00ad, // Soft hyphen
+		char == 0x034f, // Combining grapheme joiner
+		char >= 0x200b && char <= 0x200f,
+		char >= 0x2060 && char <= 0x2064,
+		char == 0xfeff:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,224 @@
+package ttf
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShapeLatin(t *testing.T) {
+	var font Font
+	require.NoError(t, Parse(robotoI, &font))
+
+	text := []rune("AVa")
+	glyphs := font.Shape(text, 0, nil)
+	require.Len(t, glyphs, len(text))
+	for i, g := range glyphs {
+		require.Equal(t, font.GlyphId(text[i]), g.Id)
+		require.Equal(t, u32(i), g.Cluster)
+		require.Equal(t, font.Width(g.Id), g.Advance)
+	}
+
+	kerned := font.Shape(text, ShapeKerning, nil)
+	require.Equal(t,
+		glyphs[0].Advance+font.Kern(glyphs[0].Id, glyphs[1].Id),
+		kerned[0].Advance,
+	)
+}
+
+func TestShapeLigature(t *testing.T) {
+	var font Font
+	require.NoError(t, Parse(robotoI, &font))
+
+	glyphs := font.Shape([]rune("fi"), 0, nil)
+	require.Len(t, glyphs, 1)
+	require.NotEqual(t, font.GlyphId('f'), glyphs[0].Id)
+	require.Equal(t, u32(0), glyphs[0].Cluster)
+}
+
+func TestShapeArabic(t *testing.T) {
+	file, err := os.ReadFile("../font/DejaVuSansCondensed.ttf")
+	require.NoError(t, err)
+
+	var font Font
+	require.NoError(t, Parse(file, &font))
+
+	// Beh, repeated: initial, medial and final forms differ from the
+	// isolated form and from each other.
+	text := []rune{0x0628, 0x0628, 0x0628}
+	glyphs := font.Shape(text, ShapeRtl, nil)
+	require.Len(t, glyphs, 3)
+
+	isol := font.GlyphId(0x0628)
+	ids := map[u16]bool{isol: true}
+	for _, g := range glyphs {
+		require.False(t, ids[g.Id], "expected distinct contextual forms")
+		ids[g.Id] = true
+	}
+
+	// Visual order is reversed.
+	require.Equal(t, u32(2), glyphs[0].Cluster)
+	require.Equal(t, u32(0), glyphs[2].Cluster)
+}
+
+func TestShapeMarkAttachment(t *testing.T) {
+	file, err := os.ReadFile("../font/DejaVuSansCondensed.ttf")
+	require.NoError(t, err)
+
+	var font Font
+	require.NoError(t, Parse(file, &font))
+
+	// 'a' + combining acute accent.
+	glyphs := font.Shape([]rune{'a', 0x0301}, 0, nil)
+	require.Len(t, glyphs, 2)
+	require.Equal(t, f32(0), glyphs[1].Advance)
+	require.Less(t, glyphs[1].XOffset, f32(0))
+}
+
+// devanagariBuffer returns a shaping buffer of text, reordered as Devanagari.
+func devanagariBuffer(text ...rune) *shapeBuffer {
+	b := &shapeBuffer{}
+	for i, char := range text {
+		b.glyphs = append(b.glyphs, glyphInfo{
+			attach:  -1,
+			char:    char,
+			cluster: u32(i),
+			mask:    maskGlobal,
+		})
+	}
+	b.reorderDevanagari()
+
+	return b
+}
+
+func chars(b *shapeBuffer) []rune {
+	var text []rune
+	for _, g := range b.glyphs {
+		text = append(text, g.char)
+	}
+
+	return text
+}
+
+func TestDevanagariPrebaseMatra(t *testing.T) {
+	// Ka + vowel sign I: the matra is drawn before the consonant.
+	b := devanagariBuffer(0x0915, 0x093f)
+	require.Equal(t, []rune{0x093f, 0x0915}, chars(b))
+	require.Equal(t, u32(0), b.glyphs[0].cluster)
+	require.Equal(t, u32(0), b.glyphs[1].cluster)
+
+	// In a conjunct, it moves before the whole cluster.
+	b = devanagariBuffer(0x0915, 0x094d, 0x0937, 0x093f)
+	require.Equal(t, []rune{0x093f, 0x0915, 0x094d, 0x0937}, chars(b))
+}
+
+func TestDevanagariReph(t *testing.T) {
+	// Ra + halant + Ka: the Ra forms a reph, moved after the base consonant.
+	b := devanagariBuffer(0x0930, 0x094d, 0x0915)
+	require.Equal(t, []rune{0x0915, 0x0930, 0x094d}, chars(b))
+	require.Zero(t, b.glyphs[0].mask&maskRphf)
+	require.NotZero(t, b.glyphs[1].mask&maskRphf)
+	require.NotZero(t, b.glyphs[2].mask&maskRphf)
+
+	// Before modifiers, with the matra before the base.
+	b = devanagariBuffer(0x0930, 0x094d, 0x0915, 0x093f, 0x0902)
+	require.Equal(t, []rune{0x093f, 0x0915, 0x0930, 0x094d, 0x0902}, chars(b))
+
+	// A Ra + halant alone isn't a reph.
+	b = devanagariBuffer(0x0930, 0x094d)
+	require.Equal(t, []rune{0x0930, 0x094d}, chars(b))
+	require.Zero(t, b.glyphs[0].mask&maskRphf)
+}
+
+func TestDevanagariConjunct(t *testing.T) {
+	// Ka + halant + Ssa: the Ka takes a half form before the base Ssa.
+	b := devanagariBuffer(0x0915, 0x094d, 0x0937)
+	require.Equal(t, []rune{0x0915, 0x094d, 0x0937}, chars(b))
+	require.NotZero(t, b.glyphs[0].mask&maskHalf)
+	require.NotZero(t, b.glyphs[1].mask&maskHalf)
+	require.Equal(t, maskGlobal, b.glyphs[2].mask)
+
+	// Ka + halant + Ra: the Ra takes a below-base form after the base Ka.
+	b = devanagariBuffer(0x0915, 0x094d, 0x0930)
+	require.Equal(t, maskGlobal, b.glyphs[0].mask)
+	require.NotZero(t, b.glyphs[1].mask&maskBlwf)
+	require.NotZero(t, b.glyphs[2].mask&maskBlwf)
+}
+
+func TestThaiSaraAm(t *testing.T) {
+	font := new(Font)
+	font.gids[thaiNikhahit] = 1
+	font.gids[thaiSaraAa] = 2
+
+	// Ko Kai + Mai Tho + Sara Am: the Nikhahit moves before the tone mark.
+	b := &shapeBuffer{font: font}
+	for i, char := range []rune{0x0e01, 0x0e49, thaiSaraAm} {
+		b.glyphs = append(b.glyphs, glyphInfo{char: char, cluster: u32(i)})
+	}
+	b.decomposeThai()
+
+	require.Equal(t, []rune{0x0e01, thaiNikhahit, 0x0e49, thaiSaraAa}, chars(b))
+	for i, cluster := range []u32{0, 1, 1, 1} {
+		require.Equal(t, cluster, b.glyphs[i].cluster)
+	}
+
+	// Fonts without the decomposed characters are left with Sara Am.
+	b = &shapeBuffer{font: new(Font), glyphs: []glyphInfo{{char: thaiSaraAm}}}
+	b.decomposeThai()
+	require.Equal(t, []rune{thaiSaraAm}, chars(b))
+}
+
+func TestScriptRuns(t *testing.T) {
+	text := []rune("(fi) कि 12, กำ بب ok")
+	var runs []string
+	var scripts []shapingScript
+	for _, run := range scriptRuns(text) {
+		runs = append(runs, string(text[run.start:run.end]))
+		scripts = append(scripts, run.script)
+	}
+
+	require.Equal(t, []string{
+		"(fi) ",
+		"कि 12, ",
+		"กำ ",
+		"بب ",
+		"ok",
+	}, runs)
+	require.Equal(t, []shapingScript{
+		shapingDefault,
+		shapingDevanagari,
+		shapingThai,
+		shapingArabic,
+		shapingDefault,
+	}, scripts)
+
+	// Leading common characters take the script of the first letter.
+	require.Equal(t,
+		[]scriptRun{{0, 4, shapingDevanagari}},
+		scriptRuns([]rune("1. क")),
+	)
+}
+
+func TestShapeMixedScripts(t *testing.T) {
+	var font Font
+	require.NoError(t, Parse(robotoI, &font))
+
+	// Latin text is shaped with Latin lookups, whatever other scripts follow
+	// it in the same run.
+	for _, text := range []string{"fi कि", "fi กำ"} {
+		glyphs := font.Shape([]rune(text), 0, nil)
+		require.NotEqual(t, font.GlyphId('f'), glyphs[0].Id)
+		require.Equal(t, u32(0), glyphs[0].Cluster)
+		require.Equal(t, u32(2), glyphs[1].Cluster)
+	}
+
+	// Runs are in visual order in right-to-left text.
+	glyphs := font.Shape([]rune("abب"), ShapeRtl, nil)
+	require.Len(t, glyphs, 3)
+	require.Equal(t, []u32{2, 1, 0}, []u32{
+		glyphs[0].Cluster,
+		glyphs[1].Cluster,
+		glyphs[2].Cluster,
+	})
+}
@@ -0,0 +1,1053 @@
+package ttf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Variation is a position on one of the design axes of a variable font, in
+// the axis's user scale, e.g. {Axis: "wght", Value: 650}.
+//
+// Registered axes include "wght" (weight), "wdth" (width, as a percentage of
+// normal), "slnt" (slant angle, in degrees), "ital" and "opsz" (optical size).
+type Variation struct {
+	Axis  string
+	Value f32
+}
+
+// varAxis is a design axis from the 'fvar' table.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/fvar
+type varAxis struct {
+	tag tag
+	min f32
+	def f32
+	max f32
+}
+
+// normalize maps val, in user scale, to the normalized [-1, 1] range used by
+// variation data, with the axis default at 0.
+func (a varAxis) normalize(val f32) f32 {
+	val = min(max(val, a.min), a.max)
+
+	switch {
+	case val < a.def:
+		return (val - a.def) / (a.def - a.min)
+	case val > a.def:
+		return (val - a.def) / (a.max - a.def)
+	}
+
+	return 0
+}
+
+type glyphPoint struct {
+	x f32
+	y f32
+}
+
+// parseVariations resolves the normalized coordinates for variations and
+// adjusts glyph advance widths for the resulting instance. Glyph outlines are
+// adjusted later, when generating subsets.
+func (p *Parser) parseVariations(variations []Variation) error {
+	if len(variations) == 0 {
+		return nil
+	}
+
+	if p.reader.Tables.Fvar.Ptr == 0 {
+		return fmt.Errorf("font has no variation axes")
+	}
+	if p.font.Cff() {
+		return fmt.Errorf("variable CFF fonts are not supported")
+	}
+	if p.reader.Tables.Gvar.Ptr == 0 && p.reader.Tables.Hvar.Ptr == 0 {
+		return fmt.Errorf("missing glyph variation tables")
+	}
+
+	axes := p.reader.varAxes()
+	coords := make([]f32, len(axes))
+	for _, v := range variations {
+		found := false
+		for i, axis := range axes {
+			if axis.tag.String() == v.Axis {
+				coords[i] = axis.normalize(v.Value)
+				found = true
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("font has no %q variation axis", v.Axis)
+		}
+	}
+
+	p.reader.avarMap(coords)
+
+	isDefault := true
+	for i, coord := range coords {
+		// Coordinates are applied as F2DOT14 values:
+		coords[i] = f32(math.Round(f64(coord)*(1<<14))) / (1 << 14)
+		isDefault = isDefault && coords[i] == 0
+	}
+	if isDefault {
+		return nil
+	}
+
+	p.font.coords = coords
+	p.font.advances = make([]u16, p.font.GlyphCount)
+	for gid := range p.font.GlyphCount {
+		const stride = 4
+		p.reader.seekTo(p.reader.Tables.Hmtx.Ptr + stride*u32(
+			min(gid, p.font.MetricCount-1),
+		))
+		p.font.advances[gid] = p.reader.u16()
+	}
+
+	if p.reader.Tables.Hvar.Ptr != 0 {
+		ptrHvar := p.reader.Tables.Hvar.Ptr
+		p.reader.seekTo(ptrHvar + 4) // Skip version.
+		ptrStore := ptrHvar + p.reader.u32()
+		offsetAdvanceMap := p.reader.u32()
+
+		scalars := p.reader.regionScalars(ptrStore, coords)
+		for gid := range p.font.GlyphCount {
+			outer, inner := u16(0), gid
+			if offsetAdvanceMap != 0 {
+				outer, inner = p.reader.deltaSetIndex(ptrHvar+offsetAdvanceMap, gid)
+			}
+
+			delta := p.reader.itemVariationDelta(ptrStore, scalars, outer, inner)
+			p.font.advances[gid] = u16(max(0, i32(p.font.advances[gid])+roundDelta(delta)))
+		}
+	} else {
+		// Without HVAR, advance widths are varied via the glyph's phantom
+		// points, which follow its outline points.
+		for gid := range p.font.GlyphCount {
+			pointCount := p.reader.glyphPointCount(gid, p.font.LocaFormat)
+			deltas := p.reader.glyphDeltas(coords, gid, pointCount+4, nil, nil)
+			if deltas == nil {
+				continue
+			}
+
+			delta := deltas[pointCount+1].x - deltas[pointCount].x
+			p.font.advances[gid] = u16(max(0, i32(p.font.advances[gid])+roundDelta(delta)))
+		}
+	}
+
+	for gid, advance := range p.font.advances {
+		p.font.widths[gid] = f32(advance) * p.font.Scale
+	}
+
+	return nil
+}
+
+func roundDelta(delta f32) i32 {
+	return i32(math.Round(f64(delta)))
+}
+
+// https://learn.microsoft.com/en-us/typography/opentype/spec/fvar
+func (r *Reader) varAxes() []varAxis {
+	ptrFvar := r.Tables.Fvar.Ptr
+	r.seekTo(ptrFvar + 4) // Skip version.
+	offsetAxes := r.u16()
+	r.skip(2) // reserved
+	axisCount := r.u16()
+	axisSize := r.u16()
+
+	axes := make([]varAxis, axisCount)
+	for i := range axes {
+		r.seekTo(ptrFvar + u32(offsetAxes) + u32(i)*u32(axisSize))
+		axes[i] = varAxis{
+			tag: r.tag(),
+			min: r.fixed().float(),
+			def: r.fixed().float(),
+			max: r.fixed().float(),
+		}
+	}
+
+	return axes
+}
+
+// avarMap applies the piecewise-linear mappings of the 'avar' table, if any,
+// to normalized coordinates.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/avar
+func (r *Reader) avarMap(coords []f32) {
+	if r.Tables.Avar.Ptr == 0 {
+		return
+	}
+
+	r.seekTo(r.Tables.Avar.Ptr + 6) // Skip version and reserved field.
+	axisCount := int(r.u16())
+
+	for i := range min(axisCount, len(coords)) {
+		mapCount := r.u16()
+		from, to := make([]f32, mapCount), make([]f32, mapCount)
+		for j := range mapCount {
+			from[j], to[j] = r.f2dot14(), r.f2dot14()
+		}
+
+		coords[i] = piecewiseMap(coords[i], from, to)
+	}
+}
+
+func piecewiseMap(coord f32, from, to []f32) f32 {
+	if len(from) == 0 {
+		return coord
+	}
+	if coord <= from[0] {
+		return to[0] + coord - from[0]
+	}
+
+	for j := 1; j < len(from); j++ {
+		if coord <= from[j] {
+			if from[j] == from[j-1] {
+				return to[j]
+			}
+
+			return to[j-1] + (coord-from[j-1])*(to[j]-to[j-1])/(from[j]-from[j-1])
+		}
+	}
+
+	return to[len(to)-1] + coord - from[len(from)-1]
+}
+
+// axisScalar returns the contribution of an axis to the scalar of a variation
+// region or tuple, which peaks at peak and falls to zero at start and end.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/otvaroverview#algorithm-for-interpolation-of-instance-values
+func axisScalar(coord, start, peak, end f32) f32 {
+	switch {
+	case peak == 0 || coord == peak:
+		return 1
+	case start > peak || peak > end || (start < 0 && end > 0):
+		return 1
+	case coord <= start || coord >= end:
+		return 0
+	case coord < peak:
+		return (coord - start) / (peak - start)
+	}
+
+	return (end - coord) / (end - peak)
+}
+
+// regionScalars returns the scalar for each of the variation regions of an
+// item variation store, at coords.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/otvarcommonformats#item-variation-store
+func (r *Reader) regionScalars(ptrStore u32, coords []f32) []f32 {
+	r.seekTo(ptrStore + 2) // Skip format.
+	r.seekTo(ptrStore + r.u32())
+	axisCount := int(r.u16())
+	regionCount := r.u16()
+
+	scalars := make([]f32, regionCount)
+	for i := range scalars {
+		scalar := f32(1)
+		for axis := range axisCount {
+			start, peak, end := r.f2dot14(), r.f2dot14(), r.f2dot14()
+
+			var coord f32
+			if axis < len(coords) {
+				coord = coords[axis]
+			}
+
+			scalar *= axisScalar(coord, start, peak, end)
+		}
+
+		scalars[i] = scalar
+	}
+
+	return scalars
+}
+
+// itemVariationDelta returns the interpolated delta for an item of an item
+// variation store, given the region scalars for the instance.
+func (r *Reader) itemVariationDelta(
+	ptrStore u32,
+	scalars []f32,
+	outer, inner u16,
+) f32 {
+	r.seekTo(ptrStore + 6) // Skip format and regions offset.
+	dataCount := r.u16()
+	if outer >= dataCount {
+		return 0
+	}
+
+	r.skip(4 * u32(outer))
+	r.seekTo(ptrStore + r.u32())
+
+	itemCount := r.u16()
+	wordCount := r.u16()
+	regionCount := r.u16()
+	if inner >= itemCount {
+		return 0
+	}
+
+	regions := r.u16s(u32(regionCount))
+
+	longWords := wordCount&0x8000 != 0
+	wordCount &= 0x7fff
+
+	wordSize, shortSize := u32(2), u32(1)
+	if longWords {
+		wordSize, shortSize = 4, 2
+	}
+
+	rowSize := u32(wordCount)*wordSize + u32(regionCount-wordCount)*shortSize
+	r.skip(u32(inner) * rowSize)
+
+	var delta f32
+	for i, region := range regions {
+		var d f32
+		switch {
+		case i < int(wordCount) && longWords:
+			d = f32(r.i32())
+		case i < int(wordCount) || longWords:
+			d = f32(r.i16())
+		default:
+			d = f32(i8(r.u8()))
+		}
+
+		if int(region) < len(scalars) {
+			delta += scalars[region] * d
+		}
+	}
+
+	return delta
+}
+
+// deltaSetIndex maps a glyph ID to the outer and inner indices of its item in
+// an item variation store.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/otvarcommonformats#associating-target-items-to-variation-data
+func (r *Reader) deltaSetIndex(ptrMap u32, gid u16) (outer, inner u16) {
+	r.seekTo(ptrMap)
+	format := r.u8()
+	entryFormat := r.u8()
+
+	var mapCount u32
+	if format == 0 {
+		mapCount = u32(r.u16())
+	} else {
+		mapCount = r.u32()
+	}
+	if mapCount == 0 {
+		return 0, gid
+	}
+
+	entrySize := u32(entryFormat&0x30>>4) + 1
+	innerBits := entryFormat&0x0f + 1
+
+	r.skip(min(u32(gid), mapCount-1) * entrySize)
+
+	var entry u32
+	for range entrySize {
+		entry = entry<<8 | u32(r.u8())
+	}
+
+	return u16(entry >> innerBits), u16(entry & (1<<innerBits - 1))
+}
+
+const (
+	gvarTupleEmbeddedPeak     = 0x8000
+	gvarTupleIntermediate     = 0x4000
+	gvarTuplePrivatePoints    = 0x2000
+	gvarTupleIndexMask        = 0x0fff
+	gvarTupleCountSharedPoint = 0x8000
+	gvarTupleCountMask        = 0x0fff
+)
+
+// glyphDeltas returns the point deltas for glyph gid at coords, where
+// pointCount includes the glyph's four phantom points, or nil if the glyph has
+// no variation data.
+//
+// For simple glyphs, orig and ends are the glyph's outline points and contour
+// end points, which are used to infer deltas for points that a variation tuple
+// doesn't reference.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gvar
+func (r *Reader) glyphDeltas(
+	coords []f32,
+	gid u16,
+	pointCount int,
+	orig []glyphPoint,
+	ends []u16,
+) []glyphPoint {
+	ptrGvar := r.Tables.Gvar.Ptr
+	if ptrGvar == 0 {
+		return nil
+	}
+
+	r.seekTo(ptrGvar + 4) // Skip version.
+	axisCount := r.u16()
+	sharedTupleCount := r.u16()
+	ptrSharedTuples := ptrGvar + r.u32()
+	glyphCount := r.u16()
+	flags := r.u16()
+	ptrData := ptrGvar + r.u32()
+
+	if gid >= glyphCount {
+		return nil
+	}
+
+	var start, end u32
+	if flags&1 == 0 {
+		r.skip(2 * u32(gid))
+		start, end = 2*u32(r.u16()), 2*u32(r.u16())
+	} else {
+		r.skip(4 * u32(gid))
+		start, end = r.u32(), r.u32()
+	}
+	if start == end {
+		return nil
+	}
+
+	r.seekTo(ptrData + start)
+	tupleCount := r.u16()
+	data := NewReader(r.buf)
+	data.seekTo(ptrData + start + u32(r.u16()))
+	ptrHeader := r.pos
+
+	var sharedPoints []u16
+	if tupleCount&gvarTupleCountSharedPoint != 0 {
+		sharedPoints = data.packedPoints()
+	}
+
+	deltas := make([]glyphPoint, pointCount)
+	peak := make([]f32, axisCount)
+	startCoords := make([]f32, axisCount)
+	endCoords := make([]f32, axisCount)
+
+	for range tupleCount & gvarTupleCountMask {
+		r.seekTo(ptrHeader)
+		lenData := u32(r.u16())
+		index := r.u16()
+
+		if index&gvarTupleEmbeddedPeak != 0 {
+			for i := range peak {
+				peak[i] = r.f2dot14()
+			}
+		} else {
+			ptrPeak := r.pos
+			if index&gvarTupleIndexMask >= sharedTupleCount {
+				return nil
+			}
+
+			r.seekTo(ptrSharedTuples + u32(index&gvarTupleIndexMask)*2*u32(axisCount))
+			for i := range peak {
+				peak[i] = r.f2dot14()
+			}
+			r.seekTo(ptrPeak)
+		}
+
+		if index&gvarTupleIntermediate != 0 {
+			for i := range startCoords {
+				startCoords[i] = r.f2dot14()
+			}
+			for i := range endCoords {
+				endCoords[i] = r.f2dot14()
+			}
+		} else {
+			for i, p := range peak {
+				startCoords[i], endCoords[i] = min(0, p), max(0, p)
+			}
+		}
+
+		ptrHeader = r.pos
+		ptrNextData := data.pos + lenData
+
+		scalar := f32(1)
+		for i := range peak {
+			var coord f32
+			if i < len(coords) {
+				coord = coords[i]
+			}
+
+			scalar *= axisScalar(coord, startCoords[i], peak[i], endCoords[i])
+		}
+		if scalar == 0 {
+			data.seekTo(ptrNextData)
+			continue
+		}
+
+		points := sharedPoints
+		if index&gvarTuplePrivatePoints != 0 {
+			points = data.packedPoints()
+		}
+
+		if points == nil {
+			xs := data.packedDeltas(pointCount)
+			ys := data.packedDeltas(pointCount)
+			for i := range deltas {
+				deltas[i].x += scalar * xs[i]
+				deltas[i].y += scalar * ys[i]
+			}
+
+			data.seekTo(ptrNextData)
+			continue
+		}
+
+		xs := data.packedDeltas(len(points))
+		ys := data.packedDeltas(len(points))
+
+		tupleDeltas := make([]glyphPoint, pointCount)
+		touched := make([]bool, pointCount)
+		for i, point := range points {
+			if int(point) < pointCount {
+				tupleDeltas[point] = glyphPoint{x: xs[i], y: ys[i]}
+				touched[point] = true
+			}
+		}
+
+		if orig != nil {
+			interpolateUntouched(tupleDeltas, touched, orig, ends)
+		}
+
+		for i, delta := range tupleDeltas {
+			deltas[i].x += scalar * delta.x
+			deltas[i].y += scalar * delta.y
+		}
+
+		data.seekTo(ptrNextData)
+	}
+
+	return deltas
+}
+
+// packedPoints reads a set of packed point numbers. A nil result refers to all
+// points in the glyph.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/otvarcommonformats#packed-point-numbers
+func (r *Reader) packedPoints() []u16 {
+	count := u16(r.u8())
+	if count == 0 {
+		return nil
+	}
+	if count&0x80 != 0 {
+		count = (count&0x7f)<<8 | u16(r.u8())
+	}
+
+	points := make([]u16, 0, count)
+	var point u16
+	for len(points) < int(count) {
+		control := r.u8()
+		runLen := min(int(control&0x7f)+1, int(count)-len(points))
+
+		for range runLen {
+			if control&0x80 != 0 {
+				point += r.u16()
+			} else {
+				point += u16(r.u8())
+			}
+
+			points = append(points, point)
+		}
+	}
+
+	return points
+}
+
+// packedDeltas reads count packed delta values.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/otvarcommonformats#packed-deltas
+func (r *Reader) packedDeltas(count int) []f32 {
+	deltas := make([]f32, 0, count)
+	for len(deltas) < count {
+		control := r.u8()
+		runLen := min(int(control&0x3f)+1, count-len(deltas))
+
+		for range runLen {
+			switch {
+			case control&0x80 != 0:
+				deltas = append(deltas, 0)
+			case control&0x40 != 0:
+				deltas = append(deltas, f32(r.i16()))
+			default:
+				deltas = append(deltas, f32(i8(r.u8())))
+			}
+		}
+	}
+
+	return deltas
+}
+
+// interpolateUntouched infers the deltas of points that aren't referenced by
+// a variation tuple from those of the nearest referenced points on either side
+// in the same contour.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gvar#inferred-deltas-for-un-referenced-point-numbers
+func interpolateUntouched(
+	deltas []glyphPoint,
+	touched []bool,
+	orig []glyphPoint,
+	ends []u16,
+) {
+	start := 0
+	for _, e := range ends {
+		end := int(e) + 1
+		if end > len(orig) || end <= start {
+			return
+		}
+
+		next := func(i int) int {
+			if i+1 == end {
+				return start
+			}
+			return i + 1
+		}
+
+		var refs []int
+		for i := start; i < end; i++ {
+			if touched[i] {
+				refs = append(refs, i)
+			}
+		}
+
+		if len(refs) > 0 && len(refs) < end-start {
+			for k, i1 := range refs {
+				i2 := refs[(k+1)%len(refs)]
+				for i := next(i1); i != i2; i = next(i) {
+					deltas[i].x = interpolateDelta(
+						orig[i].x, orig[i1].x, orig[i2].x, deltas[i1].x, deltas[i2].x,
+					)
+					deltas[i].y = interpolateDelta(
+						orig[i].y, orig[i1].y, orig[i2].y, deltas[i1].y, deltas[i2].y,
+					)
+				}
+			}
+		}
+
+		start = end
+	}
+}
+
+func interpolateDelta(coord, c1, c2, d1, d2 f32) f32 {
+	if c1 > c2 {
+		c1, c2, d1, d2 = c2, c1, d2, d1
+	}
+
+	switch {
+	case c1 == c2:
+		if d1 == d2 {
+			return d1
+		}
+		return 0
+	case coord <= c1:
+		return d1
+	case coord >= c2:
+		return d2
+	}
+
+	return d1 + (coord-c1)*(d2-d1)/(c2-c1)
+}
+
+// glyphPointCount returns the number of points in a glyph's outline, not
+// including phantom points. For composite glyphs, each component counts as a
+// point.
+func (r *Reader) glyphPointCount(gid u16, locaFormat u8) int {
+	offset, len := r.glyfLocation(gid, locaFormat)
+	if len < 10 {
+		return 0
+	}
+
+	r.seekTo(r.Tables.Glyf.Ptr + offset)
+	contourCount := r.i16()
+	if contourCount == 0 {
+		return 0
+	}
+	if contourCount > 0 {
+		r.skip(2*4 + 2*u32(contourCount-1))
+		return int(r.u16()) + 1
+	}
+
+	r.skip(2 * 4)
+	count := 0
+	for {
+		flags := r.u16()
+		r.skip(2 + componentExtraLen(flags))
+		count++
+
+		if !GlyfFlagMoreComponents.Test(flags) {
+			return count
+		}
+	}
+}
+
+// componentExtraLen returns the length of the arguments and transform of a
+// composite glyph component.
+func componentExtraLen(flags u16) u32 {
+	extraLen := u32(2)
+	if GlyfFlagArg1And2AreWords.Test(flags) {
+		extraLen = 4
+	}
+
+	switch {
+	case GlyfFlagWeHaveAScale.Test(flags):
+		extraLen += 2
+	case GlyfFlagWeHaveAnXAndYScale.Test(flags):
+		extraLen += 4
+	case GlyfFlagWeHaveATwoByTwo.Test(flags):
+		extraLen += 8
+	}
+
+	return extraLen
+}
+
+const (
+	glyfPointOnCurve u8 = 1 << 0
+	glyfPointXShort  u8 = 1 << 1
+	glyfPointYShort  u8 = 1 << 2
+	glyfPointRepeat  u8 = 1 << 3
+	glyfPointXSame   u8 = 1 << 4
+	glyfPointYSame   u8 = 1 << 5
+	glyfPointOverlap u8 = 1 << 6
+)
+
+// instancedGlyph is a glyph outline adjusted to the variation coordinates of
+// a font.
+type instancedGlyph struct {
+	data []byte
+	xMin i16
+	yMin i16
+	xMax i16
+	yMax i16
+}
+
+// instanceGlyph returns the outline of glyph gid, and those of its
+// components, adjusted to the font's variation coordinates.
+func (g *Generator) instanceGlyph(gid u16) instancedGlyph {
+	if glyph, ok := g.instances[gid]; ok {
+		return glyph
+	}
+
+	// Use a separate reader, to avoid disturbing the generator's position.
+	r := g.reader
+	offset, len := r.glyfLocation(gid, g.font.LocaFormat)
+	data := r.readAt(r.Tables.Glyf.Ptr+offset, len)
+
+	var glyph instancedGlyph
+	switch {
+	case len < 10:
+		// Empty glyph.
+	case i16(binary.BigEndian.Uint16(data)) >= 0:
+		glyph = g.instanceSimpleGlyph(&r, gid, data)
+	default:
+		glyph = g.instanceCompositeGlyph(&r, gid, data)
+	}
+
+	if g.instances == nil {
+		g.instances = map[u16]instancedGlyph{}
+	}
+	g.instances[gid] = glyph
+
+	return glyph
+}
+
+// instanceGlyfEntry replaces the data for a subset glyph with that of the
+// variable font instance.
+func (g *Generator) instanceGlyfEntry(glyf *GlyfEntry) {
+	glyf.data = g.instanceGlyph(glyf.gid).data
+	glyf.len = u32(len(glyf.data))
+}
+
+type simpleGlyph struct {
+	ends         []u16
+	instructions []byte
+	flags        []u8
+	points       []glyphPoint
+}
+
+// https://learn.microsoft.com/en-us/typography/opentype/spec/glyf#simple-glyph-description
+func parseSimpleGlyph(data []byte) (glyph simpleGlyph) {
+	in := NewReader(data)
+	contourCount := in.i16()
+	in.skip(2 * 4)
+
+	glyph.ends = in.u16s(u32(contourCount))
+	var pointCount int
+	if contourCount > 0 {
+		pointCount = int(glyph.ends[contourCount-1]) + 1
+	}
+
+	glyph.instructions = in.read(u32(in.u16()))
+
+	glyph.flags = make([]u8, 0, pointCount)
+	for len(glyph.flags) < pointCount {
+		flag := in.u8()
+		glyph.flags = append(glyph.flags, flag)
+
+		if flag&glyfPointRepeat != 0 {
+			for range in.u8() {
+				glyph.flags = append(glyph.flags, flag)
+			}
+		}
+	}
+	glyph.flags = glyph.flags[:pointCount]
+
+	glyph.points = make([]glyphPoint, pointCount)
+	var x, y i16
+	for i, flag := range glyph.flags {
+		x += in.glyfCoord(flag, glyfPointXShort, glyfPointXSame)
+		glyph.points[i].x = f32(x)
+	}
+	for i, flag := range glyph.flags {
+		y += in.glyfCoord(flag, glyfPointYShort, glyfPointYSame)
+		glyph.points[i].y = f32(y)
+	}
+
+	return glyph
+}
+
+func (g *Generator) instanceSimpleGlyph(
+	r *Reader,
+	gid u16,
+	data []byte,
+) (glyph instancedGlyph) {
+	simple := parseSimpleGlyph(data)
+	ends, flags, points := simple.ends, simple.flags, simple.points
+	pointCount := len(points)
+
+	deltas := r.glyphDeltas(g.font.coords, gid, pointCount+4, points, ends)
+
+	xs, ys := make([]i16, pointCount), make([]i16, pointCount)
+	for i, point := range points {
+		xs[i], ys[i] = i16(point.x), i16(point.y)
+		if deltas != nil {
+			xs[i] = i16(roundDelta(point.x + deltas[i].x))
+			ys[i] = i16(roundDelta(point.y + deltas[i].y))
+		}
+
+		if i == 0 {
+			glyph.xMin, glyph.xMax, glyph.yMin, glyph.yMax = xs[i], xs[i], ys[i], ys[i]
+			continue
+		}
+
+		glyph.xMin, glyph.xMax = min(glyph.xMin, xs[i]), max(glyph.xMax, xs[i])
+		glyph.yMin, glyph.yMax = min(glyph.yMin, ys[i]), max(glyph.yMax, ys[i])
+	}
+
+	out := glyph.appendHeader(nil, i16(len(ends)))
+	for _, end := range ends {
+		out = binary.BigEndian.AppendUint16(out, end)
+	}
+	out = binary.BigEndian.AppendUint16(out, u16(len(simple.instructions)))
+	out = append(out, simple.instructions...)
+
+	// Flags, using repeat counts for runs of identical flags:
+	ptrLastFlag := -1
+	var prevX, prevY i16
+	for i, flag := range flags {
+		flag &= glyfPointOnCurve | glyfPointOverlap
+		flag |= glyfCoordFlag(xs[i]-prevX, glyfPointXShort, glyfPointXSame)
+		flag |= glyfCoordFlag(ys[i]-prevY, glyfPointYShort, glyfPointYSame)
+		prevX, prevY = xs[i], ys[i]
+
+		if ptrLastFlag >= 0 && out[ptrLastFlag]&^glyfPointRepeat == flag {
+			if out[ptrLastFlag]&glyfPointRepeat == 0 {
+				out[ptrLastFlag] |= glyfPointRepeat
+				out = append(out, 1)
+				continue
+			}
+			if out[ptrLastFlag+1] < math.MaxUint8 {
+				out[ptrLastFlag+1]++
+				continue
+			}
+		}
+
+		ptrLastFlag = len(out)
+		out = append(out, flag)
+	}
+
+	prevX, prevY = 0, 0
+	for _, x := range xs {
+		out = appendGlyfCoord(out, x-prevX)
+		prevX = x
+	}
+	for _, y := range ys {
+		out = appendGlyfCoord(out, y-prevY)
+		prevY = y
+	}
+
+	glyph.data = out
+
+	return glyph
+}
+
+func (r *Reader) glyfCoord(flag, short, same u8) i16 {
+	switch {
+	case flag&short != 0:
+		if flag&same != 0 {
+			return i16(r.u8())
+		}
+		return -i16(r.u8())
+	case flag&same != 0:
+		return 0
+	}
+
+	return r.i16()
+}
+
+func glyfCoordFlag(delta i16, short, same u8) u8 {
+	switch {
+	case delta == 0:
+		return same
+	case delta > 0 && delta <= math.MaxUint8:
+		return short | same
+	case delta < 0 && delta >= -math.MaxUint8:
+		return short
+	}
+
+	return 0
+}
+
+func appendGlyfCoord(out []byte, delta i16) []byte {
+	switch {
+	case delta == 0:
+		return out
+	case delta > 0 && delta <= math.MaxUint8:
+		return append(out, u8(delta))
+	case delta < 0 && delta >= -math.MaxUint8:
+		return append(out, u8(-delta))
+	}
+
+	return binary.BigEndian.AppendUint16(out, u16(delta))
+}
+
+type glyphComponent struct {
+	flags     u16
+	gid       u16
+	arg1      i16
+	arg2      i16
+	transform []byte
+}
+
+// https://learn.microsoft.com/en-us/typography/opentype/spec/glyf#composite-glyph-description
+func (g *Generator) instanceCompositeGlyph(
+	r *Reader,
+	gid u16,
+	data []byte,
+) (glyph instancedGlyph) {
+	in := NewReader(data)
+	in.skip(2 + 2*4)
+
+	var components []glyphComponent
+	for {
+		c := glyphComponent{flags: in.u16(), gid: in.u16()}
+
+		switch {
+		case GlyfFlagArg1And2AreWords.Test(c.flags):
+			c.arg1, c.arg2 = in.i16(), in.i16()
+		case GlyfFlagArgsAreXyValues.Test(c.flags):
+			c.arg1, c.arg2 = i16(i8(in.u8())), i16(i8(in.u8()))
+		default:
+			c.arg1, c.arg2 = i16(in.u8()), i16(in.u8())
+		}
+
+		c.transform = in.read(componentExtraLen(c.flags&^u16(GlyfFlagArg1And2AreWords)) - 2)
+		components = append(components, c)
+
+		if !GlyfFlagMoreComponents.Test(c.flags) {
+			break
+		}
+	}
+
+	// Instructions, if any, follow the last component.
+	instructions := data[in.pos:]
+
+	deltas := r.glyphDeltas(g.font.coords, gid, len(components)+4, nil, nil)
+
+	first := true
+	for i := range components {
+		c := &components[i]
+		if !GlyfFlagArgsAreXyValues.Test(c.flags) {
+			continue
+		}
+
+		if deltas != nil {
+			c.arg1 = i16(roundDelta(f32(c.arg1) + deltas[i].x))
+			c.arg2 = i16(roundDelta(f32(c.arg2) + deltas[i].y))
+		}
+		c.flags |= u16(GlyfFlagArg1And2AreWords)
+
+		// Components positioned by point matching are left out of the bounding
+		// box, since it's rare for them to extend past the other components.
+		child := g.instanceGlyph(c.gid)
+		if len(child.data) == 0 {
+			continue
+		}
+
+		a, b, cc, d := c.matrix()
+		for _, corner := range [4][2]i16{
+			{child.xMin, child.yMin},
+			{child.xMin, child.yMax},
+			{child.xMax, child.yMin},
+			{child.xMax, child.yMax},
+		} {
+			x := i16(roundDelta(a*f32(corner[0]) + cc*f32(corner[1]) + f32(c.arg1)))
+			y := i16(roundDelta(b*f32(corner[0]) + d*f32(corner[1]) + f32(c.arg2)))
+
+			if first {
+				glyph.xMin, glyph.xMax, glyph.yMin, glyph.yMax = x, x, y, y
+				first = false
+				continue
+			}
+
+			glyph.xMin, glyph.xMax = min(glyph.xMin, x), max(glyph.xMax, x)
+			glyph.yMin, glyph.yMax = min(glyph.yMin, y), max(glyph.yMax, y)
+		}
+	}
+
+	out := glyph.appendHeader(nil, -1)
+	for _, c := range components {
+		out = binary.BigEndian.AppendUint16(out, c.flags)
+		out = binary.BigEndian.AppendUint16(out, c.gid)
+
+		if GlyfFlagArg1And2AreWords.Test(c.flags) {
+			out = binary.BigEndian.AppendUint16(out, u16(c.arg1))
+			out = binary.BigEndian.AppendUint16(out, u16(c.arg2))
+		} else {
+			out = append(out, u8(c.arg1), u8(c.arg2))
+		}
+
+		out = append(out, c.transform...)
+	}
+	out = append(out, instructions...)
+
+	glyph.data = out
+
+	return glyph
+}
+
+// matrix returns the 2x2 transformation of a component, in the order
+// [xscale, scale01, scale10, yscale].
+func (c *glyphComponent) matrix() (a, b, cc, d f32) {
+	r := NewReader(c.transform)
+
+	switch {
+	case GlyfFlagWeHaveAScale.Test(c.flags):
+		scale := r.f2dot14()
+		return scale, 0, 0, scale
+	case GlyfFlagWeHaveAnXAndYScale.Test(c.flags):
+		return r.f2dot14(), 0, 0, r.f2dot14()
+	case GlyfFlagWeHaveATwoByTwo.Test(c.flags):
+		return r.f2dot14(), r.f2dot14(), r.f2dot14(), r.f2dot14()
+	}
+
+	return 1, 0, 0, 1
+}
+
+func (g *instancedGlyph) appendHeader(out []byte, contourCount i16) []byte {
+	out = binary.BigEndian.AppendUint16(out, u16(contourCount))
+	out = binary.BigEndian.AppendUint16(out, u16(g.xMin))
+	out = binary.BigEndian.AppendUint16(out, u16(g.yMin))
+	out = binary.BigEndian.AppendUint16(out, u16(g.xMax))
+	return binary.BigEndian.AppendUint16(out, u16(g.yMax))
+}
+
+func (r *Reader) f2dot14() f32 {
+	return f32(r.i16()) / (1 << 14)
+}
+
+func (r *Reader) u8() u8 {
+	return r.read(1)[0]
+}
@@ -0,0 +1,332 @@
+package ttf
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/bits-and-blooms/bitset"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVariations(t *testing.T) {
+	var static Font
+	require.NoError(t, Parse(robotoI, &static))
+	gidA, gidB := static.GlyphId('A'), static.GlyphId('B')
+	advanceA := static.Width(gidA) / static.Scale
+
+	for _, test := range []struct {
+		hvar     bool
+		weight   f32
+		advanceA f32
+	}{
+		{hvar: false, weight: 900, advanceA: advanceA + 80},
+		{hvar: false, weight: 650, advanceA: advanceA + 60}, // avar: 0.5 -> 0.75
+		{hvar: false, weight: 400, advanceA: advanceA},
+		{hvar: false, weight: 100, advanceA: advanceA},
+		{hvar: true, weight: 900, advanceA: advanceA + 100},
+		{hvar: true, weight: 650, advanceA: advanceA + 75},
+	} {
+		var font Font
+		require.NoError(t, Parse(
+			testVariableFont(t, test.hvar),
+			&font,
+			Variation{Axis: "wght", Value: test.weight},
+		))
+
+		require.InDelta(t, test.advanceA, font.Width(gidA)/font.Scale, 0.01,
+			"hvar: %v, weight: %v", test.hvar, test.weight)
+		require.Equal(t, static.Width(gidB), font.Width(gidB))
+	}
+
+	var font Font
+	require.NoError(t, Parse(
+		testVariableFont(t, false),
+		&font,
+		Variation{Axis: "wght", Value: 400},
+	))
+	require.Nil(t, font.coords)
+
+	require.ErrorContains(t, Parse(
+		testVariableFont(t, false),
+		&font,
+		Variation{Axis: "wdth", Value: 75},
+	), `no "wdth" variation axis`)
+
+	require.ErrorContains(t, Parse(
+		robotoI,
+		&font,
+		Variation{Axis: "wght", Value: 700},
+	), "no variation axes")
+}
+
+func TestGenerateVariations(t *testing.T) {
+	var static, font Font
+	require.NoError(t, Parse(robotoI, &static))
+	require.NoError(t, Parse(
+		testVariableFont(t, false),
+		&font,
+		Variation{Axis: "wght", Value: 900},
+	))
+
+	gidA, gidB, gidAacute := font.GlyphId('A'), font.GlyphId('B'), font.GlyphId('Á')
+	var gids bitset.BitSet
+	gids.Set(uint(gidB)).Set(uint(gidAacute))
+
+	subsetBytes, gidRemap, err := Generate(&font, &gids, nil)
+	require.NoError(t, err)
+
+	var subset Font
+	require.NoError(t, Parse(subsetBytes, &subset))
+	require.Equal(t, font.Width(gidA), subset.Width(gidRemap[gidA]))
+
+	// Every point moves with the variation:
+	origA := testGlyphData(t, robotoI, &static, gidA)
+	instA := testGlyphData(t, subsetBytes, &subset, gidRemap[gidA])
+	for i, point := range parseSimpleGlyph(origA).points {
+		require.Equal(t,
+			glyphPoint{x: point.x + 40, y: point.y},
+			parseSimpleGlyph(instA).points[i],
+		)
+	}
+
+	xMinA := i16(binary.BigEndian.Uint16(instA[2:]))
+	require.Equal(t, i16(binary.BigEndian.Uint16(origA[2:]))+40, xMinA)
+
+	r := NewReader(subsetBytes)
+	require.NoError(t, r.parseIndex())
+	require.Zero(t, r.Tables.Gvar.Ptr)
+	r.seekTo(r.Tables.Hmtx.Ptr + 4*u32(gidRemap[gidA]) + 2)
+	require.Equal(t, xMinA, r.i16(), "left side bearing")
+
+	// Points without explicit deltas are inferred from the rest of the contour:
+	origB := parseSimpleGlyph(testGlyphData(t, robotoI, &static, gidB))
+	instB := parseSimpleGlyph(
+		testGlyphData(t, subsetBytes, &subset, gidRemap[gidB]),
+	)
+	require.Equal(t, origB.ends, instB.ends)
+	require.Equal(t, origB.instructions, instB.instructions)
+	for i, point := range origB.points {
+		if i <= int(origB.ends[0]) {
+			point.x, point.y = point.x+8, point.y-4
+		}
+
+		require.Equal(t, point, instB.points[i])
+	}
+
+	// Component offsets move, while components are instanced separately:
+	origComponents := testComponents(
+		testGlyphData(t, robotoI, &static, gidAacute),
+	)
+	instComponents := testComponents(
+		testGlyphData(t, subsetBytes, &subset, gidRemap[gidAacute]),
+	)
+	require.Len(t, instComponents, 2)
+	require.Equal(t, gidRemap[origComponents[0].gid], instComponents[0].gid)
+	require.Equal(t, origComponents[0].arg1, instComponents[0].arg1)
+	require.Equal(t, origComponents[1].arg1+12, instComponents[1].arg1)
+	require.Equal(t, origComponents[1].arg2+6, instComponents[1].arg2)
+}
+
+func TestInterpolateUntouched(t *testing.T) {
+	orig := []glyphPoint{
+		{x: 0, y: 0},
+		{x: 50, y: 100},
+		{x: 100, y: 200},
+		{x: 150, y: 100},
+		{x: 300, y: 0},
+	}
+	deltas := []glyphPoint{{x: 10}, {}, {x: 20, y: 30}, {}, {}}
+	touched := []bool{true, false, true, false, false}
+
+	interpolateUntouched(deltas, touched, orig, []u16{4})
+
+	require.Equal(t, []glyphPoint{
+		{x: 10},
+		{x: 15, y: 15},
+		{x: 20, y: 30},
+		{x: 20, y: 15},
+		{x: 20, y: 0},
+	}, deltas)
+}
+
+func testGlyphData(t *testing.T, fontBytes []byte, font *Font, gid u16) []byte {
+	t.Helper()
+
+	r := NewReader(fontBytes)
+	require.NoError(t, r.parseIndex())
+
+	offset, len := r.glyfLocation(gid, font.LocaFormat)
+	return r.readAt(r.Tables.Glyf.Ptr+offset, len)
+}
+
+func testComponents(data []byte) (components []glyphComponent) {
+	r := NewReader(data)
+	r.skip(2 + 2*4)
+
+	for {
+		c := glyphComponent{flags: r.u16(), gid: r.u16()}
+		if GlyfFlagArg1And2AreWords.Test(c.flags) {
+			c.arg1, c.arg2 = r.i16(), r.i16()
+		} else {
+			c.arg1, c.arg2 = i16(i8(r.u8())), i16(i8(r.u8()))
+		}
+		r.skip(componentExtraLen(c.flags&^u16(GlyfFlagArg1And2AreWords)) - 2)
+
+		components = append(components, c)
+		if !GlyfFlagMoreComponents.Test(c.flags) {
+			return
+		}
+	}
+}
+
+// testVariableFont adds a weight axis (100-900, with a default of 400) to
+// Roboto Italic, with variations for 'A', 'B' and 'Á' at maximum weight.
+// Advance widths are varied with either HVAR or gvar phantom points.
+func testVariableFont(t *testing.T, hvar bool) []byte {
+	t.Helper()
+
+	var font Font
+	require.NoError(t, Parse(robotoI, &font))
+	r := NewReader(robotoI)
+	require.NoError(t, r.parseIndex())
+
+	be := binary.BigEndian
+	gidA, gidB, gidAacute := font.GlyphId('A'), font.GlyphId('B'), font.GlyphId('Á')
+
+	fvar := be.AppendUint32(nil, 0x0001_0000)
+	fvar = be.AppendUint16(fvar, 16)          // axesArrayOffset
+	fvar = be.AppendUint16(fvar, 2)           // reserved
+	fvar = be.AppendUint16(fvar, 1)           // axisCount
+	fvar = be.AppendUint16(fvar, 20)          // axisSize
+	fvar = be.AppendUint16(fvar, 0)           // instanceCount
+	fvar = be.AppendUint16(fvar, 8)           // instanceSize
+	fvar = be.AppendUint32(fvar, 0x7767_6874) // 'wght'
+	fvar = be.AppendUint32(fvar, 100<<16)
+	fvar = be.AppendUint32(fvar, 400<<16)
+	fvar = be.AppendUint32(fvar, 900<<16)
+	fvar = be.AppendUint16(fvar, 0)   // flags
+	fvar = be.AppendUint16(fvar, 256) // axisNameID
+
+	avar := be.AppendUint32(nil, 0x0001_0000)
+	avar = be.AppendUint16(avar, 0) // reserved
+	avar = be.AppendUint16(avar, 1) // axisCount
+	avar = be.AppendUint16(avar, 4) // positionMapCount
+	for _, m := range [][2]i16{{-1 << 14, -1 << 14}, {0, 0}, {1 << 13, 3 << 12}, {1 << 14, 1 << 14}} {
+		avar = be.AppendUint16(avar, u16(m[0]))
+		avar = be.AppendUint16(avar, u16(m[1]))
+	}
+
+	packDeltas := func(out []byte, deltas []i16) []byte {
+		for len(deltas) > 0 {
+			run := deltas[:min(len(deltas), 64)]
+			deltas = deltas[len(run):]
+
+			out = append(out, 0x40|u8(len(run)-1))
+			for _, d := range run {
+				out = be.AppendUint16(out, u16(d))
+			}
+		}
+		return out
+	}
+
+	// A single tuple, peaking at wght=900, for each glyph.
+	glyphData := func(points []u16, xs, ys []i16) []byte {
+		serialized := []byte{0}
+		if points != nil {
+			serialized = []byte{u8(len(points)), u8(len(points) - 1)}
+			var prev u16
+			for _, point := range points {
+				serialized = append(serialized, u8(point-prev))
+				prev = point
+			}
+		}
+		serialized = packDeltas(serialized, xs)
+		serialized = packDeltas(serialized, ys)
+
+		data := be.AppendUint16(nil, 1)  // tupleVariationCount
+		data = be.AppendUint16(data, 10) // dataOffset
+		data = be.AppendUint16(data, u16(len(serialized)))
+		data = be.AppendUint16(data, gvarTupleEmbeddedPeak|gvarTuplePrivatePoints)
+		data = be.AppendUint16(data, 1<<14)
+		return append(data, serialized...)
+	}
+
+	variations := map[u16][]byte{}
+
+	countA := r.glyphPointCount(gidA, font.LocaFormat)
+	xs, ys := make([]i16, countA+4), make([]i16, countA+4)
+	for i := range countA {
+		xs[i] = 40
+	}
+	xs[countA+1] = 80 // Advance width
+	variations[gidA] = glyphData(nil, xs, ys)
+
+	variations[gidB] = glyphData([]u16{0}, []i16{8}, []i16{-4})
+
+	xs, ys = []i16{0, 12, 0, 80, 0, 0}, []i16{0, 6, 0, 0, 0, 0}
+	variations[gidAacute] = glyphData(nil, xs, ys)
+
+	gvar := be.AppendUint32(nil, 0x0001_0000)
+	gvar = be.AppendUint16(gvar, 1)  // axisCount
+	gvar = be.AppendUint16(gvar, 0)  // sharedTupleCount
+	gvar = be.AppendUint32(gvar, 20) // sharedTuplesOffset
+	gvar = be.AppendUint16(gvar, font.GlyphCount)
+	gvar = be.AppendUint16(gvar, 1) // flags: long offsets
+	gvar = be.AppendUint32(gvar, 20+4*(u32(font.GlyphCount)+1))
+
+	var data []byte
+	for gid := range font.GlyphCount {
+		gvar = be.AppendUint32(gvar, u32(len(data)))
+		data = append(data, variations[gid]...)
+	}
+	gvar = be.AppendUint32(gvar, u32(len(data)))
+	gvar = append(gvar, data...)
+
+	tables := append(
+		testTables(robotoI),
+		testTable{tag: u32(TableNameFvar), data: fvar},
+		testTable{tag: u32(TableNameAvar), data: avar},
+		testTable{tag: u32(TableNameGvar), data: gvar},
+	)
+
+	if hvar {
+		// Glyph 'A' is item 1 in the store, all others are item 0.
+		advanceMap := []byte{0, 0x00}
+		advanceMap = be.AppendUint16(advanceMap, gidA+2)
+		for gid := range gidA + 2 {
+			var entry u8
+			if gid == gidA {
+				entry = 1
+			}
+			advanceMap = append(advanceMap, entry)
+		}
+
+		store := be.AppendUint16(nil, 1) // format
+		store = be.AppendUint32(store, 12)
+		store = be.AppendUint16(store, 1) // itemVariationDataCount
+		store = be.AppendUint32(store, 12+2+2+6)
+		store = be.AppendUint16(store, 1) // axisCount
+		store = be.AppendUint16(store, 1) // regionCount
+		store = be.AppendUint16(store, 0)
+		store = be.AppendUint16(store, 1<<14)
+		store = be.AppendUint16(store, 1<<14)
+		store = be.AppendUint16(store, 2) // itemCount
+		store = be.AppendUint16(store, 1) // wordDeltaCount
+		store = be.AppendUint16(store, 1) // regionIndexCount
+		store = be.AppendUint16(store, 0)
+		store = be.AppendUint16(store, 0)
+		store = be.AppendUint16(store, 100)
+
+		hvarTable := be.AppendUint32(nil, 0x0001_0000)
+		hvarTable = be.AppendUint32(hvarTable, 20)
+		hvarTable = be.AppendUint32(hvarTable, 20+u32(len(store)))
+		hvarTable = be.AppendUint32(hvarTable, 0) // lsbMappingOffset
+		hvarTable = be.AppendUint32(hvarTable, 0) // rsbMappingOffset
+		hvarTable = append(hvarTable, store...)
+		hvarTable = append(hvarTable, advanceMap...)
+
+		tables = append(tables, testTable{tag: u32(TableNameHvar), data: hvarTable})
+	}
+
+	return testFont(0x0001_0000, tables)
+}
@@ -0,0 +1,134 @@
+package ttf
+
+// Features for vertical alternates, of which 'vrt2' supersedes 'vert' in
+// fonts that have both.
+const (
+	featureVert tag = 0x76657274 // 'vert'
+	featureVrt2 tag = 0x76727432 // 'vrt2'
+)
+
+// HasVerticalMetrics returns true if the font has vertical advances and
+// origins for its glyphs. Other fonts are set vertically with a uniform
+// advance of Ascent - Descent.
+func (f *Font) HasVerticalMetrics() bool {
+	return f.heights != nil
+}
+
+// Height returns the vertical advance of a glyph, in 1/1000 em, for vertical
+// writing.
+func (f *Font) Height(gid u16) f32 {
+	if int(gid) < len(f.heights) {
+		return f.heights[gid]
+	}
+
+	return f.Ascent - f.Descent
+}
+
+// VerticalOrigin returns the height above the baseline of a glyph's origin
+// for vertical writing, in 1/1000 em. Horizontally, the origin is centred on
+// the glyph's advance width.
+func (f *Font) VerticalOrigin(gid u16) f32 {
+	if int(gid) < len(f.vertOrigins) {
+		return f.vertOrigins[gid]
+	}
+
+	return f.Ascent
+}
+
+// parseVertical reads vertical glyph metrics, from the 'vhea' and 'vmtx'
+// tables, and the lookups for vertical alternate glyphs.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/vhea
+// https://learn.microsoft.com/en-us/typography/opentype/spec/vmtx
+func (p *Parser) parseVertical() {
+	for _, feature := range []tag{featureVrt2, featureVert} {
+		if p.reader.Tables.Gsub.Ptr == 0 {
+			break
+		}
+
+		for _, index := range p.reader.featureLookups(p.reader.Tables.Gsub.Ptr, feature) {
+			p.font.vertLookups = append(p.font.vertLookups, planLookup{
+				index: index,
+				mask:  maskGlobal,
+			})
+		}
+		if len(p.font.vertLookups) > 0 {
+			break
+		}
+	}
+
+	if p.reader.Tables.Vhea.Ptr == 0 || p.reader.Tables.Vmtx.Ptr == 0 {
+		return
+	}
+
+	p.reader.seekTo(p.reader.Tables.Vhea.Ptr + 34)
+	metricCount := u32(p.reader.u16())
+	if metricCount == 0 {
+		return
+	}
+
+	p.font.heights = make([]f32, p.font.GlyphCount)
+	p.font.vertOrigins = make([]f32, p.font.GlyphCount)
+
+	ptrVmtx := p.reader.Tables.Vmtx.Ptr
+	for gid := range p.font.GlyphCount {
+		const stride = 4
+		p.reader.seekTo(ptrVmtx + stride*min(u32(gid), metricCount-1))
+		p.font.heights[gid] = p.fwordScaled()
+
+		if u32(gid) < metricCount {
+			p.reader.seekTo(ptrVmtx + stride*u32(gid) + 2)
+		} else {
+			p.reader.seekTo(ptrVmtx + stride*metricCount + 2*(u32(gid)-metricCount))
+		}
+		topBearing := p.fwordScaled()
+
+		p.font.vertOrigins[gid] = p.font.Ascent
+		if yMax, ok := p.glyphYMax(gid); ok {
+			p.font.vertOrigins[gid] = yMax + topBearing
+		}
+	}
+
+	p.parseVorg()
+}
+
+// parseVorg reads the vertical origins of glyphs in CFF fonts, which can't be
+// derived from their top side bearings without interpreting charstrings.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/vorg
+func (p *Parser) parseVorg() {
+	ptrVorg := p.reader.Tables.Vorg.Ptr
+	if ptrVorg == 0 {
+		return
+	}
+
+	p.reader.seekTo(ptrVorg + 4) // Skip version.
+	defaultOrigin := p.fwordScaled()
+	for gid := range p.font.vertOrigins {
+		p.font.vertOrigins[gid] = defaultOrigin
+	}
+
+	for range p.reader.u16() {
+		gid := p.reader.u16()
+		origin := p.fwordScaled()
+		if int(gid) < len(p.font.vertOrigins) {
+			p.font.vertOrigins[gid] = origin
+		}
+	}
+}
+
+// glyphYMax returns the top of the outline of a glyph, for TrueType outlines.
+func (p *Parser) glyphYMax(gid u16) (yMax f32, ok bool) {
+	if p.font.Cff() {
+		return 0, false
+	}
+
+	offset, size := p.reader.glyfLocation(gid, p.font.LocaFormat)
+	if size < 10 {
+		return 0, false
+	}
+
+	p.reader.seekTo(p.reader.Tables.Glyf.Ptr + offset + 8)
+
+	return p.fwordScaled(), true
+}
@@ -0,0 +1,130 @@
+package ttf
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerticalMetrics(t *testing.T) {
+	var font Font
+	require.NoError(t, Parse(robotoI, &font))
+	require.False(t, font.HasVerticalMetrics())
+
+	gidA := font.GlyphId('A')
+	require.Equal(t, font.Ascent-font.Descent, font.Height(gidA))
+	require.Equal(t, font.Ascent, font.VerticalOrigin(gidA))
+
+	yMaxA := f32(i16(binary.BigEndian.Uint16(testGlyphData(t, robotoI, &font, gidA)[8:])))
+
+	vertical := testVerticalFont(t, nil)
+	require.NoError(t, Parse(vertical, &font))
+	require.True(t, font.HasVerticalMetrics())
+	require.Equal(t, f32(1000), font.Height(0))
+	require.Equal(t, f32(500), font.Height(gidA))
+	require.InDelta(t, (yMaxA+100)*font.Scale, font.VerticalOrigin(gidA), 0.01)
+
+	// CFF fonts take their vertical origins from the VORG table.
+	be := binary.BigEndian
+	vorg := be.AppendUint32(nil, 0x0001_0000)
+	vorg = be.AppendUint16(vorg, 1800) // defaultVertOriginY
+	vorg = be.AppendUint16(vorg, 1)    // numVertOriginYMetrics
+	vorg = be.AppendUint16(vorg, gidA)
+	vorg = be.AppendUint16(vorg, 1900)
+
+	otf := testOtf(t, vertical)
+	otf = testFont(0x4f54_544f, append(
+		testTables(otf),
+		testTable{tag: u32(TableNameVorg), data: vorg},
+	))
+	require.NoError(t, Parse(otf, &font))
+	require.Equal(t, f32(500), font.Height(gidA))
+	require.InDelta(t, 1900*font.Scale, font.VerticalOrigin(gidA), 0.01)
+	require.InDelta(t, 1800*font.Scale, font.VerticalOrigin(0), 0.01)
+}
+
+func TestShapeVertical(t *testing.T) {
+	var font Font
+	require.NoError(t, Parse(robotoI, &font))
+	gidA, gidB := font.GlyphId('A'), font.GlyphId('B')
+
+	require.NoError(t, Parse(testVerticalFont(t, []u16{gidA, gidB}), &font))
+
+	glyphs := font.Shape([]rune("AB"), ShapeVertical|ShapeKerning, nil)
+	require.Len(t, glyphs, 2)
+	require.Equal(t, gidB, glyphs[0].Id)
+	require.Equal(t, gidB, glyphs[1].Id)
+	for _, g := range glyphs {
+		require.Equal(t, font.Height(g.Id), g.Advance)
+	}
+
+	// Vertical alternates are only used for vertical text.
+	glyphs = font.Shape([]rune("AB"), 0, nil)
+	require.Equal(t, gidA, glyphs[0].Id)
+}
+
+// testVerticalFont adds vertical metrics to a copy of the Roboto test font,
+// with advances of 1 em for .notdef and 1/2 em for other glyphs, and top side
+// bearings of 100 units. If vert is set, its first glyph is substituted with
+// the second in a 'vert' GSUB feature.
+func testVerticalFont(t *testing.T, vert []u16) []byte {
+	t.Helper()
+
+	var font Font
+	require.NoError(t, Parse(robotoI, &font))
+
+	be := binary.BigEndian
+
+	vhea := be.AppendUint32(nil, 0x0001_1000)
+	vhea = append(vhea, make([]byte, 30)...)
+	vhea = be.AppendUint16(vhea, 2) // numOfLongVerMetrics
+
+	vmtx := be.AppendUint16(nil, 2048)
+	vmtx = be.AppendUint16(vmtx, 100)
+	vmtx = be.AppendUint16(vmtx, 1024)
+	vmtx = be.AppendUint16(vmtx, 100)
+	for range font.GlyphCount - 2 {
+		vmtx = be.AppendUint16(vmtx, 100)
+	}
+
+	tables := append(
+		testTables(robotoI, TableNameGsub),
+		testTable{tag: u32(TableNameVhea), data: vhea},
+		testTable{tag: u32(TableNameVmtx), data: vmtx},
+	)
+
+	if vert != nil {
+		gsub := be.AppendUint32(nil, 0x0001_0000)
+		gsub = be.AppendUint16(gsub, 10) // scriptListOffset
+		gsub = be.AppendUint16(gsub, 12) // featureListOffset
+		gsub = be.AppendUint16(gsub, 26) // lookupListOffset
+
+		gsub = be.AppendUint16(gsub, 0) // scriptCount
+
+		gsub = be.AppendUint16(gsub, 1) // featureCount
+		gsub = be.AppendUint32(gsub, u32(featureVert))
+		gsub = be.AppendUint16(gsub, 8) // featureOffset
+		gsub = be.AppendUint16(gsub, 0) // featureParamsOffset
+		gsub = be.AppendUint16(gsub, 1) // lookupIndexCount
+		gsub = be.AppendUint16(gsub, 0)
+
+		gsub = be.AppendUint16(gsub, 1) // lookupCount
+		gsub = be.AppendUint16(gsub, 4) // lookupOffset
+		gsub = be.AppendUint16(gsub, 1) // lookupType: single substitution
+		gsub = be.AppendUint16(gsub, 0) // lookupFlag
+		gsub = be.AppendUint16(gsub, 1) // subTableCount
+		gsub = be.AppendUint16(gsub, 8) // subtableOffset
+		gsub = be.AppendUint16(gsub, 2) // substFormat
+		gsub = be.AppendUint16(gsub, 8) // coverageOffset
+		gsub = be.AppendUint16(gsub, 1) // glyphCount
+		gsub = be.AppendUint16(gsub, vert[1])
+		gsub = be.AppendUint16(gsub, 1) // coverageFormat
+		gsub = be.AppendUint16(gsub, 1) // glyphCount
+		gsub = be.AppendUint16(gsub, vert[0])
+
+		tables = append(tables, testTable{tag: u32(TableNameGsub), data: gsub})
+	}
+
+	return testFont(0x0001_0000, tables)
+}
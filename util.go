@@ -30,6 +30,7 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"unicode/utf16"
 )
 
 func must1(err error) {
@@ -80,7 +81,11 @@ func (f *Scribe) utf8toutf16(s string, withBOM ...bool) string {
 			f.fmt.buf = binary.BigEndian.AppendUint16(f.fmt.buf, uint16('\\'))
 			f.fmt.buf = append(f.fmt.buf, uint8(char))
 		default:
-			f.fmt.buf = binary.BigEndian.AppendUint16(f.fmt.buf, uint16(char))
+			// Supplementary-plane characters are encoded as surrogate pairs.
+			var units [2]uint16
+			for _, unit := range utf16.AppendRune(units[:0], char) {
+				f.fmt.buf = binary.BigEndian.AppendUint16(f.fmt.buf, unit)
+			}
 		}
 	}
 
@@ -281,14 +286,6 @@ type untypedKeyMap struct {
 	valueSet []int
 }
 
-func isChinese(char rune) bool {
-	// chinese unicode: 4e00-9fa5
-	if char >= rune(0x4e00) && char <= rune(0x9fa5) {
-		return true
-	}
-	return false
-}
-
 // Condition font family string to PDF name compliance. See section 5.3 (Names)
 // in https://resources.infosecinstitute.com/pdf-file-format-basic-structure/
 func fontFamilyEscape(familyStr string) (escStr string) {
@@ -0,0 +1,162 @@
+package scribe
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/kofi-q/scribe-go/internal/bidi"
+	"github.com/kofi-q/scribe-go/ttf"
+)
+
+// TextVertical prints a string from top to bottom, as in vertical Chinese,
+// Japanese or Korean text, with the top of the first character at y,
+// horizontally centred on x.
+//
+// Glyphs are advanced by the vertical metrics in the font's vhea/vmtx tables,
+// if present, and replaced with their vertical alternates (e.g. for
+// punctuation and brackets) where the font defines them. Latin text is not
+// rotated, and is set upright like other characters.
+func (f *Scribe) TextVertical(x, y float32, txtStr string) {
+	text := f.shape(txtStr, bidi.LeftToRight, ttf.ShapeVertical)
+	s := sprintf("BT %g %g Td %s ET", x, (f.h - y), f.textArray(text, 0))
+
+	if f.colorFlag {
+		s = sprintf("q %s %s Q", f.color.text.str, s)
+	}
+
+	f.out(s)
+}
+
+// GetStringHeight returns the length of a string set vertically, in units of
+// measure. A font must be currently selected.
+func (f *Scribe) GetStringHeight(s string) float32 {
+	text := f.shape(s, bidi.LeftToRight, ttf.ShapeVertical)
+	return text.width() * f.fontSize / 1000
+}
+
+// MultiCellVertical prints vertical text in columns, running from right to
+// left, within a cell of the given width and height. Columns are broken at
+// \n characters, or as soon as the text reaches the bottom of the cell.
+// Characters are set as with TextVertical.
+//
+// The cell can be framed and the background painted, as with CellFormat().
+// Text that doesn't fit in the width of the cell is clipped by neither the
+// border nor the page, so the width should be chosen to fit the text.
+//
+// width is the width of the cell. A value of zero indicates a cell that
+// reaches to the right margin. height is the height of the cell, with a value
+// of zero indicating a cell that reaches to the bottom margin. colWidth is the
+// width of each column.
+//
+// The current position after calling MultiCellVertical() is the beginning of
+// the line below the cell.
+func (f *Scribe) MultiCellVertical(
+	width, height, colWidth float32,
+	txtStr, borderStr string,
+	fill bool,
+) {
+	if f.err != nil {
+		return
+	}
+
+	if width == 0 {
+		width = f.w - f.rMargin - f.x
+	}
+	if height == 0 {
+		height = f.pageBreakTrigger - f.y
+	}
+
+	// Frame and fill the cell, first, which also triggers a page break if
+	// needed.
+	f.CellFormat(width, height, "", borderStr, 0, "", fill, 0, "")
+	x, y := f.x-width, f.y
+
+	hmax := (height - 2*f.cMargin) * 1000 / f.fontSize
+	for i, column := range f.verticalColumns(txtStr, hmax) {
+		text := f.shape(column, bidi.LeftToRight, ttf.ShapeVertical)
+		s := sprintf(
+			"BT %g %g Td %s ET",
+			x+width-f.cMargin-colWidth*(float32(i)+0.5),
+			f.h-(y+f.cMargin),
+			f.textArray(text, 0),
+		)
+
+		if f.colorFlag {
+			s = sprintf("q %s %s Q", f.color.text.str, s)
+		}
+
+		f.out(s)
+	}
+
+	f.x = f.lMargin
+	f.y = y + height
+}
+
+// verticalColumns splits text into columns no taller than hmax, in glyph
+// units, breaking at \n characters and wherever the next character doesn't
+// fit. Trailing line breaks are removed.
+func (f *Scribe) verticalColumns(txtStr string, hmax float32) (columns []string) {
+	s := strings.TrimRight(strings.ReplaceAll(txtStr, "\r", ""), "\n")
+	if s == "" {
+		return nil
+	}
+
+	for line := range strings.SplitSeq(s, "\n") {
+		var height float32
+		start := 0
+		for i, char := range line {
+			h := f.glyphHeight(f.currentFont, char)
+			if height+h > hmax && i > start {
+				columns = append(columns, line[start:i])
+				start, height = i, 0
+			}
+			height += h
+		}
+
+		columns = append(columns, line[start:])
+	}
+
+	return columns
+}
+
+// glyphHeight returns the vertical advance of a character, in glyph units, in
+// the given font or the fallback providing it.
+func (f *Scribe) glyphHeight(id FontId, char rune) float32 {
+	font := f.fonts.Get(f.fonts.Fallback(id, char)).Font()
+	return font.Height(font.GlyphId(char))
+}
+
+// putVerticalMetrics outputs the vertical advances and origins of the used
+// glyphs of a CIDFont, for use with its Identity-V font.
+//
+// https://opensource.adobe.com/dc-acrobat-sdk-docs/pdfstandards/PDF32000_2008.pdf#G8.1904184
+func (f *Scribe) putVerticalMetrics(font *ttf.Font, usedGlyphs []uint) {
+	// Position vectors are given from the horizontal origin to the vertical
+	// origin, which is centred on the advance width.
+	f.out(
+		"/DW2 [" + f.fmtF64(font.VerticalOrigin(0), -1) + " " +
+			f.fmtF64(-font.Height(0), -1) + "]",
+	)
+
+	if !font.HasVerticalMetrics() {
+		return
+	}
+
+	lastGid := uint16(usedGlyphs[0])
+	f.put("/W2 [ " + strconv.Itoa(int(lastGid)) + " [ ")
+	for _, g := range usedGlyphs {
+		gid := uint16(g)
+		if gid < lastGid || gid-lastGid > 1 {
+			f.put("] " + strconv.Itoa(int(gid)) + " [ ")
+		}
+
+		f.put(
+			f.fmtF64(-font.Height(gid), -1) + " " +
+				f.fmtF64(font.Width(gid)/2, -1) + " " +
+				f.fmtF64(font.VerticalOrigin(gid), -1) + " ",
+		)
+
+		lastGid = gid
+	}
+	f.put("] ]\n")
+}